@@ -0,0 +1,89 @@
+// file: cmd/finalisasi/main.go
+//
+// Menjalankan finalisasi Alpa untuk rentang tanggal (backfill), misal setelah server lama mati.
+// Siswa yang sudah punya status dilewati, jadi aman dijalankan berulang kali.
+//
+// Hanya satu finalisasi yang berjalan pada satu waktu: server dan perintah ini berbagi kunci di
+// sheet KunciFinalisasi. Jika finalisasi lain (misal job 15 menitan server) sedang berjalan,
+// perintah ini berhenti dengan pesan "sedang berjalan"; jalankan ulang beberapa saat kemudian.
+//
+// Contoh:
+//
+//	go run ./cmd/finalisasi -mulai 2025-07-14 -selesai 2025-07-31
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"daarulilmi-presence/internal/config"
+	"daarulilmi-presence/internal/repository"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+func main() {
+	mulai := flag.String("mulai", "", "tanggal mulai (YYYY-MM-DD)")
+	selesai := flag.String("selesai", "", "tanggal selesai (YYYY-MM-DD, kosongkan jika sama dengan tanggal mulai)")
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "file konfigurasi YAML (opsional, sama dengan server)")
+	flag.Parse()
+	if *mulai == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *selesai == "" {
+		*selesai = *mulai
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("Gagal memuat konfigurasi: %v", err)
+	}
+	spreadsheetId := cfg.Sheets.SpreadsheetID
+	clk := cfg.Clock()
+
+	b, err := os.ReadFile(cfg.Sheets.CredentialsFile)
+	if err != nil {
+		log.Fatalf("Gagal membaca file kredensial: %v", err)
+	}
+	ctx := context.Background()
+	srv, err := sheets.NewService(ctx, option.WithCredentialsJSON(b))
+	if err != nil {
+		log.Fatalf("Gagal membuat koneksi ke Sheets: %v", err)
+	}
+
+	siswaRepo := repository.NewSiswaRepository(srv, spreadsheetId)
+	absensiUsecase := usecase.NewAbsensiUsecase(
+		repository.NewAbsensiRepository(srv, spreadsheetId, clk, cfg.Sheets.KolomKelasIzin),
+		siswaRepo,
+		repository.NewUserRepository(srv, spreadsheetId),
+		repository.NewRelasiWaliRepository(srv, spreadsheetId),
+		repository.NewFinalisasiRepository(srv, spreadsheetId),
+		repository.NewPeringatanRepository(srv, spreadsheetId),
+		usecase.NewRekonsiliasiUsecase(repository.NewRekonsiliasiRepository(srv, spreadsheetId), siswaRepo, clk),
+		usecase.NewKalenderUsecase(repository.NewKalenderRepository(srv, spreadsheetId), usecase.KalenderUsecaseConfig{
+			HariLiburMingguan: cfg.HariLiburMingguan(),
+			Clock:             clk,
+		}),
+		usecase.AbsensiUsecaseConfig{Clock: clk, AlpaCutoff: cfg.AlpaCutoff()},
+	)
+
+	hasil, err := absensiUsecase.FinalizeAlpa(ctx, *mulai, *selesai, "cmd/finalisasi")
+	if err != nil {
+		log.Fatalf("Finalisasi gagal: %v", err)
+	}
+	total := 0
+	for _, h := range hasil {
+		if h.Dilewati != "" {
+			log.Printf("%s: dilewati (%s)", h.Tanggal, h.Dilewati)
+			continue
+		}
+		log.Printf("%s: %d Alpa baru, %d sudah tercatat", h.Tanggal, h.JumlahAlpa, h.JumlahTercatat)
+		total += h.JumlahAlpa
+	}
+	log.Printf("Selesai: %d record Alpa ditulis", total)
+}
@@ -0,0 +1,147 @@
+// file: cmd/laporan/main.go
+//
+// Membuat laporan kehadiran bulanan PDF, satu file per kelas (ringkasan kelas lalu satu
+// halaman per siswa), untuk dicetak dan ditandatangani wali kelas.
+//
+// Contoh:
+//
+//	go run ./cmd/laporan -tahun 2025 -bulan 7 -keluar laporan/2025-07
+//	go run ./cmd/laporan -tahun 2025 -bulan 7 -kelas 7A
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"daarulilmi-presence/internal/config"
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/laporan"
+	"daarulilmi-presence/internal/repository"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+func main() {
+	tahun := flag.Int("tahun", 0, "tahun laporan, misal 2025")
+	bulan := flag.Int("bulan", 0, "bulan laporan (1-12)")
+	kelas := flag.String("kelas", "", "hanya kelas ini (kosongkan untuk semua kelas)")
+	keluar := flag.String("keluar", ".", "folder tujuan file PDF")
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "file konfigurasi YAML (opsional, sama dengan server)")
+	flag.Parse()
+	if *tahun == 0 || *bulan == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("Gagal memuat konfigurasi: %v", err)
+	}
+	spreadsheetId := cfg.Sheets.SpreadsheetID
+	clk := cfg.Clock()
+
+	b, err := os.ReadFile(cfg.Sheets.CredentialsFile)
+	if err != nil {
+		log.Fatalf("Gagal membaca file kredensial: %v", err)
+	}
+	ctx := context.Background()
+	srv, err := sheets.NewService(ctx, option.WithCredentialsJSON(b))
+	if err != nil {
+		log.Fatalf("Gagal membuat koneksi ke Sheets: %v", err)
+	}
+
+	siswaRepo := repository.NewSiswaRepository(srv, spreadsheetId)
+	absensiUsecase := usecase.NewAbsensiUsecase(
+		repository.NewAbsensiRepository(srv, spreadsheetId, clk, cfg.Sheets.KolomKelasIzin),
+		siswaRepo,
+		repository.NewUserRepository(srv, spreadsheetId),
+		repository.NewRelasiWaliRepository(srv, spreadsheetId),
+		repository.NewFinalisasiRepository(srv, spreadsheetId),
+		repository.NewPeringatanRepository(srv, spreadsheetId),
+		usecase.NewRekonsiliasiUsecase(repository.NewRekonsiliasiRepository(srv, spreadsheetId), siswaRepo, clk),
+		usecase.NewKalenderUsecase(repository.NewKalenderRepository(srv, spreadsheetId), usecase.KalenderUsecaseConfig{
+			HariLiburMingguan: cfg.HariLiburMingguan(),
+			Clock:             clk,
+		}),
+		usecase.AbsensiUsecaseConfig{
+			Clock:           clk,
+			AlpaCutoff:      cfg.AlpaCutoff(),
+			JamMasuk:        cfg.JamMasuk(),
+			AmbangKehadiran: cfg.School.AmbangKehadiran,
+		},
+	)
+	kop := laporan.KopSurat{
+		NamaSekolah:      cfg.Laporan.NamaSekolah,
+		Alamat:           cfg.Laporan.Alamat,
+		Kota:             cfg.Laporan.Kota,
+		KepalaSekolah:    cfg.Laporan.KepalaSekolah,
+		NIPKepalaSekolah: cfg.Laporan.NIPKepalaSekolah,
+		Logo:             cfg.Laporan.Logo,
+		WaliKelas:        cfg.Laporan.WaliKelas,
+	}
+
+	// Data seluruh kelas diambil sekali, lalu dipecah per kelas
+	lap, err := absensiUsecase.GetLaporanBulanan(ctx, *tahun, *bulan, *kelas)
+	if err != nil {
+		log.Fatalf("Gagal mengambil data laporan: %v", err)
+	}
+	if err := os.MkdirAll(*keluar, 0o755); err != nil {
+		log.Fatalf("Gagal membuat folder %s: %v", *keluar, err)
+	}
+
+	var urutan []string
+	perKelas := make(map[string][]domain.LaporanSiswa)
+	for _, s := range lap.Siswa {
+		if _, ada := perKelas[s.Siswa.Kelas]; !ada {
+			urutan = append(urutan, s.Siswa.Kelas)
+		}
+		perKelas[s.Siswa.Kelas] = append(perKelas[s.Siswa.Kelas], s)
+	}
+	if len(urutan) == 0 {
+		log.Fatalf("Tidak ada siswa untuk dilaporkan")
+	}
+
+	for _, k := range urutan {
+		lapKelas := *lap
+		lapKelas.Kelas = k
+		lapKelas.Siswa = perKelas[k]
+		nama := filepath.Join(*keluar, fmt.Sprintf("laporan-kehadiran-%s-%d-%02d.pdf", namaFile(k), *tahun, *bulan))
+		if err := tulisPDF(nama, &lapKelas, kop); err != nil {
+			log.Fatalf("Gagal membuat %s: %v", nama, err)
+		}
+		log.Printf("%s: %d siswa -> %s", k, len(lapKelas.Siswa), nama)
+	}
+	log.Printf("Selesai: %d file PDF dibuat", len(urutan))
+}
+
+func tulisPDF(nama string, lap *domain.LaporanBulanan, kop laporan.KopSurat) error {
+	f, err := os.Create(nama)
+	if err != nil {
+		return err
+	}
+	if err := laporan.LaporanBulananPDF(f, lap, kop, true); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// namaFile mengganti karakter yang tidak aman untuk nama file, misal "7/A" menjadi "7_A"
+func namaFile(kelas string) string {
+	if kelas == "" {
+		return "tanpa-kelas"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, kelas)
+}
@@ -0,0 +1,74 @@
+// file: cmd/provision/main.go
+//
+// Membuat akun siswa dan wali murid secara massal dari sheet DataSiswa, lalu menulis
+// slip kredensial siap cetak (HTML, satu slip per keluarga).
+//
+// Contoh:
+//
+//	go run ./cmd/provision -kelas "XII IPA 1" -out slip-xii-ipa-1.html
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"daarulilmi-presence/internal/config"
+	"daarulilmi-presence/internal/mailer"
+	"daarulilmi-presence/internal/repository"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+func main() {
+	kelas := flag.String("kelas", "", "hanya buat akun untuk kelas ini (kosongkan untuk semua kelas)")
+	out := flag.String("out", "slip-akun.html", "file HTML tujuan slip kredensial")
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "file konfigurasi YAML (opsional, sama dengan server)")
+	flag.Parse()
+
+	// Memakai konfigurasi yang sama dengan server agar spreadsheet dan alamat publik cocok
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("Gagal memuat konfigurasi: %v", err)
+	}
+	spreadsheetId := cfg.Sheets.SpreadsheetID
+
+	b, err := os.ReadFile(cfg.Sheets.CredentialsFile)
+	if err != nil {
+		log.Fatalf("Gagal membaca file kredensial: %v", err)
+	}
+	ctx := context.Background()
+	srv, err := sheets.NewService(ctx, option.WithCredentialsJSON(b))
+	if err != nil {
+		log.Fatalf("Gagal membuat koneksi ke Sheets: %v", err)
+	}
+
+	userUsecase := usecase.NewUserUsecase(
+		repository.NewUserRepository(srv, spreadsheetId),
+		repository.NewResetTokenRepository(srv, spreadsheetId),
+		repository.NewSesiRepository(srv, spreadsheetId),
+		repository.NewAuditRepository(srv, spreadsheetId),
+		repository.NewSiswaRepository(srv, spreadsheetId),
+		repository.NewRelasiWaliRepository(srv, spreadsheetId),
+		mailer.NewLogMailer("", ""),
+		usecase.UserUsecaseConfig{PublicBaseURL: cfg.Server.PublicBaseURL, Clock: cfg.Clock()},
+	)
+
+	hasil, err := userUsecase.ProvisionAccounts(ctx, *kelas, "cmd/provision")
+	if err != nil {
+		log.Fatalf("Gagal membuat akun: %v", err)
+	}
+	slip, err := userUsecase.RenderCredentialSlips(hasil)
+	if err != nil {
+		log.Fatalf("Akun sudah dibuat, tetapi slip gagal dibuat: %v", err)
+	}
+	// File berisi password awal, hanya boleh dibaca pemiliknya
+	if err := os.WriteFile(*out, slip, 0o600); err != nil {
+		log.Fatalf("Akun sudah dibuat, tetapi slip gagal ditulis: %v", err)
+	}
+
+	log.Printf("Selesai: %d akun dibuat, %d sudah ada. Slip ditulis ke %s", hasil.JumlahDibuat, hasil.JumlahDilewati, *out)
+}
@@ -4,10 +4,23 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"daarulilmi-presence/internal/config"
+	"daarulilmi-presence/internal/domain"
 	"daarulilmi-presence/internal/handler"
+	"daarulilmi-presence/internal/laporan"
+	"daarulilmi-presence/internal/mailer"
+	"daarulilmi-presence/internal/notifikasi"
 	"daarulilmi-presence/internal/repository"
 	"daarulilmi-presence/internal/usecase"
 
@@ -17,19 +30,22 @@ import (
 	"google.golang.org/api/sheets/v4"
 )
 
-// --- KONFIGURASI GLOBAL ---
-var (
-	// Ganti nama sekolah di sini jika perlu
-	// schoolName = "SMA Islam Daarul Ilmi Depok"
-	// Kunci rahasia untuk JWT, harus sama persis dengan yang di usecase
-	jwtSecret = []byte("daarulilmi-presence")
-	// ID Spreadsheet dari URL Google Sheet Anda
-	spreadsheetId = "1TFLV9ezeLt-q3uyNvArMfWwYoz5tDOGD-25zoPHXM3E"
-)
-
 func main() {
+	// --- MUAT KONFIGURASI ---
+	// Nilai bawaan cocok untuk pengembangan; lihat config.example.yaml untuk semua pilihan
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "file konfigurasi YAML (opsional)")
+	flag.Parse()
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("Gagal memuat konfigurasi: %v", err)
+	}
+	spreadsheetId := cfg.Sheets.SpreadsheetID
+	jwtSecret := []byte(cfg.Auth.JWTSecret)
+	// Semua keputusan tanggal memakai zona waktu sekolah, bukan zona waktu container
+	clk := cfg.Clock()
+
 	// --- SETUP KONEKSI GOOGLE SHEETS ---
-	b, err := os.ReadFile("credentials.json")
+	b, err := os.ReadFile(cfg.Sheets.CredentialsFile)
 	if err != nil {
 		log.Fatalf("Gagal membaca file kredensial: %v", err)
 	}
@@ -41,30 +57,238 @@ func main() {
 	// === DEPENDENCY INJECTION (MERAKIT SEMUA KOMPONEN) ===
 	// 1. Buat semua Repository (Kurir)
 	userRepo := repository.NewUserRepository(srv, spreadsheetId)
-	absensiRepo := repository.NewAbsensiRepository(srv, spreadsheetId)
+	absensiRepo := repository.NewAbsensiRepository(srv, spreadsheetId, clk, cfg.Sheets.KolomKelasIzin)
 	siswaRepo := repository.NewSiswaRepository(srv, spreadsheetId)
+	rekonsiliasiRepo := repository.NewRekonsiliasiRepository(srv, spreadsheetId)
+	resetTokenRepo := repository.NewResetTokenRepository(srv, spreadsheetId)
+	sesiRepo := repository.NewSesiRepository(srv, spreadsheetId)
+	auditRepo := repository.NewAuditRepository(srv, spreadsheetId)
+	relasiWaliRepo := repository.NewRelasiWaliRepository(srv, spreadsheetId)
+	finalisasiRepo := repository.NewFinalisasiRepository(srv, spreadsheetId)
+	kalenderRepo := repository.NewKalenderRepository(srv, spreadsheetId)
+	feedKalenderRepo := repository.NewFeedKalenderRepository(srv, spreadsheetId)
+	peringatanRepo := repository.NewPeringatanRepository(srv, spreadsheetId)
+	semesterRepo := repository.NewSemesterRepository(srv, spreadsheetId)
+	webhookRepo := repository.NewWebhookRepository(srv, spreadsheetId)
+	notifikasiRepo := repository.NewNotifikasiRepository(srv, spreadsheetId)
+	digestRepo := repository.NewDigestRepository(srv, spreadsheetId)
+
+	// Pengirim email: "smtp" untuk produksi, selain itu email hanya ditulis ke log/file
+	var emailSender usecase.Mailer
+	if cfg.Mail.Driver == "smtp" {
+		emailSender = mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.Mail.SMTP.Host,
+			Port:     cfg.Mail.SMTP.Port,
+			Username: cfg.Mail.SMTP.Username,
+			Password: cfg.Mail.SMTP.Password,
+			From:     cfg.Mail.From,
+		})
+	} else {
+		emailSender = mailer.NewLogMailer(cfg.Mail.LogDir, cfg.Mail.From)
+	}
+
+	// Kanal notifikasi orang tua yang dipasang; keluarga memilih di antaranya saat opt-in
+	kanalNotifikasi := make(map[string]usecase.KanalNotifikasi)
+	for _, k := range cfg.Notifikasi.Kanal {
+		switch k {
+		case "whatsapp":
+			kanalNotifikasi[k] = notifikasi.NewGateway(k, gatewayNotifikasi(cfg.Notifikasi.WhatsApp))
+		case "sms":
+			kanalNotifikasi[k] = notifikasi.NewGateway(k, gatewayNotifikasi(cfg.Notifikasi.SMS))
+		case "email":
+			kanalNotifikasi[k] = notifikasi.NewEmail(emailSender)
+		case "log":
+			kanalNotifikasi[k] = notifikasi.NewLog(cfg.Notifikasi.LogDir)
+		}
+	}
+
+	// Perlindungan brute-force login; nilai yang tidak diisi memakai bawaan usecase
+	guardCfg := usecase.DefaultLoginGuardConfig()
+	if v := cfg.LoginGuard.MaxFailuresPerUser; v > 0 {
+		guardCfg.MaxFailuresPerUser = v
+	}
+	if v := cfg.LoginGuard.MaxFailuresPerIP; v > 0 {
+		guardCfg.MaxFailuresPerIP = v
+	}
+	if v := cfg.LoginGuard.DelayAfter; v > 0 {
+		guardCfg.DelayAfter = v
+	}
+	if v := cfg.LoginGuard.LockoutDuration; v > 0 {
+		guardCfg.LockoutDuration = v
+	}
+	if v := cfg.LoginGuard.IPBlockDuration; v > 0 {
+		guardCfg.IPBlockDuration = v
+	}
+	if v := cfg.LoginGuard.FailureWindow; v > 0 {
+		guardCfg.FailureWindow = v
+	}
 
 	// 2. Buat semua Usecase (Otak Bisnis)
-	userUsecase := usecase.NewUserUsecase(userRepo, jwtSecret)
-	absensiUsecase := usecase.NewAbsensiUsecase(absensiRepo, siswaRepo, userRepo)
-	siswaUsecase := usecase.NewSiswaUsecase(siswaRepo)
+	userUsecase := usecase.NewUserUsecase(userRepo, resetTokenRepo, sesiRepo, auditRepo, siswaRepo, relasiWaliRepo, emailSender, usecase.UserUsecaseConfig{
+		JWTSecret:              jwtSecret,
+		PublicBaseURL:          cfg.Server.PublicBaseURL,
+		LoginGuard:             guardCfg,
+		TOTPIssuer:             cfg.Auth.TOTPIssuer,
+		TwoFactorRequiredRoles: cfg.Auth.TwoFactorRequiredRoles,
+		Clock:                  clk,
+	})
+	rekonsiliasiUsecase := usecase.NewRekonsiliasiUsecase(rekonsiliasiRepo, siswaRepo, clk)
+	kalenderUsecase := usecase.NewKalenderUsecase(kalenderRepo, usecase.KalenderUsecaseConfig{
+		HariLiburMingguan: cfg.HariLiburMingguan(),
+		Clock:             clk,
+	})
+	webhookUsecase := usecase.NewWebhookUsecase(webhookRepo, usecase.WebhookUsecaseConfig{
+		Clock:                clk,
+		IzinkanJaringanLokal: cfg.Webhook.IzinkanJaringanLokal,
+	})
+	notifikasiUsecase := usecase.NewNotifikasiUsecase(notifikasiRepo, siswaRepo, relasiWaliRepo, userRepo, usecase.NotifikasiUsecaseConfig{
+		Clock:         clk,
+		Kanal:         kanalNotifikasi,
+		NamaSekolah:   cfg.Laporan.NamaSekolah,
+		MaksPercobaan: cfg.Notifikasi.MaksPercobaan,
+	})
+	absensiUsecase := usecase.NewAbsensiUsecase(absensiRepo, siswaRepo, userRepo, relasiWaliRepo, finalisasiRepo, peringatanRepo, rekonsiliasiUsecase, kalenderUsecase, usecase.AbsensiUsecaseConfig{
+		QRSecretKey:     cfg.Auth.QRSecretKey,
+		Clock:           clk,
+		AlpaCutoff:      cfg.AlpaCutoff(),
+		JamMasuk:        cfg.JamMasuk(),
+		AmbangKehadiran: cfg.School.AmbangKehadiran,
+		Peringatan: usecase.AturanPeringatan{
+			JendelaHari:   cfg.Peringatan.JendelaHari,
+			AlpaBerturut:  cfg.Peringatan.AlpaBerturut,
+			AbsenMaks:     cfg.Peringatan.AbsenMaks,
+			PolaHari:      cfg.Peringatan.PolaHari,
+			TerlambatMaks: cfg.Peringatan.TerlambatMaks,
+		},
+		Event: usecase.GabungPenerbit(webhookUsecase, notifikasiUsecase),
+	})
+	siswaUsecase := usecase.NewSiswaUsecase(siswaRepo, usecase.SiswaUsecaseConfig{
+		DaftarKelas: cfg.School.DaftarKelas,
+	})
+	feedKalenderUsecase := usecase.NewFeedKalenderUsecase(feedKalenderRepo, userRepo, absensiUsecase, usecase.FeedKalenderUsecaseConfig{
+		PublicBaseURL: cfg.Server.PublicBaseURL,
+		Clock:         clk,
+	})
+	semesterUsecase := usecase.NewSemesterUsecase(semesterRepo, absensiUsecase, usecase.SemesterUsecaseConfig{
+		Clock: clk,
+	})
+	digestUsecase := usecase.NewDigestUsecase(digestRepo, absensiUsecase, userRepo, emailSender, notifikasiUsecase, usecase.DigestUsecaseConfig{
+		Clock:         clk,
+		Jam:           cfg.JamDigest(),
+		AlpaCutoff:    cfg.AlpaCutoff(),
+		WaliKelas:     cfg.Digest.WaliKelas,
+		KirimOrtu:     cfg.Digest.KirimOrtu,
+		PublicBaseURL: cfg.Server.PublicBaseURL,
+	})
+	// Akun wali kelas ada di sheet, jadi digest.wali_kelas baru bisa diperiksa di sini
+	if err := digestUsecase.PeriksaWaliKelas(context.Background()); err != nil {
+		if errors.Is(err, domain.ErrDigestWaliKelasTidakValid) {
+			log.Fatalf("Konfigurasi digest.wali_kelas tidak valid: %v", err)
+		}
+		log.Printf("WARNING: Gagal memeriksa wali kelas ringkasan pagi: %v", err)
+	}
+
+	// --- TUGAS LATAR BELAKANG ---
+	// ctx dibatalkan saat SIGINT/SIGTERM (misal docker stop); tugas latar berhenti dan antrian
+	// webhook serta notifikasi menyimpan log dan pesan tertundanya sebelum proses keluar
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var latar sync.WaitGroup
+	jalankan := func(fn func()) {
+		latar.Add(1)
+		go func() {
+			defer latar.Done()
+			fn()
+		}()
+	}
+	berkala := func(interval time.Duration, nama string, fn func(ctx context.Context) error) {
+		jalankan(func() { jalankanBerkala(ctx, interval, nama, fn) })
+	}
+
+	// Janitor: bersihkan token reset password yang sudah kedaluwarsa
+	berkala(time.Hour, "pembersihan token reset", func(ctx context.Context) error {
+		n, err := userUsecase.PurgeExpiredResetTokens(ctx)
+		if err == nil && n > 0 {
+			log.Printf("INFO: %d token reset kedaluwarsa dihapus", n)
+		}
+		return err
+	})
+	// Janitor: bersihkan sesi login yang refresh token-nya sudah kedaluwarsa
+	berkala(6*time.Hour, "pembersihan sesi", func(ctx context.Context) error {
+		n, err := userUsecase.PurgeExpiredSessions(ctx)
+		if err == nil && n > 0 {
+			log.Printf("INFO: %d sesi kedaluwarsa dihapus", n)
+		}
+		return err
+	})
+
+	// Finalisasi Alpa: setelah batas jam Alpa, siswa tanpa kabar dicatat Alpa di LogAbsensi
+	berkala(15*time.Minute, "finalisasi Alpa", func(ctx context.Context) error {
+		hasil, err := absensiUsecase.FinalizeDueDays(ctx)
+		for _, h := range hasil {
+			if h.Dilewati == "" {
+				log.Printf("INFO: Finalisasi %s: %d siswa dicatat Alpa", h.Tanggal, h.JumlahAlpa)
+			}
+		}
+		return err
+	})
+
+	// Peringatan dini: setiap malam setelah hari terakhir difinalisasi, aturan ketidakhadiran
+	// dievaluasi ulang dan hasilnya tampil di dashboard wali kelas
+	berkala(30*time.Minute, "peringatan kehadiran", func(ctx context.Context) error {
+		_, err := absensiUsecase.EvaluateDuePeringatan(ctx)
+		return err
+	})
+
+	// Ringkasan pagi: daftar siswa "Belum Ada Kabar" ke wali kelas dan orang tua sekali sehari
+	berkala(time.Minute, "ringkasan pagi", func(ctx context.Context) error {
+		_, err := digestUsecase.KirimTerjadwal(ctx)
+		return err
+	})
+
+	// Webhook: kirim event kehadiran ke sistem lain beserta percobaan ulangnya
+	jalankan(func() { webhookUsecase.Jalankan(ctx) })
+	// Notifikasi orang tua: antrian pesan masuk/pulang, jam tenang, dan percobaan ulang
+	jalankan(func() { notifikasiUsecase.Jalankan(ctx) })
+	// Pengajuan izin masuk lewat Google Form, jadi perubahannya dipantau berkala
+	berkala(2*time.Minute, "pemantauan izin", func(ctx context.Context) error {
+		_, err := absensiUsecase.PantauIzin(ctx)
+		return err
+	})
 
 	// --- SETUP SERVER ECHO ---
 	e := echo.New()
+	e.IPExtractor = ipExtractor(cfg.Server.TrustedProxies)
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"http://localhost:5174", "https://presence.zazhil.my.id"},
+		AllowOrigins: cfg.Server.CORSOrigins,
 		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
 	}))
 
 	// --- SETUP ROUTING ---
 	// Grup untuk rute API yang butuh login
 	apiGroup := e.Group("/api")
-	apiGroup.Use(handler.JWTMiddleware(jwtSecret))
+	apiGroup.Use(handler.JWTMiddleware(jwtSecret, userUsecase))
 
 	// Daftarkan semua Handler (Resepsionis)
 	handler.NewUserHandler(e, apiGroup, userUsecase)
 	handler.NewAbsensiHandler(e, apiGroup, absensiUsecase)
 	handler.NewSiswaHandler(e, apiGroup, siswaUsecase)
+	handler.NewRekonsiliasiHandler(e, apiGroup, rekonsiliasiUsecase)
+	handler.NewKalenderHandler(e, apiGroup, kalenderUsecase)
+	handler.NewFeedKalenderHandler(e, apiGroup, feedKalenderUsecase)
+	handler.NewSemesterHandler(e, apiGroup, semesterUsecase)
+	handler.NewWebhookHandler(e, apiGroup, webhookUsecase)
+	handler.NewNotifikasiHandler(e, apiGroup, notifikasiUsecase)
+	handler.NewDigestHandler(e, apiGroup, digestUsecase)
+	handler.NewLaporanHandler(e, apiGroup, absensiUsecase, laporan.KopSurat{
+		NamaSekolah:      cfg.Laporan.NamaSekolah,
+		Alamat:           cfg.Laporan.Alamat,
+		Kota:             cfg.Laporan.Kota,
+		KepalaSekolah:    cfg.Laporan.KepalaSekolah,
+		NIPKepalaSekolah: cfg.Laporan.NIPKepalaSekolah,
+		Logo:             cfg.Laporan.Logo,
+		WaliKelas:        cfg.Laporan.WaliKelas,
+	})
 
 	// Rute Halaman Publik (tidak butuh login)
 	// e.GET("/", func(c echo.Context) error {
@@ -92,6 +316,74 @@ func main() {
 	// })
 
 	// --- MULAI SERVER ---
-	log.Println("Server berjalan di http://localhost:1412")
-	e.Logger.Fatal(e.Start(":1412"))
+	log.Printf("Server berjalan di %s (port %d, mode %s, zona waktu %s)", cfg.Server.PublicBaseURL, cfg.Server.Port, cfg.Env, clk.Location())
+	go func() {
+		if err := e.Start(cfg.Addr()); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server gagal berjalan: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("INFO: Server berhenti, menunggu permintaan dan tugas latar selesai")
+	// Docker memberi 10 detik sebelum SIGKILL
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Printf("WARNING: Permintaan yang masih berjalan dihentikan paksa: %v", err)
+	}
+	selesai := make(chan struct{})
+	go func() {
+		latar.Wait()
+		close(selesai)
+	}()
+	select {
+	case <-selesai:
+		log.Printf("INFO: Server berhenti dengan rapi")
+	case <-shutdownCtx.Done():
+		log.Printf("WARNING: Tugas latar belum selesai saat batas waktu berhenti habis")
+	}
+}
+
+// jalankanBerkala menjalankan fn setiap interval sampai ctx dibatalkan
+func jalankanBerkala(ctx context.Context, interval time.Duration, nama string, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("ERROR tugas berkala %s: %v", nama, err)
+		}
+	}
+}
+
+// gatewayNotifikasi menyalin konfigurasi gateway WhatsApp/SMS ke paket notifikasi
+func gatewayNotifikasi(g config.GatewayConfig) notifikasi.GatewayConfig {
+	return notifikasi.GatewayConfig{
+		URL:         g.URL,
+		Token:       g.Token,
+		Format:      g.Format,
+		FieldTujuan: g.FieldTujuan,
+		FieldPesan:  g.FieldPesan,
+		KodeNegara:  g.KodeNegara,
+	}
+}
+
+// ipExtractor menentukan asal IP klien untuk batas login per IP. X-Forwarded-For hanya dipercaya
+// jika datang dari proxy yang terdaftar; tanpa daftar, IP koneksi langsung yang dipakai.
+func ipExtractor(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	opsi := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range trustedProxies {
+		// Format sudah diperiksa saat validasi konfigurasi
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+			opsi = append(opsi, echo.TrustIPRange(ipNet))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(opsi...)
 }
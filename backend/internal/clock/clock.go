@@ -0,0 +1,106 @@
+// file: internal/clock/clock.go
+
+// Package clock menyediakan jam yang bisa diinjeksi dan selalu berada di zona waktu sekolah.
+//
+// Semua keputusan tanggal ("hari ini", batas jam 18.00, timestamp di sheet) harus memakai
+// Clock ini, bukan time.Now() langsung, karena zona waktu server (misal UTC di dalam
+// container Docker) belum tentu sama dengan zona waktu sekolah (Asia/Jakarta).
+package clock
+
+import (
+	"fmt"
+	"time"
+
+	// Image Alpine tidak membawa database zona waktu, jadi disematkan ke dalam binary
+	_ "time/tzdata"
+)
+
+// Format tanggal dan waktu yang dipakai di sheet
+const (
+	LayoutTanggal = "2006-01-02"
+	LayoutWaktu   = "2006-01-02 15:04:05"
+	LayoutJam     = "15:04:05"
+)
+
+// DefaultTimezone adalah zona waktu sekolah bila tidak diatur
+const DefaultTimezone = "Asia/Jakarta"
+
+// Clock adalah sumber waktu "sekarang" di zona waktu sekolah
+type Clock interface {
+	Now() time.Time
+	Location() *time.Location
+}
+
+type sistem struct {
+	loc *time.Location
+}
+
+// New membuat Clock yang memakai jam sistem, dikonversi ke zona waktu loc
+func New(loc *time.Location) Clock {
+	if loc == nil {
+		loc = time.Local
+	}
+	return sistem{loc: loc}
+}
+
+func (c sistem) Now() time.Time           { return time.Now().In(c.loc) }
+func (c sistem) Location() *time.Location { return c.loc }
+
+type tetap struct {
+	t time.Time
+}
+
+// Fixed membuat Clock yang selalu mengembalikan waktu t, berguna untuk pengujian dan
+// untuk menjalankan ulang proses pada tanggal tertentu
+func Fixed(t time.Time) Clock {
+	return tetap{t: t}
+}
+
+func (c tetap) Now() time.Time           { return c.t }
+func (c tetap) Location() *time.Location { return c.t.Location() }
+
+// LoadLocation memuat zona waktu berdasarkan nama IANA, misal "Asia/Jakarta"
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultTimezone
+	}
+	return time.LoadLocation(name)
+}
+
+// Today mengembalikan tanggal hari ini (YYYY-MM-DD) di zona waktu sekolah
+func Today(c Clock) string {
+	return c.Now().Format(LayoutTanggal)
+}
+
+// Timestamp mengembalikan waktu sekarang dalam format sheet (YYYY-MM-DD HH:MM:SS)
+func Timestamp(c Clock) string {
+	return c.Now().Format(LayoutWaktu)
+}
+
+// ParseTanggal membaca tanggal YYYY-MM-DD sebagai tengah malam di zona waktu sekolah
+func ParseTanggal(c Clock, s string) (time.Time, error) {
+	return time.ParseInLocation(LayoutTanggal, s, c.Location())
+}
+
+// ParseWaktu membaca timestamp sheet (YYYY-MM-DD HH:MM:SS) di zona waktu sekolah
+func ParseWaktu(c Clock, s string) (time.Time, error) {
+	return time.ParseInLocation(LayoutWaktu, s, c.Location())
+}
+
+// ParseTanggalFleksibel membaca tanggal dari berbagai format (termasuk format Google Form)
+// sebagai tengah malam di zona waktu sekolah
+func ParseTanggalFleksibel(c Clock, s string) (time.Time, error) {
+	layouts := []string{
+		LayoutTanggal, // YYYY-MM-DD
+		"1/2/2006",    // M/D/YYYY (Format umum GForm)
+		"01/02/2006",  // MM/DD/YYYY
+		"2/1/2006",    // D/M/YYYY
+		"02/01/2006",  // DD/MM/YYYY
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, c.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("tidak dapat mem-parsing tanggal: %s", s)
+}
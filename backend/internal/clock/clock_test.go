@@ -0,0 +1,115 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// serverUTC meniru server di dalam container Docker yang zona waktunya UTC
+func serverUTC(t *testing.T) {
+	t.Helper()
+	asal := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = asal })
+}
+
+func jakarta(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := LoadLocation("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestTodaySekitarTengahMalam(t *testing.T) {
+	serverUTC(t)
+	loc := jakarta(t)
+
+	tests := []struct {
+		name  string
+		utc   time.Time
+		today string
+		waktu string
+	}{
+		// 23:59 WIB masih tanggal 10, padahal di UTC baru 16:59
+		{"23:59 WIB", time.Date(2025, 3, 10, 16, 59, 0, 0, time.UTC), "2025-03-10", "2025-03-10 23:59:00"},
+		// 00:01 WIB sudah tanggal 11, padahal di UTC masih tanggal 10
+		{"00:01 WIB", time.Date(2025, 3, 10, 17, 1, 0, 0, time.UTC), "2025-03-11", "2025-03-11 00:01:00"},
+		// 06:30 WIB: UTC masih tanggal sebelumnya
+		{"pagi WIB", time.Date(2025, 3, 10, 23, 30, 0, 0, time.UTC), "2025-03-11", "2025-03-11 06:30:00"},
+		// Pergantian tahun
+		{"tahun baru", time.Date(2024, 12, 31, 17, 0, 0, 0, time.UTC), "2025-01-01", "2025-01-01 00:00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Fixed(tt.utc.In(loc))
+			if got := Today(c); got != tt.today {
+				t.Errorf("Today = %s, want %s", got, tt.today)
+			}
+			if got := Timestamp(c); got != tt.waktu {
+				t.Errorf("Timestamp = %s, want %s", got, tt.waktu)
+			}
+		})
+	}
+}
+
+func TestNewMengikutiZonaSekolah(t *testing.T) {
+	serverUTC(t)
+	c := New(jakarta(t))
+	if _, offset := c.Now().Zone(); offset != 7*60*60 {
+		t.Fatalf("offset = %d detik, want +07:00", offset)
+	}
+	if New(nil).Location() != time.Local {
+		t.Fatal("New(nil) seharusnya memakai time.Local")
+	}
+}
+
+func TestParseTanggal(t *testing.T) {
+	serverUTC(t)
+	c := Fixed(time.Date(2025, 3, 10, 12, 0, 0, 0, jakarta(t)))
+
+	got, err := ParseTanggal(c, "2025-03-11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tengah malam WIB, yaitu 17:00 UTC hari sebelumnya
+	if want := time.Date(2025, 3, 10, 17, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("ParseTanggal = %v, want %v", got.UTC(), want)
+	}
+
+	w, err := ParseWaktu(c, "2025-03-11 00:01:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Format(LayoutTanggal) != "2025-03-11" || w.Before(got) {
+		t.Fatalf("ParseWaktu 00:01 = %v, seharusnya setelah tengah malam %v", w, got)
+	}
+}
+
+func TestParseTanggalFleksibel(t *testing.T) {
+	c := Fixed(time.Date(2025, 3, 10, 12, 0, 0, 0, jakarta(t)))
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"2025-03-11", "2025-03-11", false},
+		{"3/11/2025", "2025-03-11", false},  // M/D/YYYY dari Google Form
+		{"03/11/2025", "2025-03-11", false}, // MM/DD/YYYY
+		{"25/3/2025", "2025-03-25", false},  // D/M/YYYY, angka pertama bukan bulan
+		{"31/12/2025", "2025-12-31", false}, // DD/MM/YYYY
+		{"11 Maret 2025", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTanggalFleksibel(c, tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTanggalFleksibel(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (got.Format(LayoutTanggal) != tt.want || got.Location() != c.Location()) {
+			t.Errorf("ParseTanggalFleksibel(%q) = %v, want %s di %s", tt.in, got, tt.want, c.Location())
+		}
+	}
+}
@@ -0,0 +1,343 @@
+// file: internal/config/config.go
+
+// Package config memuat pengaturan server dari file YAML (opsional) dan environment variable.
+//
+// Urutan prioritas: nilai bawaan < file YAML (CONFIG_FILE / flag -config) < environment variable.
+// Nilai bawaan ditujukan untuk pengembangan lokal; dengan APP_ENV=production, validasi
+// menolak secret bawaan dan pengaturan yang tidak aman. Lihat config.example.yaml.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+
+	// Secret bawaan hanya untuk pengembangan, ditolak saat APP_ENV=production
+	defaultSecret = "daarulilmi-presence"
+)
+
+// Config adalah seluruh pengaturan aplikasi
+type Config struct {
+	Env        string           `yaml:"env"`
+	Server     ServerConfig     `yaml:"server"`
+	School     SchoolConfig     `yaml:"school"`
+	Sheets     SheetsConfig     `yaml:"sheets"`
+	Auth       AuthConfig       `yaml:"auth"`
+	LoginGuard LoginGuardConfig `yaml:"login_guard"`
+	Mail       MailConfig       `yaml:"mail"`
+	Laporan    LaporanConfig    `yaml:"laporan"`
+	Peringatan PeringatanConfig `yaml:"peringatan"`
+	Notifikasi NotifikasiConfig `yaml:"notifikasi"`
+	Digest     DigestConfig     `yaml:"digest"`
+	Webhook    WebhookConfig    `yaml:"webhook"`
+}
+
+type ServerConfig struct {
+	Port int `yaml:"port"`
+	// Alamat publik aplikasi untuk tautan di email dan slip akun (misal https://presence.zazhil.my.id)
+	PublicBaseURL string   `yaml:"public_base_url"`
+	CORSOrigins   []string `yaml:"cors_origins"`
+	// Rentang IP (CIDR) reverse proxy yang dipercaya mengisi X-Forwarded-For. Kosong berarti
+	// IP klien diambil langsung dari koneksi dan header X-Forwarded-For diabaikan.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type SchoolConfig struct {
+	// Zona waktu IANA sekolah; semua keputusan "hari ini" memakai zona ini, bukan zona server
+	Timezone string `yaml:"timezone"`
+	// Jam (HH:MM) setelah siswa tanpa kabar pada hari sekolah dicatat Alpa
+	AlpaCutoff string `yaml:"alpa_cutoff"`
+	// Jam (HH:MM) masuk sekolah; scan datang setelah jam ini dicatat terlambat di laporan
+	JamMasuk string `yaml:"jam_masuk"`
+	// Persen hadir minimal (misal 85 untuk syarat ikut ujian); siswa di bawahnya ditandai di
+	// rekap dan statistik. 0 berarti tidak ada ambang.
+	AmbangKehadiran float64 `yaml:"ambang_kehadiran"`
+	// Hari libur mingguan, misal [sabtu, minggu]. Hari masuk pengganti diatur di kalender sekolah.
+	HariLiburMingguan []string `yaml:"hari_libur_mingguan"`
+	// Daftar kelas resmi, misal [7A, 7B, 8A]. Jika diisi, impor siswa menolak kelas di luar daftar;
+	// jika kosong, kelas baru hanya diberi peringatan.
+	DaftarKelas []string `yaml:"daftar_kelas"`
+}
+
+// LaporanConfig berisi kop surat dan penanda tangan laporan PDF
+type LaporanConfig struct {
+	NamaSekolah string `yaml:"nama_sekolah"`
+	Alamat      string `yaml:"alamat"`
+	// Kota di atas tanda tangan, misal "Bogor, 31 Juli 2025"
+	Kota             string `yaml:"kota"`
+	KepalaSekolah    string `yaml:"kepala_sekolah"`
+	NIPKepalaSekolah string `yaml:"nip_kepala_sekolah"`
+	// File logo PNG/JPG di kiri kop surat (opsional)
+	Logo string `yaml:"logo"`
+	// Nama wali kelas per kelas untuk blok tanda tangan, misal {7A: "Ustadzah Aisyah"}
+	WaliKelas map[string]string `yaml:"wali_kelas"`
+}
+
+// PeringatanConfig mengatur aturan peringatan dini ketidakhadiran yang dievaluasi setiap malam.
+// Ambang 0 mematikan aturan tersebut.
+type PeringatanConfig struct {
+	// Jumlah hari kalender ke belakang yang dievaluasi
+	JendelaHari int `yaml:"jendela_hari"`
+	// Alpa sebanyak ini di hari sekolah berturut-turut
+	AlpaBerturut int `yaml:"alpa_berturut"`
+	// Tidak hadir (Izin, Sakit, Alpa) sebanyak ini dalam jendela
+	AbsenMaks int `yaml:"absen_maks"`
+	// Tidak hadir sebanyak ini pada hari yang sama, misal setiap Senin
+	PolaHari int `yaml:"pola_hari"`
+	// Terlambat sebanyak ini dalam jendela
+	TerlambatMaks int `yaml:"terlambat_maks"`
+}
+
+// NotifikasiConfig mengatur pesan ke orang tua saat siswa scan masuk dan pulang. Pesan hanya
+// dikirim ke keluarga yang mengaktifkannya di portal wali murid atau lewat admin.
+type NotifikasiConfig struct {
+	// Kanal yang dipasang: whatsapp, sms, email, log. Kosong berarti notifikasi mati.
+	Kanal    []string      `yaml:"kanal"`
+	WhatsApp GatewayConfig `yaml:"whatsapp"`
+	SMS      GatewayConfig `yaml:"sms"`
+	// Folder file untuk kanal log; kosong berarti pesan hanya ditulis ke log server
+	LogDir string `yaml:"log_dir"`
+	// Batas percobaan kirim per pesan sebelum menyerah
+	MaksPercobaan int `yaml:"maks_percobaan"`
+}
+
+// DigestConfig mengatur ringkasan pagi siswa "Belum Ada Kabar" untuk wali kelas dan orang tua
+type DigestConfig struct {
+	// Jam (HH:MM) ringkasan dikirim pada hari sekolah; kosong berarti ringkasan terjadwal mati
+	Jam string `yaml:"jam"`
+	// Username akun wali kelas per kelas, misal {7A: [ustadzah.aisyah]}. Ringkasan dikirim ke
+	// email akun tersebut; kelas tanpa wali kelas dikirim ke admin.
+	WaliKelas map[string][]string `yaml:"wali_kelas"`
+	// Kirim permintaan konfirmasi ke orang tua yang mengaktifkan notifikasi
+	KirimOrtu bool `yaml:"kirim_ortu"`
+}
+
+// WebhookConfig mengatur pengiriman event ke sistem lain
+type WebhookConfig struct {
+	// Izinkan URL webhook di jaringan lokal (loopback, IP privat, link-local). Mati secara
+	// bawaan agar URL webhook tidak bisa dipakai untuk menjangkau layanan internal server,
+	// misal alamat metadata cloud.
+	IzinkanJaringanLokal bool `yaml:"izinkan_jaringan_lokal"`
+}
+
+// GatewayConfig adalah gateway HTTP WhatsApp/SMS yang menerima nomor tujuan dan isi pesan,
+// misal Fonnte atau Wablas
+type GatewayConfig struct {
+	URL string `yaml:"url"`
+	// Dikirim apa adanya di header Authorization; tulis "Bearer ..." jika gateway memintanya
+	Token string `yaml:"token"`
+	// "json" atau "form"
+	Format string `yaml:"format"`
+	// Nama field nomor tujuan dan isi pesan di body permintaan
+	FieldTujuan string `yaml:"field_tujuan"`
+	FieldPesan  string `yaml:"field_pesan"`
+	// Nomor berawalan 0 diubah menjadi kode negara ini, misal 0812... menjadi 62812...
+	KodeNegara string `yaml:"kode_negara"`
+}
+
+type SheetsConfig struct {
+	SpreadsheetID   string `yaml:"spreadsheet_id"`
+	CredentialsFile string `yaml:"credentials_file"`
+	// Judul kolom kelas di sheet PengajuanIzin (respons Google Form), dicocokkan persis
+	KolomKelasIzin string `yaml:"kolom_kelas_izin"`
+}
+
+type AuthConfig struct {
+	JWTSecret   string `yaml:"jwt_secret"`
+	QRSecretKey string `yaml:"qr_secret_key"`
+	TOTPIssuer  string `yaml:"totp_issuer"`
+	// Peran yang wajib memakai 2FA, misal [admin, walikelas]
+	TwoFactorRequiredRoles []string `yaml:"two_factor_required_roles"`
+}
+
+// LoginGuardConfig mengatur perlindungan brute-force login. Nilai nol berarti memakai
+// bawaan dari usecase.DefaultLoginGuardConfig.
+type LoginGuardConfig struct {
+	MaxFailuresPerUser int           `yaml:"max_gagal_per_user"`
+	MaxFailuresPerIP   int           `yaml:"max_gagal_per_ip"`
+	DelayAfter         int           `yaml:"tunda_setelah"`
+	LockoutDuration    time.Duration `yaml:"lama_kunci"`
+	IPBlockDuration    time.Duration `yaml:"lama_blokir_ip"`
+	FailureWindow      time.Duration `yaml:"jendela_gagal"`
+}
+
+type MailConfig struct {
+	Driver string `yaml:"driver"` // "smtp" atau "log"
+	From   string `yaml:"from"`
+	// Folder tujuan file .eml untuk driver "log"; kosong berarti hanya ditulis ke log
+	LogDir string     `yaml:"log_dir"`
+	SMTP   SMTPConfig `yaml:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// Default mengembalikan pengaturan untuk pengembangan lokal
+func Default() Config {
+	return Config{
+		Env: EnvDevelopment,
+		Server: ServerConfig{
+			Port:          1412,
+			PublicBaseURL: "http://localhost:1412",
+			CORSOrigins:   []string{"http://localhost:5174"},
+		},
+		School: SchoolConfig{
+			Timezone:          clock.DefaultTimezone,
+			AlpaCutoff:        "18:00",
+			JamMasuk:          "07:00",
+			AmbangKehadiran:   85,
+			HariLiburMingguan: []string{"sabtu", "minggu"},
+		},
+		Sheets: SheetsConfig{
+			CredentialsFile: "credentials.json",
+			KolomKelasIzin:  "Kelas",
+		},
+		Auth: AuthConfig{
+			JWTSecret:   defaultSecret,
+			QRSecretKey: defaultSecret,
+			TOTPIssuer:  "Presensi Daarul Ilmi",
+		},
+		Laporan: LaporanConfig{
+			NamaSekolah: "Daarul Ilmi",
+		},
+		Peringatan: PeringatanConfig{
+			JendelaHari:   30,
+			AlpaBerturut:  3,
+			AbsenMaks:     5,
+			PolaHari:      3,
+			TerlambatMaks: 5,
+		},
+		Notifikasi: NotifikasiConfig{
+			WhatsApp:      gatewayBawaan(),
+			SMS:           gatewayBawaan(),
+			MaksPercobaan: 4,
+		},
+		Digest: DigestConfig{
+			Jam:       "08:00",
+			KirimOrtu: true,
+		},
+		Mail: MailConfig{
+			Driver: "log",
+			From:   "Presensi Daarul Ilmi <noreply@localhost>",
+			SMTP:   SMTPConfig{Port: 587},
+		},
+	}
+}
+
+func gatewayBawaan() GatewayConfig {
+	return GatewayConfig{Format: "json", FieldTujuan: "target", FieldPesan: "message", KodeNegara: "62"}
+}
+
+// Load membaca file YAML (jika path diisi), menimpa dengan environment variable,
+// lalu memvalidasi hasilnya
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca file konfigurasi %s: %v", path, err)
+		}
+		if err := yaml.Unmarshal(b, &cfg); err != nil {
+			return nil, fmt.Errorf("file konfigurasi %s tidak valid: %v", path, err)
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Addr adalah alamat listen server, misal ":1412"
+func (c *Config) Addr() string {
+	return fmt.Sprintf(":%d", c.Server.Port)
+}
+
+// Clock membuat jam sistem di zona waktu sekolah. Zona waktu sudah diperiksa oleh Validate.
+func (c *Config) Clock() clock.Clock {
+	loc, err := clock.LoadLocation(c.School.Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	return clock.New(loc)
+}
+
+// AlpaCutoff mengubah school.alpa_cutoff menjadi lama setelah tengah malam. Format sudah
+// diperiksa oleh Validate.
+func (c *Config) AlpaCutoff() time.Duration {
+	d, _ := parseJam(c.School.AlpaCutoff)
+	return d
+}
+
+// JamMasuk mengubah school.jam_masuk menjadi lama setelah tengah malam. Format sudah
+// diperiksa oleh Validate.
+func (c *Config) JamMasuk() time.Duration {
+	d, _ := parseJam(c.School.JamMasuk)
+	return d
+}
+
+// JamDigest mengubah digest.jam menjadi lama setelah tengah malam; 0 berarti ringkasan
+// terjadwal mati. Format sudah diperiksa oleh Validate.
+func (c *Config) JamDigest() time.Duration {
+	d, _ := parseJam(c.Digest.Jam)
+	return d
+}
+
+// HariLiburMingguan mengubah school.hari_libur_mingguan menjadi time.Weekday. Nama hari sudah
+// diperiksa oleh Validate. Daftar kosong berarti sekolah masuk setiap hari.
+func (c *Config) HariLiburMingguan() []time.Weekday {
+	hari := []time.Weekday{}
+	for _, nama := range c.School.HariLiburMingguan {
+		if w, ok := parseHari(nama); ok {
+			hari = append(hari, w)
+		}
+	}
+	return hari
+}
+
+// namaHari menerima nama hari dalam bahasa Indonesia atau Inggris
+var namaHari = map[string]time.Weekday{
+	"minggu": time.Sunday, "ahad": time.Sunday, "sunday": time.Sunday,
+	"senin": time.Monday, "monday": time.Monday,
+	"selasa": time.Tuesday, "tuesday": time.Tuesday,
+	"rabu": time.Wednesday, "wednesday": time.Wednesday,
+	"kamis": time.Thursday, "thursday": time.Thursday,
+	"jumat": time.Friday, "jum'at": time.Friday, "friday": time.Friday,
+	"sabtu": time.Saturday, "saturday": time.Saturday,
+}
+
+func parseHari(s string) (time.Weekday, bool) {
+	w, ok := namaHari[strings.ToLower(strings.TrimSpace(s))]
+	return w, ok
+}
+
+// parseJam membaca jam "HH:MM" menjadi lama setelah tengah malam
+func parseJam(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ErrKonfigurasi membungkus semua kesalahan validasi agar bisa ditampilkan sekaligus
+var ErrKonfigurasi = errors.New("konfigurasi tidak valid")
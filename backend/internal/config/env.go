@@ -0,0 +1,145 @@
+// file: internal/config/env.go
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyEnv menimpa pengaturan dengan environment variable yang diisi
+func applyEnv(cfg *Config) error {
+	var errs []string
+	setString := func(key string, dst *string) {
+		if v := os.Getenv(key); v != "" {
+			*dst = v
+		}
+	}
+	setList := func(key string, dst *[]string) {
+		if v := os.Getenv(key); v != "" {
+			*dst = splitList(v)
+		}
+	}
+	setInt := func(key string, dst *int) {
+		if v := os.Getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s harus berupa angka, bukan %q", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	setFloat := func(key string, dst *float64) {
+		if v := os.Getenv(key); v != "" {
+			n, err := strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 64)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s harus berupa angka, bukan %q", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	setBool := func(key string, dst *bool) {
+		if v := os.Getenv(key); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s harus berupa true atau false, bukan %q", key, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	setDuration := func(key string, dst *time.Duration) {
+		if v := os.Getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s harus berupa durasi seperti 15m atau 1h, bukan %q", key, v))
+				return
+			}
+			*dst = d
+		}
+	}
+
+	setString("APP_ENV", &cfg.Env)
+
+	setInt("PORT", &cfg.Server.Port)
+	setString("PUBLIC_BASE_URL", &cfg.Server.PublicBaseURL)
+	setList("CORS_ORIGINS", &cfg.Server.CORSOrigins)
+	setList("TRUSTED_PROXIES", &cfg.Server.TrustedProxies)
+
+	setString("SCHOOL_TIMEZONE", &cfg.School.Timezone)
+	setString("ALPA_CUTOFF", &cfg.School.AlpaCutoff)
+	setString("JAM_MASUK", &cfg.School.JamMasuk)
+	setFloat("AMBANG_KEHADIRAN", &cfg.School.AmbangKehadiran)
+	setList("HARI_LIBUR_MINGGUAN", &cfg.School.HariLiburMingguan)
+	setList("DAFTAR_KELAS", &cfg.School.DaftarKelas)
+
+	setString("SPREADSHEET_ID", &cfg.Sheets.SpreadsheetID)
+	setString("GOOGLE_CREDENTIALS_FILE", &cfg.Sheets.CredentialsFile)
+	setString("KOLOM_KELAS_IZIN", &cfg.Sheets.KolomKelasIzin)
+
+	setString("JWT_SECRET", &cfg.Auth.JWTSecret)
+	setString("QR_SECRET_KEY", &cfg.Auth.QRSecretKey)
+	setString("TOTP_ISSUER", &cfg.Auth.TOTPIssuer)
+	setList("WAJIB_2FA_ROLES", &cfg.Auth.TwoFactorRequiredRoles)
+
+	setInt("LOGIN_MAX_GAGAL_PER_USER", &cfg.LoginGuard.MaxFailuresPerUser)
+	setInt("LOGIN_MAX_GAGAL_PER_IP", &cfg.LoginGuard.MaxFailuresPerIP)
+	setInt("LOGIN_TUNDA_SETELAH", &cfg.LoginGuard.DelayAfter)
+	setDuration("LOGIN_LAMA_KUNCI", &cfg.LoginGuard.LockoutDuration)
+	setDuration("LOGIN_LAMA_BLOKIR_IP", &cfg.LoginGuard.IPBlockDuration)
+	setDuration("LOGIN_JENDELA_GAGAL", &cfg.LoginGuard.FailureWindow)
+
+	setString("MAILER", &cfg.Mail.Driver)
+	setString("MAIL_FROM", &cfg.Mail.From)
+	setString("MAIL_LOG_DIR", &cfg.Mail.LogDir)
+	setString("SMTP_HOST", &cfg.Mail.SMTP.Host)
+	setInt("SMTP_PORT", &cfg.Mail.SMTP.Port)
+	setString("SMTP_USERNAME", &cfg.Mail.SMTP.Username)
+	setString("SMTP_PASSWORD", &cfg.Mail.SMTP.Password)
+
+	setString("LAPORAN_NAMA_SEKOLAH", &cfg.Laporan.NamaSekolah)
+	setString("LAPORAN_ALAMAT", &cfg.Laporan.Alamat)
+	setString("LAPORAN_KOTA", &cfg.Laporan.Kota)
+	setString("LAPORAN_KEPALA_SEKOLAH", &cfg.Laporan.KepalaSekolah)
+	setString("LAPORAN_NIP_KEPALA_SEKOLAH", &cfg.Laporan.NIPKepalaSekolah)
+	setString("LAPORAN_LOGO", &cfg.Laporan.Logo)
+
+	setInt("PERINGATAN_JENDELA_HARI", &cfg.Peringatan.JendelaHari)
+	setInt("PERINGATAN_ALPA_BERTURUT", &cfg.Peringatan.AlpaBerturut)
+	setInt("PERINGATAN_ABSEN_MAKS", &cfg.Peringatan.AbsenMaks)
+	setInt("PERINGATAN_POLA_HARI", &cfg.Peringatan.PolaHari)
+	setInt("PERINGATAN_TERLAMBAT_MAKS", &cfg.Peringatan.TerlambatMaks)
+
+	setList("NOTIFIKASI_KANAL", &cfg.Notifikasi.Kanal)
+	setString("NOTIFIKASI_LOG_DIR", &cfg.Notifikasi.LogDir)
+	setInt("NOTIFIKASI_MAKS_PERCOBAAN", &cfg.Notifikasi.MaksPercobaan)
+	setString("WHATSAPP_GATEWAY_URL", &cfg.Notifikasi.WhatsApp.URL)
+	setString("WHATSAPP_GATEWAY_TOKEN", &cfg.Notifikasi.WhatsApp.Token)
+	setString("SMS_GATEWAY_URL", &cfg.Notifikasi.SMS.URL)
+	setString("SMS_GATEWAY_TOKEN", &cfg.Notifikasi.SMS.Token)
+
+	setString("DIGEST_JAM", &cfg.Digest.Jam)
+	setBool("DIGEST_KIRIM_ORTU", &cfg.Digest.KirimOrtu)
+
+	setBool("WEBHOOK_IZINKAN_JARINGAN_LOKAL", &cfg.Webhook.IzinkanJaringanLokal)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w:\n  - %s", ErrKonfigurasi, strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+// splitList memecah daftar yang dipisah koma dan membuang entri kosong
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
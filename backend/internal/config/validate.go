@@ -0,0 +1,194 @@
+// file: internal/config/validate.go
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// Validate memeriksa semua pengaturan dan mengembalikan seluruh kesalahan sekaligus
+func (c *Config) Validate() error {
+	var errs []string
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	if c.Env != EnvDevelopment && c.Env != EnvProduction {
+		add("env (APP_ENV) harus %q atau %q, bukan %q", EnvDevelopment, EnvProduction, c.Env)
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		add("server.port (PORT) harus antara 1 dan 65535, bukan %d", c.Server.Port)
+	}
+	if u, err := url.Parse(c.Server.PublicBaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		add("server.public_base_url (PUBLIC_BASE_URL) harus berupa URL lengkap, misal https://presence.zazhil.my.id")
+	}
+	if len(c.Server.CORSOrigins) == 0 {
+		add("server.cors_origins (CORS_ORIGINS) minimal berisi satu origin frontend")
+	}
+	for _, cidr := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			add("server.trusted_proxies (TRUSTED_PROXIES) harus berisi rentang CIDR seperti 172.16.0.0/12, bukan %q", cidr)
+		}
+	}
+
+	if _, err := clock.LoadLocation(c.School.Timezone); err != nil {
+		add("school.timezone (SCHOOL_TIMEZONE) %q bukan zona waktu yang dikenal, misal Asia/Jakarta", c.School.Timezone)
+	}
+	if _, err := parseJam(c.School.AlpaCutoff); err != nil {
+		add("school.alpa_cutoff (ALPA_CUTOFF) harus berformat HH:MM, misal 18:00, bukan %q", c.School.AlpaCutoff)
+	}
+	if jam, err := parseJam(c.School.JamMasuk); err != nil {
+		add("school.jam_masuk (JAM_MASUK) harus berformat HH:MM, misal 07:00, bukan %q", c.School.JamMasuk)
+	} else if cutoff, err := parseJam(c.School.AlpaCutoff); err == nil && jam >= cutoff {
+		add("school.jam_masuk (JAM_MASUK) harus sebelum school.alpa_cutoff (ALPA_CUTOFF)")
+	}
+	if c.School.AmbangKehadiran < 0 || c.School.AmbangKehadiran > 100 {
+		add("school.ambang_kehadiran (AMBANG_KEHADIRAN) harus antara 0 dan 100, bukan %v", c.School.AmbangKehadiran)
+	}
+	for _, nama := range c.School.HariLiburMingguan {
+		if _, ok := parseHari(nama); !ok {
+			add("school.hari_libur_mingguan (HARI_LIBUR_MINGGUAN) berisi nama hari tidak dikenal %q, misal sabtu atau minggu", nama)
+		}
+	}
+
+	if c.Sheets.SpreadsheetID == "" {
+		add("sheets.spreadsheet_id (SPREADSHEET_ID) wajib diisi")
+	}
+	if c.Sheets.CredentialsFile == "" {
+		add("sheets.credentials_file (GOOGLE_CREDENTIALS_FILE) wajib diisi")
+	} else if _, err := os.Stat(c.Sheets.CredentialsFile); err != nil {
+		add("file kredensial Google %q tidak bisa dibaca: %v", c.Sheets.CredentialsFile, err)
+	}
+
+	if c.Auth.JWTSecret == "" {
+		add("auth.jwt_secret (JWT_SECRET) wajib diisi")
+	}
+	if c.Auth.QRSecretKey == "" {
+		add("auth.qr_secret_key (QR_SECRET_KEY) wajib diisi")
+	}
+	if strings.Contains(c.Auth.QRSecretKey, ":") {
+		add("auth.qr_secret_key (QR_SECRET_KEY) tidak boleh mengandung karakter ':'")
+	}
+	for _, role := range c.Auth.TwoFactorRequiredRoles {
+		if !domain.IsValidRole(role) {
+			add("auth.two_factor_required_roles (WAJIB_2FA_ROLES) berisi peran tidak dikenal %q", role)
+		}
+	}
+
+	if c.Laporan.Logo != "" {
+		switch strings.ToLower(filepath.Ext(c.Laporan.Logo)) {
+		case ".png", ".jpg", ".jpeg":
+			if _, err := os.Stat(c.Laporan.Logo); err != nil {
+				add("file logo laporan %q tidak bisa dibaca: %v", c.Laporan.Logo, err)
+			}
+		default:
+			add("laporan.logo (LAPORAN_LOGO) harus file .png atau .jpg")
+		}
+	}
+
+	if c.Peringatan.JendelaHari < 7 || c.Peringatan.JendelaHari > 366 {
+		add("peringatan.jendela_hari (PERINGATAN_JENDELA_HARI) harus antara 7 dan 366, bukan %d", c.Peringatan.JendelaHari)
+	}
+	for _, a := range []struct {
+		nama  string
+		nilai int
+	}{
+		{"alpa_berturut (PERINGATAN_ALPA_BERTURUT)", c.Peringatan.AlpaBerturut},
+		{"absen_maks (PERINGATAN_ABSEN_MAKS)", c.Peringatan.AbsenMaks},
+		{"pola_hari (PERINGATAN_POLA_HARI)", c.Peringatan.PolaHari},
+		{"terlambat_maks (PERINGATAN_TERLAMBAT_MAKS)", c.Peringatan.TerlambatMaks},
+	} {
+		if a.nilai < 0 {
+			add("peringatan.%s tidak boleh negatif; isi 0 untuk mematikan aturan", a.nama)
+		}
+	}
+
+	for _, k := range c.Notifikasi.Kanal {
+		switch k {
+		case "email", "log":
+		case "whatsapp", "sms":
+			g := c.Notifikasi.WhatsApp
+			if k == "sms" {
+				g = c.Notifikasi.SMS
+			}
+			if !strings.HasPrefix(g.URL, "http://") && !strings.HasPrefix(g.URL, "https://") {
+				add("notifikasi.%s.url (%s_GATEWAY_URL) wajib berupa URL http(s) jika kanal %s dipasang", k, strings.ToUpper(k), k)
+			}
+			if g.Format != "json" && g.Format != "form" {
+				add("notifikasi.%s.format harus \"json\" atau \"form\", bukan %q", k, g.Format)
+			}
+			if g.FieldTujuan == "" || g.FieldPesan == "" {
+				add("notifikasi.%s.field_tujuan dan field_pesan wajib diisi", k)
+			}
+		default:
+			add("notifikasi.kanal (NOTIFIKASI_KANAL) berisi %q; pilihan: whatsapp, sms, email, log", k)
+		}
+	}
+	if c.Notifikasi.MaksPercobaan < 1 || c.Notifikasi.MaksPercobaan > 10 {
+		add("notifikasi.maks_percobaan (NOTIFIKASI_MAKS_PERCOBAAN) harus antara 1 dan 10, bukan %d", c.Notifikasi.MaksPercobaan)
+	}
+
+	if c.Digest.Jam != "" {
+		jam, err := parseJam(c.Digest.Jam)
+		masuk, _ := parseJam(c.School.JamMasuk)
+		cutoff, _ := parseJam(c.School.AlpaCutoff)
+		switch {
+		case err != nil:
+			add("digest.jam (DIGEST_JAM) harus berformat HH:MM, misal 08:00, bukan %q", c.Digest.Jam)
+		case jam <= masuk || jam >= cutoff:
+			add("digest.jam (DIGEST_JAM) harus setelah school.jam_masuk dan sebelum school.alpa_cutoff")
+		}
+	}
+	for kelas, daftar := range c.Digest.WaliKelas {
+		if len(daftar) == 0 {
+			add("digest.wali_kelas.%s harus berisi minimal satu username", kelas)
+		}
+	}
+
+	switch c.Mail.Driver {
+	case "log":
+	case "smtp":
+		if c.Mail.SMTP.Host == "" {
+			add("mail.smtp.host (SMTP_HOST) wajib diisi jika mail.driver = smtp")
+		}
+		if c.Mail.SMTP.Port < 1 || c.Mail.SMTP.Port > 65535 {
+			add("mail.smtp.port (SMTP_PORT) harus antara 1 dan 65535, bukan %d", c.Mail.SMTP.Port)
+		}
+	default:
+		add("mail.driver (MAILER) harus \"smtp\" atau \"log\", bukan %q", c.Mail.Driver)
+	}
+
+	// Aturan tambahan untuk server produksi
+	if c.Env == EnvProduction {
+		if c.Auth.JWTSecret == defaultSecret || len(c.Auth.JWTSecret) < 32 {
+			add("auth.jwt_secret (JWT_SECRET) harus diganti dengan string acak minimal 32 karakter di produksi")
+		}
+		if c.Auth.QRSecretKey == defaultSecret {
+			add("auth.qr_secret_key (QR_SECRET_KEY) harus diganti di produksi")
+		}
+		if !strings.HasPrefix(c.Server.PublicBaseURL, "https://") {
+			add("server.public_base_url (PUBLIC_BASE_URL) harus memakai https di produksi")
+		}
+		for _, origin := range c.Server.CORSOrigins {
+			if !strings.HasPrefix(origin, "https://") {
+				add("server.cors_origins (CORS_ORIGINS) harus berisi origin https frontend produksi, bukan %q", origin)
+			}
+		}
+		if c.Mail.Driver != "smtp" {
+			add("mail.driver (MAILER) harus \"smtp\" di produksi agar email reset password terkirim")
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w:\n  - %s", ErrKonfigurasi, strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// configValid membuat konfigurasi bawaan dengan file kredensial yang benar-benar ada
+func configValid(t *testing.T) Config {
+	t.Helper()
+	cred := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(cred, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Default()
+	cfg.Sheets.SpreadsheetID = "spreadsheet-uji"
+	cfg.Sheets.CredentialsFile = cred
+	return cfg
+}
+
+func TestValidateDefault(t *testing.T) {
+	cfg := configValid(t)
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("konfigurasi bawaan seharusnya valid: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		ubah  func(c *Config)
+		pesan string // potongan pesan yang diharapkan; kosong berarti valid
+	}{
+		{"env tidak dikenal", func(c *Config) { c.Env = "staging" }, "env (APP_ENV)"},
+		{"port nol", func(c *Config) { c.Server.Port = 0 }, "server.port"},
+		{"port terlalu besar", func(c *Config) { c.Server.Port = 70000 }, "server.port"},
+		{"public base url tanpa skema", func(c *Config) { c.Server.PublicBaseURL = "presence.zazhil.my.id" }, "server.public_base_url"},
+		{"cors kosong", func(c *Config) { c.Server.CORSOrigins = nil }, "server.cors_origins"},
+		{"proxy bukan cidr", func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.1"} }, "server.trusted_proxies"},
+		{"proxy cidr", func(c *Config) { c.Server.TrustedProxies = []string{"172.16.0.0/12", "::1/128"} }, ""},
+		{"zona waktu tidak dikenal", func(c *Config) { c.School.Timezone = "Asia/Bandung" }, "school.timezone"},
+		{"cutoff bukan jam", func(c *Config) { c.School.AlpaCutoff = "jam 6" }, "school.alpa_cutoff"},
+		{"jam masuk setelah cutoff", func(c *Config) { c.School.JamMasuk = "19:00" }, "harus sebelum school.alpa_cutoff"},
+		{"ambang di atas 100", func(c *Config) { c.School.AmbangKehadiran = 120 }, "school.ambang_kehadiran"},
+		{"hari libur tidak dikenal", func(c *Config) { c.School.HariLiburMingguan = []string{"sabtu", "libur"} }, "school.hari_libur_mingguan"},
+		{"hari libur bahasa inggris", func(c *Config) { c.School.HariLiburMingguan = []string{"Friday"} }, ""},
+		{"spreadsheet kosong", func(c *Config) { c.Sheets.SpreadsheetID = "" }, "sheets.spreadsheet_id"},
+		{"kredensial tidak ada", func(c *Config) { c.Sheets.CredentialsFile = "/tidak/ada.json" }, "file kredensial Google"},
+		{"kunci qr dengan titik dua", func(c *Config) { c.Auth.QRSecretKey = "a:b" }, "tidak boleh mengandung karakter ':'"},
+		{"peran 2fa tidak dikenal", func(c *Config) { c.Auth.TwoFactorRequiredRoles = []string{"kepsek"} }, "auth.two_factor_required_roles"},
+		{"logo bukan gambar", func(c *Config) { c.Laporan.Logo = "logo.svg" }, "laporan.logo"},
+		{"jendela peringatan pendek", func(c *Config) { c.Peringatan.JendelaHari = 3 }, "peringatan.jendela_hari"},
+		{"ambang peringatan negatif", func(c *Config) { c.Peringatan.AlpaBerturut = -1 }, "peringatan.alpa_berturut"},
+		{"kanal tidak dikenal", func(c *Config) { c.Notifikasi.Kanal = []string{"telegram"} }, "notifikasi.kanal"},
+		{"kanal whatsapp tanpa url", func(c *Config) { c.Notifikasi.Kanal = []string{"whatsapp"} }, "notifikasi.whatsapp.url"},
+		{"kanal sms format salah", func(c *Config) {
+			c.Notifikasi.Kanal = []string{"sms"}
+			c.Notifikasi.SMS.URL = "https://sms.example.com"
+			c.Notifikasi.SMS.Format = "xml"
+		}, "notifikasi.sms.format"},
+		{"maks percobaan nol", func(c *Config) { c.Notifikasi.MaksPercobaan = 0 }, "notifikasi.maks_percobaan"},
+		{"digest sebelum jam masuk", func(c *Config) { c.Digest.Jam = "06:30" }, "digest.jam"},
+		{"digest mati", func(c *Config) { c.Digest.Jam = "" }, ""},
+		{"wali kelas tanpa username", func(c *Config) { c.Digest.WaliKelas = map[string][]string{"7A": nil} }, "digest.wali_kelas.7A"},
+		{"smtp tanpa host", func(c *Config) { c.Mail.Driver = "smtp" }, "mail.smtp.host"},
+		{"driver mail tidak dikenal", func(c *Config) { c.Mail.Driver = "sendgrid" }, "mail.driver"},
+		{"produksi dengan rahasia bawaan", func(c *Config) { c.Env = EnvProduction }, "auth.jwt_secret (JWT_SECRET) harus diganti"},
+		{"produksi lengkap", func(c *Config) {
+			c.Env = EnvProduction
+			c.Server.PublicBaseURL = "https://presence.zazhil.my.id"
+			c.Server.CORSOrigins = []string{"https://presence.zazhil.my.id"}
+			c.Auth.JWTSecret = strings.Repeat("j", 32)
+			c.Auth.QRSecretKey = "kunci-qr-produksi"
+			c.Mail.Driver = "smtp"
+			c.Mail.SMTP.Host = "smtp.example.com"
+		}, ""},
+		{"produksi tanpa https", func(c *Config) {
+			c.Env = EnvProduction
+			c.Auth.JWTSecret = strings.Repeat("j", 32)
+			c.Auth.QRSecretKey = "kunci-qr-produksi"
+			c.Mail.Driver = "smtp"
+			c.Mail.SMTP.Host = "smtp.example.com"
+		}, "harus memakai https di produksi"},
+		{"produksi dengan cors bawaan", func(c *Config) {
+			c.Env = EnvProduction
+			c.Server.PublicBaseURL = "https://presence.zazhil.my.id"
+			c.Auth.JWTSecret = strings.Repeat("j", 32)
+			c.Auth.QRSecretKey = "kunci-qr-produksi"
+			c.Mail.Driver = "smtp"
+			c.Mail.SMTP.Host = "smtp.example.com"
+		}, "server.cors_origins (CORS_ORIGINS) harus berisi origin https"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := configValid(t)
+			tt.ubah(&cfg)
+			err := cfg.Validate()
+			if tt.pesan == "" {
+				if err != nil {
+					t.Fatalf("seharusnya valid: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("seharusnya gagal dengan pesan %q", tt.pesan)
+			}
+			if !errors.Is(err, ErrKonfigurasi) {
+				t.Errorf("kesalahan tidak membungkus ErrKonfigurasi: %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.pesan) {
+				t.Errorf("pesan %q tidak memuat %q", err.Error(), tt.pesan)
+			}
+		})
+	}
+}
+
+func TestValidateMengumpulkanSemuaKesalahan(t *testing.T) {
+	cfg := configValid(t)
+	cfg.Server.Port = 0
+	cfg.School.Timezone = "Mars/Olympus"
+	cfg.Mail.Driver = ""
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("seharusnya gagal")
+	}
+	for _, k := range []string{"server.port", "school.timezone", "mail.driver"} {
+		if !strings.Contains(err.Error(), k) {
+			t.Errorf("kesalahan %q tidak dilaporkan: %v", k, err)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("PORT", "8080")
+	t.Setenv("TRUSTED_PROXIES", " 10.0.0.0/8, ,172.16.0.0/12 ")
+	t.Setenv("AMBANG_KEHADIRAN", "87,5")
+	t.Setenv("LOGIN_LAMA_KUNCI", "20m")
+	t.Setenv("DIGEST_KIRIM_ORTU", "false")
+
+	cfg := Default()
+	if err := applyEnv(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 8080 {
+		t.Errorf("port = %d, ingin 8080", cfg.Server.Port)
+	}
+	if got := strings.Join(cfg.Server.TrustedProxies, "|"); got != "10.0.0.0/8|172.16.0.0/12" {
+		t.Errorf("trusted proxies = %q", got)
+	}
+	if cfg.School.AmbangKehadiran != 87.5 {
+		t.Errorf("ambang = %v, ingin 87.5", cfg.School.AmbangKehadiran)
+	}
+	if cfg.LoginGuard.LockoutDuration != 20*time.Minute {
+		t.Errorf("lama kunci = %v, ingin 20m", cfg.LoginGuard.LockoutDuration)
+	}
+	if cfg.Digest.KirimOrtu {
+		t.Error("DIGEST_KIRIM_ORTU=false tidak diterapkan")
+	}
+}
+
+func TestApplyEnvTidakValid(t *testing.T) {
+	t.Setenv("PORT", "delapan")
+	t.Setenv("LOGIN_LAMA_KUNCI", "sebentar")
+
+	cfg := Default()
+	err := applyEnv(&cfg)
+	if !errors.Is(err, ErrKonfigurasi) {
+		t.Fatalf("err = %v, ingin ErrKonfigurasi", err)
+	}
+	for _, k := range []string{"PORT", "LOGIN_LAMA_KUNCI"} {
+		if !strings.Contains(err.Error(), k) {
+			t.Errorf("kesalahan %s tidak dilaporkan: %v", k, err)
+		}
+	}
+}
+
+func TestParseJam(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"07:00", 7 * time.Hour, true},
+		{"18:30", 18*time.Hour + 30*time.Minute, true},
+		{"00:00", 0, true},
+		{"24:00", 0, false},
+		{"7", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseJam(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseJam(%q) = %v, %v; ingin %v, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
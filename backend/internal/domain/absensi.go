@@ -1,7 +1,10 @@
 // file: internal/domain/absensi.go
 package domain
 
-import "context"
+import (
+	"context"
+	"math"
+)
 
 type LogAbsensi struct {
 	RowNumber       int    `json:"rowNumber"`
@@ -10,6 +13,7 @@ type LogAbsensi struct {
 	NamaLengkap     string `json:"namaLengkap"`
 	Status          string `json:"status"`
 	TimestampPulang string `json:"timestampPulang"`
+	DicatatOleh     string `json:"dicatatOleh,omitempty"`
 }
 
 type PengajuanIzinLengkap struct {
@@ -21,6 +25,7 @@ type PengajuanIzinLengkap struct {
 	TanggalMulai   string `json:"tanggalMulai"`
 	TanggalSelesai string `json:"tanggalSelesai"`
 	Status         string `json:"status"`
+	KelasInput     string `json:"kelasInput,omitempty"` // Kelas yang diketik di Google Form (jika ada)
 }
 
 type DashboardData struct {
@@ -29,6 +34,7 @@ type DashboardData struct {
 	TotalSiswa          int
 	TotalHadirHariIni   int
 	TotalIzinHariIni    int
+	TotalAlpaHariIni    int
 	TotalBelumAdaKabar  int
 	LogKehadiranHariIni []LogAbsensi
 	SiswaIzinHariIni    []PengajuanIzinLengkap
@@ -38,11 +44,16 @@ type SmartDashboardData struct {
 	NamaLengkapUser    string        `json:"namaLengkapUser"`
 	IsHoliday          bool          `json:"isHoliday"`
 	HolidayDescription string        `json:"holidayDescription"`
+	SetengahHari       bool          `json:"setengahHari,omitempty"`
+	KeteranganHari     string        `json:"keteranganHari,omitempty"` // Misal "Masuk Pengganti" atau "Pulang Cepat"
 	TotalSiswa         int           `json:"totalSiswa"`
 	TotalHadir         int           `json:"totalHadir"`
 	TotalIzin          int           `json:"totalIzin"`
+	TotalAlpa          int           `json:"totalAlpa"`
 	TotalBelumAdaKabar int           `json:"totalBelumAdaKabar"`
 	DaftarStatusSiswa  []SiswaStatus `json:"daftarStatusSiswa"`
+	// Peringatan dini ketidakhadiran hasil evaluasi malam terakhir
+	Peringatan []PeringatanKehadiran `json:"peringatan"`
 }
 
 type SiswaStatus struct {
@@ -65,10 +76,68 @@ type KehadiranManual struct {
 type RekapSiswa struct {
 	NISN        string `json:"nisn"`
 	NamaLengkap string `json:"namaLengkap"`
+	Kelas       string `json:"kelas"`
 	Hadir       int    `json:"hadir"`
 	Izin        int    `json:"izin"`
 	Sakit       int    `json:"sakit"`
 	Alpa        int    `json:"alpa"`
+	PersentaseKehadiran
+}
+
+// PersentaseKehadiran membandingkan jumlah status dengan hari efektif, yaitu hari sekolah
+// dalam periode yang sudah berjalan (hari yang akan datang belum dihitung)
+type PersentaseKehadiran struct {
+	HariEfektif int     `json:"hariEfektif"`
+	PersenHadir float64 `json:"persenHadir"`
+	// Ketidakhadiran berizin (Izin dan Sakit)
+	PersenIzin float64 `json:"persenIzin"`
+	// Ketidakhadiran tanpa keterangan (Alpa)
+	PersenAlpa float64 `json:"persenAlpa"`
+	// Batas minimal persen hadir (misal 85 untuk syarat ikut ujian); 0 berarti tidak dipakai
+	AmbangKehadiran float64 `json:"ambangKehadiran"`
+	DiBawahAmbang   bool    `json:"diBawahAmbang"`
+}
+
+// persenDari menghitung n/pembagi dalam persen, dibulatkan satu desimal
+func persenDari(n, pembagi int) float64 {
+	if pembagi <= 0 {
+		return 0
+	}
+	return math.Round(float64(n)*1000/float64(pembagi)) / 10
+}
+
+// hitungPersentase mengisi persentase dari jumlah status dan pembagi (hari efektif, atau
+// hari efektif × jumlah siswa untuk statistik seluruh sekolah)
+func hitungPersentase(hariEfektif, pembagi, hadir, izin, sakit, alpa int, ambang float64) PersentaseKehadiran {
+	p := PersentaseKehadiran{
+		HariEfektif:     hariEfektif,
+		PersenHadir:     persenDari(hadir, pembagi),
+		PersenIzin:      persenDari(izin+sakit, pembagi),
+		PersenAlpa:      persenDari(alpa, pembagi),
+		AmbangKehadiran: ambang,
+	}
+	// Tanpa hari efektif (periode belum dimulai) belum ada yang bisa dinilai
+	p.DiBawahAmbang = ambang > 0 && pembagi > 0 && p.PersenHadir < ambang
+	return p
+}
+
+// HitungPersentase mengisi persentase kehadiran siswa terhadap hari efektif
+func (r *RekapSiswa) HitungPersentase(hariEfektif int, ambang float64) {
+	r.PersentaseKehadiran = hitungPersentase(hariEfektif, hariEfektif, r.Hadir, r.Izin, r.Sakit, r.Alpa, ambang)
+}
+
+// Tambah menghitung satu status harian ke rekap siswa
+func (r *RekapSiswa) Tambah(status string) {
+	switch status {
+	case StatusHadir:
+		r.Hadir++
+	case StatusIzin:
+		r.Izin++
+	case StatusSakit:
+		r.Sakit++
+	case StatusAlpa:
+		r.Alpa++
+	}
 }
 
 // Struct baru untuk data terpadu di portal wali murid
@@ -85,18 +154,50 @@ type CalendarEvent struct {
 	Color string `json:"color"`
 }
 
+// KalenderAnak adalah event kehadiran satu anak untuk feed kalender wali murid
+type KalenderAnak struct {
+	Siswa  Siswa
+	Events []CalendarEvent
+}
+
 type StatistikData struct {
 	TotalHadir int `json:"totalHadir"`
 	TotalIzin  int `json:"totalIzin"`
 	TotalSakit int `json:"totalSakit"`
 	TotalAlpa  int `json:"totalAlpa"`
+	// Jumlah siswa yang dihitung; persentase memakai hari efektif × jumlah siswa sebagai pembagi
+	JumlahSiswa int `json:"jumlahSiswa"`
+	// Jumlah siswa yang persen hadirnya di bawah ambang kehadiran
+	JumlahDiBawahAmbang int `json:"jumlahDiBawahAmbang"`
+	PersentaseKehadiran
+}
+
+// HitungPersentase mengisi persentase kehadiran gabungan jumlahSiswa siswa terhadap hari efektif
+func (s *StatistikData) HitungPersentase(hariEfektif, jumlahSiswa int, ambang float64) {
+	s.JumlahSiswa = jumlahSiswa
+	s.PersentaseKehadiran = hitungPersentase(hariEfektif, hariEfektif*jumlahSiswa, s.TotalHadir, s.TotalIzin, s.TotalSakit, s.TotalAlpa, ambang)
+}
+
+// Tambah menghitung satu status harian ke statistik
+func (s *StatistikData) Tambah(status string) {
+	switch status {
+	case StatusHadir:
+		s.TotalHadir++
+	case StatusIzin:
+		s.TotalIzin++
+	case StatusSakit:
+		s.TotalSakit++
+	case StatusAlpa:
+		s.TotalAlpa++
+	}
 }
 
 // AbsensiUsecase mendefinisikan kontrak untuk logika bisnis absensi.
 type AbsensiUsecase interface {
 	GenerateQR(ctx context.Context, qrType string) ([]byte, error)
 	VerifyAndRecordScan(ctx context.Context, qrData string, username string) (string, error)
-	GetAttendanceForUser(ctx context.Context, username string) ([]LogAbsensi, error)
+	// GetAttendanceForUser mengambil riwayat kehadiran untuk portal; nisn memilih anak wali murid
+	GetAttendanceForUser(ctx context.Context, username, nisn string) ([]LogAbsensi, error)
 	GetAllLeaveRequests(ctx context.Context) ([]PengajuanIzinLengkap, error)
 	GetDashboardData(ctx context.Context, username string) (*DashboardData, error)
 	GetTodaysAttendanceAndLeave(ctx context.Context) ([]LogAbsensi, []PengajuanIzinLengkap, error)
@@ -107,6 +208,32 @@ type AbsensiUsecase interface {
 	UpdateAttendance(ctx context.Context, rowNumber int, data *KehadiranManual) error
 	GetAttendanceByRow(ctx context.Context, rowNumber int) (*LogAbsensi, error)
 	GetMonthlyStats(ctx context.Context, year, month int) (*StatistikData, error)
+	// GetTren menyusun deret waktu persentase kehadiran per periode dan kelompok, dengan pembanding
+	GetTren(ctx context.Context, filter FilterTren) (*TrenKehadiran, error)
 	GetRekapByDateRange(ctx context.Context, startDate, endDate string) ([]RekapSiswa, error)
-	GetPortalDashboardData(ctx context.Context, username string, year, month int) (*PortalDashboardData, error)
+	GetPortalDashboardData(ctx context.Context, username, nisn string, year, month int) (*PortalDashboardData, error)
+	GetLinkedChildren(ctx context.Context, username string) ([]AnakWali, error)
+	// FinalizeAlpa menulis record Alpa untuk rentang tanggal (backfill); aman dijalankan ulang
+	FinalizeAlpa(ctx context.Context, startDate, endDate, by string) ([]HasilFinalisasi, error)
+	// FinalizeDueDays memfinalisasi hari sekolah yang sudah lewat batas jam Alpa dan belum difinalisasi
+	FinalizeDueDays(ctx context.Context) ([]HasilFinalisasi, error)
+	// GetKalenderWali mengembalikan event kehadiran setiap anak dan event hari libur untuk feed kalender
+	GetKalenderWali(ctx context.Context, username, startDate, endDate string) ([]KalenderAnak, []CalendarEvent, error)
+	// GetGridBulanan menyusun tabel siswa × tanggal satu bulan; kelas kosong berarti semua kelas
+	GetGridBulanan(ctx context.Context, year, month int, kelas string) (*GridBulanan, error)
+	// GetLaporanBulanan menyusun isi laporan kehadiran bulanan PDF; kelas kosong berarti semua kelas
+	GetLaporanBulanan(ctx context.Context, year, month int, kelas string) (*LaporanBulanan, error)
+	// GetLogsForExport mengembalikan LogAbsensi dalam rentang tanggal (wajib) untuk ekspor CSV
+	GetLogsForExport(ctx context.Context, filter FilterEkspor) ([]LogAbsensiEkspor, error)
+	// GetLeaveRequestsForExport mengembalikan pengajuan izin yang beririsan dengan rentang (opsional)
+	GetLeaveRequestsForExport(ctx context.Context, filter FilterEkspor) ([]IzinEkspor, error)
+	// EvaluatePeringatan menjalankan aturan peringatan dini sekarang dan menyimpan hasilnya
+	EvaluatePeringatan(ctx context.Context) (*HasilEvaluasiPeringatan, error)
+	// EvaluateDuePeringatan menjalankan evaluasi sekali sehari setelah hari terakhir difinalisasi
+	EvaluateDuePeringatan(ctx context.Context) (*HasilEvaluasiPeringatan, error)
+	// GetPeringatan mengembalikan peringatan hasil evaluasi terakhir, tingkat tertinggi dulu
+	GetPeringatan(ctx context.Context, filter FilterPeringatan) ([]PeringatanKehadiran, error)
+	// PantauIzin menerbitkan event webhook untuk pengajuan izin yang baru atau berubah sejak
+	// pemeriksaan sebelumnya dan mengembalikan jumlah event
+	PantauIzin(ctx context.Context) (int, error)
 }
@@ -0,0 +1,31 @@
+// file: internal/domain/audit.go
+package domain
+
+import "time"
+
+// Jenis kejadian yang dicatat di AuditLog
+const (
+	AuditLoginGagal   = "LOGIN_GAGAL"
+	AuditLoginDitunda = "LOGIN_DITUNDA"
+	AuditAkunTerkunci = "AKUN_TERKUNCI"
+	AuditAkunDibuka   = "AKUN_DIBUKA"
+	Audit2FAGagal     = "2FA_GAGAL"
+	Audit2FAAktif     = "2FA_DIAKTIFKAN"
+	Audit2FANonaktif  = "2FA_DINONAKTIFKAN"
+	AuditKodePemulih  = "KODE_PEMULIHAN_DIPAKAI"
+	// Perubahan akun oleh admin
+	AuditPenggunaDibuat     = "PENGGUNA_DIBUAT"
+	AuditPenggunaDiubah     = "PENGGUNA_DIUBAH"
+	AuditAkunNonaktif       = "AKUN_DINONAKTIFKAN"
+	AuditAkunAktif          = "AKUN_DIAKTIFKAN"
+	AuditWajibGantiPassword = "WAJIB_GANTI_PASSWORD"
+)
+
+// AuditLog adalah satu catatan kejadian penting terkait keamanan
+type AuditLog struct {
+	Timestamp time.Time `json:"timestamp"`
+	Event     string    `json:"event"`
+	Username  string    `json:"username"`
+	IPAddress string    `json:"ipAddress"`
+	Detail    string    `json:"detail"`
+}
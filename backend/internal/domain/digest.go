@@ -0,0 +1,79 @@
+// file: internal/domain/digest.go
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// JenisKonfirmasiKehadiran adalah jenis pesan ke orang tua yang anaknya belum ada kabar.
+// Jenis ini hanya dipakai notifikasi orang tua, bukan event webhook.
+const JenisKonfirmasiKehadiran = "kehadiran.konfirmasi"
+
+// DigestKelas adalah daftar siswa "Belum Ada Kabar" di satu kelas beserta penerimanya
+type DigestKelas struct {
+	Kelas string `json:"kelas"`
+	// Username wali kelas; berisi admin jika kelas belum punya akun wali kelas aktif di
+	// konfigurasi
+	Penerima      []string      `json:"penerima"`
+	BelumAdaKabar []SiswaStatus `json:"belumAdaKabar"`
+}
+
+// DigestHarian adalah ringkasan pagi untuk satu tanggal. Status siswa sama dengan dashboard.
+type DigestHarian struct {
+	Tanggal            string        `json:"tanggal"`
+	IsHoliday          bool          `json:"isHoliday"`
+	HolidayDescription string        `json:"holidayDescription,omitempty"`
+	TotalSiswa         int           `json:"totalSiswa"`
+	TotalBelumAdaKabar int           `json:"totalBelumAdaKabar"`
+	Kelas              []DigestKelas `json:"kelas"`
+}
+
+// RiwayatDigest adalah satu kali pengiriman ringkasan
+type RiwayatDigest struct {
+	RowNumber   int       `json:"-"`
+	Tanggal     string    `json:"tanggal"`
+	DikirimPada time.Time `json:"dikirimPada"`
+	// Username admin, atau DigestOlehSistem untuk pengiriman terjadwal
+	DikirimOleh        string `json:"dikirimOleh"`
+	JumlahKelas        int    `json:"jumlahKelas"`
+	TotalBelumAdaKabar int    `json:"totalBelumAdaKabar"`
+	EmailTerkirim      int    `json:"emailTerkirim"`
+	EmailGagal         int    `json:"emailGagal"`
+	// Jumlah orang tua yang pesannya masuk antrian notifikasi
+	PesanOrtu int `json:"pesanOrtu"`
+}
+
+// HasilDigest adalah ringkasan yang baru saja dikirim beserta hasil pengirimannya
+type HasilDigest struct {
+	DigestHarian
+	Riwayat RiwayatDigest `json:"riwayat"`
+	// Penerima yang tidak bisa dikirimi email beserta alasannya
+	Gagal []string `json:"gagal"`
+}
+
+// DigestOlehSistem menandai ringkasan yang dikirim oleh jadwal harian
+const DigestOlehSistem = "Sistem (Ringkasan Pagi)"
+
+var (
+	ErrDigestHariLibur = errors.New("hari ini bukan hari sekolah, ringkasan tidak dikirim")
+	// Username di digest.wali_kelas tidak ada atau bukan akun wali kelas
+	ErrDigestWaliKelasTidakValid = errors.New("penerima ringkasan pagi tidak valid")
+)
+
+// DigestUsecase menyusun dan mengirim ringkasan pagi siswa "Belum Ada Kabar"
+type DigestUsecase interface {
+	// Pratinjau menyusun ringkasan tanpa mengirim; tanggal kosong berarti hari ini
+	Pratinjau(ctx context.Context, tanggal string) (*DigestHarian, error)
+	// Kirim menyusun dan mengirim ringkasan hari ini sekarang juga
+	Kirim(ctx context.Context, by string) (*HasilDigest, error)
+	// KirimTerjadwal mengirim ringkasan hari ini jika jamnya sudah lewat dan belum pernah
+	// dikirim; nil berarti tidak ada yang dikirim
+	KirimTerjadwal(ctx context.Context) (*HasilDigest, error)
+	// GetRiwayat mengembalikan pengiriman terbaru lebih dulu
+	GetRiwayat(ctx context.Context) ([]RiwayatDigest, error)
+	// PeriksaWaliKelas memastikan setiap username wali kelas di konfigurasi adalah akun
+	// dengan peran walikelas; dipanggil saat server mulai
+	PeriksaWaliKelas(ctx context.Context) error
+}
@@ -0,0 +1,10 @@
+// file: internal/domain/email.go
+package domain
+
+// EmailMessage adalah satu email yang siap dikirim oleh Mailer
+type EmailMessage struct {
+	To       string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
@@ -0,0 +1,47 @@
+// file: internal/domain/feed_kalender.go
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TokenFeedKalender adalah token langganan kalender (.ics) milik satu wali murid.
+// Hanya hash-nya yang disimpan; token aslinya ada di URL yang diberikan ke wali murid.
+type TokenFeedKalender struct {
+	RowNumber   int
+	Username    string
+	TokenHash   string
+	DibuatPada  time.Time
+	DicabutPada time.Time // Kosong (zero) jika masih berlaku
+}
+
+// Aktif memeriksa apakah token belum dicabut
+func (t TokenFeedKalender) Aktif() bool {
+	return t.DicabutPada.IsZero()
+}
+
+// InfoFeedKalender adalah status langganan kalender wali murid. URL hanya terisi tepat
+// setelah token dibuat, karena token aslinya tidak disimpan.
+type InfoFeedKalender struct {
+	Aktif      bool      `json:"aktif"`
+	URL        string    `json:"url,omitempty"`
+	DibuatPada time.Time `json:"dibuatPada,omitempty"`
+}
+
+var (
+	ErrFeedKalenderTidakValid = errors.New("tautan kalender tidak valid atau sudah dicabut")
+	ErrFeedKalenderDibatasi   = errors.New("terlalu banyak permintaan tautan kalender yang tidak valid, coba lagi nanti")
+)
+
+// FeedKalenderUsecase mengelola langganan kalender kehadiran untuk aplikasi kalender di ponsel
+type FeedKalenderUsecase interface {
+	GetInfo(ctx context.Context, username string) (*InfoFeedKalender, error)
+	// Regenerate membuat token baru dan mencabut token lama milik username
+	Regenerate(ctx context.Context, username string) (*InfoFeedKalender, error)
+	Revoke(ctx context.Context, username, by string) error
+	// Feed mengembalikan isi file .ics untuk token langganan. ip dipakai untuk membatasi
+	// peminta yang mencoba-coba token.
+	Feed(ctx context.Context, token, ip string) ([]byte, error)
+}
@@ -0,0 +1,87 @@
+// file: internal/domain/finalisasi.go
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Status kehadiran yang tersimpan di LogAbsensi
+const (
+	StatusHadir         = "Hadir"
+	StatusIzin          = "Izin"
+	StatusSakit         = "Sakit"
+	StatusAlpa          = "Alpa"
+	StatusBelumAdaKabar = "Belum Ada Kabar"
+)
+
+// DicatatOlehFinalisasi menandai record Alpa yang ditulis oleh job finalisasi, bukan oleh guru
+const DicatatOlehFinalisasi = "Sistem (Finalisasi Alpa)"
+
+// Sumber status harian
+const (
+	SumberLog  = "log"
+	SumberIzin = "izin"
+)
+
+// StatusHarian adalah status akhir satu siswa pada satu tanggal, hasil gabungan LogAbsensi
+// dan pengajuan izin yang disetujui. Semua laporan membaca status ini agar angkanya sama.
+type StatusHarian struct {
+	NISN            string `json:"nisn"`
+	Tanggal         string `json:"tanggal"`
+	Status          string `json:"status"`
+	Sumber          string `json:"sumber"`
+	RowNumber       int    `json:"rowNumber,omitempty"`
+	Timestamp       string `json:"timestamp,omitempty"`
+	TimestampPulang string `json:"timestampPulang,omitempty"`
+	DicatatOleh     string `json:"dicatatOleh,omitempty"`
+}
+
+// FinalisasiAlpa adalah catatan bahwa satu tanggal sudah difinalisasi
+type FinalisasiAlpa struct {
+	Tanggal        string    `json:"tanggal"`
+	JumlahAlpa     int       `json:"jumlahAlpa"`
+	DijalankanPada time.Time `json:"dijalankanPada"`
+	Oleh           string    `json:"oleh"`
+}
+
+// HasilFinalisasi adalah ringkasan finalisasi Alpa untuk satu tanggal
+type HasilFinalisasi struct {
+	Tanggal    string `json:"tanggal"`
+	JumlahAlpa int    `json:"jumlahAlpa"`
+	// Siswa yang sudah punya status (hadir, izin, sakit, atau Alpa dari finalisasi sebelumnya)
+	JumlahTercatat int `json:"jumlahTercatat"`
+	// Alasan tanggal dilewati, misal "Akhir Pekan" atau "Tanggal Merah"
+	Dilewati string `json:"dilewati,omitempty"`
+}
+
+// FinalisasiRequest adalah rentang tanggal yang difinalisasi ulang (backfill) oleh admin
+type FinalisasiRequest struct {
+	TanggalMulai   string `json:"tanggalMulai"`
+	TanggalSelesai string `json:"tanggalSelesai"`
+}
+
+var (
+	ErrRentangTanggal        = errors.New("rentang tanggal tidak valid")
+	ErrFinalisasiBelumWaktu  = errors.New("tanggal ini belum bisa difinalisasi sebelum batas jam Alpa")
+	ErrFinalisasiSedangJalan = errors.New("finalisasi Alpa sedang berjalan, coba lagi sebentar")
+)
+
+// IzinDisetujui memeriksa kolom "Tindak Lanjut Wali Kelas" pada pengajuan izin.
+// Pengajuan yang masih menunggu atau ditolak tidak membebaskan siswa dari Alpa.
+func IzinDisetujui(status string) bool {
+	s := strings.ToLower(strings.TrimSpace(status))
+	if s == "" || s == "menunggu" {
+		return false
+	}
+	return !strings.Contains(s, "tolak")
+}
+
+// StatusDariJenisIzin mengubah jenis izin di Google Form menjadi status kehadiran
+func StatusDariJenisIzin(jenis string) string {
+	if strings.Contains(strings.ToLower(jenis), "sakit") {
+		return StatusSakit
+	}
+	return StatusIzin
+}
@@ -0,0 +1,139 @@
+// file: internal/domain/kalender.go
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Jenis entri kalender sekolah
+const (
+	// KalenderLibur meliburkan satu tanggal atau rentang tanggal (libur nasional, libur Ramadan, libur semester)
+	KalenderLibur = "libur"
+	// KalenderSetengahHari menandai hari sekolah yang pulang lebih awal
+	KalenderSetengahHari = "setengah_hari"
+	// KalenderMasukPengganti menjadikan hari libur mingguan (misal Sabtu) sebagai hari sekolah
+	KalenderMasukPengganti = "masuk_pengganti"
+)
+
+const (
+	// KeteranganTanggalMerah dipakai untuk tanggal dari sheet lama TanggalLibur yang tidak punya keterangan
+	KeteranganTanggalMerah = "Tanggal Merah"
+	// KeteranganAkhirPekan dipakai untuk hari libur mingguan
+	KeteranganAkhirPekan = "Akhir Pekan"
+)
+
+// KalenderSekolah adalah satu entri kalender yang berlaku dari TanggalMulai sampai TanggalSelesai
+type KalenderSekolah struct {
+	RowNumber      int       `json:"-"`
+	ID             string    `json:"id"`
+	TanggalMulai   string    `json:"tanggalMulai"`   // YYYY-MM-DD
+	TanggalSelesai string    `json:"tanggalSelesai"` // YYYY-MM-DD, sama dengan TanggalMulai untuk satu hari
+	Jenis          string    `json:"jenis"`
+	Keterangan     string    `json:"keterangan"`
+	DibuatOleh     string    `json:"dibuatOleh,omitempty"`
+	DibuatPada     time.Time `json:"dibuatPada"`
+	// Entri dari sheet lama TanggalLibur hanya bisa dibaca, tidak bisa diubah lewat API
+	HanyaBaca bool `json:"hanyaBaca,omitempty"`
+}
+
+// KalenderRequest adalah data entri kalender yang dikirim admin
+type KalenderRequest struct {
+	TanggalMulai   string `json:"tanggalMulai"`
+	TanggalSelesai string `json:"tanggalSelesai"`
+	Jenis          string `json:"jenis"`
+	Keterangan     string `json:"keterangan"`
+}
+
+// HariKalender adalah hasil aturan hari sekolah untuk satu tanggal
+type HariKalender struct {
+	Tanggal      string `json:"tanggal"`
+	HariSekolah  bool   `json:"hariSekolah"`
+	SetengahHari bool   `json:"setengahHari,omitempty"`
+	// Alasan libur ("Akhir Pekan", nama hari libur) atau keterangan hari sekolah khusus
+	Keterangan string `json:"keterangan,omitempty"`
+}
+
+// Format file impor kalender
+const (
+	FormatImporICS = "ics"
+	FormatImporCSV = "csv"
+)
+
+// Status setiap baris pratinjau impor kalender
+const (
+	// ImporBaru belum ada di kalender dan akan ditambahkan
+	ImporBaru = "baru"
+	// ImporDuplikat sudah ada di kalender dengan tanggal dan jenis yang sama
+	ImporDuplikat = "duplikat"
+	// ImporKonflik beririsan dengan entri lain yang tanggal atau jenisnya berbeda
+	ImporKonflik = "konflik"
+	// ImporDitolak tidak bisa dibaca atau dibatalkan di file sumber
+	ImporDitolak = "ditolak"
+)
+
+// ImporKalenderRequest adalah file kalender yang diunggah admin
+type ImporKalenderRequest struct {
+	Format string // FormatImporICS atau FormatImporCSV
+	Data   []byte
+	// Jenis untuk event .ics dan baris CSV tanpa kolom jenis; kosong berarti KalenderLibur
+	Jenis string
+	// Terapkan = false hanya menampilkan pratinjau tanpa menyimpan apa pun
+	Terapkan bool
+	// SertakanKonflik ikut menyimpan baris berstatus konflik (diperiksa dulu di pratinjau)
+	SertakanKonflik bool
+}
+
+// ImporKalenderItem adalah satu baris pratinjau impor kalender
+type ImporKalenderItem struct {
+	Baris          int    `json:"baris,omitempty"`
+	TanggalMulai   string `json:"tanggalMulai,omitempty"`
+	TanggalSelesai string `json:"tanggalSelesai,omitempty"`
+	Jenis          string `json:"jenis,omitempty"`
+	Keterangan     string `json:"keterangan,omitempty"`
+	Status         string `json:"status"`
+	Alasan         string `json:"alasan,omitempty"`
+	// Entri kalender yang sudah ada dan beririsan dengan baris ini
+	Bentrok  []KalenderSekolah `json:"bentrok,omitempty"`
+	Disimpan bool              `json:"disimpan"`
+}
+
+// HasilImporKalender adalah ringkasan pratinjau atau hasil impor kalender
+type HasilImporKalender struct {
+	Diterapkan     bool                `json:"diterapkan"`
+	Items          []ImporKalenderItem `json:"items"`
+	JumlahBaru     int                 `json:"jumlahBaru"`
+	JumlahDuplikat int                 `json:"jumlahDuplikat"`
+	JumlahKonflik  int                 `json:"jumlahKonflik"`
+	JumlahDitolak  int                 `json:"jumlahDitolak"`
+	JumlahDisimpan int                 `json:"jumlahDisimpan"`
+}
+
+// IsValidJenisKalender memeriksa jenis entri kalender
+func IsValidJenisKalender(jenis string) bool {
+	switch jenis {
+	case KalenderLibur, KalenderSetengahHari, KalenderMasukPengganti:
+		return true
+	}
+	return false
+}
+
+var (
+	ErrKalenderTidakValid     = errors.New("data kalender tidak valid")
+	ErrKalenderTidakDitemukan = errors.New("entri kalender tidak ditemukan")
+	ErrKalenderHanyaBaca      = errors.New("entri dari sheet TanggalLibur hanya bisa diubah langsung di spreadsheet")
+	ErrFormatImporKalender    = errors.New("file kalender tidak bisa dibaca")
+)
+
+// KalenderUsecase mengelola kalender sekolah dan menjadi satu-satunya sumber aturan hari sekolah
+type KalenderUsecase interface {
+	List(ctx context.Context, startDate, endDate string) ([]KalenderSekolah, error)
+	Create(ctx context.Context, req KalenderRequest, by string) (*KalenderSekolah, error)
+	Update(ctx context.Context, id string, req KalenderRequest, by string) (*KalenderSekolah, error)
+	Delete(ctx context.Context, id, by string) error
+	// GetHari mengembalikan status hari sekolah untuk setiap tanggal dalam rentang
+	GetHari(ctx context.Context, startDate, endDate string) ([]HariKalender, error)
+	// Impor membaca file .ics atau CSV, menampilkan pratinjau, dan (jika Terapkan) menggabungkannya
+	Impor(ctx context.Context, req ImporKalenderRequest, by string) (*HasilImporKalender, error)
+}
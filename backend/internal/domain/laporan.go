@@ -0,0 +1,106 @@
+// file: internal/domain/laporan.go
+package domain
+
+import "time"
+
+// Kode kehadiran di grid bulanan, seperti di buku absen kelas
+const (
+	KodeHadir = "H"
+	KodeIzin  = "I"
+	KodeSakit = "S"
+	KodeAlpa  = "A"
+)
+
+// KodeStatus mengubah status kehadiran menjadi kode satu huruf (kosong jika tidak dikenal)
+func KodeStatus(status string) string {
+	switch status {
+	case StatusHadir:
+		return KodeHadir
+	case StatusIzin:
+		return KodeIzin
+	case StatusSakit:
+		return KodeSakit
+	case StatusAlpa:
+		return KodeAlpa
+	}
+	return ""
+}
+
+// GridBulanan adalah rekap satu bulan dalam bentuk tabel siswa × tanggal
+type GridBulanan struct {
+	Tahun int            `json:"tahun"`
+	Bulan int            `json:"bulan"`
+	Kelas string         `json:"kelas,omitempty"`
+	Hari  []HariKalender `json:"hari"`
+	// Jumlah hari sekolah yang sudah berjalan sampai hari ini, pembagi persentase kehadiran
+	HariEfektif int         `json:"hariEfektif"`
+	Baris       []BarisGrid `json:"baris"`
+}
+
+// BarisGrid adalah satu siswa di grid bulanan
+type BarisGrid struct {
+	Siswa Siswa `json:"siswa"`
+	// Kode per tanggal, sejajar dengan GridBulanan.Hari; kosong jika bukan hari sekolah
+	// atau belum ada status
+	Kode        []string   `json:"kode"`
+	Rekap       RekapSiswa `json:"rekap"`
+	PersenHadir float64    `json:"persenHadir"`
+}
+
+// FilterEkspor membatasi data yang diekspor ke CSV. Tanggal berformat YYYY-MM-DD;
+// kelas kosong berarti semua kelas.
+type FilterEkspor struct {
+	Mulai   string
+	Selesai string
+	Kelas   string
+}
+
+// LogAbsensiEkspor adalah satu baris LogAbsensi beserta kelas siswanya
+type LogAbsensiEkspor struct {
+	LogAbsensi
+	Kelas string `json:"kelas"`
+}
+
+// IzinEkspor adalah satu pengajuan izin beserta kelas siswanya
+type IzinEkspor struct {
+	PengajuanIzinLengkap
+	Kelas string `json:"kelas"`
+}
+
+// LaporanBulanan adalah isi laporan kehadiran bulanan satu kelas (atau semua kelas) yang
+// ditandatangani wali kelas: kalender per siswa, total, keterlambatan dan alasan izin
+type LaporanBulanan struct {
+	Tahun       int            `json:"tahun"`
+	Bulan       int            `json:"bulan"`
+	Kelas       string         `json:"kelas,omitempty"`
+	Hari        []HariKalender `json:"hari"`
+	HariEfektif int            `json:"hariEfektif"`
+	// Jam masuk sekolah (HH:MM) yang dipakai untuk menentukan keterlambatan
+	JamMasuk string         `json:"jamMasuk"`
+	Siswa    []LaporanSiswa `json:"siswa"`
+	// Waktu laporan disusun, dipakai sebagai tanggal tanda tangan
+	DibuatPada time.Time `json:"dibuatPada"`
+}
+
+// LaporanSiswa adalah bagian laporan bulanan untuk satu siswa
+type LaporanSiswa struct {
+	BarisGrid
+	Statistik StatistikData   `json:"statistik"`
+	Terlambat []Keterlambatan `json:"terlambat"`
+	Izin      []CatatanIzin   `json:"izin"`
+}
+
+// Keterlambatan adalah satu scan datang setelah jam masuk
+type Keterlambatan struct {
+	Tanggal string `json:"tanggal"`
+	Jam     string `json:"jam"`
+	Menit   int    `json:"menit"`
+}
+
+// CatatanIzin adalah satu pengajuan izin siswa di bulan laporan
+type CatatanIzin struct {
+	TanggalMulai   string `json:"tanggalMulai"`
+	TanggalSelesai string `json:"tanggalSelesai"`
+	Alasan         string `json:"alasan"`
+	Status         string `json:"status"`
+}
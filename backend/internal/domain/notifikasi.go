@@ -0,0 +1,122 @@
+// file: internal/domain/notifikasi.go
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Kanal pengiriman notifikasi ke orang tua
+const (
+	KanalWhatsApp = "whatsapp"
+	KanalSMS      = "sms"
+	KanalEmail    = "email"
+	// Pesan hanya ditulis ke log/file, untuk uji coba
+	KanalLog = "log"
+)
+
+// Status pengiriman notifikasi di LogNotifikasi
+const (
+	NotifikasiTerkirim = "terkirim"
+	// Gagal dan akan dicoba lagi
+	NotifikasiGagal = "gagal"
+	// Gagal dan tidak dicoba lagi
+	NotifikasiMenyerah = "menyerah"
+	// Ditahan sampai jam tenang keluarga selesai
+	NotifikasiDitunda = "ditunda"
+)
+
+// PesanNotifikasi adalah satu pesan yang siap dikirim oleh satu kanal
+type PesanNotifikasi struct {
+	// Nomor telepon (WhatsApp/SMS) atau alamat email
+	Tujuan string
+	// Judul pesan; hanya dipakai kanal email
+	Subjek string
+	Isi    string
+}
+
+// PreferensiNotifikasi adalah pilihan satu keluarga (satu siswa) untuk notifikasi kehadiran.
+// Notifikasi bersifat opt-in: siswa tanpa preferensi tidak dikirimi pesan.
+type PreferensiNotifikasi struct {
+	RowNumber int    `json:"-"`
+	NISN      string `json:"nisn"`
+	Aktif     bool   `json:"aktif"`
+	// Kanal yang dipakai, misal [whatsapp, email]
+	Kanal []string `json:"kanal"`
+	// Jam tenang (HH:MM) di zona waktu sekolah, boleh melewati tengah malam (21:00 s.d. 06:00).
+	// Pesan pada jam tenang ditahan sampai jam tenang selesai. Kosong berarti tanpa jam tenang.
+	JamTenangMulai   string    `json:"jamTenangMulai"`
+	JamTenangSelesai string    `json:"jamTenangSelesai"`
+	DiubahOleh       string    `json:"diubahOleh,omitempty"`
+	DiubahPada       time.Time `json:"diubahPada"`
+}
+
+// PreferensiNotifikasiRequest adalah perubahan preferensi dari wali murid atau admin
+type PreferensiNotifikasiRequest struct {
+	Aktif            bool     `json:"aktif"`
+	Kanal            []string `json:"kanal"`
+	JamTenangMulai   string   `json:"jamTenangMulai"`
+	JamTenangSelesai string   `json:"jamTenangSelesai"`
+}
+
+// PreferensiAnak adalah preferensi notifikasi satu anak di portal wali murid, beserta
+// kontak yang akan dihubungi
+type PreferensiAnak struct {
+	PreferensiNotifikasi
+	NamaLengkap      string `json:"namaLengkap"`
+	NomorTeleponOrtu string `json:"nomorTeleponOrtu"`
+	EmailOrtu        string `json:"emailOrtu"`
+}
+
+// LogNotifikasi adalah satu percobaan (atau penundaan) pengiriman notifikasi
+type LogNotifikasi struct {
+	// ID sama untuk semua percobaan pesan yang sama ke kanal yang sama
+	ID        string    `json:"id"`
+	Waktu     time.Time `json:"waktu"`
+	NISN      string    `json:"nisn"`
+	Jenis     string    `json:"jenis"` // Jenis event, misal absensi.masuk
+	Kanal     string    `json:"kanal"`
+	Tujuan    string    `json:"tujuan"` // Disamarkan sebagian
+	Status    string    `json:"status"`
+	Percobaan int       `json:"percobaan"`
+	Error     string    `json:"error,omitempty"`
+	// Kapan pesan gagal/ditunda dikirim lagi
+	BerikutnyaPada time.Time `json:"berikutnyaPada"`
+	// Event asal pesan (tanpa kontak orang tua) untuk pesan gagal/ditunda; disimpan agar
+	// pesan tetap dikirim setelah server restart
+	Payload string `json:"-"`
+}
+
+// FilterLogNotifikasi menyaring log notifikasi; Batas 0 berarti bawaan
+type FilterLogNotifikasi struct {
+	NISN   string
+	Status string
+	Batas  int
+}
+
+var (
+	ErrPreferensiNotifikasiTidakValid = errors.New("preferensi notifikasi tidak valid")
+	ErrSiswaTidakDitemukan            = errors.New("siswa tidak ditemukan")
+)
+
+// NotifikasiUsecase mengirim pesan ke orang tua saat siswa scan masuk dan pulang
+type NotifikasiUsecase interface {
+	// KanalTersedia mengembalikan kanal yang dipasang di server
+	KanalTersedia() []string
+	GetPreferensi(ctx context.Context, nisn string) (*PreferensiNotifikasi, error)
+	SetPreferensi(ctx context.Context, nisn string, req PreferensiNotifikasiRequest, by string) (*PreferensiNotifikasi, error)
+	// GetPreferensiWali mengembalikan preferensi setiap anak yang tertaut ke akun wali murid
+	GetPreferensiWali(ctx context.Context, username string) ([]PreferensiAnak, error)
+	// SetPreferensiWali mengubah preferensi satu anak milik wali murid
+	SetPreferensiWali(ctx context.Context, username, nisn string, req PreferensiNotifikasiRequest) (*PreferensiNotifikasi, error)
+	// GetLog mengembalikan log pengiriman terbaru lebih dulu
+	GetLog(ctx context.Context, filter FilterLogNotifikasi) ([]LogNotifikasi, error)
+	// Terbitkan menerima event kehadiran; hanya absensi.masuk dan absensi.pulang yang dikirim
+	Terbitkan(jenis string, data interface{})
+	// MintaKonfirmasi mengantrikan permintaan konfirmasi ke orang tua siswa yang belum ada kabar
+	// dan mengembalikan jumlah keluarga yang akan dikirimi pesan
+	MintaKonfirmasi(ctx context.Context, daftar []SiswaStatus) int
+	// Jalankan memproses antrian, jam tenang, dan percobaan ulang sampai ctx selesai
+	Jalankan(ctx context.Context)
+}
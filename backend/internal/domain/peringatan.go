@@ -0,0 +1,86 @@
+// file: internal/domain/peringatan.go
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Aturan peringatan dini ketidakhadiran
+const (
+	// Alpa beberapa hari sekolah berturut-turut
+	AturanAlpaBerturut = "alpa_berturut"
+	// Terlalu sering tidak hadir (Izin, Sakit, Alpa) dalam jendela evaluasi
+	AturanAbsenJendela = "absen_jendela"
+	// Tidak hadir berulang pada hari yang sama, misal Sakit setiap Senin
+	AturanPolaHari = "pola_hari"
+	// Terlambat berulang kali dalam jendela evaluasi
+	AturanTerlambat = "terlambat_berulang"
+)
+
+// Tingkat keparahan peringatan
+const (
+	TingkatRendah = "rendah"
+	TingkatSedang = "sedang"
+	TingkatTinggi = "tinggi"
+)
+
+// BobotTingkat mengurutkan tingkat peringatan; tingkat tidak dikenal bernilai 0
+func BobotTingkat(tingkat string) int {
+	switch tingkat {
+	case TingkatRendah:
+		return 1
+	case TingkatSedang:
+		return 2
+	case TingkatTinggi:
+		return 3
+	}
+	return 0
+}
+
+// PeringatanKehadiran adalah satu temuan aturan peringatan dini untuk satu siswa. Daftar
+// peringatan dihitung ulang setiap malam, jadi peringatan hilang sendiri jika pola berhenti.
+type PeringatanKehadiran struct {
+	// ID tetap selama aturan yang sama masih terpenuhi untuk siswa yang sama
+	ID          string `json:"id"`
+	NISN        string `json:"nisn"`
+	NamaLengkap string `json:"namaLengkap"`
+	Kelas       string `json:"kelas"`
+	Aturan      string `json:"aturan"`
+	Tingkat     string `json:"tingkat"`
+	Pesan       string `json:"pesan"`
+	// Rentang tanggal kejadian yang memicu peringatan (YYYY-MM-DD)
+	TanggalMulai   string `json:"tanggalMulai"`
+	TanggalSelesai string `json:"tanggalSelesai"`
+	// Jumlah hari yang memicu aturan (hari Alpa, hari tidak hadir, atau hari terlambat)
+	Jumlah int `json:"jumlah"`
+	// Kapan peringatan ini pertama kali muncul dan terakhir dievaluasi
+	PertamaMuncul  time.Time `json:"pertamaMuncul"`
+	DievaluasiPada time.Time `json:"dievaluasiPada"`
+}
+
+// FilterPeringatan membatasi daftar peringatan; field kosong berarti semua
+type FilterPeringatan struct {
+	Kelas string
+	NISN  string
+	// Tingkat minimal, misal "sedang" menampilkan sedang dan tinggi
+	Tingkat string
+}
+
+// HasilEvaluasiPeringatan adalah ringkasan satu kali evaluasi aturan peringatan
+type HasilEvaluasiPeringatan struct {
+	TanggalMulai   string `json:"tanggalMulai"`
+	TanggalSelesai string `json:"tanggalSelesai"`
+	JumlahSiswa    int    `json:"jumlahSiswa"`
+	// Jumlah peringatan per tingkat
+	Tinggi int `json:"tinggi"`
+	Sedang int `json:"sedang"`
+	Rendah int `json:"rendah"`
+	// Peringatan yang belum ada pada evaluasi sebelumnya
+	Baru int `json:"baru"`
+}
+
+var (
+	ErrTingkatPeringatan       = errors.New("tingkat peringatan harus rendah, sedang, atau tinggi")
+	ErrEvaluasiPeringatanJalan = errors.New("evaluasi peringatan sedang berjalan, coba lagi sebentar")
+)
@@ -0,0 +1,29 @@
+// file: internal/domain/provisioning.go
+package domain
+
+// PrefixUsernameOrtu ditambahkan di depan NISN untuk username akun wali murid, misal "ortu0012345678"
+const PrefixUsernameOrtu = "ortu"
+
+// KredensialAkun adalah username dan password awal satu akun hasil provisioning.
+// Password kosong jika akunnya sudah ada sebelumnya (tidak diubah).
+type KredensialAkun struct {
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+	Dibuat   bool   `json:"dibuat"`
+}
+
+// KredensialKeluarga berisi akun siswa dan akun wali murid untuk satu siswa (satu slip)
+type KredensialKeluarga struct {
+	Siswa     Siswa          `json:"siswa"`
+	AkunSiswa KredensialAkun `json:"akunSiswa"`
+	AkunOrtu  KredensialAkun `json:"akunOrtu"`
+}
+
+// HasilProvisioning adalah ringkasan pembuatan akun massal dari data siswa
+type HasilProvisioning struct {
+	Kelas          string               `json:"kelas,omitempty"`
+	LoginURL       string               `json:"loginUrl"`
+	Keluarga       []KredensialKeluarga `json:"keluarga"`
+	JumlahDibuat   int                  `json:"jumlahDibuat"`
+	JumlahDilewati int                  `json:"jumlahDilewati"`
+}
@@ -0,0 +1,50 @@
+// file: internal/domain/rekonsiliasi.go
+package domain
+
+import "context"
+
+// NISNTidakDitemukan adalah penanda untuk pengajuan izin dari Google Form
+// yang nama siswanya belum bisa dicocokkan dengan DataSiswa
+const NISNTidakDitemukan = "N/A - Nama tidak ditemukan di DataSiswa"
+
+// Status antrian rekonsiliasi nama
+const (
+	RekonsiliasiMenunggu = "Menunggu"
+	RekonsiliasiSelesai  = "Selesai"
+)
+
+// KandidatSiswa adalah siswa yang mungkin dimaksud oleh nama yang diketik di Google Form
+type KandidatSiswa struct {
+	NISN        string  `json:"nisn"`
+	NamaLengkap string  `json:"namaLengkap"`
+	Kelas       string  `json:"kelas"`
+	Skor        float64 `json:"skor"`
+}
+
+// RekonsiliasiNama adalah satu nama dari Google Form yang ambigu dan perlu diputuskan admin
+type RekonsiliasiNama struct {
+	RowNumber        int             `json:"rowNumber"`
+	ID               string          `json:"id"`
+	Timestamp        string          `json:"timestamp"`
+	NamaInput        string          `json:"namaInput"`
+	KelasInput       string          `json:"kelasInput"`
+	Kandidat         []KandidatSiswa `json:"kandidat"`
+	Status           string          `json:"status"`
+	NISNTerpilih     string          `json:"nisnTerpilih"`
+	DiselesaikanOleh string          `json:"diselesaikanOleh"`
+}
+
+// AliasNamaSiswa menyimpan keputusan admin agar nama yang sama langsung dikenali di baris berikutnya
+type AliasNamaSiswa struct {
+	NamaNormal  string `json:"namaNormal"`
+	NISN        string `json:"nisn"`
+	DicatatOleh string `json:"dicatatOleh"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// RekonsiliasiUsecase mendefinisikan kontrak pencocokan nama siswa dari Google Form
+type RekonsiliasiUsecase interface {
+	ResolveLeaveRequests(ctx context.Context, requests []PengajuanIzinLengkap) error
+	GetQueue(ctx context.Context, status string) ([]RekonsiliasiNama, error)
+	ResolveQueueItem(ctx context.Context, id, nisn, resolvedBy string) error
+}
@@ -0,0 +1,26 @@
+// file: internal/domain/relasi_wali.go
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// RelasiWaliSiswa menautkan satu akun wali murid ke satu siswa.
+// Satu wali bisa punya beberapa anak, dan satu siswa bisa punya beberapa wali (ayah, ibu, dll.).
+type RelasiWaliSiswa struct {
+	RowNumber  int       `json:"-"`
+	Username   string    `json:"username"`
+	SiswaNISN  string    `json:"siswaNisn"`
+	Hubungan   string    `json:"hubungan"` // Misal "Ayah", "Ibu", "Wali"
+	DibuatPada time.Time `json:"dibuatPada"`
+}
+
+// AnakWali adalah data siswa yang tertaut ke akun wali murid yang sedang login
+type AnakWali struct {
+	Siswa
+	Hubungan string `json:"hubungan,omitempty"`
+}
+
+// ErrAnakTidakTertaut dikembalikan jika wali murid meminta data siswa yang bukan anaknya
+var ErrAnakTidakTertaut = errors.New("akun ini tidak terhubung dengan siswa tersebut")
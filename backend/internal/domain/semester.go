@@ -0,0 +1,75 @@
+// file: internal/domain/semester.go
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Status semester
+const (
+	// SemesterTerbuka: rekap dihitung langsung dari LogAbsensi setiap kali diminta
+	SemesterTerbuka = "terbuka"
+	// SemesterTerkunci: rekap dibaca dari salinan yang disimpan saat dikunci, sehingga raport
+	// yang sudah terbit tidak berubah walaupun LogAbsensi diedit
+	SemesterTerkunci = "terkunci"
+)
+
+// Semester adalah satu periode raport, misal "2025/2026 Ganjil"
+type Semester struct {
+	RowNumber      int       `json:"-"`
+	ID             string    `json:"id"`
+	Nama           string    `json:"nama"`
+	TanggalMulai   string    `json:"tanggalMulai"`   // YYYY-MM-DD
+	TanggalSelesai string    `json:"tanggalSelesai"` // YYYY-MM-DD
+	Status         string    `json:"status"`
+	DibuatOleh     string    `json:"dibuatOleh,omitempty"`
+	DibuatPada     time.Time `json:"dibuatPada"`
+	DikunciOleh    string    `json:"dikunciOleh,omitempty"`
+	DikunciPada    time.Time `json:"dikunciPada"`
+	// Pembukaan kunci terakhir beserta alasannya
+	DibukaOleh string    `json:"dibukaOleh,omitempty"`
+	DibukaPada time.Time `json:"dibukaPada"`
+	AlasanBuka string    `json:"alasanBuka,omitempty"`
+}
+
+// SemesterRequest adalah data semester yang dikirim admin
+type SemesterRequest struct {
+	Nama           string `json:"nama"`
+	TanggalMulai   string `json:"tanggalMulai"`
+	TanggalSelesai string `json:"tanggalSelesai"`
+}
+
+// BukaSemesterRequest adalah permintaan membuka kunci semester; alasan wajib diisi
+type BukaSemesterRequest struct {
+	Alasan string `json:"alasan"`
+}
+
+// RekapSemester adalah jumlah Sakit/Izin/Alpa setiap siswa selama satu semester untuk raport
+type RekapSemester struct {
+	Semester Semester     `json:"semester"`
+	Terkunci bool         `json:"terkunci"`
+	Siswa    []RekapSiswa `json:"siswa"`
+}
+
+var (
+	ErrSemesterTidakValid     = errors.New("data semester tidak valid")
+	ErrSemesterTidakDitemukan = errors.New("semester tidak ditemukan")
+	ErrSemesterTerkunci       = errors.New("semester sudah dikunci; buka kunci dulu untuk mengubahnya")
+	ErrSemesterBelumTerkunci  = errors.New("semester belum dikunci")
+)
+
+// SemesterUsecase mengelola semester dan rekap ketidakhadiran untuk raport
+type SemesterUsecase interface {
+	List(ctx context.Context) ([]Semester, error)
+	Create(ctx context.Context, req SemesterRequest, by string) (*Semester, error)
+	Update(ctx context.Context, id string, req SemesterRequest, by string) (*Semester, error)
+	Delete(ctx context.Context, id, by string) error
+	// GetRekap mengembalikan rekap per siswa; kelas kosong berarti semua kelas
+	GetRekap(ctx context.Context, id, kelas string) (*RekapSemester, error)
+	// Lock menyimpan salinan rekap saat ini dan mengunci semester
+	Lock(ctx context.Context, id, by string) (*RekapSemester, error)
+	// Unlock membuka kunci semester sehingga rekap kembali dihitung dari LogAbsensi
+	Unlock(ctx context.Context, id string, req BukaSemesterRequest, by string) (*Semester, error)
+}
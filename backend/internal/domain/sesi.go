@@ -0,0 +1,47 @@
+// file: internal/domain/sesi.go
+package domain
+
+import "time"
+
+// SesiPengguna adalah satu sesi login (satu perangkat) yang disimpan di server.
+// Access token JWT membawa ID sesi ini sehingga sesi bisa dicabut kapan saja.
+type SesiPengguna struct {
+	RowNumber         int       `json:"-"`
+	ID                string    `json:"id"`
+	Username          string    `json:"username"`
+	RefreshTokenHash  string    `json:"-"`
+	PreviousTokenHash string    `json:"-"` // Hash refresh token sebelumnya, untuk mendeteksi pemakaian ulang
+	CreatedAt         time.Time `json:"createdAt"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+	LastUsedAt        time.Time `json:"lastUsedAt"`
+	RevokedAt         time.Time `json:"revokedAt"`
+	UserAgent         string    `json:"userAgent"`
+	IPAddress         string    `json:"ipAddress"`
+}
+
+// Active bernilai true jika sesi belum dicabut dan belum kedaluwarsa
+func (s *SesiPengguna) Active(now time.Time) bool {
+	return s.RevokedAt.IsZero() && now.Before(s.ExpiresAt)
+}
+
+// ClientInfo berisi informasi perangkat yang melakukan login
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// LoginResult dikirim ke klien setelah login atau refresh berhasil.
+// Jika akun memakai 2FA, token belum diterbitkan: klien harus mengirim kode
+// bersama ChallengeToken ke langkah kedua.
+type LoginResult struct {
+	AccessToken  string `json:"token,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	ExpiresIn    int    `json:"expiresIn,omitempty"` // Masa berlaku access token dalam detik
+	// Token hanya bisa dipakai untuk mengganti password sampai password diganti
+	PasswordChangeRequired bool `json:"passwordChangeRequired,omitempty"`
+
+	TwoFactorRequired      bool     `json:"twoFactorRequired,omitempty"`
+	TwoFactorSetupRequired bool     `json:"twoFactorSetupRequired,omitempty"`
+	ChallengeToken         string   `json:"challengeToken,omitempty"`
+	RecoveryCodes          []string `json:"recoveryCodes,omitempty"` // Hanya dikirim sekali saat 2FA baru diaktifkan
+}
@@ -1,7 +1,10 @@
 // file: internal/domain/siswa.go
 package domain
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 // Siswa merepresentasikan data seorang siswa
 type Siswa struct {
@@ -20,6 +23,10 @@ type SiswaRepository interface {
 	Save(ctx context.Context, siswa *Siswa) error
 	Update(ctx context.Context, nisn string, siswa *Siswa) error
 	Delete(ctx context.Context, nisn string) error
+	// SaveAll dan UpdateAll menulis banyak siswa dalam satu panggilan API (dipakai impor),
+	// termasuk nama orang tua
+	SaveAll(ctx context.Context, list []Siswa) error
+	UpdateAll(ctx context.Context, list []Siswa) error
 }
 
 type SiswaUsecase interface {
@@ -28,4 +35,40 @@ type SiswaUsecase interface {
 	GetByNISN(ctx context.Context, nisn string) (*Siswa, error)
 	Update(ctx context.Context, nisn string, siswa *Siswa) error
 	Delete(ctx context.Context, nisn string) error
+	// Impor memvalidasi CSV siswa per baris; jika terapkan dan semua baris benar, data disimpan
+	Impor(ctx context.Context, data []byte, terapkan bool, by string) (*HasilImporSiswa, error)
 }
+
+// Aksi yang akan dilakukan untuk satu baris impor siswa
+const (
+	AksiImporTambah = "tambah"
+	AksiImporUbah   = "ubah"
+	AksiImporTetap  = "tetap" // Data di file sama dengan yang sudah tersimpan
+)
+
+// ImporSiswaBaris adalah hasil validasi satu baris file CSV siswa
+type ImporSiswaBaris struct {
+	Baris      int      `json:"baris"`
+	Siswa      Siswa    `json:"siswa"`
+	Aksi       string   `json:"aksi,omitempty"`
+	Galat      []string `json:"galat,omitempty"`
+	Peringatan []string `json:"peringatan,omitempty"`
+}
+
+// HasilImporSiswa adalah ringkasan pratinjau (dry run) atau hasil impor siswa
+type HasilImporSiswa struct {
+	Diterapkan   bool              `json:"diterapkan"`
+	Baris        []ImporSiswaBaris `json:"baris"`
+	JumlahTambah int               `json:"jumlahTambah"`
+	JumlahUbah   int               `json:"jumlahUbah"`
+	JumlahTetap  int               `json:"jumlahTetap"`
+	JumlahGalat  int               `json:"jumlahGalat"`
+	// Message diisi saat impor ditolak karena masih ada baris yang salah
+	Message string `json:"message,omitempty"`
+}
+
+var (
+	ErrFormatImporSiswa = errors.New("file CSV siswa tidak bisa dibaca")
+	// ErrImporSiswaGalat: impor tidak diterapkan sama sekali selama masih ada baris yang salah
+	ErrImporSiswaGalat = errors.New("masih ada baris yang salah, perbaiki file lalu unggah ulang")
+)
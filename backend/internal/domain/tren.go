@@ -0,0 +1,67 @@
+// file: internal/domain/tren.go
+package domain
+
+import "errors"
+
+// Panjang satu titik pada grafik tren
+const (
+	PeriodeHarian   = "harian"
+	PeriodeMingguan = "mingguan"
+	PeriodeBulanan  = "bulanan"
+)
+
+// Pengelompokan seri tren
+const (
+	KelompokSekolah = "sekolah"
+	KelompokKelas   = "kelas"
+	// Tingkat kelas, misal 7A dan 7B sama-sama tingkat 7
+	KelompokTingkat = "tingkat"
+)
+
+// FilterTren adalah parameter analitik tren kehadiran. Tanggal berformat YYYY-MM-DD;
+// field kosong memakai bawaan (30 hari terakhir, harian, seluruh sekolah).
+type FilterTren struct {
+	Mulai    string
+	Selesai  string
+	Periode  string
+	Kelompok string
+	// Hanya siswa kelas ini (opsional)
+	Kelas string
+}
+
+// TrenKehadiran adalah deret waktu persentase kehadiran untuk grafik di halaman statistik
+type TrenKehadiran struct {
+	Mulai    string `json:"mulai"`
+	Selesai  string `json:"selesai"`
+	Periode  string `json:"periode"`
+	Kelompok string `json:"kelompok"`
+	// Rentang pembanding dengan panjang yang sama tepat sebelum Mulai
+	MulaiSebelumnya   string `json:"mulaiSebelumnya"`
+	SelesaiSebelumnya string `json:"selesaiSebelumnya"`
+	// Seri pertama selalu seluruh sekolah (atau kelas pada filter), lalu satu seri per kelompok
+	Seri []SeriTren `json:"seri"`
+}
+
+// SeriTren adalah satu garis pada grafik: seluruh sekolah, satu kelas, atau satu tingkat
+type SeriTren struct {
+	Nama  string      `json:"nama"`
+	Titik []TitikTren `json:"titik"`
+	// Statistik seluruh rentang dan rentang sebelumnya
+	Total      StatistikData `json:"total"`
+	Sebelumnya StatistikData `json:"sebelumnya"`
+	// Perubahan persen hadir dibanding rentang sebelumnya, dalam poin persen
+	SelisihHadir float64 `json:"selisihHadir"`
+}
+
+// TitikTren adalah statistik satu hari, minggu, atau bulan
+type TitikTren struct {
+	// Misal "2025-09-01" (harian), "2025-W36" (mingguan), atau "2025-09" (bulanan)
+	Label   string `json:"label"`
+	Mulai   string `json:"mulai"`
+	Selesai string `json:"selesai"`
+	StatistikData
+	// Perubahan persen hadir dibanding titik sebelumnya (poin persen); 0 untuk titik pertama
+	SelisihHadir float64 `json:"selisihHadir"`
+}
+
+var ErrParameterTren = errors.New("parameter tren tidak valid")
@@ -1,22 +1,160 @@
 // file: internal/domain/user.go
 package domain
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Peran (role) pengguna yang dikenal sistem
+const (
+	RoleAdmin = "admin"
+	RoleSiswa = "siswa"
+	RoleOrtu  = "ortu"
+	// Wali kelas mengelola kehadiran kelasnya dari dashboard
+	RoleWaliKelas = "walikelas"
+)
+
+// IsValidRole memeriksa apakah role termasuk peran yang dikenal sistem
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleSiswa, RoleOrtu, RoleWaliKelas:
+		return true
+	}
+	return false
+}
 
 // User mendefinisikan struktur data utama dari pengguna
 type User struct {
-	Username     string `json:"username"`
-	PasswordHash string `json:"-"` // Sembunyikan dari JSON
-	Role         string `json:"role"`
-	NamaLengkap  string `json:"namaLengkap"`
-	SiswaNISN    string `json:"siswaNisn"` // <-- TAMBAHKAN INI
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"` // Sembunyikan dari JSON
+	Role         string    `json:"role"`
+	NamaLengkap  string    `json:"namaLengkap"`
+	SiswaNISN    string    `json:"siswaNisn"` // <-- TAMBAHKAN INI
+	Email        string    `json:"email"`
+	LockedUntil  time.Time `json:"lockedUntil"` // Akun terkunci sementara sampai waktu ini
+	// Autentikasi dua faktor (TOTP). Rahasia dan hash kode pemulihan tidak pernah dikirim ke klien.
+	TOTPSecret         string   `json:"-"`
+	TOTPEnabled        bool     `json:"totpEnabled"`
+	RecoveryCodeHashes []string `json:"-"`
+	// Langkah waktu (counter) kode TOTP terakhir yang diterima; kode dengan counter yang sama
+	// atau lebih lama ditolak agar kode yang tersadap tidak bisa dipakai ulang
+	TOTPCounterTerakhir int64 `json:"-"`
+	// Akun yang dinonaktifkan admin tidak bisa login dan semua sesinya dicabut
+	Disabled bool `json:"disabled"`
+	// Pengguna harus mengganti password sebelum bisa memakai fitur lain
+	MustChangePassword bool `json:"mustChangePassword"`
+}
+
+// UserFilter menyaring daftar pengguna di halaman admin
+type UserFilter struct {
+	Role   string
+	Query  string // Dicocokkan ke username, nama lengkap, email atau NISN
+	Status string // "aktif", "nonaktif", atau kosong untuk semua
+}
+
+// CreateUserRequest adalah data akun baru yang dibuat oleh admin
+type CreateUserRequest struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"` // Kosongkan agar dibuatkan password sementara
+	Role        string `json:"role"`
+	NamaLengkap string `json:"namaLengkap"`
+	SiswaNISN   string `json:"siswaNisn"`
+	Email       string `json:"email"`
+}
+
+// UpdateUserRequest adalah perubahan data akun oleh admin; field kosong tidak diubah
+type UpdateUserRequest struct {
+	Role        string `json:"role"`
+	NamaLengkap string `json:"namaLengkap"`
+	Email       string `json:"email"`
+}
+
+// UserBaru dikembalikan setelah admin membuat akun.
+// PasswordSementara hanya terisi jika password dibuatkan oleh sistem.
+type UserBaru struct {
+	User              *User  `json:"user"`
+	PasswordSementara string `json:"passwordSementara,omitempty"`
+}
+
+// TwoFactorSetup berisi data untuk didaftarkan ke aplikasi authenticator
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"`
+	QRCodePNG  []byte `json:"qrCode"` // PNG, dikirim sebagai base64 di JSON
+}
+
+// ErrTerlaluBanyakPermintaanReset dikembalikan jika username meminta reset password terlalu sering
+var ErrTerlaluBanyakPermintaanReset = errors.New("terlalu banyak permintaan reset password, coba lagi nanti")
+
+// ErrSesiTidakValid dikembalikan jika refresh token tidak dikenal, kedaluwarsa atau sudah dicabut
+var ErrSesiTidakValid = errors.New("sesi tidak valid, silakan login kembali")
+
+// ErrKodeTidakValid dikembalikan jika kode authenticator atau kode pemulihan salah
+var ErrKodeTidakValid = errors.New("kode verifikasi tidak valid")
+
+// ErrAkunNonaktif dikembalikan jika akun sudah dinonaktifkan oleh admin
+var ErrAkunNonaktif = errors.New("akun ini sudah dinonaktifkan, hubungi admin sekolah")
+
+// ErrKredensialSalah dikembalikan jika username tidak ada atau password salah
+var ErrKredensialSalah = errors.New("username atau password salah")
+
+// LoginDitundaError dikembalikan jika percobaan login ditolak karena terlalu banyak kegagalan
+type LoginDitundaError struct {
+	RetryAfter time.Duration
+	Terkunci   bool // true jika akunnya dikunci, bukan sekadar diperlambat
+}
+
+func (e *LoginDitundaError) Error() string {
+	detik := int(e.RetryAfter.Round(time.Second).Seconds())
+	if e.Terkunci {
+		return fmt.Sprintf("akun terkunci karena terlalu banyak percobaan login gagal, coba lagi dalam %d detik", detik)
+	}
+	return fmt.Sprintf("terlalu banyak percobaan login, coba lagi dalam %d detik", detik)
 }
 
 type UserUsecase interface {
-	Login(ctx context.Context, username, password string) (string, error)
+	Login(ctx context.Context, username, password string, client ClientInfo) (*LoginResult, error)
 	Register(ctx context.Context, user *User) error
-	UpdateUser(ctx context.Context, currentUsername string, newUsername, newPassword string) error
-	RequestPasswordReset(ctx context.Context, username string) (string, error)
+	UpdateUser(ctx context.Context, currentUsername, sessionID string, newUsername, newPassword string) (*LoginResult, error)
+	RequestPasswordReset(ctx context.Context, username string) error
 	ResetPassword(ctx context.Context, token, newPassword string) error
 	GetByUsername(ctx context.Context, username string) (*User, error)
+	PurgeExpiredResetTokens(ctx context.Context) (int, error)
+	RefreshSession(ctx context.Context, refreshToken string, client ClientInfo) (*LoginResult, error)
+	Logout(ctx context.Context, sessionID string) error
+	LogoutAll(ctx context.Context, username string) (int, error)
+	ListSessions(ctx context.Context, username string) ([]SesiPengguna, error)
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+	PurgeExpiredSessions(ctx context.Context) (int, error)
+	UnlockAccount(ctx context.Context, username, unlockedBy string) error
+	VerifyTwoFactorLogin(ctx context.Context, challengeToken, code string, client ClientInfo) (*LoginResult, error)
+	BeginRequiredTwoFactorSetup(ctx context.Context, challengeToken string) (*TwoFactorSetup, error)
+	CompleteRequiredTwoFactorSetup(ctx context.Context, challengeToken, code string, client ClientInfo) (*LoginResult, error)
+	SetupTwoFactor(ctx context.Context, username string) (*TwoFactorSetup, error)
+	EnableTwoFactor(ctx context.Context, username, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, username, password, code string) error
+	ResetTwoFactor(ctx context.Context, username, resetBy string) error
+	ListUsers(ctx context.Context, filter UserFilter) ([]User, error)
+	CreateUser(ctx context.Context, req CreateUserRequest, createdBy string) (*UserBaru, error)
+	UpdateUserByAdmin(ctx context.Context, username string, req UpdateUserRequest, updatedBy string) (*User, error)
+	LinkParentToStudent(ctx context.Context, username, nisn, hubungan, linkedBy string) error
+	UnlinkParentFromStudent(ctx context.Context, username, nisn, unlinkedBy string) error
+	ListLinkedStudents(ctx context.Context, username string) ([]RelasiWaliSiswa, error)
+	SetUserDisabled(ctx context.Context, username string, disabled bool, changedBy string) error
+	ForcePasswordReset(ctx context.Context, username, requestedBy string) error
+	ProvisionAccounts(ctx context.Context, kelas, provisionedBy string) (*HasilProvisioning, error)
+	RenderCredentialSlips(hasil *HasilProvisioning) ([]byte, error)
+}
+
+// TokenReset adalah token reset password yang tersimpan (hanya hash-nya, bukan token aslinya)
+type TokenReset struct {
+	RowNumber int
+	TokenHash string
+	Username  string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    time.Time // Kosong (zero) jika belum dipakai
 }
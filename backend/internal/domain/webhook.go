@@ -0,0 +1,178 @@
+// file: internal/domain/webhook.go
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Jenis event yang dikirim ke webhook
+const (
+	// Siswa memindai QR masuk
+	EventAbsensiMasuk = "absensi.masuk"
+	// Siswa memindai QR pulang
+	EventAbsensiPulang = "absensi.pulang"
+	// Wali kelas mencatat kehadiran manual (satu atau massal)
+	EventAbsensiDicatat = "absensi.dicatat"
+	// Record LogAbsensi diubah admin atau wali kelas
+	EventAbsensiDiubah = "absensi.diubah"
+	// Siswa dicatat Alpa, oleh finalisasi maupun manual
+	EventAbsensiAlpa = "absensi.alpa"
+	// Pengajuan izin baru masuk dari Google Form
+	EventIzinDiajukan = "izin.diajukan"
+	// Status atau tanggal pengajuan izin berubah
+	EventIzinBerubah = "izin.berubah"
+	// Event uji yang hanya dikirim lewat tombol "kirim uji"
+	EventPing = "ping"
+)
+
+// DaftarEventWebhook adalah event yang bisa dipilih saat mendaftarkan webhook
+var DaftarEventWebhook = []string{
+	EventAbsensiMasuk, EventAbsensiPulang, EventAbsensiDicatat, EventAbsensiDiubah,
+	EventAbsensiAlpa, EventIzinDiajukan, EventIzinBerubah,
+}
+
+// IsValidEventWebhook memeriksa apakah jenis event dikenal
+func IsValidEventWebhook(jenis string) bool {
+	for _, e := range DaftarEventWebhook {
+		if e == jenis {
+			return true
+		}
+	}
+	return false
+}
+
+// Status satu percobaan pengiriman webhook
+const (
+	PengirimanBerhasil = "berhasil"
+	// Gagal dan akan dicoba lagi pada BerikutnyaPada
+	PengirimanGagal = "gagal"
+	// Gagal dan tidak dicoba lagi (batas percobaan habis atau ditolak penerima)
+	PengirimanMenyerah = "menyerah"
+)
+
+// Webhook adalah alamat sistem lain (asrama, kantin, bot WhatsApp) yang menerima event kehadiran.
+// Secret dipakai untuk tanda tangan HMAC dan hanya ditampilkan saat dibuat atau diganti.
+type Webhook struct {
+	RowNumber int    `json:"-"`
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	// Jenis event yang dikirim; kosong berarti semua event
+	Events     []string  `json:"events"`
+	Secret     string    `json:"-"`
+	Aktif      bool      `json:"aktif"`
+	Keterangan string    `json:"keterangan,omitempty"`
+	DibuatOleh string    `json:"dibuatOleh,omitempty"`
+	DibuatPada time.Time `json:"dibuatPada"`
+	DiubahPada time.Time `json:"diubahPada"`
+}
+
+// Menerima memeriksa apakah webhook aktif dan berlangganan jenis event
+func (w Webhook) Menerima(jenis string) bool {
+	if !w.Aktif {
+		return false
+	}
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == jenis {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookRequest adalah data webhook yang dikirim admin. Aktif kosong (nil) berarti aktif
+// saat dibuat dan tidak berubah saat diubah.
+type WebhookRequest struct {
+	URL        string   `json:"url"`
+	Events     []string `json:"events"`
+	Keterangan string   `json:"keterangan"`
+	Aktif      *bool    `json:"aktif"`
+}
+
+// WebhookDenganSecret dikirim tepat setelah webhook dibuat atau secret-nya diganti
+type WebhookDenganSecret struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// EventWebhook adalah isi JSON yang dikirim ke setiap webhook
+type EventWebhook struct {
+	ID    string      `json:"id"`
+	Jenis string      `json:"jenis"`
+	Waktu time.Time   `json:"waktu"`
+	Data  interface{} `json:"data"`
+}
+
+// DataEventAbsensi adalah isi event absensi.*
+type DataEventAbsensi struct {
+	RowNumber       int    `json:"rowNumber,omitempty"`
+	NISN            string `json:"nisn"`
+	NamaLengkap     string `json:"namaLengkap"`
+	Kelas           string `json:"kelas"`
+	Status          string `json:"status"`
+	Timestamp       string `json:"timestamp"`
+	TimestampPulang string `json:"timestampPulang,omitempty"`
+	DicatatOleh     string `json:"dicatatOleh,omitempty"`
+}
+
+// DataEventIzin adalah isi event izin.*
+type DataEventIzin struct {
+	PengajuanIzinLengkap
+	// Kelas siswa di DataSiswa; kosong jika NISN belum dicocokkan
+	Kelas string `json:"kelas"`
+	// Status sebelum berubah; kosong untuk pengajuan baru
+	StatusSebelumnya string `json:"statusSebelumnya,omitempty"`
+}
+
+// PengirimanWebhook adalah satu percobaan pengiriman event ke satu webhook
+type PengirimanWebhook struct {
+	// ID pengiriman sama untuk semua percobaan event yang sama ke webhook yang sama
+	ID        string `json:"id"`
+	WebhookID string `json:"webhookId"`
+	EventID   string `json:"eventId"`
+	Jenis     string `json:"jenis"`
+	Percobaan int    `json:"percobaan"`
+	Status    string `json:"status"`
+	// Kode status HTTP dari penerima; 0 jika tidak tersambung
+	KodeHTTP       int       `json:"kodeHttp"`
+	Error          string    `json:"error,omitempty"`
+	DurasiMs       int64     `json:"durasiMs"`
+	Waktu          time.Time `json:"waktu"`
+	BerikutnyaPada time.Time `json:"berikutnyaPada"`
+	// JSON yang dikirim; disimpan agar percobaan ulang tetap jalan setelah server restart
+	Payload string `json:"-"`
+}
+
+// FilterPengirimanWebhook menyaring log pengiriman; Batas 0 berarti bawaan
+type FilterPengirimanWebhook struct {
+	WebhookID string
+	Status    string
+	Batas     int
+}
+
+var (
+	ErrWebhookTidakValid     = errors.New("data webhook tidak valid")
+	ErrWebhookTidakDitemukan = errors.New("webhook tidak ditemukan")
+)
+
+// WebhookUsecase mengelola langganan webhook dan mengirim event kehadiran ke sistem lain
+type WebhookUsecase interface {
+	List(ctx context.Context) ([]Webhook, error)
+	Create(ctx context.Context, req WebhookRequest, by string) (*WebhookDenganSecret, error)
+	Update(ctx context.Context, id string, req WebhookRequest, by string) (*Webhook, error)
+	Delete(ctx context.Context, id, by string) error
+	// RotateSecret membuat secret baru; secret lama langsung tidak berlaku
+	RotateSecret(ctx context.Context, id, by string) (*WebhookDenganSecret, error)
+	// KirimUji mengirim event ping sekarang dan mengembalikan hasil percobaannya
+	KirimUji(ctx context.Context, id string) (*PengirimanWebhook, error)
+	// GetPengiriman mengembalikan log pengiriman terbaru lebih dulu
+	GetPengiriman(ctx context.Context, filter FilterPengirimanWebhook) ([]PengirimanWebhook, error)
+	// Terbitkan memasukkan event ke antrian pengiriman tanpa menunggu
+	Terbitkan(jenis string, data interface{})
+	// Jalankan memproses antrian dan percobaan ulang sampai ctx selesai
+	Jalankan(ctx context.Context)
+}
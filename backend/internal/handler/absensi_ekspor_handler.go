@@ -0,0 +1,150 @@
+// file: internal/handler/absensi_ekspor_handler.go
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/laporan"
+
+	"github.com/labstack/echo/v4"
+)
+
+// kirimFile mengirim file untuk diunduh dengan nama filename
+func kirimFile(c echo.Context, contentType, filename string, isi []byte) error {
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	return c.Blob(http.StatusOK, contentType, isi)
+}
+
+// kirimCSV mengalirkan CSV langsung ke respons tanpa menampungnya dulu di memori. Setelah
+// header terkirim status tidak bisa diubah lagi, jadi error saat menulis hanya dicatat.
+func kirimCSV(c echo.Context, filename string, tulis func(w io.Writer) error) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, laporan.ContentTypeCSV)
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	res.WriteHeader(http.StatusOK)
+	if err := tulis(res); err != nil {
+		log.Printf("ERROR menulis %s: %v", filename, err)
+	}
+	return nil
+}
+
+// namaFileKelas membuat potongan nama file dari nama kelas, misal "7A" menjadi "-7A"
+func namaFileKelas(kelas string) string {
+	if kelas == "" {
+		return ""
+	}
+	return "-" + strings.Map(func(r rune) rune {
+		if r == ' ' || r == '/' || r == '\\' || r == '"' {
+			return '_'
+		}
+		return r
+	}, kelas)
+}
+
+// ExportRekapXLSXAPI mengunduh rekap kehadiran sebagai Excel, satu lembar per kelas.
+// Query: mulai, selesai (YYYY-MM-DD), kelas (opsional)
+func (h *AbsensiHandler) ExportRekapXLSXAPI(c echo.Context) error {
+	startDate, endDate, kelas := c.QueryParam("mulai"), c.QueryParam("selesai"), c.QueryParam("kelas")
+	rekap, err := h.absensiUsecase.GetRekapByDateRange(c.Request().Context(), startDate, endDate)
+	if err != nil {
+		if errors.Is(err, domain.ErrRentangTanggal) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": "Tanggal mulai dan selesai harus berformat YYYY-MM-DD"})
+		}
+		log.Printf("ERROR mengambil rekap untuk Excel: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil data rekap"})
+	}
+	if kelas != "" {
+		var terpilih []domain.RekapSiswa
+		for _, r := range rekap {
+			if r.Kelas == kelas {
+				terpilih = append(terpilih, r)
+			}
+		}
+		rekap = terpilih
+	}
+
+	isi, err := laporan.RekapXLSX(rekap, startDate, endDate)
+	if err != nil {
+		log.Printf("ERROR membuat file Excel rekap: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal membuat file Excel"})
+	}
+	return kirimFile(c, laporan.ContentTypeXLSX, fmt.Sprintf("rekap-kehadiran%s-%s-%s.xlsx", namaFileKelas(kelas), startDate, endDate), isi)
+}
+
+// ExportGridBulananXLSXAPI mengunduh daftar hadir bulanan (siswa × tanggal) sebagai Excel.
+// Query: kelas (opsional)
+func (h *AbsensiHandler) ExportGridBulananXLSXAPI(c echo.Context) error {
+	tahun, errTahun := strconv.Atoi(c.Param("tahun"))
+	bulan, errBulan := strconv.Atoi(c.Param("bulan"))
+	if errTahun != nil || errBulan != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Tahun atau bulan tidak valid"})
+	}
+	kelas := c.QueryParam("kelas")
+
+	grid, err := h.absensiUsecase.GetGridBulanan(c.Request().Context(), tahun, bulan, kelas)
+	if err != nil {
+		if errors.Is(err, domain.ErrRentangTanggal) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		}
+		log.Printf("ERROR mengambil grid bulanan: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil data kehadiran bulanan"})
+	}
+
+	isi, err := laporan.GridXLSX(grid)
+	if err != nil {
+		log.Printf("ERROR membuat file Excel grid bulanan: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal membuat file Excel"})
+	}
+	return kirimFile(c, laporan.ContentTypeXLSX, fmt.Sprintf("daftar-hadir%s-%d-%02d.xlsx", namaFileKelas(kelas), tahun, bulan), isi)
+}
+
+// filterEkspor membaca query mulai, selesai (YYYY-MM-DD) dan kelas
+func filterEkspor(c echo.Context) domain.FilterEkspor {
+	return domain.FilterEkspor{
+		Mulai:   c.QueryParam("mulai"),
+		Selesai: c.QueryParam("selesai"),
+		Kelas:   c.QueryParam("kelas"),
+	}
+}
+
+// ExportLogCSVAPI mengunduh isi LogAbsensi sebagai CSV.
+// Query: mulai, selesai (YYYY-MM-DD, wajib), kelas (opsional)
+func (h *AbsensiHandler) ExportLogCSVAPI(c echo.Context) error {
+	filter := filterEkspor(c)
+	logs, err := h.absensiUsecase.GetLogsForExport(c.Request().Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrRentangTanggal) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		}
+		log.Printf("ERROR mengambil log absensi untuk CSV: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil log absensi"})
+	}
+	nama := fmt.Sprintf("log-absensi%s-%s-%s.csv", namaFileKelas(filter.Kelas), filter.Mulai, filter.Selesai)
+	return kirimCSV(c, nama, func(w io.Writer) error { return laporan.LogAbsensiCSV(w, logs) })
+}
+
+// ExportIzinCSVAPI mengunduh pengajuan izin sebagai CSV.
+// Query: mulai, selesai (YYYY-MM-DD, opsional), kelas (opsional)
+func (h *AbsensiHandler) ExportIzinCSVAPI(c echo.Context) error {
+	filter := filterEkspor(c)
+	list, err := h.absensiUsecase.GetLeaveRequestsForExport(c.Request().Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrRentangTanggal) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		}
+		log.Printf("ERROR mengambil pengajuan izin untuk CSV: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil data pengajuan izin"})
+	}
+	nama := "pengajuan-izin" + namaFileKelas(filter.Kelas)
+	if filter.Mulai != "" || filter.Selesai != "" {
+		nama += "-" + filter.Mulai + "-" + filter.Selesai
+	}
+	return kirimCSV(c, nama+".csv", func(w io.Writer) error { return laporan.IzinCSV(w, list) })
+}
@@ -0,0 +1,37 @@
+// file: internal/handler/absensi_finalisasi_handler.go
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// FinalizeAlpaAPI menjalankan ulang finalisasi Alpa untuk rentang tanggal (backfill).
+// Siswa yang sudah punya status dilewati, jadi aman dipanggil berulang kali.
+func (h *AbsensiHandler) FinalizeAlpaAPI(c echo.Context) error {
+	var req domain.FinalisasiRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+	if req.TanggalSelesai == "" {
+		req.TanggalSelesai = req.TanggalMulai
+	}
+
+	hasil, err := h.absensiUsecase.FinalizeAlpa(c.Request().Context(), req.TanggalMulai, req.TanggalSelesai, adminUsername(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRentangTanggal), errors.Is(err, domain.ErrFinalisasiBelumWaktu):
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		case errors.Is(err, domain.ErrFinalisasiSedangJalan):
+			return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+		}
+		log.Printf("ERROR finalisasi Alpa: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal menjalankan finalisasi Alpa"})
+	}
+	return c.JSON(http.StatusOK, hasil)
+}
@@ -2,10 +2,10 @@
 package handler
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
-	"time"
 
 	"daarulilmi-presence/internal/domain" // Ganti dengan nama modul Anda
 
@@ -32,8 +32,19 @@ func NewAbsensiHandler(e *echo.Echo, api *echo.Group, absensiUsecase domain.Abse
 	api.GET("/absensi/log/:row", handler.GetAttendanceByRowAPI)
 	api.GET("/absensi/rekap/:tanggal", handler.GetRekapByDateAPI)
 	api.GET("/statistik/bulanan/:tahun/:bulan", handler.GetMonthlyStatsAPI)
+	api.GET("/statistik/tren", handler.GetTrenAPI, RequireRole(domain.RoleAdmin, domain.RoleWaliKelas))
 	api.GET("/rekap", handler.GetRekapAPI)
+	api.GET("/rekap/xlsx", handler.ExportRekapXLSXAPI, RequireRole(domain.RoleAdmin, domain.RoleWaliKelas))
+	api.GET("/rekap/bulanan/:tahun/:bulan/xlsx", handler.ExportGridBulananXLSXAPI, RequireRole(domain.RoleAdmin, domain.RoleWaliKelas))
+	api.GET("/absensi/csv", handler.ExportLogCSVAPI, RequireRole(domain.RoleAdmin, domain.RoleWaliKelas))
+	api.GET("/izin/csv", handler.ExportIzinCSVAPI, RequireRole(domain.RoleAdmin, domain.RoleWaliKelas))
+	api.GET("/peringatan", handler.GetPeringatanAPI, RequireRole(domain.RoleAdmin, domain.RoleWaliKelas))
 	api.GET("/portal/dashboard-data/:tahun/:bulan", handler.GetPortalDashboardDataAPI)
+	api.GET("/portal/anak", handler.GetLinkedChildrenAPI)
+
+	// Rute API khusus admin
+	api.POST("/admin/absensi/finalisasi-alpa", handler.FinalizeAlpaAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/peringatan/evaluasi", handler.EvaluatePeringatanAPI, RequireRole(domain.RoleAdmin))
 
 	// Rute Halaman
 	e.GET("/dashboard", handler.ShowDashboardPage)
@@ -99,9 +110,12 @@ func (h *AbsensiHandler) GetPortalData(c echo.Context) error {
 	userClaims := c.Get("user").(jwt.MapClaims)
 	username := userClaims["username"].(string)
 
-	// Logika untuk memanggil usecase tetap sama
-	attendanceData, err := h.absensiUsecase.GetAttendanceForUser(c.Request().Context(), username)
+	// ?nisn= memilih anak untuk wali murid dengan lebih dari satu anak
+	attendanceData, err := h.absensiUsecase.GetAttendanceForUser(c.Request().Context(), username, c.QueryParam("nisn"))
 	if err != nil {
+		if errors.Is(err, domain.ErrAnakTidakTertaut) {
+			return c.JSON(http.StatusForbidden, map[string]string{"message": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil data absensi"})
 	}
 
@@ -164,7 +178,7 @@ func (h *AbsensiHandler) GetDashboardData(c echo.Context) error {
 
 func (h *AbsensiHandler) GetTodaysAttendanceAPI(c echo.Context) error {
 	// Panggil logika "Smart Dashboard" yang sudah ada
-	smartData, err := h.absensiUsecase.GetSmartDashboardData(c.Request().Context(), "", time.Now().Format("2006-01-02"))
+	smartData, err := h.absensiUsecase.GetSmartDashboardData(c.Request().Context(), "", "") // tanggal kosong = hari ini di zona waktu sekolah
 	if err != nil {
 		log.Printf("ERROR getting smart dashboard data for attendance page: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal memuat data"})
@@ -181,11 +195,7 @@ func (h *AbsensiHandler) CreateManualAttendanceAPI(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
 	}
 
-	// Set timestamp ke waktu sekarang jika tidak disediakan oleh frontend
-	if data.Timestamp == "" {
-		data.Timestamp = time.Now().Format("2006-01-02 15:04:05")
-	}
-
+	// Timestamp kosong diisi usecase dengan waktu sekarang di zona waktu sekolah
 	err := h.absensiUsecase.CreateManualAttendance(c.Request().Context(), data)
 	if err != nil {
 		log.Printf("ERROR usecase CreateManualAttendance: %v", err)
@@ -263,6 +273,20 @@ func (h *AbsensiHandler) GetMonthlyStatsAPI(c echo.Context) error {
 	tahun, _ := strconv.Atoi(tahunStr)
 	bulan, _ := strconv.Atoi(bulanStr)
 
+	// Wali murid hanya melihat statistik anaknya (?nisn= memilih anak), bukan seluruh sekolah
+	userClaims := c.Get("user").(jwt.MapClaims)
+	if role, _ := userClaims["role"].(string); role == domain.RoleOrtu {
+		username, _ := userClaims["username"].(string)
+		data, err := h.absensiUsecase.GetPortalDashboardData(c.Request().Context(), username, c.QueryParam("nisn"), tahun, bulan)
+		if err != nil {
+			if errors.Is(err, domain.ErrAnakTidakTertaut) {
+				return c.JSON(http.StatusForbidden, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusNotFound, map[string]string{"message": err.Error()})
+		}
+		return c.JSON(http.StatusOK, data.StatistikBulan)
+	}
+
 	stats, err := h.absensiUsecase.GetMonthlyStats(c.Request().Context(), tahun, bulan)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
@@ -289,9 +313,26 @@ func (h *AbsensiHandler) GetPortalDashboardDataAPI(c echo.Context) error {
 	tahun, _ := strconv.Atoi(tahunStr)
 	bulan, _ := strconv.Atoi(bulanStr)
 
-	data, err := h.absensiUsecase.GetPortalDashboardData(c.Request().Context(), username, tahun, bulan)
+	// ?nisn= memilih anak untuk wali murid dengan lebih dari satu anak
+	data, err := h.absensiUsecase.GetPortalDashboardData(c.Request().Context(), username, c.QueryParam("nisn"), tahun, bulan)
 	if err != nil {
+		if errors.Is(err, domain.ErrAnakTidakTertaut) {
+			return c.JSON(http.StatusForbidden, map[string]string{"message": err.Error()})
+		}
 		return c.JSON(http.StatusNotFound, map[string]string{"message": err.Error()})
 	}
 	return c.JSON(http.StatusOK, data)
 }
+
+// GetLinkedChildrenAPI menampilkan daftar anak milik wali murid yang sedang login (untuk pilihan anak di portal)
+func (h *AbsensiHandler) GetLinkedChildrenAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	anak, err := h.absensiUsecase.GetLinkedChildren(c.Request().Context(), username)
+	if err != nil {
+		log.Printf("ERROR mengambil daftar anak %s: %v", username, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil daftar anak"})
+	}
+	return c.JSON(http.StatusOK, anak)
+}
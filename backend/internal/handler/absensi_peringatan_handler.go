@@ -0,0 +1,44 @@
+// file: internal/handler/absensi_peringatan_handler.go
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetPeringatanAPI mengembalikan peringatan dini ketidakhadiran hasil evaluasi terakhir.
+// Query opsional: kelas, nisn, tingkat (tingkat minimal: rendah, sedang, tinggi)
+func (h *AbsensiHandler) GetPeringatanAPI(c echo.Context) error {
+	filter := domain.FilterPeringatan{
+		Kelas:   c.QueryParam("kelas"),
+		NISN:    c.QueryParam("nisn"),
+		Tingkat: c.QueryParam("tingkat"),
+	}
+	list, err := h.absensiUsecase.GetPeringatan(c.Request().Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrTingkatPeringatan) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		}
+		log.Printf("ERROR mengambil peringatan kehadiran: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil peringatan kehadiran"})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// EvaluatePeringatanAPI menjalankan evaluasi peringatan sekarang tanpa menunggu jadwal malam
+func (h *AbsensiHandler) EvaluatePeringatanAPI(c echo.Context) error {
+	hasil, err := h.absensiUsecase.EvaluatePeringatan(c.Request().Context())
+	if err != nil {
+		if errors.Is(err, domain.ErrEvaluasiPeringatanJalan) {
+			return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+		}
+		log.Printf("ERROR evaluasi peringatan kehadiran: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal menjalankan evaluasi peringatan"})
+	}
+	return c.JSON(http.StatusOK, hasil)
+}
@@ -0,0 +1,34 @@
+// file: internal/handler/absensi_tren_handler.go
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetTrenAPI mengembalikan deret waktu persentase kehadiran untuk grafik statistik.
+// Query opsional: mulai, selesai (YYYY-MM-DD), periode (harian, mingguan, bulanan),
+// kelompok (sekolah, kelas, tingkat), dan kelas
+func (h *AbsensiHandler) GetTrenAPI(c echo.Context) error {
+	filter := domain.FilterTren{
+		Mulai:    c.QueryParam("mulai"),
+		Selesai:  c.QueryParam("selesai"),
+		Periode:  c.QueryParam("periode"),
+		Kelompok: c.QueryParam("kelompok"),
+		Kelas:    c.QueryParam("kelas"),
+	}
+	tren, err := h.absensiUsecase.GetTren(c.Request().Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrRentangTanggal) || errors.Is(err, domain.ErrParameterTren) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		}
+		log.Printf("ERROR menghitung tren kehadiran: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal menghitung tren kehadiran"})
+	}
+	return c.JSON(http.StatusOK, tren)
+}
@@ -0,0 +1,62 @@
+// file: internal/handler/digest_handler.go
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+type DigestHandler struct {
+	usecase domain.DigestUsecase
+}
+
+func NewDigestHandler(e *echo.Echo, api *echo.Group, usecase domain.DigestUsecase) {
+	handler := &DigestHandler{usecase}
+
+	// Ringkasan pagi dikirim otomatis sesuai jadwal; admin bisa melihat isinya lebih dulu
+	// atau mengirimnya lebih awal
+	admin := RequireRole(domain.RoleAdmin)
+	api.GET("/admin/digest", handler.PratinjauAPI, admin)
+	api.POST("/admin/digest/kirim", handler.KirimAPI, admin)
+	api.GET("/admin/digest/riwayat", handler.ListRiwayatAPI, admin)
+}
+
+// PratinjauAPI menampilkan isi ringkasan tanpa mengirim. Query: tanggal (YYYY-MM-DD, bawaan hari ini)
+func (h *DigestHandler) PratinjauAPI(c echo.Context) error {
+	digest, err := h.usecase.Pratinjau(c.Request().Context(), c.QueryParam("tanggal"))
+	if err != nil {
+		if errors.Is(err, domain.ErrRentangTanggal) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		}
+		log.Printf("ERROR menyusun ringkasan pagi: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal menyusun ringkasan pagi"})
+	}
+	return c.JSON(http.StatusOK, digest)
+}
+
+// KirimAPI mengirim ringkasan hari ini sekarang juga ke wali kelas dan orang tua
+func (h *DigestHandler) KirimAPI(c echo.Context) error {
+	hasil, err := h.usecase.Kirim(c.Request().Context(), adminUsername(c))
+	if err != nil {
+		if errors.Is(err, domain.ErrDigestHariLibur) {
+			return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+		}
+		log.Printf("ERROR mengirim ringkasan pagi: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengirim ringkasan pagi"})
+	}
+	return c.JSON(http.StatusOK, hasil)
+}
+
+func (h *DigestHandler) ListRiwayatAPI(c echo.Context) error {
+	list, err := h.usecase.GetRiwayat(c.Request().Context())
+	if err != nil {
+		log.Printf("ERROR mengambil riwayat ringkasan pagi: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil riwayat ringkasan pagi"})
+	}
+	return c.JSON(http.StatusOK, list)
+}
@@ -0,0 +1,97 @@
+// file: internal/handler/feed_kalender_handler.go
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+type FeedKalenderHandler struct {
+	usecase domain.FeedKalenderUsecase
+}
+
+func NewFeedKalenderHandler(e *echo.Echo, api *echo.Group, usecase domain.FeedKalenderUsecase) {
+	handler := &FeedKalenderHandler{usecase}
+
+	// Rute publik untuk aplikasi kalender; token di URL menggantikan login
+	e.GET("/feed/kalender/:token", handler.FeedAPI)
+
+	// Wali murid mengatur tautan langganan miliknya sendiri
+	api.GET("/portal/kalender-feed", handler.GetInfoAPI, RequireRole(domain.RoleOrtu))
+	api.POST("/portal/kalender-feed", handler.RegenerateAPI, RequireRole(domain.RoleOrtu))
+	api.DELETE("/portal/kalender-feed", handler.RevokeAPI, RequireRole(domain.RoleOrtu))
+
+	// Admin bisa mencabut tautan milik wali murid mana pun
+	api.DELETE("/admin/users/:username/kalender-feed", handler.AdminRevokeAPI, RequireRole(domain.RoleAdmin))
+}
+
+// FeedAPI mengembalikan file .ics untuk token langganan (/feed/kalender/<token>.ics)
+func (h *FeedKalenderHandler) FeedAPI(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	isi, err := h.usecase.Feed(c.Request().Context(), token, c.RealIP())
+	if err != nil {
+		if errors.Is(err, domain.ErrFeedKalenderTidakValid) {
+			return c.String(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, domain.ErrFeedKalenderDibatasi) {
+			c.Response().Header().Set("Retry-After", "600")
+			return c.String(http.StatusTooManyRequests, err.Error())
+		}
+		log.Printf("ERROR membuat feed kalender: %v", err)
+		return c.String(http.StatusInternalServerError, "Gagal membuat feed kalender")
+	}
+	c.Response().Header().Set("Cache-Control", "private, max-age=900")
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", isi)
+}
+
+func (h *FeedKalenderHandler) GetInfoAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	info, err := h.usecase.GetInfo(c.Request().Context(), username)
+	if err != nil {
+		log.Printf("ERROR mengambil status tautan kalender: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil status tautan kalender"})
+	}
+	return c.JSON(http.StatusOK, info)
+}
+
+// RegenerateAPI membuat tautan langganan baru. Tautan lama langsung berhenti bekerja,
+// dan tautan baru hanya ditampilkan sekali ini.
+func (h *FeedKalenderHandler) RegenerateAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	info, err := h.usecase.Regenerate(c.Request().Context(), username)
+	if err != nil {
+		log.Printf("ERROR membuat tautan kalender: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal membuat tautan kalender"})
+	}
+	return c.JSON(http.StatusOK, info)
+}
+
+func (h *FeedKalenderHandler) RevokeAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	if err := h.usecase.Revoke(c.Request().Context(), username, username); err != nil {
+		log.Printf("ERROR mencabut tautan kalender: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mencabut tautan kalender"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Tautan kalender berhasil dicabut"})
+}
+
+func (h *FeedKalenderHandler) AdminRevokeAPI(c echo.Context) error {
+	if err := h.usecase.Revoke(c.Request().Context(), c.Param("username"), adminUsername(c)); err != nil {
+		log.Printf("ERROR mencabut tautan kalender: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mencabut tautan kalender"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Tautan kalender berhasil dicabut"})
+}
@@ -0,0 +1,141 @@
+// file: internal/handler/kalender_handler.go
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+type KalenderHandler struct {
+	usecase domain.KalenderUsecase
+}
+
+func NewKalenderHandler(e *echo.Echo, api *echo.Group, usecase domain.KalenderUsecase) {
+	handler := &KalenderHandler{usecase}
+
+	// Semua pengguna yang login boleh melihat kalender sekolah
+	api.GET("/kalender", handler.ListAPI)
+	api.GET("/kalender/hari", handler.GetHariAPI)
+
+	// Rute API khusus admin
+	api.POST("/admin/kalender", handler.CreateAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/kalender/impor", handler.ImporAPI, RequireRole(domain.RoleAdmin))
+	api.PUT("/admin/kalender/:id", handler.UpdateAPI, RequireRole(domain.RoleAdmin))
+	api.DELETE("/admin/kalender/:id", handler.DeleteAPI, RequireRole(domain.RoleAdmin))
+}
+
+// kalenderError mengubah error usecase kalender menjadi respons HTTP
+func kalenderError(c echo.Context, err error, pesan string) error {
+	switch {
+	case errors.Is(err, domain.ErrRentangTanggal), errors.Is(err, domain.ErrKalenderTidakValid),
+		errors.Is(err, domain.ErrFormatImporKalender):
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	case errors.Is(err, domain.ErrKalenderTidakDitemukan):
+		return c.JSON(http.StatusNotFound, map[string]string{"message": err.Error()})
+	case errors.Is(err, domain.ErrKalenderHanyaBaca):
+		return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+	}
+	log.Printf("ERROR %s: %v", pesan, err)
+	return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal " + pesan})
+}
+
+// ListAPI menampilkan entri kalender. Filter opsional: ?mulai=YYYY-MM-DD&selesai=YYYY-MM-DD
+func (h *KalenderHandler) ListAPI(c echo.Context) error {
+	list, err := h.usecase.List(c.Request().Context(), c.QueryParam("mulai"), c.QueryParam("selesai"))
+	if err != nil {
+		return kalenderError(c, err, "mengambil kalender sekolah")
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// GetHariAPI menampilkan status hari sekolah per tanggal: ?mulai=YYYY-MM-DD&selesai=YYYY-MM-DD
+func (h *KalenderHandler) GetHariAPI(c echo.Context) error {
+	hari, err := h.usecase.GetHari(c.Request().Context(), c.QueryParam("mulai"), c.QueryParam("selesai"))
+	if err != nil {
+		return kalenderError(c, err, "mengambil hari sekolah")
+	}
+	return c.JSON(http.StatusOK, hari)
+}
+
+func (h *KalenderHandler) CreateAPI(c echo.Context) error {
+	var req domain.KalenderRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+	k, err := h.usecase.Create(c.Request().Context(), req, adminUsername(c))
+	if err != nil {
+		return kalenderError(c, err, "menyimpan entri kalender")
+	}
+	return c.JSON(http.StatusCreated, k)
+}
+
+func (h *KalenderHandler) UpdateAPI(c echo.Context) error {
+	var req domain.KalenderRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+	k, err := h.usecase.Update(c.Request().Context(), c.Param("id"), req, adminUsername(c))
+	if err != nil {
+		return kalenderError(c, err, "mengubah entri kalender")
+	}
+	return c.JSON(http.StatusOK, k)
+}
+
+func (h *KalenderHandler) DeleteAPI(c echo.Context) error {
+	if err := h.usecase.Delete(c.Request().Context(), c.Param("id"), adminUsername(c)); err != nil {
+		return kalenderError(c, err, "menghapus entri kalender")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Entri kalender berhasil dihapus"})
+}
+
+// Batas ukuran file kalender yang diunggah
+const maksUkuranImporKalender = 2 << 20
+
+// ImporAPI mengimpor hari libur dari file .ics atau CSV (multipart, field "file").
+// Form: jenis (bawaan libur), terapkan=true untuk menyimpan (tanpa itu hanya pratinjau),
+// sertakanKonflik=true untuk ikut menyimpan baris yang beririsan dengan entri lain.
+// Format dibaca dari field format atau ekstensi file.
+func (h *KalenderHandler) ImporAPI(c echo.Context) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "File kalender (.ics atau .csv) wajib diunggah"})
+	}
+	if fileHeader.Size > maksUkuranImporKalender {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Ukuran file kalender maksimal 2 MB"})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "File kalender tidak bisa dibuka"})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maksUkuranImporKalender))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "File kalender tidak bisa dibaca"})
+	}
+
+	format := c.FormValue("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+	req := domain.ImporKalenderRequest{
+		Format:          format,
+		Data:            data,
+		Jenis:           c.FormValue("jenis"),
+		Terapkan:        c.FormValue("terapkan") == "true",
+		SertakanKonflik: c.FormValue("sertakanKonflik") == "true",
+	}
+
+	hasil, err := h.usecase.Impor(c.Request().Context(), req, adminUsername(c))
+	if err != nil {
+		return kalenderError(c, err, "mengimpor kalender")
+	}
+	return c.JSON(http.StatusOK, hasil)
+}
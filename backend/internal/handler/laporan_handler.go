@@ -0,0 +1,72 @@
+// file: internal/handler/laporan_handler.go
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/laporan"
+
+	"github.com/labstack/echo/v4"
+)
+
+type LaporanHandler struct {
+	absensiUsecase domain.AbsensiUsecase
+	kop            laporan.KopSurat
+}
+
+func NewLaporanHandler(e *echo.Echo, api *echo.Group, absensiUsecase domain.AbsensiUsecase, kop laporan.KopSurat) {
+	handler := &LaporanHandler{absensiUsecase, kop}
+
+	api.GET("/laporan/bulanan/:tahun/:bulan/pdf", handler.LaporanBulananPDFAPI, RequireRole(domain.RoleAdmin, domain.RoleWaliKelas))
+}
+
+// LaporanBulananPDFAPI mengunduh laporan kehadiran bulanan sebagai PDF.
+// Query: kelas (ringkasan kelas + satu halaman per siswa) atau nisn (hanya laporan satu siswa)
+func (h *LaporanHandler) LaporanBulananPDFAPI(c echo.Context) error {
+	tahun, errTahun := strconv.Atoi(c.Param("tahun"))
+	bulan, errBulan := strconv.Atoi(c.Param("bulan"))
+	if errTahun != nil || errBulan != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Tahun atau bulan tidak valid"})
+	}
+	kelas, nisn := c.QueryParam("kelas"), c.QueryParam("nisn")
+	if kelas == "" && nisn == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Pilih kelas atau NISN siswa"})
+	}
+
+	lap, err := h.absensiUsecase.GetLaporanBulanan(c.Request().Context(), tahun, bulan, kelas)
+	if err != nil {
+		if errors.Is(err, domain.ErrRentangTanggal) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		}
+		log.Printf("ERROR mengambil data laporan bulanan: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil data laporan bulanan"})
+	}
+
+	nama := fmt.Sprintf("laporan-kehadiran%s-%d-%02d.pdf", namaFileKelas(kelas), tahun, bulan)
+	if nisn != "" {
+		var terpilih []domain.LaporanSiswa
+		for _, s := range lap.Siswa {
+			if s.Siswa.NISN == nisn {
+				terpilih = append(terpilih, s)
+			}
+		}
+		if len(terpilih) == 0 {
+			return c.JSON(http.StatusNotFound, map[string]string{"message": "Siswa tidak ditemukan"})
+		}
+		lap.Siswa = terpilih
+		nama = fmt.Sprintf("laporan-kehadiran-%s-%d-%02d.pdf", nisn, tahun, bulan)
+	}
+
+	var buf bytes.Buffer
+	if err := laporan.LaporanBulananPDF(&buf, lap, h.kop, nisn == ""); err != nil {
+		log.Printf("ERROR membuat PDF laporan bulanan: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal membuat file PDF"})
+	}
+	return kirimFile(c, laporan.ContentTypePDF, nama, buf.Bytes())
+}
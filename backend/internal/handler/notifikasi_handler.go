@@ -0,0 +1,120 @@
+// file: internal/handler/notifikasi_handler.go
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+type NotifikasiHandler struct {
+	usecase domain.NotifikasiUsecase
+}
+
+func NewNotifikasiHandler(e *echo.Echo, api *echo.Group, usecase domain.NotifikasiUsecase) {
+	handler := &NotifikasiHandler{usecase}
+
+	// Kanal yang dipasang, untuk pilihan di form preferensi
+	api.GET("/notifikasi/kanal", handler.ListKanalAPI, RequireRole(domain.RoleAdmin, domain.RoleOrtu))
+
+	// Wali murid mengatur notifikasi anak-anaknya sendiri
+	api.GET("/portal/notifikasi", handler.GetPreferensiWaliAPI, RequireRole(domain.RoleOrtu))
+	api.PUT("/portal/notifikasi/:nisn", handler.SetPreferensiWaliAPI, RequireRole(domain.RoleOrtu))
+
+	// Admin mengatur preferensi siswa mana pun dan memantau log pengiriman
+	admin := RequireRole(domain.RoleAdmin)
+	api.GET("/admin/notifikasi/preferensi/:nisn", handler.GetPreferensiAPI, admin)
+	api.PUT("/admin/notifikasi/preferensi/:nisn", handler.SetPreferensiAPI, admin)
+	api.GET("/admin/notifikasi/log", handler.ListLogAPI, admin)
+}
+
+// notifikasiError mengubah error usecase notifikasi menjadi respons HTTP
+func notifikasiError(c echo.Context, err error, pesan string) error {
+	switch {
+	case errors.Is(err, domain.ErrPreferensiNotifikasiTidakValid):
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	case errors.Is(err, domain.ErrSiswaTidakDitemukan):
+		return c.JSON(http.StatusNotFound, map[string]string{"message": err.Error()})
+	case errors.Is(err, domain.ErrAnakTidakTertaut):
+		return c.JSON(http.StatusForbidden, map[string]string{"message": err.Error()})
+	}
+	log.Printf("ERROR %s: %v", pesan, err)
+	return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal " + pesan})
+}
+
+func (h *NotifikasiHandler) ListKanalAPI(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.usecase.KanalTersedia())
+}
+
+func (h *NotifikasiHandler) GetPreferensiWaliAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	list, err := h.usecase.GetPreferensiWali(c.Request().Context(), username)
+	if err != nil {
+		return notifikasiError(c, err, "mengambil pengaturan notifikasi")
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+func (h *NotifikasiHandler) SetPreferensiWaliAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	var req domain.PreferensiNotifikasiRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+	p, err := h.usecase.SetPreferensiWali(c.Request().Context(), username, c.Param("nisn"), req)
+	if err != nil {
+		return notifikasiError(c, err, "menyimpan pengaturan notifikasi")
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+func (h *NotifikasiHandler) GetPreferensiAPI(c echo.Context) error {
+	p, err := h.usecase.GetPreferensi(c.Request().Context(), c.Param("nisn"))
+	if err != nil {
+		return notifikasiError(c, err, "mengambil pengaturan notifikasi")
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+func (h *NotifikasiHandler) SetPreferensiAPI(c echo.Context) error {
+	var req domain.PreferensiNotifikasiRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+	p, err := h.usecase.SetPreferensi(c.Request().Context(), c.Param("nisn"), req, adminUsername(c))
+	if err != nil {
+		return notifikasiError(c, err, "menyimpan pengaturan notifikasi")
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// ListLogAPI menampilkan log pengiriman terbaru.
+// Query: nisn, status (terkirim/gagal/menyerah/ditunda), batas (bawaan 100) — semuanya opsional
+func (h *NotifikasiHandler) ListLogAPI(c echo.Context) error {
+	filter := domain.FilterLogNotifikasi{
+		NISN:   c.QueryParam("nisn"),
+		Status: c.QueryParam("status"),
+	}
+	if s := c.QueryParam("batas"); s != "" {
+		batas, err := strconv.Atoi(s)
+		if err != nil || batas < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": "Parameter batas harus angka positif"})
+		}
+		filter.Batas = batas
+	}
+	list, err := h.usecase.GetLog(c.Request().Context(), filter)
+	if err != nil {
+		return notifikasiError(c, err, "mengambil log notifikasi")
+	}
+	return c.JSON(http.StatusOK, list)
+}
@@ -0,0 +1,54 @@
+// file: internal/handler/rekonsiliasi_handler.go
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+type RekonsiliasiHandler struct {
+	usecase domain.RekonsiliasiUsecase
+}
+
+func NewRekonsiliasiHandler(e *echo.Echo, api *echo.Group, usecase domain.RekonsiliasiUsecase) {
+	handler := &RekonsiliasiHandler{usecase}
+
+	// Rute API khusus admin
+	api.GET("/admin/rekonsiliasi", handler.GetQueueAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/rekonsiliasi/:id", handler.ResolveAPI, RequireRole(domain.RoleAdmin))
+}
+
+type ResolveRekonsiliasiRequest struct {
+	NISN string `json:"nisn" form:"nisn"`
+}
+
+// GetQueueAPI mengembalikan antrian nama yang ambigu. Gunakan ?status=Menunggu untuk yang belum diputuskan.
+func (h *RekonsiliasiHandler) GetQueueAPI(c echo.Context) error {
+	queue, err := h.usecase.GetQueue(c.Request().Context(), c.QueryParam("status"))
+	if err != nil {
+		log.Printf("ERROR mengambil antrian rekonsiliasi: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil antrian rekonsiliasi"})
+	}
+	return c.JSON(http.StatusOK, queue)
+}
+
+func (h *RekonsiliasiHandler) ResolveAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	req := new(ResolveRekonsiliasiRequest)
+	if err := c.Bind(req); err != nil || req.NISN == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "NISN wajib diisi"})
+	}
+
+	err := h.usecase.ResolveQueueItem(c.Request().Context(), c.Param("id"), req.NISN, username)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Nama berhasil dicocokkan dan akan diingat untuk pengajuan berikutnya"})
+}
@@ -0,0 +1,135 @@
+// file: internal/handler/semester_handler.go
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/laporan"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SemesterHandler struct {
+	usecase domain.SemesterUsecase
+}
+
+func NewSemesterHandler(e *echo.Echo, api *echo.Group, usecase domain.SemesterUsecase) {
+	handler := &SemesterHandler{usecase}
+
+	// Admin dan wali kelas boleh melihat dan mengunduh rekap raport
+	staf := RequireRole(domain.RoleAdmin, domain.RoleWaliKelas)
+	api.GET("/semester", handler.ListAPI, staf)
+	api.GET("/semester/:id/rekap", handler.GetRekapAPI, staf)
+	api.GET("/semester/:id/rekap/csv", handler.ExportRaportCSVAPI, staf)
+
+	// Rute API khusus admin
+	api.POST("/admin/semester", handler.CreateAPI, RequireRole(domain.RoleAdmin))
+	api.PUT("/admin/semester/:id", handler.UpdateAPI, RequireRole(domain.RoleAdmin))
+	api.DELETE("/admin/semester/:id", handler.DeleteAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/semester/:id/kunci", handler.LockAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/semester/:id/buka", handler.UnlockAPI, RequireRole(domain.RoleAdmin))
+}
+
+// semesterError mengubah error usecase semester menjadi respons HTTP
+func semesterError(c echo.Context, err error, pesan string) error {
+	switch {
+	case errors.Is(err, domain.ErrRentangTanggal), errors.Is(err, domain.ErrSemesterTidakValid):
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	case errors.Is(err, domain.ErrSemesterTidakDitemukan):
+		return c.JSON(http.StatusNotFound, map[string]string{"message": err.Error()})
+	case errors.Is(err, domain.ErrSemesterTerkunci), errors.Is(err, domain.ErrSemesterBelumTerkunci):
+		return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+	}
+	log.Printf("ERROR %s: %v", pesan, err)
+	return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal " + pesan})
+}
+
+func (h *SemesterHandler) ListAPI(c echo.Context) error {
+	list, err := h.usecase.List(c.Request().Context())
+	if err != nil {
+		return semesterError(c, err, "mengambil daftar semester")
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// GetRekapAPI menampilkan jumlah Sakit/Izin/Alpa per siswa selama semester. Query: kelas (opsional)
+func (h *SemesterHandler) GetRekapAPI(c echo.Context) error {
+	rekap, err := h.usecase.GetRekap(c.Request().Context(), c.Param("id"), c.QueryParam("kelas"))
+	if err != nil {
+		return semesterError(c, err, "mengambil rekap semester")
+	}
+	return c.JSON(http.StatusOK, rekap)
+}
+
+// ExportRaportCSVAPI mengunduh rekap semester dalam format impor aplikasi raport
+// (nisn, sakit, izin, alpa). Query: kelas (opsional)
+func (h *SemesterHandler) ExportRaportCSVAPI(c echo.Context) error {
+	kelas := c.QueryParam("kelas")
+	rekap, err := h.usecase.GetRekap(c.Request().Context(), c.Param("id"), kelas)
+	if err != nil {
+		return semesterError(c, err, "mengambil rekap semester")
+	}
+	if !rekap.Terkunci {
+		// Tetap dikirim agar bisa dicek dulu, tapi angkanya masih bisa berubah
+		c.Response().Header().Set("X-Semester-Terkunci", "false")
+	}
+	nama := "raport" + namaFileKelas(rekap.Semester.Nama) + namaFileKelas(kelas) + ".csv"
+	return kirimCSV(c, nama, func(w io.Writer) error { return laporan.RaportCSV(w, rekap.Siswa) })
+}
+
+func (h *SemesterHandler) CreateAPI(c echo.Context) error {
+	var req domain.SemesterRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+	s, err := h.usecase.Create(c.Request().Context(), req, adminUsername(c))
+	if err != nil {
+		return semesterError(c, err, "menyimpan semester")
+	}
+	return c.JSON(http.StatusCreated, s)
+}
+
+func (h *SemesterHandler) UpdateAPI(c echo.Context) error {
+	var req domain.SemesterRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+	s, err := h.usecase.Update(c.Request().Context(), c.Param("id"), req, adminUsername(c))
+	if err != nil {
+		return semesterError(c, err, "mengubah semester")
+	}
+	return c.JSON(http.StatusOK, s)
+}
+
+func (h *SemesterHandler) DeleteAPI(c echo.Context) error {
+	if err := h.usecase.Delete(c.Request().Context(), c.Param("id"), adminUsername(c)); err != nil {
+		return semesterError(c, err, "menghapus semester")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Semester berhasil dihapus"})
+}
+
+// LockAPI mengunci rekap semester sehingga perubahan LogAbsensi tidak lagi mengubah raport
+func (h *SemesterHandler) LockAPI(c echo.Context) error {
+	rekap, err := h.usecase.Lock(c.Request().Context(), c.Param("id"), adminUsername(c))
+	if err != nil {
+		return semesterError(c, err, "mengunci semester")
+	}
+	return c.JSON(http.StatusOK, rekap)
+}
+
+// UnlockAPI membuka kunci semester. Body: {"alasan": "..."}
+func (h *SemesterHandler) UnlockAPI(c echo.Context) error {
+	var req domain.BukaSemesterRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+	s, err := h.usecase.Unlock(c.Request().Context(), c.Param("id"), req, adminUsername(c))
+	if err != nil {
+		return semesterError(c, err, "membuka kunci semester")
+	}
+	return c.JSON(http.StatusOK, s)
+}
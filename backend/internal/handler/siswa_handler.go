@@ -3,10 +3,13 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"log"
 	"net/http"
 
 	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/laporan"
 
 	"github.com/labstack/echo/v4"
 )
@@ -24,6 +27,8 @@ func NewSiswaHandler(e *echo.Echo, api *echo.Group, usecase domain.SiswaUsecase)
 
 	// Rute API
 	api.GET("/siswa", handler.GetAllSiswaAPI)
+	api.GET("/siswa/csv", handler.ExportSiswaCSVAPI, RequireRole(domain.RoleAdmin, domain.RoleWaliKelas))
+	api.POST("/admin/siswa/impor", handler.ImporSiswaAPI, RequireRole(domain.RoleAdmin))
 	api.POST("/admin/siswa/tambah", handler.CreateSiswa)
 	api.GET("/siswa/:nisn", handler.GetSiswaByNISNAPI)
 	api.PUT("/siswa/:nisn", handler.UpdateSiswaAPI)
@@ -111,3 +116,61 @@ func (h *SiswaHandler) DeleteSiswaAPI(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, map[string]string{"message": "Data siswa berhasil dihapus"})
 }
+
+// ExportSiswaCSVAPI mengunduh data siswa sebagai CSV. Query: kelas (opsional)
+func (h *SiswaHandler) ExportSiswaCSVAPI(c echo.Context) error {
+	siswaList, err := h.usecase.GetAll(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil data siswa"})
+	}
+	kelas := c.QueryParam("kelas")
+	if kelas != "" {
+		var terpilih []domain.Siswa
+		for _, s := range siswaList {
+			if s.Kelas == kelas {
+				terpilih = append(terpilih, s)
+			}
+		}
+		siswaList = terpilih
+	}
+	return kirimCSV(c, "data-siswa"+namaFileKelas(kelas)+".csv", func(w io.Writer) error {
+		return laporan.SiswaCSV(w, siswaList)
+	})
+}
+
+// Batas ukuran file CSV siswa yang diunggah
+const maksUkuranImporSiswa = 2 << 20
+
+// ImporSiswaAPI mengimpor data siswa dari CSV (multipart, field "file"). Tanpa terapkan=true
+// hanya pratinjau per baris; dengan terapkan=true data disimpan jika tidak ada baris yang salah.
+func (h *SiswaHandler) ImporSiswaAPI(c echo.Context) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "File CSV siswa wajib diunggah"})
+	}
+	if fileHeader.Size > maksUkuranImporSiswa {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Ukuran file CSV maksimal 2 MB"})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "File CSV tidak bisa dibuka"})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maksUkuranImporSiswa))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "File CSV tidak bisa dibaca"})
+	}
+
+	hasil, err := h.usecase.Impor(c.Request().Context(), data, c.FormValue("terapkan") == "true", adminUsername(c))
+	switch {
+	case errors.Is(err, domain.ErrImporSiswaGalat):
+		// Rincian galat per baris tetap dikirim agar admin tahu apa yang harus diperbaiki
+		return c.JSON(http.StatusUnprocessableEntity, hasil)
+	case errors.Is(err, domain.ErrFormatImporSiswa):
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	case err != nil:
+		log.Printf("ERROR mengimpor siswa: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengimpor data siswa"})
+	}
+	return c.JSON(http.StatusOK, hasil)
+}
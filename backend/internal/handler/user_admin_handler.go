@@ -0,0 +1,160 @@
+// file: internal/handler/user_admin_handler.go
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// adminUsername mengambil username admin yang sedang login dari token JWT
+func adminUsername(c echo.Context) string {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username, _ := userClaims["username"].(string)
+	return username
+}
+
+// ListUsersAPI menampilkan daftar pengguna. Filter: ?role=ortu&status=aktif|nonaktif&q=kata
+func (h *UserHandler) ListUsersAPI(c echo.Context) error {
+	filter := domain.UserFilter{
+		Role:   c.QueryParam("role"),
+		Query:  c.QueryParam("q"),
+		Status: c.QueryParam("status"),
+	}
+	users, err := h.userUsecase.ListUsers(c.Request().Context(), filter)
+	if err != nil {
+		log.Printf("ERROR mengambil daftar pengguna: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil daftar pengguna"})
+	}
+	return c.JSON(http.StatusOK, users)
+}
+
+func (h *UserHandler) GetUserAPI(c echo.Context) error {
+	user, err := h.userUsecase.GetByUsername(c.Request().Context(), c.Param("username"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil data pengguna"})
+	}
+	if user == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Pengguna tidak ditemukan"})
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// CreateUserAPI membuat akun baru dengan peran tertentu.
+// Password sementara (jika dibuatkan sistem) hanya ditampilkan sekali di respons ini.
+func (h *UserHandler) CreateUserAPI(c echo.Context) error {
+	var req domain.CreateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+
+	hasil, err := h.userUsecase.CreateUser(c.Request().Context(), req, adminUsername(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, hasil)
+}
+
+func (h *UserHandler) UpdateUserByAdminAPI(c echo.Context) error {
+	var req domain.UpdateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+
+	user, err := h.userUsecase.UpdateUserByAdmin(c.Request().Context(), c.Param("username"), req, adminUsername(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) ListLinkedStudentsAPI(c echo.Context) error {
+	relasi, err := h.userUsecase.ListLinkedStudents(c.Request().Context(), c.Param("username"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusOK, relasi)
+}
+
+// LinkParentToStudentAPI menautkan akun wali murid ke siswa (form/JSON: nisn, hubungan)
+func (h *UserHandler) LinkParentToStudentAPI(c echo.Context) error {
+	var req struct {
+		NISN     string `json:"nisn" form:"nisn"`
+		Hubungan string `json:"hubungan" form:"hubungan"`
+	}
+	if err := c.Bind(&req); err != nil || req.NISN == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "NISN wajib diisi"})
+	}
+
+	err := h.userUsecase.LinkParentToStudent(c.Request().Context(), c.Param("username"), req.NISN, req.Hubungan, adminUsername(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Akun wali murid berhasil ditautkan ke siswa"})
+}
+
+func (h *UserHandler) UnlinkParentFromStudentAPI(c echo.Context) error {
+	err := h.userUsecase.UnlinkParentFromStudent(c.Request().Context(), c.Param("username"), c.Param("nisn"), adminUsername(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Tautan wali murid ke siswa berhasil dilepas"})
+}
+
+func (h *UserHandler) DisableUserAPI(c echo.Context) error {
+	err := h.userUsecase.SetUserDisabled(c.Request().Context(), c.Param("username"), true, adminUsername(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Akun berhasil dinonaktifkan"})
+}
+
+func (h *UserHandler) EnableUserAPI(c echo.Context) error {
+	err := h.userUsecase.SetUserDisabled(c.Request().Context(), c.Param("username"), false, adminUsername(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Akun berhasil diaktifkan kembali"})
+}
+
+// ForcePasswordResetAPI mewajibkan pengguna mengganti password saat login berikutnya
+func (h *UserHandler) ForcePasswordResetAPI(c echo.Context) error {
+	err := h.userUsecase.ForcePasswordReset(c.Request().Context(), c.Param("username"), adminUsername(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Pengguna wajib mengganti password saat login berikutnya"})
+}
+
+// ProvisionAccountsAPI membuat akun siswa dan wali murid dari data siswa (form/JSON: kelas, opsional).
+// Dengan ?format=html respons berupa slip kredensial siap cetak; password hanya ditampilkan sekali ini.
+func (h *UserHandler) ProvisionAccountsAPI(c echo.Context) error {
+	var req struct {
+		Kelas string `json:"kelas" form:"kelas"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+
+	hasil, err := h.userUsecase.ProvisionAccounts(c.Request().Context(), req.Kelas, adminUsername(c))
+	if err != nil {
+		log.Printf("ERROR provisioning akun: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal membuat akun"})
+	}
+
+	// Respons berisi password, jangan sampai disimpan cache browser/proxy
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	if c.QueryParam("format") == "html" {
+		slip, err := h.userUsecase.RenderCredentialSlips(hasil)
+		if err != nil {
+			log.Printf("ERROR membuat slip kredensial: %v", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Akun dibuat, tetapi slip gagal dibuat"})
+		}
+		return c.HTMLBlob(http.StatusOK, slip)
+	}
+	return c.JSON(http.StatusOK, hasil)
+}
@@ -2,8 +2,11 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"daarulilmi-presence/internal/domain" // Ganti dengan nama modul Anda
@@ -22,6 +25,10 @@ func NewUserHandler(e *echo.Echo, api *echo.Group, userUsecase domain.UserUsecas
 
 	// Daftarkan rute-rute yang akan ditangani oleh handler ini
 	e.POST("/login", handler.Login)
+	e.POST("/login/2fa", handler.VerifyTwoFactorLogin)
+	e.POST("/login/2fa/setup", handler.BeginRequiredTwoFactorSetup)
+	e.POST("/login/2fa/setup/verify", handler.CompleteRequiredTwoFactorSetup)
+	e.POST("/refresh", handler.Refresh)
 	e.POST("/register", handler.Register)
 	e.POST("/request-reset", handler.RequestPasswordReset)
 	e.POST("/reset-password", handler.ResetPassword)
@@ -30,6 +37,35 @@ func NewUserHandler(e *echo.Echo, api *echo.Group, userUsecase domain.UserUsecas
 	// Rute terproteksi
 	api.POST("/user/update", handler.UpdateUser)
 	api.GET("/user/profile", handler.GetUserProfileAPI)
+	api.GET("/user/sessions", handler.ListSessionsAPI)
+	api.POST("/logout", handler.Logout)
+	api.POST("/logout/semua", handler.LogoutAll)
+	api.POST("/user/2fa/setup", handler.SetupTwoFactorAPI)
+	api.POST("/user/2fa/aktifkan", handler.EnableTwoFactorAPI)
+	api.POST("/user/2fa/nonaktifkan", handler.DisableTwoFactorAPI)
+
+	// Rute khusus admin
+	api.POST("/admin/users/:username/sessions/revoke", handler.RevokeUserSessionsAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/users/:username/unlock", handler.UnlockAccountAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/users/:username/2fa/reset", handler.ResetTwoFactorAPI, RequireRole(domain.RoleAdmin))
+	api.GET("/admin/users", handler.ListUsersAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/users", handler.CreateUserAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/users/provisioning", handler.ProvisionAccountsAPI, RequireRole(domain.RoleAdmin))
+	api.GET("/admin/users/:username", handler.GetUserAPI, RequireRole(domain.RoleAdmin))
+	api.PUT("/admin/users/:username", handler.UpdateUserByAdminAPI, RequireRole(domain.RoleAdmin))
+	api.GET("/admin/users/:username/siswa", handler.ListLinkedStudentsAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/users/:username/siswa", handler.LinkParentToStudentAPI, RequireRole(domain.RoleAdmin))
+	api.DELETE("/admin/users/:username/siswa/:nisn", handler.UnlinkParentFromStudentAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/users/:username/nonaktifkan", handler.DisableUserAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/users/:username/aktifkan", handler.EnableUserAPI, RequireRole(domain.RoleAdmin))
+	api.POST("/admin/users/:username/wajib-ganti-password", handler.ForcePasswordResetAPI, RequireRole(domain.RoleAdmin))
+}
+
+func clientInfo(c echo.Context) domain.ClientInfo {
+	return domain.ClientInfo{
+		UserAgent: c.Request().UserAgent(),
+		IPAddress: c.RealIP(),
+	}
 }
 
 // Login adalah fungsi yang dipanggil saat ada request ke POST /login
@@ -37,15 +73,89 @@ func (h *UserHandler) Login(c echo.Context) error {
 	username := c.FormValue("username")
 	password := c.FormValue("password")
 
-	token, err := h.userUsecase.Login(c.Request().Context(), username, password)
-	if err != nil || token == "" {
+	result, err := h.userUsecase.Login(c.Request().Context(), username, password, clientInfo(c))
+	if err != nil {
+		var ditunda *domain.LoginDitundaError
+		if errors.As(err, &ditunda) {
+			c.Response().Header().Set("Retry-After", strconv.Itoa(int(ditunda.RetryAfter.Seconds())+1))
+			return c.JSON(http.StatusTooManyRequests, map[string]string{"message": ditunda.Error()})
+		}
+		if errors.Is(err, domain.ErrAkunNonaktif) {
+			return c.JSON(http.StatusForbidden, map[string]string{"message": err.Error()})
+		}
+		if !errors.Is(err, domain.ErrKredensialSalah) {
+			log.Printf("ERROR login: %v", err)
+		}
 		return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Username atau password salah"})
 	}
 
-	// Jika berhasil, kirim token dalam format JSON
-	return c.JSON(http.StatusOK, map[string]string{
-		"token": token,
-	})
+	// Jika berhasil, kirim access token dan refresh token dalam format JSON
+	return c.JSON(http.StatusOK, result)
+}
+
+// Refresh menukar refresh token dengan access token baru (refresh token juga ikut diganti)
+func (h *UserHandler) Refresh(c echo.Context) error {
+	refreshToken := c.FormValue("refresh_token")
+	if refreshToken == "" {
+		var body struct {
+			RefreshToken string `json:"refreshToken"`
+		}
+		_ = c.Bind(&body)
+		refreshToken = body.RefreshToken
+	}
+
+	result, err := h.userUsecase.RefreshSession(c.Request().Context(), refreshToken, clientInfo(c))
+	if err != nil {
+		if !errors.Is(err, domain.ErrSesiTidakValid) {
+			log.Printf("ERROR refresh sesi: %v", err)
+		}
+		return c.JSON(http.StatusUnauthorized, map[string]string{"message": domain.ErrSesiTidakValid.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// Logout mengakhiri sesi yang sedang dipakai
+func (h *UserHandler) Logout(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	sessionID, _ := userClaims["sid"].(string)
+
+	if err := h.userUsecase.Logout(c.Request().Context(), sessionID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Berhasil logout"})
+}
+
+// LogoutAll mengakhiri semua sesi milik pengguna yang sedang login (semua perangkat)
+func (h *UserHandler) LogoutAll(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	count, err := h.userUsecase.LogoutAll(c.Request().Context(), username)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengakhiri sesi"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Berhasil logout dari semua perangkat", "jumlahSesi": count})
+}
+
+func (h *UserHandler) ListSessionsAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	sessions, err := h.userUsecase.ListSessions(c.Request().Context(), username)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengambil daftar sesi"})
+	}
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeUserSessionsAPI dipakai admin untuk mengakhiri semua sesi pengguna lain (misal HP hilang)
+func (h *UserHandler) RevokeUserSessionsAPI(c echo.Context) error {
+	username := c.Param("username")
+	count, err := h.userUsecase.LogoutAll(c.Request().Context(), username)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal mengakhiri sesi pengguna"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Semua sesi pengguna telah diakhiri", "jumlahSesi": count})
 }
 
 func (h *UserHandler) Register(c echo.Context) error {
@@ -53,7 +163,7 @@ func (h *UserHandler) Register(c echo.Context) error {
 	user := new(domain.User)
 	user.Username = c.FormValue("username")
 	user.PasswordHash = c.FormValue("password") // Ini masih password mentah
-	user.Role = c.FormValue("role")
+	// Peran tidak diambil dari form: pendaftaran mandiri selalu menjadi akun wali murid
 
 	// Panggil usecase untuk melakukan proses registrasi
 	err := h.userUsecase.Register(c.Request().Context(), user)
@@ -76,21 +186,42 @@ func (h *UserHandler) UpdateUser(c echo.Context) error {
 	// Ambil username saat ini dari token JWT
 	userClaims := c.Get("user").(jwt.MapClaims)
 	currentUsername := userClaims["username"].(string)
+	sessionID, _ := userClaims["sid"].(string)
 
 	// Ambil data baru dari form
 	newUsername := c.FormValue("new_username")
 	newPassword := c.FormValue("new_password")
 
-	err := h.userUsecase.UpdateUser(c.Request().Context(), currentUsername, newUsername, newPassword)
+	result, err := h.userUsecase.UpdateUser(c.Request().Context(), currentUsername, sessionID, newUsername, newPassword)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "Profil berhasil diperbarui."})
+	// Klien wajib mengganti token lamanya dengan token ini; token lama masih membawa
+	// pembatasan ganti password
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":   "Profil berhasil diperbarui.",
+		"token":     result.AccessToken,
+		"expiresIn": result.ExpiresIn,
+	})
+}
+
+// SessionChecker memeriksa apakah sesi di dalam token masih aktif (belum logout atau dicabut)
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// ruteGantiPassword adalah rute yang tetap bisa diakses selama pengguna wajib ganti password
+var ruteGantiPassword = map[string]bool{
+	"/api/user/update":   true,
+	"/api/user/profile":  true,
+	"/api/logout":        true,
+	"/api/logout/semua":  true,
+	"/api/user/sessions": true,
 }
 
 // --- MIDDLEWARE PENJAGA KEAMANAN JWT ---
-func JWTMiddleware(secret []byte) echo.MiddlewareFunc {
+func JWTMiddleware(secret []byte, sessions SessionChecker) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			authHeader := c.Request().Header.Get("Authorization")
@@ -118,6 +249,29 @@ func JWTMiddleware(secret []byte) echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Invalid token"})
 			}
 
+			// Pastikan sesinya belum dicabut. Token lama tanpa ID sesi tidak lagi diterima.
+			claims, ok := token.Claims.(jwt.MapClaims)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Invalid token"})
+			}
+			// Challenge token 2FA hanya untuk langkah kedua login, bukan untuk mengakses API
+			if _, isChallenge := claims["typ"]; isChallenge {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Invalid token"})
+			}
+			// Pengguna yang wajib ganti password hanya boleh mengakses rute ganti password
+			if wajib, _ := claims["ganti_password"].(bool); wajib && !ruteGantiPassword[c.Path()] {
+				return c.JSON(http.StatusForbidden, map[string]string{"message": "Anda harus mengganti password terlebih dahulu"})
+			}
+			sessionID, _ := claims["sid"].(string)
+			active, err := sessions.IsSessionActive(c.Request().Context(), sessionID)
+			if err != nil {
+				log.Printf("ERROR memeriksa sesi: %v", err)
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{"message": "Gagal memeriksa sesi, coba lagi"})
+			}
+			if !active {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Sesi sudah berakhir, silakan login kembali"})
+			}
+
 			// Simpan data dari token ke context agar bisa diakses handler selanjutnya
 			c.Set("user", token.Claims)
 			return next(c)
@@ -125,20 +279,40 @@ func JWTMiddleware(secret []byte) echo.MiddlewareFunc {
 	}
 }
 
+// --- MIDDLEWARE PEMBATAS PERAN ---
+// RequireRole hanya meneruskan request jika peran di token JWT termasuk salah satu roles.
+// Harus dipasang setelah JWTMiddleware.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userClaims, ok := c.Get("user").(jwt.MapClaims)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Invalid token"})
+			}
+			role, _ := userClaims["role"].(string)
+			for _, allowed := range roles {
+				if role == allowed {
+					return next(c)
+				}
+			}
+			return c.JSON(http.StatusForbidden, map[string]string{"message": "Anda tidak memiliki akses ke fitur ini"})
+		}
+	}
+}
+
 func (h *UserHandler) RequestPasswordReset(c echo.Context) error {
 	username := c.FormValue("username")
-	token, err := h.userUsecase.RequestPasswordReset(c.Request().Context(), username)
+	err := h.userUsecase.RequestPasswordReset(c.Request().Context(), username)
 	if err != nil {
-		// Demi keamanan, jangan beri tahu jika username ada/tidak.
-		return c.String(http.StatusOK, "Jika username terdaftar, link reset akan disimulasikan di konsol server.")
+		if errors.Is(err, domain.ErrTerlaluBanyakPermintaanReset) {
+			log.Printf("WARNING: Permintaan reset password untuk %s dibatasi (rate limit)", username)
+		} else {
+			log.Printf("INFO: Reset password untuk %s tidak dikirim: %v", username, err)
+		}
 	}
 
-	// Simulasi pengiriman email: cetak link ke konsol
-	log.Printf("== SIMULASI EMAIL ==")
-	log.Printf("Link Reset Password untuk %s: http://localhost:1412/reset-password?token=%s", username, token)
-	log.Printf("====================")
-
-	return c.String(http.StatusOK, "Jika username terdaftar, link reset akan disimulasikan di konsol server.")
+	// Demi keamanan, jangan beri tahu jika username ada/tidak.
+	return c.String(http.StatusOK, "Jika username terdaftar dan memiliki email, tautan reset password telah dikirim.")
 }
 
 func (h *UserHandler) ShowResetPasswordForm(c echo.Context) error {
@@ -175,3 +349,15 @@ func (h *UserHandler) GetUserProfileAPI(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, user)
 }
+
+// UnlockAccountAPI dipakai admin untuk membuka akun yang terkunci karena terlalu banyak login gagal
+func (h *UserHandler) UnlockAccountAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	adminUsername := userClaims["username"].(string)
+
+	err := h.userUsecase.UnlockAccount(c.Request().Context(), c.Param("username"), adminUsername)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Akun berhasil dibuka"})
+}
@@ -0,0 +1,103 @@
+// file: internal/handler/user_twofactor_handler.go
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// twoFactorError memetakan error verifikasi 2FA ke respons HTTP
+func twoFactorError(c echo.Context, err error) error {
+	var ditunda *domain.LoginDitundaError
+	if errors.As(err, &ditunda) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(ditunda.RetryAfter.Seconds())+1))
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"message": ditunda.Error()})
+	}
+	if errors.Is(err, domain.ErrKodeTidakValid) || errors.Is(err, domain.ErrKredensialSalah) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+}
+
+// VerifyTwoFactorLogin adalah langkah kedua login (POST /login/2fa)
+func (h *UserHandler) VerifyTwoFactorLogin(c echo.Context) error {
+	result, err := h.userUsecase.VerifyTwoFactorLogin(c.Request().Context(), c.FormValue("challenge_token"), c.FormValue("code"), clientInfo(c))
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// BeginRequiredTwoFactorSetup menampilkan QR code untuk pengguna yang wajib 2FA saat login pertama
+func (h *UserHandler) BeginRequiredTwoFactorSetup(c echo.Context) error {
+	setup, err := h.userUsecase.BeginRequiredTwoFactorSetup(c.Request().Context(), c.FormValue("challenge_token"))
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, setup)
+}
+
+// CompleteRequiredTwoFactorSetup mengaktifkan 2FA lalu mengembalikan token login dan kode pemulihan
+func (h *UserHandler) CompleteRequiredTwoFactorSetup(c echo.Context) error {
+	result, err := h.userUsecase.CompleteRequiredTwoFactorSetup(c.Request().Context(), c.FormValue("challenge_token"), c.FormValue("code"), clientInfo(c))
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *UserHandler) SetupTwoFactorAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	setup, err := h.userUsecase.SetupTwoFactor(c.Request().Context(), username)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, setup)
+}
+
+func (h *UserHandler) EnableTwoFactorAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	codes, err := h.userUsecase.EnableTwoFactor(c.Request().Context(), username, c.FormValue("code"))
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":       "2FA berhasil diaktifkan. Simpan kode pemulihan di tempat yang aman.",
+		"recoveryCodes": codes,
+	})
+}
+
+func (h *UserHandler) DisableTwoFactorAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	username := userClaims["username"].(string)
+
+	err := h.userUsecase.DisableTwoFactor(c.Request().Context(), username, c.FormValue("password"), c.FormValue("code"))
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "2FA berhasil dinonaktifkan"})
+}
+
+// ResetTwoFactorAPI dipakai admin jika pengguna kehilangan authenticator dan kode pemulihannya
+func (h *UserHandler) ResetTwoFactorAPI(c echo.Context) error {
+	userClaims := c.Get("user").(jwt.MapClaims)
+	adminUsername := userClaims["username"].(string)
+
+	err := h.userUsecase.ResetTwoFactor(c.Request().Context(), c.Param("username"), adminUsername)
+	if err != nil {
+		log.Printf("ERROR reset 2FA %s: %v", c.Param("username"), err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "2FA pengguna telah direset"})
+}
@@ -0,0 +1,128 @@
+// file: internal/handler/webhook_handler.go
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookHandler struct {
+	usecase domain.WebhookUsecase
+}
+
+func NewWebhookHandler(e *echo.Echo, api *echo.Group, usecase domain.WebhookUsecase) {
+	handler := &WebhookHandler{usecase}
+
+	// Semua rute webhook khusus admin
+	admin := RequireRole(domain.RoleAdmin)
+	api.GET("/admin/webhook", handler.ListAPI, admin)
+	api.GET("/admin/webhook/events", handler.ListEventAPI, admin)
+	api.GET("/admin/webhook/pengiriman", handler.ListPengirimanAPI, admin)
+	api.POST("/admin/webhook", handler.CreateAPI, admin)
+	api.PUT("/admin/webhook/:id", handler.UpdateAPI, admin)
+	api.DELETE("/admin/webhook/:id", handler.DeleteAPI, admin)
+	api.POST("/admin/webhook/:id/secret", handler.RotateSecretAPI, admin)
+	api.POST("/admin/webhook/:id/uji", handler.KirimUjiAPI, admin)
+}
+
+// webhookError mengubah error usecase webhook menjadi respons HTTP
+func webhookError(c echo.Context, err error, pesan string) error {
+	switch {
+	case errors.Is(err, domain.ErrWebhookTidakValid):
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	case errors.Is(err, domain.ErrWebhookTidakDitemukan):
+		return c.JSON(http.StatusNotFound, map[string]string{"message": err.Error()})
+	}
+	log.Printf("ERROR %s: %v", pesan, err)
+	return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Gagal " + pesan})
+}
+
+func (h *WebhookHandler) ListAPI(c echo.Context) error {
+	list, err := h.usecase.List(c.Request().Context())
+	if err != nil {
+		return webhookError(c, err, "mengambil daftar webhook")
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// ListEventAPI menampilkan jenis event yang bisa dipilih saat mendaftarkan webhook
+func (h *WebhookHandler) ListEventAPI(c echo.Context) error {
+	return c.JSON(http.StatusOK, domain.DaftarEventWebhook)
+}
+
+// ListPengirimanAPI menampilkan log pengiriman terbaru.
+// Query: webhookId, status (berhasil/gagal/menyerah), batas (bawaan 100) — semuanya opsional
+func (h *WebhookHandler) ListPengirimanAPI(c echo.Context) error {
+	filter := domain.FilterPengirimanWebhook{
+		WebhookID: c.QueryParam("webhookId"),
+		Status:    c.QueryParam("status"),
+	}
+	if s := c.QueryParam("batas"); s != "" {
+		batas, err := strconv.Atoi(s)
+		if err != nil || batas < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": "Parameter batas harus angka positif"})
+		}
+		filter.Batas = batas
+	}
+	list, err := h.usecase.GetPengiriman(c.Request().Context(), filter)
+	if err != nil {
+		return webhookError(c, err, "mengambil log pengiriman webhook")
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// CreateAPI mendaftarkan webhook. Secret hanya dikirim sekali di respons ini.
+func (h *WebhookHandler) CreateAPI(c echo.Context) error {
+	var req domain.WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+	w, err := h.usecase.Create(c.Request().Context(), req, adminUsername(c))
+	if err != nil {
+		return webhookError(c, err, "mendaftarkan webhook")
+	}
+	return c.JSON(http.StatusCreated, w)
+}
+
+func (h *WebhookHandler) UpdateAPI(c echo.Context) error {
+	var req domain.WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Data yang dikirim tidak valid"})
+	}
+	w, err := h.usecase.Update(c.Request().Context(), c.Param("id"), req, adminUsername(c))
+	if err != nil {
+		return webhookError(c, err, "mengubah webhook")
+	}
+	return c.JSON(http.StatusOK, w)
+}
+
+func (h *WebhookHandler) DeleteAPI(c echo.Context) error {
+	if err := h.usecase.Delete(c.Request().Context(), c.Param("id"), adminUsername(c)); err != nil {
+		return webhookError(c, err, "menghapus webhook")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Webhook berhasil dihapus"})
+}
+
+// RotateSecretAPI membuat secret baru; secret hanya dikirim sekali di respons ini
+func (h *WebhookHandler) RotateSecretAPI(c echo.Context) error {
+	w, err := h.usecase.RotateSecret(c.Request().Context(), c.Param("id"), adminUsername(c))
+	if err != nil {
+		return webhookError(c, err, "mengganti secret webhook")
+	}
+	return c.JSON(http.StatusOK, w)
+}
+
+// KirimUjiAPI mengirim event ping dan menampilkan hasilnya (kode HTTP, error, durasi)
+func (h *WebhookHandler) KirimUjiAPI(c echo.Context) error {
+	p, err := h.usecase.KirimUji(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return webhookError(c, err, "mengirim uji webhook")
+	}
+	return c.JSON(http.StatusOK, p)
+}
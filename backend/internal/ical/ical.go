@@ -0,0 +1,288 @@
+// file: internal/ical/ical.go
+
+// Package ical membaca event dari file iCalendar (.ics, RFC 5545), misal daftar hari libur
+// nasional yang diekspor dari Google Calendar atau kalender pemerintah.
+//
+// Hanya bagian yang dibutuhkan kalender sekolah yang dibaca: VEVENT dengan UID, SUMMARY,
+// DESCRIPTION, STATUS, DTSTART, DTEND, dan DURATION harian. Aturan berulang (RRULE) tidak
+// dijabarkan; event berulang hanya diambil kemunculan pertamanya dan ditandai Berulang.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrBukanICalendar dikembalikan jika isi file tidak diawali BEGIN:VCALENDAR
+var ErrBukanICalendar = errors.New("file bukan iCalendar (.ics)")
+
+// Event adalah satu VEVENT. Mulai dan Selesai adalah tanggal (tengah malam) di zona waktu
+// yang diberikan ke Parse, dan Selesai sudah inklusif: libur satu hari punya Mulai == Selesai.
+type Event struct {
+	UID         string
+	Summary     string
+	Deskripsi   string
+	Status      string // Misal "CONFIRMED" atau "CANCELLED"
+	Mulai       time.Time
+	Selesai     time.Time
+	SehariPenuh bool
+	Berulang    bool
+	// Nomor baris BEGIN:VEVENT di file, untuk pesan kesalahan
+	Baris int
+	// Err terisi jika tanggal event tidak bisa dibaca; Mulai dan Selesai kosong
+	Err error
+}
+
+// Dibatalkan memeriksa STATUS:CANCELLED
+func (e Event) Dibatalkan() bool {
+	return strings.EqualFold(e.Status, "CANCELLED")
+}
+
+// properti adalah satu baris konten, misal DTSTART;VALUE=DATE:20260101
+type properti struct {
+	nama  string
+	param map[string]string
+	nilai string
+}
+
+// Parse membaca semua VEVENT dari r. Tanggal dan waktu tanpa zona waktu dianggap berada di loc.
+// Event yang tanggalnya tidak bisa dibaca tetap dikembalikan dengan Err terisi agar pemanggil
+// bisa melaporkannya per event; error Parse sendiri hanya untuk file yang bukan iCalendar.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	baris, err := bacaBaris(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(baris) == 0 || !strings.EqualFold(strings.TrimSpace(baris[0].teks), "BEGIN:VCALENDAR") {
+		return nil, ErrBukanICalendar
+	}
+
+	var (
+		events  []Event
+		cur     *Event
+		dtStart *properti
+		dtEnd   *properti
+		durasi  string
+		// Kedalaman komponen di dalam VEVENT, misal VALARM, yang isinya diabaikan
+		sub int
+	)
+	for _, b := range baris {
+		p, ok := parseProperti(b.teks)
+		if !ok {
+			continue
+		}
+		switch {
+		case p.nama == "BEGIN" && strings.EqualFold(p.nilai, "VEVENT") && cur == nil:
+			cur = &Event{Baris: b.nomor}
+			dtStart, dtEnd, durasi, sub = nil, nil, "", 0
+			continue
+		case cur == nil:
+			continue
+		case p.nama == "BEGIN":
+			sub++
+			continue
+		case p.nama == "END" && sub > 0:
+			sub--
+			continue
+		case p.nama == "END" && strings.EqualFold(p.nilai, "VEVENT"):
+			cur.Err = selesaikan(cur, dtStart, dtEnd, durasi, loc)
+			events = append(events, *cur)
+			cur = nil
+			continue
+		case sub > 0:
+			continue
+		}
+
+		switch p.nama {
+		case "UID":
+			cur.UID = p.nilai
+		case "SUMMARY":
+			cur.Summary = unescape(p.nilai)
+		case "DESCRIPTION":
+			cur.Deskripsi = unescape(p.nilai)
+		case "STATUS":
+			cur.Status = strings.ToUpper(p.nilai)
+		case "RRULE", "RDATE":
+			cur.Berulang = true
+		case "DTSTART":
+			salinan := p
+			dtStart = &salinan
+		case "DTEND":
+			salinan := p
+			dtEnd = &salinan
+		case "DURATION":
+			durasi = p.nilai
+		}
+	}
+	return events, nil
+}
+
+// selesaikan membaca DTSTART dan menghitung tanggal selesai inklusif dari DTEND (eksklusif) atau DURATION
+func selesaikan(e *Event, dtStart, dtEnd *properti, durasi string, loc *time.Location) error {
+	if dtStart == nil {
+		return errors.New("DTSTART tidak ada")
+	}
+	t, sehari, err := parseTanggal(*dtStart, loc)
+	if err != nil {
+		return fmt.Errorf("DTSTART %q tidak valid", dtStart.nilai)
+	}
+	e.SehariPenuh = sehari
+	mulai := tengahMalam(t, loc)
+	selesai := mulai
+
+	switch {
+	case dtEnd != nil:
+		t, sehari, err := parseTanggal(*dtEnd, loc)
+		if err != nil {
+			return fmt.Errorf("DTEND %q tidak valid", dtEnd.nilai)
+		}
+		akhir := tengahMalam(t, loc)
+		// DTEND eksklusif: libur 1 Januari ditulis DTSTART 20260101, DTEND 20260102.
+		// Event berjam yang berakhir tepat tengah malam juga tidak memakai hari berikutnya.
+		if (sehari || t.Equal(akhir)) && akhir.After(mulai) {
+			akhir = akhir.AddDate(0, 0, -1)
+		}
+		if akhir.After(selesai) {
+			selesai = akhir
+		}
+	case durasi != "":
+		hari, err := parseDurasiHari(durasi)
+		if err != nil {
+			return err
+		}
+		if hari > 1 {
+			selesai = mulai.AddDate(0, 0, hari-1)
+		}
+	}
+
+	e.Mulai, e.Selesai = mulai, selesai
+	return nil
+}
+
+func tengahMalam(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// parseTanggal membaca nilai DATE (20260101) atau DATE-TIME (20260101T080000, akhiran Z
+// untuk UTC, atau parameter TZID)
+func parseTanggal(p properti, loc *time.Location) (time.Time, bool, error) {
+	nilai := strings.TrimSpace(p.nilai)
+	if strings.EqualFold(p.param["VALUE"], "DATE") || len(nilai) == 8 {
+		t, err := time.ParseInLocation("20060102", nilai, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(nilai, "Z") {
+		t, err := time.Parse("20060102T150405Z", nilai)
+		return t, false, err
+	}
+	zona := loc
+	if tzid := p.param["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(strings.Trim(tzid, `"`)); err == nil {
+			zona = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", nilai, zona)
+	return t, false, err
+}
+
+// parseDurasiHari membaca DURATION dalam hari atau minggu, misal P1D atau P2W
+func parseDurasiHari(s string) (int, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "+")
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("DURATION %q tidak valid", s)
+	}
+	// Bagian jam (T...) tidak menambah hari libur
+	if i := strings.Index(s, "T"); i >= 0 {
+		s = s[:i]
+	}
+	kali := 1
+	switch {
+	case strings.HasSuffix(s, "W"):
+		kali = 7
+	case strings.HasSuffix(s, "D"):
+	case s == "P":
+		return 1, nil
+	default:
+		return 0, fmt.Errorf("DURATION %q tidak valid", s)
+	}
+	n, err := strconv.Atoi(s[1 : len(s)-1])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("DURATION %q tidak valid", s)
+	}
+	return n * kali, nil
+}
+
+type barisKonten struct {
+	nomor int
+	teks  string
+}
+
+// bacaBaris membaca file dan menyambung baris lanjutan (diawali spasi atau tab)
+func bacaBaris(r io.Reader) ([]barisKonten, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var hasil []barisKonten
+	nomor := 0
+	for scanner.Scan() {
+		nomor++
+		teks := strings.TrimRight(scanner.Text(), "\r")
+		if nomor == 1 {
+			teks = strings.TrimPrefix(teks, "\ufeff")
+		}
+		if (strings.HasPrefix(teks, " ") || strings.HasPrefix(teks, "\t")) && len(hasil) > 0 {
+			hasil[len(hasil)-1].teks += teks[1:]
+			continue
+		}
+		if strings.TrimSpace(teks) == "" {
+			continue
+		}
+		hasil = append(hasil, barisKonten{nomor: nomor, teks: teks})
+	}
+	return hasil, scanner.Err()
+}
+
+// parseProperti memecah "NAMA;PARAM=NILAI:isi". Titik dua di dalam parameter bertanda kutip diabaikan.
+func parseProperti(teks string) (properti, bool) {
+	kutip := false
+	pisah := -1
+	for i, r := range teks {
+		if r == '"' {
+			kutip = !kutip
+		}
+		if r == ':' && !kutip {
+			pisah = i
+			break
+		}
+	}
+	if pisah < 0 {
+		return properti{}, false
+	}
+
+	bagian := strings.Split(teks[:pisah], ";")
+	p := properti{
+		nama:  strings.ToUpper(strings.TrimSpace(bagian[0])),
+		param: make(map[string]string),
+		nilai: teks[pisah+1:],
+	}
+	for _, prm := range bagian[1:] {
+		if k, v, ok := strings.Cut(prm, "="); ok {
+			p.param[strings.ToUpper(k)] = v
+		}
+	}
+	return p, true
+}
+
+// unescape mengembalikan karakter yang di-escape pada nilai TEXT
+func unescape(s string) string {
+	r := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return strings.TrimSpace(r.Replace(s))
+}
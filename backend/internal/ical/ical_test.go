@@ -0,0 +1,144 @@
+package ical
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var jakarta = time.FixedZone("WIB", 7*3600)
+
+func tgl(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02", s, jakarta)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// kalender membungkus isi VEVENT menjadi file .ics dengan akhir baris CRLF
+func kalender(event ...string) string {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\n")
+	for _, e := range event {
+		b.WriteString("BEGIN:VEVENT\r\n")
+		b.WriteString(strings.ReplaceAll(strings.TrimSpace(e), "\n", "\r\n"))
+		b.WriteString("\r\nEND:VEVENT\r\n")
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+	return b.String()
+}
+
+func TestParseTanggal(t *testing.T) {
+	tests := []struct {
+		name        string
+		isi         string
+		mulai       string
+		selesai     string
+		sehariPenuh bool
+		gagal       bool
+	}{
+		{"tanpa DTEND", "DTSTART;VALUE=DATE:20260101", "2026-01-01", "2026-01-01", true, false},
+		{"DTEND eksklusif satu hari", "DTSTART;VALUE=DATE:20260101\nDTEND;VALUE=DATE:20260102", "2026-01-01", "2026-01-01", true, false},
+		{"DTEND eksklusif tiga hari", "DTSTART;VALUE=DATE:20260330\nDTEND;VALUE=DATE:20260402", "2026-03-30", "2026-04-01", true, false},
+		{"DTEND sama dengan DTSTART", "DTSTART;VALUE=DATE:20260101\nDTEND;VALUE=DATE:20260101", "2026-01-01", "2026-01-01", true, false},
+		{"DTEND sebelum DTSTART", "DTSTART;VALUE=DATE:20260105\nDTEND;VALUE=DATE:20260101", "2026-01-05", "2026-01-05", true, false},
+		{"tanggal tanpa VALUE", "DTSTART:20260817\nDTEND:20260818", "2026-08-17", "2026-08-17", true, false},
+		{"DURATION satu hari", "DTSTART;VALUE=DATE:20260101\nDURATION:P1D", "2026-01-01", "2026-01-01", true, false},
+		{"DURATION tiga hari", "DTSTART;VALUE=DATE:20260101\nDURATION:P3D", "2026-01-01", "2026-01-03", true, false},
+		{"DURATION minggu", "DTSTART;VALUE=DATE:20260622\nDURATION:P2W", "2026-06-22", "2026-07-05", true, false},
+		{"DURATION jam saja", "DTSTART:20260101T080000\nDURATION:PT8H", "2026-01-01", "2026-01-01", false, false},
+		{"DURATION hari dan jam", "DTSTART;VALUE=DATE:20260101\nDURATION:P2DT12H", "2026-01-01", "2026-01-02", true, false},
+		{"DURATION tidak valid", "DTSTART;VALUE=DATE:20260101\nDURATION:3 hari", "", "", true, true},
+		{"DTEND mengalahkan DURATION", "DTSTART;VALUE=DATE:20260101\nDTEND;VALUE=DATE:20260103\nDURATION:P7D", "2026-01-01", "2026-01-02", true, false},
+		{"berjam sampai tengah malam", "DTSTART:20260101T080000\nDTEND:20260102T000000", "2026-01-01", "2026-01-01", false, false},
+		{"berjam melewati tengah malam", "DTSTART:20260101T200000\nDTEND:20260102T020000", "2026-01-01", "2026-01-02", false, false},
+		// 17:00 UTC tanggal 31 Desember sudah 1 Januari di Jakarta
+		{"UTC dikonversi ke zona sekolah", "DTSTART:20251231T170000Z\nDTEND:20251231T180000Z", "2026-01-01", "2026-01-01", false, false},
+		{"TZID", "DTSTART;TZID=Asia/Jakarta:20260101T080000\nDTEND;TZID=Asia/Jakarta:20260101T090000", "2026-01-01", "2026-01-01", false, false},
+		{"tanpa DTSTART", "SUMMARY:Tanpa tanggal", "", "", false, true},
+		{"DTSTART rusak", "DTSTART;VALUE=DATE:2026-01-01", "", "", false, true},
+		{"DTEND rusak", "DTSTART;VALUE=DATE:20260101\nDTEND;VALUE=DATE:besok", "", "", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := Parse(strings.NewReader(kalender(tt.isi)), jakarta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 1 {
+				t.Fatalf("jumlah event = %d, ingin 1", len(events))
+			}
+			e := events[0]
+			if tt.gagal {
+				if e.Err == nil {
+					t.Fatalf("seharusnya Err terisi, dapat %v s.d. %v", e.Mulai, e.Selesai)
+				}
+				return
+			}
+			if e.Err != nil {
+				t.Fatalf("Err = %v", e.Err)
+			}
+			if !e.Mulai.Equal(tgl(tt.mulai)) || !e.Selesai.Equal(tgl(tt.selesai)) {
+				t.Errorf("rentang = %s s.d. %s, ingin %s s.d. %s",
+					e.Mulai.Format("2006-01-02"), e.Selesai.Format("2006-01-02"), tt.mulai, tt.selesai)
+			}
+			if e.SehariPenuh != tt.sehariPenuh {
+				t.Errorf("SehariPenuh = %v, ingin %v", e.SehariPenuh, tt.sehariPenuh)
+			}
+		})
+	}
+}
+
+func TestParseProperti(t *testing.T) {
+	isi := kalender(`UID:libur-1@example.com
+SUMMARY:Tahun Baru\, Libur Nasional
+DESCRIPTION:Baris pertama\nBaris kedua yang
+  disambung
+STATUS:cancelled
+RRULE:FREQ=YEARLY
+DTSTART;VALUE=DATE:20260101
+BEGIN:VALARM
+DESCRIPTION:Pengingat
+TRIGGER:-PT15M
+END:VALARM`, `SUMMARY:Event kedua
+DTSTART;VALUE=DATE:20260102`)
+
+	events, err := Parse(strings.NewReader("\ufeff"+isi), jakarta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("jumlah event = %d, ingin 2", len(events))
+	}
+	e := events[0]
+	if e.UID != "libur-1@example.com" {
+		t.Errorf("UID = %q", e.UID)
+	}
+	if e.Summary != "Tahun Baru, Libur Nasional" {
+		t.Errorf("Summary = %q", e.Summary)
+	}
+	// Isi VALARM tidak boleh menimpa deskripsi event
+	if e.Deskripsi != "Baris pertama\nBaris kedua yang disambung" {
+		t.Errorf("Deskripsi = %q", e.Deskripsi)
+	}
+	if !e.Dibatalkan() || !e.Berulang {
+		t.Errorf("Dibatalkan = %v, Berulang = %v; ingin keduanya true", e.Dibatalkan(), e.Berulang)
+	}
+	if e.Baris != 3 || events[1].Baris != 16 {
+		t.Errorf("baris = %d dan %d, ingin 3 dan 16", e.Baris, events[1].Baris)
+	}
+	if events[1].Dibatalkan() || events[1].Berulang {
+		t.Error("atribut event pertama terbawa ke event kedua")
+	}
+}
+
+func TestParseBukanICalendar(t *testing.T) {
+	for _, isi := range []string{"", "tanggal,keterangan\n2026-01-01,Tahun Baru\n"} {
+		if _, err := Parse(strings.NewReader(isi), jakarta); !errors.Is(err, ErrBukanICalendar) {
+			t.Errorf("Parse(%q) err = %v, ingin ErrBukanICalendar", isi, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// file: internal/ical/tulis.go
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Kalender adalah isi file .ics yang ditulis oleh Tulis
+type Kalender struct {
+	ProdID string
+	// Nama kalender yang tampil di aplikasi kalender (X-WR-CALNAME)
+	Nama string
+	// Zona waktu IANA kalender (X-WR-TIMEZONE), misal Asia/Jakarta
+	Zona string
+	// Saran seberapa sering aplikasi kalender mengambil ulang feed; nol berarti tidak ditulis
+	Refresh time.Duration
+	Events  []Event
+}
+
+// Tulis menulis kalender sebagai iCalendar (RFC 5545). Event SehariPenuh ditulis sebagai
+// tanggal dengan DTEND eksklusif (Selesai + 1 hari); event lain ditulis dalam UTC.
+func Tulis(w io.Writer, k Kalender, dibuat time.Time) error {
+	bw := bufio.NewWriter(w)
+	tulis := func(nama, nilai string) {
+		lipat(bw, nama+":"+nilai)
+	}
+
+	tulis("BEGIN", "VCALENDAR")
+	tulis("VERSION", "2.0")
+	tulis("PRODID", k.ProdID)
+	tulis("CALSCALE", "GREGORIAN")
+	tulis("METHOD", "PUBLISH")
+	if k.Nama != "" {
+		tulis("X-WR-CALNAME", escape(k.Nama))
+	}
+	if k.Zona != "" {
+		tulis("X-WR-TIMEZONE", k.Zona)
+	}
+	if k.Refresh > 0 {
+		durasi := "PT" + strconv.Itoa(int(k.Refresh.Hours())) + "H"
+		lipat(bw, "REFRESH-INTERVAL;VALUE=DURATION:"+durasi)
+		tulis("X-PUBLISHED-TTL", durasi)
+	}
+
+	stamp := dibuat.UTC().Format("20060102T150405Z")
+	for _, e := range k.Events {
+		tulis("BEGIN", "VEVENT")
+		tulis("UID", e.UID)
+		tulis("DTSTAMP", stamp)
+		if e.SehariPenuh {
+			selesai := e.Selesai
+			if selesai.Before(e.Mulai) {
+				selesai = e.Mulai
+			}
+			lipat(bw, "DTSTART;VALUE=DATE:"+e.Mulai.Format("20060102"))
+			lipat(bw, "DTEND;VALUE=DATE:"+selesai.AddDate(0, 0, 1).Format("20060102"))
+		} else {
+			tulis("DTSTART", e.Mulai.UTC().Format("20060102T150405Z"))
+			if e.Selesai.After(e.Mulai) {
+				tulis("DTEND", e.Selesai.UTC().Format("20060102T150405Z"))
+			}
+		}
+		tulis("SUMMARY", escape(e.Summary))
+		if e.Deskripsi != "" {
+			tulis("DESCRIPTION", escape(e.Deskripsi))
+		}
+		if e.Status != "" {
+			tulis("STATUS", e.Status)
+		}
+		// Event kehadiran dan libur tidak membuat pemakai terlihat sibuk di kalendernya
+		tulis("TRANSP", "TRANSPARENT")
+		tulis("END", "VEVENT")
+	}
+	tulis("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// lipat menulis satu baris konten, dipotong setiap 75 oktet dengan baris lanjutan
+// diawali spasi, tanpa memotong karakter UTF-8 di tengah
+func lipat(w *bufio.Writer, baris string) {
+	batas := 75
+	for len(baris) > batas {
+		potong := batas
+		for potong > 0 && !utf8.RuneStart(baris[potong]) {
+			potong--
+		}
+		w.WriteString(baris[:potong])
+		w.WriteString("\r\n ")
+		baris = baris[potong:]
+		batas = 74 // Spasi di awal baris lanjutan ikut dihitung
+	}
+	w.WriteString(baris)
+	w.WriteString("\r\n")
+}
+
+// escape meng-escape karakter khusus pada nilai TEXT
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
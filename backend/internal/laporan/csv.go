@@ -0,0 +1,95 @@
+// file: internal/laporan/csv.go
+package laporan
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// ContentTypeCSV adalah tipe MIME file CSV
+const ContentTypeCSV = "text/csv; charset=utf-8"
+
+// tulisCSV menulis judul dan baris-baris CSV. Diawali BOM UTF-8 agar Excel membaca nama
+// dengan huruf non-ASCII dengan benar; impor siswa membuang BOM ini lagi.
+func tulisCSV(w io.Writer, judul []string, jumlah int, baris func(i int) []string) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(judul); err != nil {
+		return err
+	}
+	for i := 0; i < jumlah; i++ {
+		if err := cw.Write(baris(i)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// SiswaCSV menulis data siswa. Kolomnya sama dengan yang dibaca impor siswa, sehingga hasil
+// ekspor bisa diubah di Excel lalu diunggah kembali; impor membuang lagi tanda kutip teksAman.
+func SiswaCSV(w io.Writer, list []domain.Siswa) error {
+	judul := []string{"nisn", "nama_lengkap", "kelas", "nomor_telepon_ortu", "email_ortu", "nama_orang_tua"}
+	return tulisCSV(w, judul, len(list), func(i int) []string {
+		s := list[i]
+		return teksAmanSemua(s.NISN, s.NamaLengkap, s.Kelas, s.NomorTeleponOrtu, s.EmailOrtu, s.NamaOrangTua)
+	})
+}
+
+// LogAbsensiCSV menulis isi LogAbsensi beserta nomor baris sheet-nya
+func LogAbsensiCSV(w io.Writer, logs []domain.LogAbsensiEkspor) error {
+	judul := []string{"baris", "timestamp", "nisn", "nama_lengkap", "kelas", "status", "timestamp_pulang", "dicatat_oleh"}
+	return tulisCSV(w, judul, len(logs), func(i int) []string {
+		l := logs[i]
+		return append([]string{strconv.Itoa(l.RowNumber)},
+			teksAmanSemua(l.Timestamp, l.Username, l.NamaLengkap, l.Kelas, l.Status, l.TimestampPulang, l.DicatatOleh)...)
+	})
+}
+
+// teksAman mencegah isian bebas (dari Google Form atau diketik admin di sheet) dibaca Excel
+// sebagai rumus
+func teksAman(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func teksAmanSemua(sel ...string) []string {
+	for i := range sel {
+		sel[i] = teksAman(sel[i])
+	}
+	return sel
+}
+
+// IzinCSV menulis pengajuan izin beserta nomor baris sheet-nya
+func IzinCSV(w io.Writer, list []domain.IzinEkspor) error {
+	judul := []string{"baris", "timestamp", "nisn", "nama_lengkap", "kelas", "jenis_izin", "tanggal_mulai", "tanggal_selesai", "status"}
+	return tulisCSV(w, judul, len(list), func(i int) []string {
+		r := list[i]
+		return append([]string{strconv.Itoa(r.RowNumber)},
+			teksAmanSemua(r.Timestamp, r.SiswaNISN, r.NamaLengkap, r.Kelas, r.JenisIzin, r.TanggalMulai, r.TanggalSelesai, r.Status)...)
+	})
+}
+
+// RaportCSV menulis jumlah Sakit/Izin/Alpa per siswa dengan kolom yang dibaca aplikasi raport.
+// Tanpa BOM karena aplikasi raport mencocokkan judul kolom apa adanya.
+func RaportCSV(w io.Writer, list []domain.RekapSiswa) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"nisn", "sakit", "izin", "alpa"}); err != nil {
+		return err
+	}
+	for _, r := range list {
+		if err := cw.Write([]string{r.NISN, strconv.Itoa(r.Sakit), strconv.Itoa(r.Izin), strconv.Itoa(r.Alpa)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,459 @@
+// file: internal/laporan/pdf.go
+package laporan
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// ContentTypePDF adalah MIME type file PDF
+const ContentTypePDF = "application/pdf"
+
+// KopSurat berisi identitas sekolah di kop laporan dan nama penanda tangan
+type KopSurat struct {
+	NamaSekolah      string
+	Alamat           string
+	Kota             string
+	KepalaSekolah    string
+	NIPKepalaSekolah string
+	// Path file logo PNG/JPG; kosong berarti kop tanpa logo
+	Logo string
+	// Nama wali kelas per kelas
+	WaliKelas map[string]string
+}
+
+// Ukuran halaman A4 tegak dalam milimeter
+const (
+	pdfMargin    = 15.0
+	pdfLebar     = 210.0 - 2*pdfMargin
+	pdfTinggiMax = 297.0 - pdfMargin
+)
+
+var namaHariPendek = [...]string{"Sen", "Sel", "Rab", "Kam", "Jum", "Sab", "Min"}
+
+// pdfLaporan menyimpan dokumen yang sedang ditulis beserta penerjemah teks ke cp1252
+// (font bawaan PDF tidak mendukung UTF-8 secara langsung)
+type pdfLaporan struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string
+	kop KopSurat
+	lap *domain.LaporanBulanan
+}
+
+// LaporanBulananPDF menulis laporan kehadiran bulanan. Untuk setiap kelas: halaman ringkasan
+// kelas (jika ringkasan), lalu satu halaman per siswa berisi kalender bulan itu, total
+// kehadiran, keterlambatan dan alasan izin, masing-masing dengan blok tanda tangan.
+func LaporanBulananPDF(w io.Writer, lap *domain.LaporanBulanan, kop KopSurat, ringkasan bool) error {
+	if kop.NamaSekolah == "" {
+		kop.NamaSekolah = namaSekolah
+	}
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle(fmt.Sprintf("Laporan Kehadiran %s %d", NamaBulan(lap.Bulan), lap.Tahun), true)
+	pdf.SetCreator("Presensi "+kop.NamaSekolah, true)
+	p := &pdfLaporan{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor(""), kop: kop, lap: lap}
+
+	daftarKelas, grup := perKelas(lap.Siswa, func(s domain.LaporanSiswa) string { return s.Siswa.Kelas })
+	if len(daftarKelas) == 0 {
+		// Kelas tanpa siswa tetap menghasilkan halaman ringkasan kosong, bukan file rusak
+		daftarKelas = []string{lap.Kelas}
+		ringkasan = true
+	}
+	for _, kelas := range daftarKelas {
+		if ringkasan {
+			p.halamanKelas(kelas, grup[kelas])
+		}
+		for _, s := range grup[kelas] {
+			p.halamanSiswa(s)
+		}
+	}
+	return pdf.Output(w)
+}
+
+// teks menyiapkan string untuk font bawaan PDF
+func (p *pdfLaporan) teks(s string) string {
+	return p.tr(s)
+}
+
+// kopSurat menulis logo, nama dan alamat sekolah, lalu garis pemisah
+func (p *pdfLaporan) kopSurat() {
+	pdf := p.pdf
+	y := pdf.GetY()
+	if p.kop.Logo != "" {
+		pdf.ImageOptions(p.kop.Logo, pdfMargin, y, 0, 20, false, fpdf.ImageOptions{ReadDpi: true}, 0, "")
+	}
+	pdf.SetFont("Helvetica", "B", 15)
+	pdf.CellFormat(pdfLebar, 8, p.teks(strings.ToUpper(p.kop.NamaSekolah)), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	if p.kop.Alamat != "" {
+		pdf.MultiCell(pdfLebar, 4.5, p.teks(p.kop.Alamat), "", "C", false)
+	}
+	garis := y + 22
+	if pdf.GetY() > garis {
+		garis = pdf.GetY() + 2
+	}
+	pdf.SetLineWidth(0.8)
+	pdf.Line(pdfMargin, garis, pdfMargin+pdfLebar, garis)
+	pdf.SetLineWidth(0.2)
+	pdf.Line(pdfMargin, garis+1, pdfMargin+pdfLebar, garis+1)
+	pdf.SetY(garis + 5)
+}
+
+// judul menulis judul laporan dan periodenya di tengah halaman
+func (p *pdfLaporan) judul(teks string) {
+	pdf := p.pdf
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(pdfLebar, 6, p.teks(teks), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(pdfLebar, 5, p.teks(fmt.Sprintf("Bulan %s %d", NamaBulan(p.lap.Bulan), p.lap.Tahun)), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+}
+
+// identitas menulis pasangan label dan nilai, misal "Kelas : 7A"
+func (p *pdfLaporan) identitas(baris [][2]string) {
+	pdf := p.pdf
+	pdf.SetFont("Helvetica", "", 10)
+	for _, b := range baris {
+		pdf.CellFormat(35, 5.5, p.teks(b[0]), "", 0, "L", false, 0, "")
+		pdf.CellFormat(4, 5.5, ":", "", 0, "L", false, 0, "")
+		pdf.CellFormat(pdfLebar-39, 5.5, p.teks(b[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(3)
+}
+
+// header menulis baris judul tabel berlatar abu-abu
+func (p *pdfLaporan) header(lebar []float64, judul []string) {
+	pdf := p.pdf
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(220, 220, 220)
+	for i, j := range judul {
+		pdf.CellFormat(lebar[i], 7, p.teks(j), "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 9)
+}
+
+// pastikanRuang pindah ke halaman baru jika sisa halaman kurang dari tinggi h
+func (p *pdfLaporan) pastikanRuang(h float64) {
+	if p.pdf.GetY()+h > pdfTinggiMax {
+		p.pdf.AddPage()
+	}
+}
+
+// penandaTangan adalah satu blok tanda tangan
+type penandaTangan struct {
+	atas  []string // Baris di atas ruang tanda tangan, misal kota/tanggal dan jabatan
+	nama  string
+	bawah string // Baris di bawah nama, misal NIP
+}
+
+// tandaTangan menulis blok tanda tangan berdampingan dari kiri ke kanan; satu blok saja
+// diletakkan di tengah
+func (p *pdfLaporan) tandaTangan(blok ...penandaTangan) {
+	pdf := p.pdf
+	const tinggi = 42.0
+	p.pastikanRuang(tinggi)
+	lebarBlok := pdfLebar / float64(max(len(blok), 2))
+	y := pdf.GetY() + 4
+	for i, b := range blok {
+		x := pdfMargin + float64(i)*lebarBlok
+		if len(blok) == 1 {
+			x = pdfMargin + lebarBlok/2
+		}
+		pdf.SetXY(x, y)
+		pdf.SetFont("Helvetica", "", 10)
+		for _, a := range b.atas {
+			pdf.SetX(x)
+			pdf.CellFormat(lebarBlok, 5, p.teks(a), "", 1, "C", false, 0, "")
+		}
+		pdf.SetXY(x, y+30)
+		nama := b.nama
+		if nama == "" {
+			nama = "(.................................................)"
+		}
+		pdf.SetFont("Helvetica", "BU", 10)
+		pdf.CellFormat(lebarBlok, 5, p.teks(nama), "", 1, "C", false, 0, "")
+		if b.bawah != "" {
+			pdf.SetX(x)
+			pdf.SetFont("Helvetica", "", 9)
+			pdf.CellFormat(lebarBlok, 5, p.teks(b.bawah), "", 1, "C", false, 0, "")
+		}
+	}
+	pdf.SetXY(pdfMargin, y+tinggi)
+}
+
+// tempatTanggal menulis baris "Kota, 31 Juli 2025" di atas tanda tangan wali kelas
+func (p *pdfLaporan) tempatTanggal() string {
+	tanggal := TanggalIndonesia(p.lap.DibuatPada.Format("2006-01-02"))
+	if p.kop.Kota == "" {
+		return tanggal
+	}
+	return p.kop.Kota + ", " + tanggal
+}
+
+func (p *pdfLaporan) kepalaSekolah() penandaTangan {
+	nip := ""
+	if p.kop.NIPKepalaSekolah != "" {
+		nip = "NIP. " + p.kop.NIPKepalaSekolah
+	}
+	return penandaTangan{atas: []string{"Mengetahui,", "Kepala Sekolah"}, nama: p.kop.KepalaSekolah, bawah: nip}
+}
+
+func (p *pdfLaporan) waliKelas(kelas string) penandaTangan {
+	return penandaTangan{atas: []string{p.tempatTanggal(), "Wali Kelas " + kelas}, nama: p.kop.WaliKelas[kelas]}
+}
+
+// halamanKelas menulis tabel ringkasan kehadiran seluruh siswa di satu kelas
+func (p *pdfLaporan) halamanKelas(kelas string, list []domain.LaporanSiswa) {
+	pdf := p.pdf
+	pdf.AddPage()
+	p.kopSurat()
+	p.judul("REKAPITULASI KEHADIRAN SISWA")
+	p.identitas([][2]string{
+		{"Kelas", keteranganKelas(kelas)},
+		{"Hari efektif", strconv.Itoa(p.lap.HariEfektif) + " hari"},
+		{"Jam masuk", p.lap.JamMasuk},
+	})
+
+	lebar := []float64{9, 26, 64, 12, 12, 12, 12, 15, 18}
+	p.header(lebar, []string{"No", "NISN", "Nama Lengkap", "H", "I", "S", "A", "Telat", "% Hadir"})
+	var total domain.StatistikData
+	totalTelat := 0
+	for n, s := range list {
+		if pdf.GetY()+6 > pdfTinggiMax {
+			pdf.AddPage()
+			p.header(lebar, []string{"No", "NISN", "Nama Lengkap", "H", "I", "S", "A", "Telat", "% Hadir"})
+		}
+		nilai := []string{
+			strconv.Itoa(n + 1), s.Siswa.NISN, s.Siswa.NamaLengkap,
+			strconv.Itoa(s.Statistik.TotalHadir), strconv.Itoa(s.Statistik.TotalIzin),
+			strconv.Itoa(s.Statistik.TotalSakit), strconv.Itoa(s.Statistik.TotalAlpa),
+			strconv.Itoa(len(s.Terlambat)), persen(s.PersenHadir),
+		}
+		for i, v := range nilai {
+			rata := "C"
+			if i == 2 {
+				rata = "L"
+			}
+			// Persen hadir di bawah ambang ditulis merah
+			if i == len(nilai)-1 && s.Statistik.DiBawahAmbang {
+				pdf.SetTextColor(192, 0, 0)
+			}
+			pdf.CellFormat(lebar[i], 6, p.teks(potong(pdf, v, lebar[i]-2)), "1", 0, rata, false, 0, "")
+			pdf.SetTextColor(0, 0, 0)
+		}
+		pdf.Ln(-1)
+		total.TotalHadir += s.Statistik.TotalHadir
+		total.TotalIzin += s.Statistik.TotalIzin
+		total.TotalSakit += s.Statistik.TotalSakit
+		total.TotalAlpa += s.Statistik.TotalAlpa
+		totalTelat += len(s.Terlambat)
+	}
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(lebar[0]+lebar[1]+lebar[2], 6, "Jumlah", "1", 0, "C", false, 0, "")
+	for i, v := range []int{total.TotalHadir, total.TotalIzin, total.TotalSakit, total.TotalAlpa, totalTelat} {
+		pdf.CellFormat(lebar[3+i], 6, strconv.Itoa(v), "1", 0, "C", false, 0, "")
+	}
+	total.HitungPersentase(p.lap.HariEfektif, len(list), 0)
+	pdf.CellFormat(lebar[8], 6, persen(total.PersenHadir), "1", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.Ln(1)
+	ket := "H = Hadir, I = Izin, S = Sakit, A = Alpa. Telat = jumlah hari datang setelah jam masuk. " +
+		"% Hadir dihitung dari hari efektif yang sudah berjalan."
+	if len(list) > 0 && list[0].Statistik.AmbangKehadiran > 0 {
+		ket += fmt.Sprintf(" %% Hadir merah berada di bawah batas minimal %s.", persen(list[0].Statistik.AmbangKehadiran))
+	}
+	pdf.MultiCell(pdfLebar, 4, p.teks(ket), "", "L", false)
+	p.catatanLibur()
+
+	p.tandaTangan(p.kepalaSekolah(), p.waliKelas(kelas))
+}
+
+// catatanLibur menulis daftar hari libur dan hari khusus di bulan laporan
+func (p *pdfLaporan) catatanLibur() {
+	var catatan []string
+	for _, h := range p.lap.Hari {
+		if h.Keterangan == "" || h.Keterangan == domain.KeteranganAkhirPekan {
+			continue
+		}
+		catatan = append(catatan, fmt.Sprintf("%s: %s", TanggalIndonesia(h.Tanggal), h.Keterangan))
+	}
+	if len(catatan) == 0 {
+		return
+	}
+	pdf := p.pdf
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.MultiCell(pdfLebar, 4, p.teks("Hari khusus: "+strings.Join(catatan, "; ")), "", "L", false)
+}
+
+// halamanSiswa menulis laporan satu siswa untuk diserahkan ke orang tua
+func (p *pdfLaporan) halamanSiswa(s domain.LaporanSiswa) {
+	pdf := p.pdf
+	pdf.AddPage()
+	p.kopSurat()
+	p.judul("LAPORAN KEHADIRAN SISWA")
+	p.identitas([][2]string{
+		{"Nama", s.Siswa.NamaLengkap},
+		{"NISN", s.Siswa.NISN},
+		{"Kelas", keteranganKelas(s.Siswa.Kelas)},
+	})
+
+	p.kalender(s)
+
+	// Total kehadiran
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(pdfLebar, 6, "Jumlah Kehadiran", "", 1, "L", false, 0, "")
+	lebar := pdfLebar / 6
+	p.header([]float64{lebar, lebar, lebar, lebar, lebar, lebar}, []string{"Hari Efektif", "Hadir", "Izin", "Sakit", "Alpa", "% Hadir"})
+	for _, v := range []string{
+		strconv.Itoa(p.lap.HariEfektif), strconv.Itoa(s.Statistik.TotalHadir), strconv.Itoa(s.Statistik.TotalIzin),
+		strconv.Itoa(s.Statistik.TotalSakit), strconv.Itoa(s.Statistik.TotalAlpa), persen(s.PersenHadir),
+	} {
+		pdf.CellFormat(lebar, 6, v, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	if s.Statistik.DiBawahAmbang {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetTextColor(192, 0, 0)
+		pdf.CellFormat(pdfLebar, 5, p.teks(fmt.Sprintf("Kehadiran di bawah batas minimal %s.", persen(s.Statistik.AmbangKehadiran))), "", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	} else {
+		pdf.Ln(5)
+	}
+	pdf.Ln(4)
+
+	// Keterlambatan
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(pdfLebar, 6, p.teks(fmt.Sprintf("Keterlambatan (jam masuk %s)", p.lap.JamMasuk)), "", 1, "L", false, 0, "")
+	if len(s.Terlambat) == 0 {
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.CellFormat(pdfLebar, 5, "Tidak pernah terlambat.", "", 1, "L", false, 0, "")
+	} else {
+		lebarTelat := []float64{10, 70, 40, 40}
+		p.header(lebarTelat, []string{"No", "Tanggal", "Jam Datang", "Terlambat"})
+		for n, t := range s.Terlambat {
+			nilai := []string{strconv.Itoa(n + 1), TanggalIndonesia(t.Tanggal), t.Jam, fmt.Sprintf("%d menit", t.Menit)}
+			for i, v := range nilai {
+				pdf.CellFormat(lebarTelat[i], 5.5, p.teks(v), "1", 0, "C", false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+	}
+	pdf.Ln(3)
+
+	// Izin dan sakit beserta alasannya
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(pdfLebar, 6, "Pengajuan Izin", "", 1, "L", false, 0, "")
+	if len(s.Izin) == 0 {
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.CellFormat(pdfLebar, 5, "Tidak ada pengajuan izin.", "", 1, "L", false, 0, "")
+	} else {
+		lebarIzin := []float64{10, 55, 85, 30}
+		p.header(lebarIzin, []string{"No", "Tanggal", "Alasan", "Status"})
+		for n, iz := range s.Izin {
+			tanggal := TanggalIndonesia(iz.TanggalMulai)
+			if iz.TanggalSelesai != iz.TanggalMulai {
+				tanggal += " - " + TanggalIndonesia(iz.TanggalSelesai)
+			}
+			nilai := []string{strconv.Itoa(n + 1), tanggal, iz.Alasan, iz.Status}
+			for i, v := range nilai {
+				rata := "C"
+				if i == 2 {
+					rata = "L"
+				}
+				pdf.CellFormat(lebarIzin[i], 5.5, p.teks(potong(pdf, v, lebarIzin[i]-2)), "1", 0, rata, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+	}
+
+	orangTua := penandaTangan{atas: []string{"", "Orang Tua/Wali Murid"}, nama: s.Siswa.NamaOrangTua}
+	p.tandaTangan(orangTua, p.kepalaSekolah(), p.waliKelas(s.Siswa.Kelas))
+}
+
+// kalender menulis kalender bulan laporan (Senin sampai Minggu) dengan kode kehadiran
+// setiap tanggal; hari libur diarsir abu-abu
+func (p *pdfLaporan) kalender(s domain.LaporanSiswa) {
+	pdf := p.pdf
+	if len(p.lap.Hari) == 0 {
+		return
+	}
+	const tinggi = 10.0
+	lebar := pdfLebar / 7
+	p.header([]float64{lebar, lebar, lebar, lebar, lebar, lebar, lebar}, namaHariPendek[:])
+
+	awal, err := time.Parse("2006-01-02", p.lap.Hari[0].Tanggal)
+	if err != nil {
+		return
+	}
+	kolom := (int(awal.Weekday()) + 6) % 7 // Senin = 0
+	x0, y := pdf.GetXY()
+	for i, h := range p.lap.Hari {
+		x := x0 + float64(kolom)*lebar
+		kode := ""
+		if i < len(s.Kode) {
+			kode = s.Kode[i]
+		}
+
+		gaya := "FD"
+		switch {
+		case !h.HariSekolah:
+			pdf.SetFillColor(225, 225, 225)
+		case kode == domain.KodeAlpa:
+			pdf.SetFillColor(248, 215, 218)
+		case kode == domain.KodeIzin || kode == domain.KodeSakit:
+			pdf.SetFillColor(255, 243, 205)
+		default:
+			gaya = "D"
+		}
+		pdf.Rect(x, y, lebar, tinggi, gaya)
+
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.SetXY(x+0.8, y+0.5)
+		pdf.CellFormat(lebar-1.6, 3, strconv.Itoa(i+1), "", 0, "L", false, 0, "")
+		if !h.HariSekolah && h.Keterangan != "" && h.Keterangan != domain.KeteranganAkhirPekan {
+			pdf.SetFont("Helvetica", "", 5.5)
+			pdf.SetXY(x+0.5, y+tinggi-3.5)
+			pdf.CellFormat(lebar-1, 3, p.teks(potong(pdf, h.Keterangan, lebar-1)), "", 0, "C", false, 0, "")
+		}
+		if kode != "" {
+			pdf.SetFont("Helvetica", "B", 11)
+			pdf.SetXY(x, y+2.5)
+			pdf.CellFormat(lebar, 5, kode, "", 0, "C", false, 0, "")
+		}
+
+		kolom++
+		if kolom == 7 && i < len(p.lap.Hari)-1 {
+			kolom = 0
+			y += tinggi
+		}
+	}
+	pdf.SetXY(pdfMargin, y+tinggi+1)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(pdfLebar, 4, p.teks("H = Hadir, I = Izin, S = Sakit, A = Alpa. Kotak abu-abu adalah hari libur."), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+}
+
+// potong memendekkan teks dengan "..." agar muat di lebar sel
+func potong(pdf *fpdf.Fpdf, s string, lebar float64) string {
+	if pdf.GetStringWidth(s) <= lebar {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && pdf.GetStringWidth(string(r)+"...") > lebar {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "..."
+}
+
+func persen(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', 1, 64), ".", ",", 1) + "%"
+}
@@ -0,0 +1,50 @@
+package laporan
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+func TestLaporanBulananPDF(t *testing.T) {
+	lap := &domain.LaporanBulanan{Tahun: 2026, Bulan: 3, Kelas: "7A", JamMasuk: "07:00", DibuatPada: time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)}
+	for d := 1; d <= 31; d++ {
+		tgl := time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC)
+		akhirPekan := tgl.Weekday() == time.Saturday || tgl.Weekday() == time.Sunday
+		lap.Hari = append(lap.Hari, domain.HariKalender{Tanggal: tgl.Format("2006-01-02"), HariSekolah: !akhirPekan})
+		if !akhirPekan {
+			lap.HariEfektif++
+		}
+	}
+	for i, nama := range []string{"Ahmad Fauzi", "Siti Nur'aini", "Zoë Ramadhani"} {
+		s := domain.LaporanSiswa{
+			BarisGrid: domain.BarisGrid{
+				Siswa: domain.Siswa{NISN: fmt.Sprintf("00123456%02d", i), NamaLengkap: nama, Kelas: "7A", NamaOrangTua: "Orang Tua " + nama},
+				Kode:  make([]string, len(lap.Hari)),
+			},
+			Terlambat: []domain.Keterlambatan{{Tanggal: "2026-03-02", Jam: "07:15", Menit: 15}},
+		}
+		for j, h := range lap.Hari {
+			if h.HariSekolah {
+				s.Kode[j] = "H"
+			}
+		}
+		lap.Siswa = append(lap.Siswa, s)
+	}
+
+	var buf bytes.Buffer
+	kop := KopSurat{KepalaSekolah: "Kepala Sekolah", WaliKelas: map[string]string{"7A": "Wali 7A"}}
+	if err := LaporanBulananPDF(&buf, lap, kop, true); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("hasil bukan file PDF: %q", buf.Bytes()[:min(20, buf.Len())])
+	}
+	// Ringkasan kelas ditambah satu halaman per siswa
+	if n := bytes.Count(buf.Bytes(), []byte("/Type /Page\n")); n != 1+len(lap.Siswa) {
+		t.Errorf("jumlah halaman = %d, ingin %d", n, 1+len(lap.Siswa))
+	}
+}
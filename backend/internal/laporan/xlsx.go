@@ -0,0 +1,349 @@
+// file: internal/laporan/xlsx.go
+
+// Package laporan mengubah data rekap kehadiran menjadi file untuk diunduh (Excel, CSV, PDF).
+// Datanya selalu berasal dari usecase absensi, jadi angka di file sama dengan di dashboard.
+package laporan
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ContentTypeXLSX adalah MIME type file Excel
+const ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// Judul di kop setiap lembar
+const namaSekolah = "Daarul Ilmi"
+
+var namaBulan = [...]string{"", "Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli",
+	"Agustus", "September", "Oktober", "November", "Desember"}
+
+// NamaBulan mengembalikan nama bulan dalam bahasa Indonesia, misal 8 menjadi "Agustus"
+func NamaBulan(bulan int) string {
+	if bulan < 1 || bulan > 12 {
+		return ""
+	}
+	return namaBulan[bulan]
+}
+
+// TanggalIndonesia menulis tanggal YYYY-MM-DD sebagai "17 Agustus 2026"
+func TanggalIndonesia(tanggal string) string {
+	t, err := time.Parse("2006-01-02", tanggal)
+	if err != nil {
+		return tanggal
+	}
+	return fmt.Sprintf("%d %s %d", t.Day(), NamaBulan(int(t.Month())), t.Year())
+}
+
+// gaya adalah ID style excelize yang dipakai di semua lembar
+type gaya struct {
+	judul, header, sel, angka, persen, libur, alpa int
+	// Persen hadir yang di bawah ambang kehadiran
+	diBawahAmbang int
+}
+
+func buatGaya(f *excelize.File) (gaya, error) {
+	garis := []excelize.Border{
+		{Type: "left", Color: "000000", Style: 1},
+		{Type: "right", Color: "000000", Style: 1},
+		{Type: "top", Color: "000000", Style: 1},
+		{Type: "bottom", Color: "000000", Style: 1},
+	}
+	tengah := &excelize.Alignment{Horizontal: "center", Vertical: "center"}
+	styles := []*excelize.Style{
+		{Font: &excelize.Font{Bold: true, Size: 12}},
+		{Font: &excelize.Font{Bold: true}, Border: garis, Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"DDEBF7"}}},
+		{Border: garis},
+		{Border: garis, Alignment: tengah},
+		{Border: garis, Alignment: tengah, NumFmt: 2}, // 0.00
+		{Border: garis, Alignment: tengah, Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9D9D9"}}},
+		{Border: garis, Alignment: tengah, Font: &excelize.Font{Bold: true, Color: "C00000"}},
+		{Border: garis, Alignment: tengah, NumFmt: 2, Font: &excelize.Font{Bold: true, Color: "C00000"},
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FCE4D6"}}},
+	}
+	ids := make([]int, len(styles))
+	for i, s := range styles {
+		id, err := f.NewStyle(s)
+		if err != nil {
+			return gaya{}, err
+		}
+		ids[i] = id
+	}
+	return gaya{ids[0], ids[1], ids[2], ids[3], ids[4], ids[5], ids[6], ids[7]}, nil
+}
+
+// perKelas mengelompokkan data per kelas, diurutkan menurut nama kelas
+func perKelas[T any](list []T, kelas func(T) string) ([]string, map[string][]T) {
+	grup := make(map[string][]T)
+	for _, item := range list {
+		k := strings.TrimSpace(kelas(item))
+		grup[k] = append(grup[k], item)
+	}
+	nama := make([]string, 0, len(grup))
+	for k := range grup {
+		nama = append(nama, k)
+	}
+	sort.Strings(nama)
+	return nama, grup
+}
+
+var karakterSheetTerlarang = strings.NewReplacer(":", "-", "\\", "-", "/", "-", "?", "", "*", "", "[", "(", "]", ")")
+
+// namaSheet membuat nama lembar yang sah di Excel (maksimal 31 karakter) dan belum dipakai
+func namaSheet(kelas string, dipakai map[string]bool) string {
+	nama := karakterSheetTerlarang.Replace(kelas)
+	if nama == "" {
+		nama = "Tanpa Kelas"
+	}
+	for utf8.RuneCountInString(nama) > 31 {
+		_, size := utf8.DecodeLastRuneInString(nama)
+		nama = nama[:len(nama)-size]
+	}
+	asli := nama
+	for i := 2; dipakai[strings.ToLower(nama)]; i++ {
+		akhiran := fmt.Sprintf(" (%d)", i)
+		nama = asli
+		for utf8.RuneCountInString(nama)+len(akhiran) > 31 {
+			_, size := utf8.DecodeLastRuneInString(nama)
+			nama = nama[:len(nama)-size]
+		}
+		nama += akhiran
+	}
+	dipakai[strings.ToLower(nama)] = true
+	return nama
+}
+
+// lembarBaru membuat lembar untuk satu kelas; lembar bawaan "Sheet1" dipakai untuk kelas pertama
+func lembarBaru(f *excelize.File, nama string, pertama bool) error {
+	if pertama {
+		return f.SetSheetName(f.GetSheetName(0), nama)
+	}
+	_, err := f.NewSheet(nama)
+	return err
+}
+
+func sel(col, row int) string {
+	name, _ := excelize.CoordinatesToCellName(col, row)
+	return name
+}
+
+// kop menulis judul, kelas, dan periode di tiga baris pertama lembar
+func kop(f *excelize.File, sheet string, g gaya, judul, kelas, periode string, lebar int) {
+	baris := []string{judul, "Kelas: " + keteranganKelas(kelas), "Periode: " + periode}
+	for i, teks := range baris {
+		f.SetCellValue(sheet, sel(1, i+1), teks)
+		f.MergeCell(sheet, sel(1, i+1), sel(lebar, i+1))
+	}
+	f.SetCellStyle(sheet, "A1", "A1", g.judul)
+}
+
+func keteranganKelas(kelas string) string {
+	if kelas == "" {
+		return "-"
+	}
+	return kelas
+}
+
+// RekapXLSX membuat file Excel rekap kehadiran (jumlah Hadir, Izin, Sakit, Alpa dan
+// persentasenya per siswa) untuk rentang tanggal, satu lembar per kelas
+func RekapXLSX(rekap []domain.RekapSiswa, startDate, endDate string) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	g, err := buatGaya(f)
+	if err != nil {
+		return nil, err
+	}
+
+	kolom := []string{"No", "NISN", "Nama Lengkap", "Hadir", "Izin", "Sakit", "Alpa", "Jumlah", "% Hadir", "% Izin", "% Alpa"}
+	periode := TanggalIndonesia(startDate) + " s.d. " + TanggalIndonesia(endDate)
+	var ambang float64
+	if len(rekap) > 0 {
+		periode += fmt.Sprintf(" (hari efektif: %d)", rekap[0].HariEfektif)
+		ambang = rekap[0].AmbangKehadiran
+	}
+	daftarKelas, grup := perKelas(rekap, func(r domain.RekapSiswa) string { return r.Kelas })
+	if len(daftarKelas) == 0 {
+		daftarKelas = []string{""}
+	}
+
+	dipakai := make(map[string]bool)
+	for i, kelas := range daftarKelas {
+		sheet := namaSheet(kelas, dipakai)
+		if err := lembarBaru(f, sheet, i == 0); err != nil {
+			return nil, err
+		}
+		kop(f, sheet, g, "REKAP KEHADIRAN SISWA "+strings.ToUpper(namaSekolah), kelas, periode, len(kolom))
+
+		const barisHeader = 5
+		for c, judul := range kolom {
+			f.SetCellValue(sheet, sel(c+1, barisHeader), judul)
+		}
+		f.SetCellStyle(sheet, sel(1, barisHeader), sel(len(kolom), barisHeader), g.header)
+
+		list := grup[kelas]
+		sort.SliceStable(list, func(a, b int) bool { return list[a].NamaLengkap < list[b].NamaLengkap })
+		for n, r := range list {
+			row := barisHeader + 1 + n
+			nilai := []interface{}{n + 1, r.NISN, r.NamaLengkap, r.Hadir, r.Izin, r.Sakit, r.Alpa, r.Hadir + r.Izin + r.Sakit + r.Alpa,
+				r.PersenHadir, r.PersenIzin, r.PersenAlpa}
+			for c, v := range nilai {
+				f.SetCellValue(sheet, sel(c+1, row), v)
+			}
+			f.SetCellStyle(sheet, sel(1, row), sel(8, row), g.angka)
+			f.SetCellStyle(sheet, sel(9, row), sel(len(kolom), row), g.persen)
+			f.SetCellStyle(sheet, sel(2, row), sel(3, row), g.sel)
+			if r.Alpa > 0 {
+				f.SetCellStyle(sheet, sel(7, row), sel(7, row), g.alpa)
+			}
+			if r.DiBawahAmbang {
+				f.SetCellStyle(sheet, sel(9, row), sel(9, row), g.diBawahAmbang)
+			}
+		}
+		if ambang > 0 {
+			f.SetCellValue(sheet, sel(1, barisHeader+len(list)+2),
+				fmt.Sprintf("Persen dihitung dari hari efektif. %% Hadir yang ditandai merah berada di bawah ambang kehadiran %s%%.", angkaPersen(ambang)))
+		}
+
+		f.SetColWidth(sheet, "A", "A", 5)
+		f.SetColWidth(sheet, "B", "B", 14)
+		f.SetColWidth(sheet, "C", "C", 32)
+		f.SetColWidth(sheet, "D", "K", 9)
+		f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: barisHeader, TopLeftCell: sel(1, barisHeader+1), ActivePane: "bottomLeft"})
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GridXLSX membuat file Excel grid bulanan: siswa × tanggal dengan kode H/I/S/A, hari libur
+// diarsir abu-abu, lalu jumlah per status dan persentase kehadiran. Satu lembar per kelas.
+func GridXLSX(grid *domain.GridBulanan) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	g, err := buatGaya(f)
+	if err != nil {
+		return nil, err
+	}
+
+	const (
+		kolomTanggal = 4 // Kolom pertama tanggal, setelah No, NISN, Nama
+		barisHeader  = 5
+	)
+	kolomTotal := kolomTanggal + len(grid.Hari)
+	lebar := kolomTotal + 4 // H, I, S, A, %
+	periode := fmt.Sprintf("%s %d (hari efektif: %d)", NamaBulan(grid.Bulan), grid.Tahun, grid.HariEfektif)
+	daftarKelas, grup := perKelas(grid.Baris, func(b domain.BarisGrid) string { return b.Siswa.Kelas })
+	if len(daftarKelas) == 0 {
+		daftarKelas = []string{grid.Kelas}
+	}
+
+	dipakai := make(map[string]bool)
+	for i, kelas := range daftarKelas {
+		sheet := namaSheet(kelas, dipakai)
+		if err := lembarBaru(f, sheet, i == 0); err != nil {
+			return nil, err
+		}
+		kop(f, sheet, g, "DAFTAR HADIR SISWA "+strings.ToUpper(namaSekolah), kelas, periode, lebar)
+
+		// Baris judul: identitas, tanggal 1..31, lalu jumlah
+		for c, judul := range []string{"No", "NISN", "Nama Lengkap"} {
+			f.SetCellValue(sheet, sel(c+1, barisHeader), judul)
+		}
+		for d := range grid.Hari {
+			f.SetCellValue(sheet, sel(kolomTanggal+d, barisHeader), d+1)
+		}
+		for c, judul := range []string{"H", "I", "S", "A", "% Hadir"} {
+			f.SetCellValue(sheet, sel(kolomTotal+c, barisHeader), judul)
+		}
+		f.SetCellStyle(sheet, sel(1, barisHeader), sel(lebar, barisHeader), g.header)
+
+		list := grup[kelas]
+		for n, b := range list {
+			row := barisHeader + 1 + n
+			f.SetCellValue(sheet, sel(1, row), n+1)
+			f.SetCellValue(sheet, sel(2, row), b.Siswa.NISN)
+			f.SetCellValue(sheet, sel(3, row), b.Siswa.NamaLengkap)
+			f.SetCellStyle(sheet, sel(1, row), sel(1, row), g.angka)
+			f.SetCellStyle(sheet, sel(2, row), sel(3, row), g.sel)
+
+			for d, kode := range b.Kode {
+				cell := sel(kolomTanggal+d, row)
+				f.SetCellValue(sheet, cell, kode)
+				switch {
+				case !grid.Hari[d].HariSekolah:
+					f.SetCellStyle(sheet, cell, cell, g.libur)
+				case kode == domain.KodeAlpa:
+					f.SetCellStyle(sheet, cell, cell, g.alpa)
+				default:
+					f.SetCellStyle(sheet, cell, cell, g.angka)
+				}
+			}
+
+			total := []interface{}{b.Rekap.Hadir, b.Rekap.Izin, b.Rekap.Sakit, b.Rekap.Alpa, b.PersenHadir}
+			for c, v := range total {
+				f.SetCellValue(sheet, sel(kolomTotal+c, row), v)
+			}
+			f.SetCellStyle(sheet, sel(kolomTotal, row), sel(kolomTotal+3, row), g.angka)
+			if b.Rekap.DiBawahAmbang {
+				f.SetCellStyle(sheet, sel(kolomTotal+4, row), sel(kolomTotal+4, row), g.diBawahAmbang)
+			} else {
+				f.SetCellStyle(sheet, sel(kolomTotal+4, row), sel(kolomTotal+4, row), g.persen)
+			}
+		}
+
+		// Arsir kolom libur pada baris judul juga, dan tulis keterangan libur di bawah tabel
+		barisKet := barisHeader + len(list) + 2
+		for d, h := range grid.Hari {
+			if h.HariSekolah && !h.SetengahHari {
+				continue
+			}
+			if !h.HariSekolah {
+				f.SetCellStyle(sheet, sel(kolomTanggal+d, barisHeader), sel(kolomTanggal+d, barisHeader), g.libur)
+			}
+			if h.Keterangan != "" && h.Keterangan != domain.KeteranganAkhirPekan {
+				f.SetCellValue(sheet, sel(1, barisKet), fmt.Sprintf("%s: %s", TanggalIndonesia(h.Tanggal), h.Keterangan))
+				barisKet++
+			}
+		}
+		f.SetCellValue(sheet, sel(1, barisKet), "Keterangan: H = Hadir, I = Izin, S = Sakit, A = Alpa. Kolom abu-abu adalah hari libur.")
+
+		f.SetColWidth(sheet, "A", "A", 5)
+		f.SetColWidth(sheet, "B", "B", 14)
+		f.SetColWidth(sheet, "C", "C", 30)
+		awal, _ := excelize.ColumnNumberToName(kolomTanggal)
+		akhir, _ := excelize.ColumnNumberToName(kolomTotal - 1)
+		f.SetColWidth(sheet, awal, akhir, 3.5)
+		awal, _ = excelize.ColumnNumberToName(kolomTotal)
+		akhir, _ = excelize.ColumnNumberToName(lebar)
+		f.SetColWidth(sheet, awal, akhir, 6)
+		f.SetColWidth(sheet, akhir, akhir, 9)
+		f.SetPanes(sheet, &excelize.Panes{Freeze: true, XSplit: kolomTanggal - 1, YSplit: barisHeader,
+			TopLeftCell: sel(kolomTanggal, barisHeader+1), ActivePane: "bottomRight"})
+		f.SetPageLayout(sheet, &excelize.PageLayoutOptions{Orientation: strPtr("landscape")})
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func strPtr(s string) *string { return &s }
+
+// angkaPersen menulis angka persen tanpa desimal nol, misal 85 atau 87,5
+func angkaPersen(v float64) string {
+	return strings.ReplaceAll(strconv.FormatFloat(v, 'f', -1, 64), ".", ",")
+}
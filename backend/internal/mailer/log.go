@@ -0,0 +1,51 @@
+// file: internal/mailer/log.go
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+)
+
+type logMailer struct {
+	dir  string
+	from string
+}
+
+// NewLogMailer membuat Mailer untuk pengembangan: email tidak dikirim, tetapi ditulis
+// sebagai file .eml di dir (jika diisi) dan diringkas di log server.
+func NewLogMailer(dir, from string) usecase.Mailer {
+	return &logMailer{dir: dir, from: from}
+}
+
+var karakterTidakAman = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+func (m *logMailer) Send(ctx context.Context, msg domain.EmailMessage) error {
+	log.Printf("== EMAIL (mode log) == Kepada: %s | Subjek: %s", msg.To, msg.Subject)
+	if m.dir == "" {
+		log.Printf("%s", msg.TextBody)
+		return nil
+	}
+
+	raw, err := buildMessage(m.from, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102-150405.000"), karakterTidakAman.ReplaceAllString(msg.To, "_"))
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return err
+	}
+	log.Printf("Email disimpan di %s", path)
+	return nil
+}
@@ -0,0 +1,183 @@
+// file: internal/mailer/smtp.go
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+)
+
+// SMTPConfig berisi pengaturan server SMTP
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string // Contoh: "Presensi Daarul Ilmi <noreply@sekolah.sch.id>"
+}
+
+type smtpMailer struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPMailer membuat Mailer yang mengirim lewat server SMTP.
+// STARTTLS dipakai otomatis jika server mendukungnya.
+func NewSMTPMailer(cfg SMTPConfig) usecase.Mailer {
+	return &smtpMailer{cfg}
+}
+
+// smtpTimeout membatasi seluruh percakapan SMTP, agar server SMTP yang macet tidak menahan
+// permintaan (misal reset password) tanpa batas
+const smtpTimeout = 30 * time.Second
+
+func (m *smtpMailer) Send(ctx context.Context, msg domain.EmailMessage) error {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return fmt.Errorf("alamat email tujuan tidak valid: %q", msg.To)
+	}
+	raw, err := buildMessage(m.cfg.From, msg)
+	if err != nil {
+		return err
+	}
+	if err := m.kirim(ctx, envelopeAddress(m.cfg.From), msg.To, raw); err != nil {
+		return fmt.Errorf("gagal mengirim email ke %s: %v", msg.To, err)
+	}
+	return nil
+}
+
+// kirim sama dengan smtp.SendMail, tetapi koneksinya mengikuti ctx dan smtpTimeout
+func (m *smtpMailer) kirim(ctx context.Context, from, to string, raw []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(m.cfg.Host, fmt.Sprint(m.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	// Tenggat berlaku untuk setiap baca/tulis berikutnya; pembatalan ctx menutup koneksi
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if m.cfg.Username != "" {
+		auth := smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// envelopeAddress mengambil alamat email murni dari format "Nama <alamat>"
+func envelopeAddress(from string) string {
+	if i := strings.LastIndex(from, "<"); i >= 0 {
+		return strings.TrimSuffix(strings.TrimSpace(from[i+1:]), ">")
+	}
+	return strings.TrimSpace(from)
+}
+
+// buildMessage menyusun email MIME multipart/alternative (teks + HTML)
+func buildMessage(from string, msg domain.EmailMessage) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.TextBody},
+		{"text/html; charset=UTF-8", msg.HTMLBody},
+	}
+	for _, p := range parts {
+		if p.content == "" {
+			continue
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", p.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		w, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "From: %s\r\n", from)
+	fmt.Fprintf(&out, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&out, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&out, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&out, "Message-ID: <%s@%s>\r\n", randomID(), domainOf(envelopeAddress(from)))
+	fmt.Fprintf(&out, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&out, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+func randomID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprint(time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+func domainOf(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
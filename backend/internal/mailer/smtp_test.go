@@ -0,0 +1,206 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// suratStub adalah satu email yang diterima server SMTP tiruan
+type suratStub struct {
+	from, to string
+	data     string
+}
+
+// jalankanStubSMTP menjalankan server SMTP minimal (tanpa STARTTLS dan AUTH) di 127.0.0.1
+// dan mengirim setiap email yang diterima ke channel
+func jalankanStubSMTP(t *testing.T) (SMTPConfig, <-chan suratStub) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	surat := make(chan suratStub, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go layaniSMTP(conn, surat)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return SMTPConfig{Host: host, Port: p, From: "Presensi Daarul Ilmi <noreply@sekolah.sch.id>"}, surat
+}
+
+func layaniSMTP(conn net.Conn, surat chan<- suratStub) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	var s suratStub
+	tp.PrintfLine("220 stub ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		perintah := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(perintah, "EHLO"), strings.HasPrefix(perintah, "HELO"):
+			tp.PrintfLine("250 stub")
+		case strings.HasPrefix(perintah, "MAIL FROM:"):
+			s.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			tp.PrintfLine("250 OK")
+		case strings.HasPrefix(perintah, "RCPT TO:"):
+			s.to = strings.Trim(line[len("RCPT TO:"):], "<> ")
+			tp.PrintfLine("250 OK")
+		case perintah == "DATA":
+			tp.PrintfLine("354 lanjut")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			tp.PrintfLine("250 OK")
+			surat <- s
+		case perintah == "QUIT":
+			tp.PrintfLine("221 sampai jumpa")
+			return
+		default:
+			tp.PrintfLine("502 tidak didukung")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	cfg, surat := jalankanStubSMTP(t)
+	m := NewSMTPMailer(cfg)
+
+	err := m.Send(context.Background(), domain.EmailMessage{
+		To:       "ortu@contoh.id",
+		Subject:  "Reset Password Akun Presensi",
+		TextBody: "Halo, klik tautan berikut.",
+		HTMLBody: "<p>Halo, klik tautan berikut.</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var s suratStub
+	select {
+	case s = <-surat:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stub tidak menerima email")
+	}
+	if s.from != "noreply@sekolah.sch.id" || s.to != "ortu@contoh.id" {
+		t.Fatalf("envelope = %q -> %q", s.from, s.to)
+	}
+
+	pesan, err := mail.ReadMessage(strings.NewReader(s.data))
+	if err != nil {
+		t.Fatalf("email tidak bisa dibaca: %v", err)
+	}
+	header := map[string]string{
+		"From":         cfg.From,
+		"To":           "ortu@contoh.id",
+		"MIME-Version": "1.0",
+	}
+	for k, want := range header {
+		if got := pesan.Header.Get(k); got != want {
+			t.Errorf("header %s = %q, want %q", k, got, want)
+		}
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(pesan.Header.Get("Subject"))
+	if subject != "Reset Password Akun Presensi" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if pesan.Header.Get("Date") == "" || !strings.HasSuffix(pesan.Header.Get("Message-ID"), "@sekolah.sch.id>") {
+		t.Errorf("Date/Message-ID tidak lengkap: %v", pesan.Header)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(pesan.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q", pesan.Header.Get("Content-Type"))
+	}
+	mr := multipart.NewReader(pesan.Body, params["boundary"])
+	isi := map[string]string{}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// multipart.Reader sudah mendekode quoted-printable
+		b, _ := io.ReadAll(part)
+		isi[strings.SplitN(part.Header.Get("Content-Type"), ";", 2)[0]] = string(b)
+	}
+	if isi["text/plain"] != "Halo, klik tautan berikut." {
+		t.Errorf("bagian teks = %q", isi["text/plain"])
+	}
+	if isi["text/html"] != "<p>Halo, klik tautan berikut.</p>" {
+		t.Errorf("bagian HTML = %q", isi["text/html"])
+	}
+}
+
+func TestSMTPMailerTolakHeaderInjection(t *testing.T) {
+	cfg, surat := jalankanStubSMTP(t)
+	m := NewSMTPMailer(cfg)
+
+	for _, to := range []string{"a@contoh.id\r\nBcc: b@contoh.id", "a@contoh.id\nBcc: b@contoh.id"} {
+		if err := m.Send(context.Background(), domain.EmailMessage{To: to, Subject: "x", TextBody: "x"}); err == nil {
+			t.Errorf("Send(%q) seharusnya ditolak", to)
+		}
+	}
+	select {
+	case s := <-surat:
+		t.Fatalf("email dengan header sisipan tetap terkirim ke %q", s.to)
+	default:
+	}
+}
+
+func TestSMTPMailerServerMacet(t *testing.T) {
+	// Server menerima koneksi tetapi tidak pernah menyapa
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	m := NewSMTPMailer(SMTPConfig{Host: host, Port: p, From: "noreply@sekolah.sch.id"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	mulai := time.Now()
+	if err := m.Send(ctx, domain.EmailMessage{To: "a@contoh.id", Subject: "x", TextBody: "x"}); err == nil {
+		t.Fatal("Send ke server macet seharusnya gagal")
+	}
+	if lama := time.Since(mulai); lama > 2*time.Second {
+		t.Fatalf("Send tertahan %v, seharusnya berhenti saat ctx habis", lama)
+	}
+}
@@ -0,0 +1,31 @@
+// file: internal/notifikasi/email.go
+package notifikasi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+)
+
+type kanalEmail struct {
+	mailer usecase.Mailer
+}
+
+// NewEmail membuat kanal yang mengirim pesan lewat Mailer yang sama dengan email akun
+func NewEmail(mailer usecase.Mailer) usecase.KanalNotifikasi {
+	return &kanalEmail{mailer: mailer}
+}
+
+func (k *kanalEmail) Kirim(ctx context.Context, pesan domain.PesanNotifikasi) error {
+	if !strings.Contains(pesan.Tujuan, "@") {
+		return fmt.Errorf("alamat email %q tidak valid", pesan.Tujuan)
+	}
+	return k.mailer.Send(ctx, domain.EmailMessage{
+		To:       pesan.Tujuan,
+		Subject:  pesan.Subjek,
+		TextBody: pesan.Isi,
+	})
+}
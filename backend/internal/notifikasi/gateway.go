@@ -0,0 +1,109 @@
+// file: internal/notifikasi/gateway.go
+package notifikasi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+)
+
+// GatewayConfig adalah gateway HTTP WhatsApp/SMS yang menerima nomor tujuan dan isi pesan
+type GatewayConfig struct {
+	URL string
+	// Dikirim apa adanya di header Authorization (kosong berarti tanpa header)
+	Token string
+	// "json" atau "form"
+	Format      string
+	FieldTujuan string
+	FieldPesan  string
+	// Nomor berawalan 0 diubah menjadi kode negara ini
+	KodeNegara string
+}
+
+type gatewayHTTP struct {
+	nama   string
+	cfg    GatewayConfig
+	client *http.Client
+}
+
+// NewGateway membuat kanal yang mengirim pesan lewat gateway HTTP. nama hanya dipakai di
+// pesan error, misal "whatsapp" atau "sms".
+func NewGateway(nama string, cfg GatewayConfig) usecase.KanalNotifikasi {
+	return &gatewayHTTP{nama: nama, cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (g *gatewayHTTP) Kirim(ctx context.Context, pesan domain.PesanNotifikasi) error {
+	nomor := NormalisasiNomor(pesan.Tujuan, g.cfg.KodeNegara)
+	if nomor == "" {
+		return fmt.Errorf("nomor tujuan %s tidak valid", g.nama)
+	}
+
+	var body io.Reader
+	contentType := "application/json"
+	if g.cfg.Format == "form" {
+		form := url.Values{}
+		form.Set(g.cfg.FieldTujuan, nomor)
+		form.Set(g.cfg.FieldPesan, pesan.Isi)
+		body = strings.NewReader(form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	} else {
+		b, err := json.Marshal(map[string]string{g.cfg.FieldTujuan: nomor, g.cfg.FieldPesan: pesan.Isi})
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.cfg.URL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "DaarulIlmi-Presensi-Notifikasi/1.0")
+	if g.cfg.Token != "" {
+		req.Header.Set("Authorization", g.cfg.Token)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Sebagian isi respons ikut di pesan error agar alasan penolakan gateway terlihat di log
+	isi, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("gateway %s membalas HTTP %d: %s", g.nama, resp.StatusCode, strings.TrimSpace(string(isi)))
+	}
+	return nil
+}
+
+// NormalisasiNomor membuang spasi, tanda hubung, dan tanda plus, lalu mengganti awalan 0
+// dengan kode negara: "0812-3456 789" menjadi "628123456789". Hasil kosong jika bukan nomor.
+func NormalisasiNomor(nomor, kodeNegara string) string {
+	var b strings.Builder
+	for _, r := range nomor {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '+' || r == '(' || r == ')' || r == '.':
+		default:
+			return ""
+		}
+	}
+	hasil := b.String()
+	if strings.HasPrefix(hasil, "0") && kodeNegara != "" {
+		hasil = kodeNegara + strings.TrimPrefix(hasil, "0")
+	}
+	if len(hasil) < 8 {
+		return ""
+	}
+	return hasil
+}
@@ -0,0 +1,26 @@
+package notifikasi
+
+import "testing"
+
+func TestNormalisasiNomor(t *testing.T) {
+	tests := []struct {
+		nomor, kodeNegara, ingin string
+	}{
+		{"081234567890", "62", "6281234567890"},
+		{"0812-3456 789", "62", "628123456789"},
+		{"+62 812-3456-7890", "62", "6281234567890"},
+		{"6281234567890", "62", "6281234567890"},
+		{"(0274) 512.345", "62", "62274512345"},
+		{"081234567890", "", "081234567890"},
+		{"0812345", "62", "62812345"},
+		{"0812", "62", ""},
+		{"", "62", ""},
+		{"0812-3456-789 ext 2", "62", ""},
+		{"ortu@example.com", "62", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalisasiNomor(tt.nomor, tt.kodeNegara); got != tt.ingin {
+			t.Errorf("NormalisasiNomor(%q, %q) = %q, ingin %q", tt.nomor, tt.kodeNegara, got, tt.ingin)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// file: internal/notifikasi/log.go
+package notifikasi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+)
+
+type kanalLog struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewLog membuat kanal untuk uji coba: pesan tidak dikirim ke mana pun, tetapi ditulis ke
+// log server dan, jika dir diisi, ditambahkan ke file notifikasi-YYYY-MM-DD.txt di dir
+func NewLog(dir string) usecase.KanalNotifikasi {
+	return &kanalLog{dir: dir}
+}
+
+func (k *kanalLog) Kirim(ctx context.Context, pesan domain.PesanNotifikasi) error {
+	log.Printf("== NOTIFIKASI (mode log) == Kepada: %s | %s", pesan.Tujuan, pesan.Subjek)
+	if k.dir == "" {
+		log.Printf("%s", pesan.Isi)
+		return nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := os.MkdirAll(k.dir, 0o755); err != nil {
+		return err
+	}
+	now := time.Now()
+	path := filepath.Join(k.dir, "notifikasi-"+now.Format("2006-01-02")+".txt")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "=== %s | Kepada: %s | %s\n%s\n\n", now.Format(time.RFC3339), pesan.Tujuan, pesan.Subjek, pesan.Isi)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
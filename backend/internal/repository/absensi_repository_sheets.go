@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"daarulilmi-presence/internal/clock"
 	"daarulilmi-presence/internal/domain" // Pastikan nama modul sudah benar
 	"daarulilmi-presence/internal/usecase"
 
@@ -18,27 +19,34 @@ import (
 type absensiRepository struct {
 	db            *sheets.Service
 	spreadsheetId string
+	// Menentukan "hari ini" dan timestamp baru di zona waktu sekolah
+	clock clock.Clock
+	// Judul kolom kelas di sheet PengajuanIzin, dicocokkan persis (tanpa beda huruf besar/kecil)
+	kolomKelasIzin string
 }
 
-func NewAbsensiRepository(db *sheets.Service, spreadsheetId string) usecase.AbsensiRepository {
-	return &absensiRepository{db, spreadsheetId}
+// KolomKelasIzinBawaan adalah judul kolom kelas di Google Form izin jika tidak diatur
+const KolomKelasIzinBawaan = "Kelas"
+
+// NewAbsensiRepository membuat repository absensi. kolomKelasIzin kosong berarti KolomKelasIzinBawaan.
+func NewAbsensiRepository(db *sheets.Service, spreadsheetId string, clk clock.Clock, kolomKelasIzin string) usecase.AbsensiRepository {
+	if strings.TrimSpace(kolomKelasIzin) == "" {
+		kolomKelasIzin = KolomKelasIzinBawaan
+	}
+	return &absensiRepository{db, spreadsheetId, clk, kolomKelasIzin}
 }
 
-func parseFlexibleDate(dateStr string) (time.Time, error) {
-	layouts := []string{
-		"2006-01-02", // YYYY-MM-DD
-		"1/2/2006",   // M/D/YYYY (Format umum GForm)
-		"01/02/2006", // MM/DD/YYYY
-		"2/1/2006",   // D/M/YYYY
-		"02/01/2006", // DD/MM/YYYY
-	}
-	for _, layout := range layouts {
-		t, err := time.Parse(layout, dateStr)
-		if err == nil {
-			return t, nil
+// cariKolom mengembalikan indeks kolom yang judulnya sama persis dengan nama (tanpa beda huruf
+// besar/kecil dan spasi di tepi), atau -1. Judul yang hanya mengandung nama, misal "Wali kelas",
+// tidak dianggap cocok.
+func cariKolom(header []interface{}, nama string) int {
+	nama = strings.TrimSpace(nama)
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(getStringFromCell(h)), nama) {
+			return i
 		}
 	}
-	return time.Time{}, fmt.Errorf("tidak dapat mem-parsing tanggal: %s", dateStr)
+	return -1
 }
 
 func (r *absensiRepository) GetAttendanceByDate(ctx context.Context, date string) ([]domain.LogAbsensi, error) {
@@ -66,7 +74,7 @@ func (r *absensiRepository) GetAttendanceByDate(ctx context.Context, date string
 // --- FUNGSI INI DIPERBAIKI (untuk menulis data baru dengan benar) ---
 func (r *absensiRepository) RecordAttendance(ctx context.Context, username, status string) error {
 	writeRange := "LogAbsensi"
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	timestamp := clock.Timestamp(r.clock)
 
 	var values [][]interface{}
 	// Sesuaikan urutan agar cocok dengan sheet: A(kosong), B(Timestamp), C(NIS), D(NamaSiswa-kosong), E(Status)
@@ -85,29 +93,12 @@ func (r *absensiRepository) RecordAttendance(ctx context.Context, username, stat
 }
 
 // --- FUNGSI INI DIPERBAIKI (untuk membaca data dengan benar) ---
-func (r *absensiRepository) GetAttendanceForUser(ctx context.Context, username string) ([]domain.LogAbsensi, error) {
-	// 1. Dapatkan NISN Siswa (logika ini tetap sama)
-	userRange := "DataPengguna!A2:D"
-	userResp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, userRange).Do()
-	if err != nil {
-		return nil, err
-	}
-
-	var siswaNISN string
-	for _, row := range userResp.Values {
-		if len(row) > 3 && row[0].(string) == username {
-			siswaNISN = row[3].(string)
-			break
-		}
-	}
-	if siswaNISN == "" {
-		siswaNISN = username
-	}
-
+// GetAttendanceByNISN mengambil riwayat scan dan pengajuan izin satu siswa
+func (r *absensiRepository) GetAttendanceByNISN(ctx context.Context, siswaNISN string) ([]domain.LogAbsensi, error) {
 	// --- INI BAGIAN BARUNYA ---
 	var results []domain.LogAbsensi
 
-	// 2. Baca dari LogAbsensi
+	// 1. Baca dari LogAbsensi
 	logRange := "LogAbsensi!A2:E"
 	logResp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, logRange).Do()
 	if err != nil {
@@ -124,7 +115,7 @@ func (r *absensiRepository) GetAttendanceForUser(ctx context.Context, username s
 		}
 	}
 
-	// 3. Baca dari PengajuanIzin (dari Google Form)
+	// 2. Baca dari PengajuanIzin (dari Google Form)
 	// Asumsi: Kolom C adalah NISN, Kolom E adalah Jenis Izin, Kolom F adalah Tanggal Mulai
 	izinRange := "PengajuanIzin!A2:F"
 	izinResp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, izinRange).Do()
@@ -175,6 +166,13 @@ func (r *absensiRepository) GetAllLeaveRequests(ctx context.Context) ([]domain.P
 	}
 
 	var requests []domain.PengajuanIzinLengkap
+	if len(izinResp.Values) == 0 {
+		return requests, nil
+	}
+
+	// Cari kolom kelas dari header (jika form menanyakannya) sebagai petunjuk pencocokan nama
+	kelasCol := cariKolom(izinResp.Values[0], r.kolomKelasIzin)
+
 	// Lewati baris pertama (header) dengan memulai loop dari indeks 1
 	for _, row := range izinResp.Values[1:] {
 		// Pastikan baris memiliki cukup kolom untuk dibaca
@@ -194,19 +192,27 @@ func (r *absensiRepository) GetAllLeaveRequests(ctx context.Context) ([]domain.P
 			status = row[31].(string) // Kolom AF (indeks 31)
 		}
 
-		// Cocokkan nama siswa dengan NISN dari kamus yang kita buat
+		// Cocokkan nama siswa dengan NISN dari kamus yang kita buat.
+		// Nama yang tidak cocok persis akan dicocokkan ulang oleh usecase rekonsiliasi.
 		nisn, found := namaToNisnMap[namaSiswa]
 		if !found {
-			nisn = "N/A - Nama tidak ditemukan di DataSiswa" // Penanda jika nama tidak cocok
+			nisn = domain.NISNTidakDitemukan // Penanda jika nama tidak cocok
+		}
+
+		kelasInput := ""
+		if kelasCol >= 0 {
+			kelasInput = getStringFromCellByIndex(row, kelasCol)
 		}
 
 		requests = append(requests, domain.PengajuanIzinLengkap{
 			Timestamp:      timestamp,
 			SiswaNISN:      nisn,
+			NamaLengkap:    namaSiswa,
 			JenisIzin:      jenisPengaduan,
 			TanggalMulai:   tglMulai,
 			TanggalSelesai: tglMulai, // Asumsi izin hanya 1 hari, karena form hanya ada 1 kolom tanggal
 			Status:         status,
+			KelasInput:     kelasInput,
 		})
 	}
 
@@ -244,7 +250,7 @@ func (r *absensiRepository) GetAttendanceByRow(ctx context.Context, rowNumber in
 
 // --- FUNGSI BARU UNTUK MENGAMBIL ABSENSI & IZIN HARI INI ---
 func (r *absensiRepository) GetTodaysAttendanceAndLeave(ctx context.Context) ([]domain.LogAbsensi, []domain.PengajuanIzinLengkap, error) {
-	today := time.Now().Format("2006-01-02")
+	today := clock.Today(r.clock)
 	var todaysAttendance []domain.LogAbsensi
 	var todaysLeave []domain.PengajuanIzinLengkap
 
@@ -283,7 +289,7 @@ func (r *absensiRepository) GetTodaysAttendanceAndLeave(ctx context.Context) ([]
 }
 
 func (r *absensiRepository) GetTodaysAttendance(ctx context.Context) ([]domain.LogAbsensi, error) {
-	today := time.Now().Format("2006-01-02")
+	today := clock.Today(r.clock)
 	var hadirList []domain.LogAbsensi
 
 	readRange := "LogAbsensi!A2:E"
@@ -309,7 +315,7 @@ func (r *absensiRepository) GetTodaysAttendance(ctx context.Context) ([]domain.L
 }
 
 func (r *absensiRepository) GetTodaysLeave(ctx context.Context) ([]domain.PengajuanIzinLengkap, error) {
-	today := time.Now().Format("2006-01-02")
+	today := clock.Today(r.clock)
 	var izinList []domain.PengajuanIzinLengkap
 
 	readRange := "PengajuanIzin!A2:J"
@@ -455,22 +461,6 @@ func (r *absensiRepository) GetLeaveByDate(ctx context.Context, date string) ([]
 	return izinList, nil
 }
 
-func (r *absensiRepository) GetHolidays(ctx context.Context) (map[string]bool, error) {
-	holidayMap := make(map[string]bool)
-	readRange := "TanggalLibur!A2:A"
-	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, readRange).Do()
-	if err != nil {
-		if strings.Contains(err.Error(), "Unable to parse range") {
-			return holidayMap, nil
-		}
-		return nil, err
-	}
-	for _, row := range resp.Values {
-		holidayMap[getStringFromCellByIndex(row, 0)] = true
-	}
-	return holidayMap, nil
-}
-
 func (r *absensiRepository) GetAllLogsInMonth(ctx context.Context, year, month int) ([]domain.LogAbsensi, error) {
 	var allLogs []domain.LogAbsensi
 	monthPrefix := fmt.Sprintf("%d-%02d", year, month) // Format: YYYY-MM
@@ -495,99 +485,80 @@ func (r *absensiRepository) GetAllLogsInMonth(ctx context.Context, year, month i
 	return allLogs, nil
 }
 
-// file: backend/internal/repository/absensi_repository_sheets.go
+// GetLogsByDateRange mengambil semua log absensi (hadir, manual, dan Alpa dari finalisasi)
+// dari tanggal mulai sampai tanggal selesai
+func (r *absensiRepository) GetLogsByDateRange(ctx context.Context, startDate, endDate string) ([]domain.LogAbsensi, error) {
+	var logs []domain.LogAbsensi
 
-func (r *absensiRepository) GetLogsByDateRange(ctx context.Context, startDate, endDate string) ([]domain.LogAbsensi, []domain.PengajuanIzinLengkap, error) {
-	var hadirLogs []domain.LogAbsensi
-	var izinLogs []domain.PengajuanIzinLengkap
-
-	start, err := time.Parse("2006-01-02", startDate)
+	start, err := clock.ParseTanggal(r.clock, startDate)
 	if err != nil {
-		return nil, nil, fmt.Errorf("format tanggal mulai salah: %v", err)
+		return nil, fmt.Errorf("format tanggal mulai salah: %v", err)
 	}
-	end, err := time.Parse("2006-01-02", endDate)
+	end, err := clock.ParseTanggal(r.clock, endDate)
 	if err != nil {
-		return nil, nil, fmt.Errorf("format tanggal selesai salah: %v", err)
+		return nil, fmt.Errorf("format tanggal selesai salah: %v", err)
 	}
-	end = end.Add(23*time.Hour + 59*time.Minute)
+	// Sampai detik terakhir tanggal selesai (AddDate aman untuk hari yang panjangnya bukan 24 jam)
+	end = end.AddDate(0, 0, 1).Add(-time.Second)
 
-	// 2. Baca LogAbsensi
-	logRange := "LogAbsensi!A2:E"
-	logResp, _ := r.db.Spreadsheets.Values.Get(r.spreadsheetId, logRange).Do()
-	if logResp != nil {
-		for i, row := range logResp.Values {
-			timestampStr := getStringFromCellByIndex(row, 1) // Kolom B
-			// Coba parsing timestamp dari format "YYYY-MM-DD HH:MM:SS"
-			checkTime, err := time.Parse("2006-01-02 15:04:05", timestampStr)
-			if err != nil {
-				continue
-			} // Lewati jika format salah
-
-			// Lakukan perbandingan waktu yang benar
-			if !checkTime.Before(start) && !checkTime.After(end) {
-				hadirLogs = append(hadirLogs, domain.LogAbsensi{
-					RowNumber:   i + 2,
-					Timestamp:   timestampStr,
-					Username:    getStringFromCellByIndex(row, 2),
-					NamaLengkap: "", // Akan diisi oleh usecase
-					Status:      getStringFromCellByIndex(row, 4),
-				})
-			}
-		}
+	// Kolom: B=Timestamp, C=NISN, E=Status, H=DicatatOleh, I=TimestampPulang
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "LogAbsensi!A2:I").Do()
+	if err != nil {
+		return nil, err
 	}
-
-	// 3. Baca PengajuanIzin
-	izinRange := "PengajuanIzin!A2:J"
-	izinResp, _ := r.db.Spreadsheets.Values.Get(r.spreadsheetId, izinRange).Do()
-	if izinResp != nil {
-		for i, row := range izinResp.Values {
-			tglMulaiStr := getStringFromCellByIndex(row, 5) // Kolom F
-			checkTime, err := parseFlexibleDate(tglMulaiStr)
-			if err != nil {
-				continue
-			}
-
-			if !checkTime.Before(start) && !checkTime.After(end) {
-				izinLogs = append(izinLogs, domain.PengajuanIzinLengkap{
-					RowNumber:      i + 2,
-					Timestamp:      getStringFromCellByIndex(row, 1),
-					SiswaNISN:      getStringFromCellByIndex(row, 2),
-					NamaLengkap:    "", // Akan diisi oleh usecase
-					JenisIzin:      getStringFromCellByIndex(row, 4),
-					TanggalMulai:   tglMulaiStr,
-					TanggalSelesai: getStringFromCellByIndex(row, 6),
-					Status:         getStringFromCellByIndex(row, 9),
-				})
-			}
+	for i, row := range resp.Values {
+		timestampStr := getStringFromCellByIndex(row, 1)
+		checkTime, err := clock.ParseWaktu(r.clock, timestampStr)
+		if err != nil {
+			continue // Lewati jika format salah
+		}
+		if checkTime.Before(start) || checkTime.After(end) {
+			continue
 		}
+		logs = append(logs, domain.LogAbsensi{
+			RowNumber:       i + 2,
+			Timestamp:       timestampStr,
+			Username:        getStringFromCellByIndex(row, 2),
+			NamaLengkap:     "", // Akan diisi oleh usecase
+			Status:          getStringFromCellByIndex(row, 4),
+			DicatatOleh:     getStringFromCellByIndex(row, 7),
+			TimestampPulang: getStringFromCellByIndex(row, 8),
+		})
 	}
-
-	return hadirLogs, izinLogs, nil
+	return logs, nil
 }
 
 func (r *absensiRepository) FindTodaysAttendanceLog(ctx context.Context, nisn string) (*domain.LogAbsensi, error) {
-	today := time.Now().Format("2006-01-02")
 	readRange := "LogAbsensi!A2:I" // Baca sampai TimestampPulang
 	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, readRange).Do()
 	if err != nil {
 		return nil, err
 	}
+	return cariLogHariIni(resp.Values, nisn, clock.Today(r.clock)), nil // nil jika tidak ditemukan, bukan error
+}
 
-	for i, row := range resp.Values {
+// cariLogHariIni mengembalikan log absensi pertama siswa pada tanggal hari ini, lengkap dengan
+// status dan pencatatnya agar event pulang membawa data yang sama dengan event masuk
+func cariLogHariIni(rows [][]interface{}, nisn, today string) *domain.LogAbsensi {
+	for i, row := range rows {
 		rowNISN := getStringFromCellByIndex(row, 2)      // Kolom C
 		rowTimestamp := getStringFromCellByIndex(row, 1) // Kolom B
 		if rowNISN == nisn && strings.HasPrefix(rowTimestamp, today) {
 			return &domain.LogAbsensi{
 				RowNumber:       i + 2,
-				TimestampPulang: getStringFromCellByIndex(row, 7), // Kolom H
-			}, nil
+				Timestamp:       rowTimestamp,
+				Username:        rowNISN,
+				Status:          getStringFromCellByIndex(row, 4), // Kolom E
+				DicatatOleh:     getStringFromCellByIndex(row, 7), // Kolom H
+				TimestampPulang: getStringFromCellByIndex(row, 8), // Kolom I
+			}
 		}
 	}
-	return nil, nil // Tidak ditemukan, bukan error
+	return nil
 }
 
 func (r *absensiRepository) UpdateClockOut(ctx context.Context, rowNumber int, clockOutTime string) error {
-	// Update kolom H (TimestampPulang) dan I (KeteranganPulang)
+	// Update kolom I (TimestampPulang) dan J (KeteranganPulang)
 	updateRange := fmt.Sprintf("LogAbsensi!I%d:J%d", rowNumber, rowNumber)
 	var values [][]interface{}
 	row := []interface{}{clockOutTime, "Scan QR Pulang"}
@@ -597,51 +568,3 @@ func (r *absensiRepository) UpdateClockOut(ctx context.Context, rowNumber int, c
 	_, err := r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
 	return err
 }
-
-func (r *absensiRepository) GetAllLogsForUserInMonth(ctx context.Context, nisn string, year, month int) ([]domain.LogAbsensi, []domain.PengajuanIzinLengkap, error) {
-	monthPrefix := fmt.Sprintf("%d-%02d", year, month)
-	var hadirLogs []domain.LogAbsensi
-	var izinLogs []domain.PengajuanIzinLengkap
-
-	// Baca LogAbsensi
-	logRange := "LogAbsensi!A2:K"
-	logResp, _ := r.db.Spreadsheets.Values.Get(r.spreadsheetId, logRange).Do()
-	if logResp != nil {
-		for i, row := range logResp.Values {
-			rowNISN := getStringFromCellByIndex(row, 2)
-			timestamp := getStringFromCellByIndex(row, 1)
-			if rowNISN == nisn && strings.HasPrefix(timestamp, monthPrefix) {
-				hadirLogs = append(hadirLogs, domain.LogAbsensi{
-					RowNumber:       i + 2,
-					Timestamp:       timestamp,
-					Username:        rowNISN,
-					Status:          getStringFromCellByIndex(row, 4),
-					TimestampPulang: getStringFromCellByIndex(row, 8),
-				})
-			}
-		}
-	}
-
-	// Baca PengajuanIzin
-	izinRange := "PengajuanIzin!A2:K"
-	izinResp, _ := r.db.Spreadsheets.Values.Get(r.spreadsheetId, izinRange).Do()
-	if izinResp != nil {
-		for i, row := range izinResp.Values {
-			rowNISN := getStringFromCellByIndex(row, 2)
-			tglMulai := getStringFromCellByIndex(row, 5)
-			if rowNISN == nisn && strings.HasPrefix(tglMulai, monthPrefix) {
-				izinLogs = append(izinLogs, domain.PengajuanIzinLengkap{
-					RowNumber:      i + 2,
-					Timestamp:      getStringFromCellByIndex(row, 1),
-					SiswaNISN:      rowNISN,
-					JenisIzin:      getStringFromCellByIndex(row, 4),
-					TanggalMulai:   tglMulai,
-					TanggalSelesai: getStringFromCellByIndex(row, 6),
-					Status:         getStringFromCellByIndex(row, 9),
-				})
-			}
-		}
-	}
-
-	return hadirLogs, izinLogs, nil
-}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"testing"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+func TestCariKolom(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []interface{}
+		nama   string
+		want   int
+	}{
+		{"persis", []interface{}{"Timestamp", "Nama Siswa/i", "Kelas"}, "Kelas", 2},
+		{"beda huruf dan spasi", []interface{}{"Timestamp", " kelas "}, "Kelas", 1},
+		{"wali kelas tidak dianggap kolom kelas", []interface{}{"Timestamp", "Wali kelas", "Kelas"}, "Kelas", 2},
+		{"hanya mengandung kata kelas", []interface{}{"Keterangan kelas", "Wali kelas"}, "Kelas", -1},
+		{"nama kolom diatur", []interface{}{"Kelas", "Kelas Siswa/i"}, "Kelas Siswa/i", 1},
+		{"header kosong", nil, "Kelas", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cariKolom(tt.header, tt.nama); got != tt.want {
+				t.Errorf("cariKolom(%v, %q) = %d, ingin %d", tt.header, tt.nama, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCariLogHariIni(t *testing.T) {
+	// Kolom LogAbsensi: A=LogID, B=Timestamp, C=NISN, D=NamaSiswa, E=Status, F=Keterangan,
+	// G=URLBuktiFoto, H=DicatatOleh, I=TimestampPulang, J=KeteranganPulang
+	rows := [][]interface{}{
+		{"LOG-1", "2026-03-09 07:01:00", "0012345678", "Ahmad", "Hadir", "", "", "Sistem QR", "14:05:00", "Scan QR Pulang"},
+		{"LOG-2", "2026-03-10 07:02:00", "0012345679", "Budi", "Hadir", "", "", "Sistem QR", "14:10:00", "Scan QR Pulang"},
+		{"LOG-3", "2026-03-10 07:03:00", "0012345678", "Ahmad", "Terlambat", "", "", "Sistem QR"},
+	}
+
+	tests := []struct {
+		name string
+		nisn string
+		want domain.LogAbsensi
+	}{
+		// DicatatOleh di kolom H selalu terisi dan tidak boleh dibaca sebagai jam pulang
+		{"belum pulang", "0012345678", domain.LogAbsensi{
+			RowNumber: 4, Timestamp: "2026-03-10 07:03:00", Username: "0012345678", Status: "Terlambat", DicatatOleh: "Sistem QR",
+		}},
+		{"sudah pulang", "0012345679", domain.LogAbsensi{
+			RowNumber: 3, Timestamp: "2026-03-10 07:02:00", Username: "0012345679", Status: "Hadir", DicatatOleh: "Sistem QR",
+			TimestampPulang: "14:10:00",
+		}},
+		{"belum masuk", "0012345680", domain.LogAbsensi{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cariLogHariIni(rows, tt.nisn, "2026-03-10")
+			if tt.want.RowNumber == 0 {
+				if got != nil {
+					t.Fatalf("log = %+v, ingin nil", got)
+				}
+				return
+			}
+			if got == nil || *got != tt.want {
+				t.Errorf("log = %+v, ingin %+v", got, tt.want)
+			}
+		})
+	}
+}
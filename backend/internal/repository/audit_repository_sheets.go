@@ -0,0 +1,38 @@
+// file: internal/repository/audit_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"log"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet AuditLog: A=Timestamp, B=Event, C=Username, D=IP, E=Detail
+type auditRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewAuditRepository(db *sheets.Service, spreadsheetId string) usecase.AuditRepository {
+	return &auditRepository{db, spreadsheetId}
+}
+
+func (r *auditRepository) Save(ctx context.Context, entry *domain.AuditLog) error {
+	row := []interface{}{
+		entry.Timestamp.Format("2006-01-02 15:04:05"),
+		entry.Event,
+		entry.Username,
+		entry.IPAddress,
+		entry.Detail,
+	}
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{row}}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "AuditLog", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan audit log ke sheet: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+// file: internal/repository/digest_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet RiwayatDigest (satu baris per pengiriman):
+// A=Tanggal, B=DikirimPada (RFC3339), C=DikirimOleh, D=JumlahKelas, E=TotalBelumAdaKabar,
+// F=EmailTerkirim, G=EmailGagal, H=PesanOrtu, I=KlaimID (hanya pengiriman terjadwal)
+//
+// Pengiriman terjadwal menambah barisnya lebih dulu sebagai klaim. Seperti klaim token reset,
+// urutan append menentukan pemenang: baris paling atas untuk tanggal itu.
+type digestRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewDigestRepository(db *sheets.Service, spreadsheetId string) usecase.DigestRepository {
+	return &digestRepository{db, spreadsheetId}
+}
+
+func (r *digestRepository) FindAll(ctx context.Context) ([]domain.RiwayatDigest, error) {
+	hasil := []domain.RiwayatDigest{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "RiwayatDigest!A2:H").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return hasil, nil
+		}
+		return nil, err
+	}
+
+	angka := func(row []interface{}, i int) int {
+		n, _ := strconv.Atoi(getStringFromCellByIndex(row, i))
+		return n
+	}
+	for i, row := range resp.Values {
+		tanggal := getStringFromCellByIndex(row, 0)
+		if tanggal == "" {
+			continue
+		}
+		hasil = append(hasil, domain.RiwayatDigest{
+			RowNumber:          i + 2,
+			Tanggal:            tanggal,
+			DikirimPada:        parseWaktu(getStringFromCellByIndex(row, 1)),
+			DikirimOleh:        getStringFromCellByIndex(row, 2),
+			JumlahKelas:        angka(row, 3),
+			TotalBelumAdaKabar: angka(row, 4),
+			EmailTerkirim:      angka(row, 5),
+			EmailGagal:         angka(row, 6),
+			PesanOrtu:          angka(row, 7),
+		})
+	}
+	return hasil, nil
+}
+
+func riwayatDigestKeBaris(d *domain.RiwayatDigest) []interface{} {
+	return []interface{}{
+		d.Tanggal, formatWaktu(d.DikirimPada), d.DikirimOleh, d.JumlahKelas, d.TotalBelumAdaKabar,
+		d.EmailTerkirim, d.EmailGagal, d.PesanOrtu,
+	}
+}
+
+func (r *digestRepository) Save(ctx context.Context, d *domain.RiwayatDigest) error {
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{riwayatDigestKeBaris(d)}}
+	if d.RowNumber >= 2 {
+		updateRange := fmt.Sprintf("RiwayatDigest!A%d:H%d", d.RowNumber, d.RowNumber)
+		_, err := r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
+		return err
+	}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "RiwayatDigest", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan riwayat ringkasan pagi ke sheet: %v", err)
+	}
+	return err
+}
+
+func (r *digestRepository) Klaim(ctx context.Context, d *domain.RiwayatDigest, klaimID string) (bool, error) {
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{append(riwayatDigestKeBaris(d), klaimID)}}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "RiwayatDigest", valueRange).
+		ValueInputOption("RAW").InsertDataOption("INSERT_ROWS").Do()
+	if err != nil {
+		return false, err
+	}
+
+	// Baca ulang setelah append: baris paling atas untuk tanggal ini yang menang, termasuk
+	// baris pengiriman manual admin
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "RiwayatDigest!A2:I").Do()
+	if err != nil {
+		return false, err
+	}
+	pertama, milikSendiri := 0, 0
+	for i, row := range resp.Values {
+		if getStringFromCellByIndex(row, 0) != d.Tanggal {
+			continue
+		}
+		if pertama == 0 {
+			pertama = i + 2
+		}
+		if getStringFromCellByIndex(row, 8) == klaimID {
+			milikSendiri = i + 2
+		}
+	}
+	if milikSendiri == 0 {
+		return false, fmt.Errorf("klaim ringkasan pagi tidak ditemukan setelah disimpan")
+	}
+	if pertama == milikSendiri {
+		d.RowNumber = milikSendiri
+		return true, nil
+	}
+	// Kalah: hapus baris sendiri agar riwayat tidak mencatat pengiriman yang tidak terjadi
+	if err := r.Delete(ctx, milikSendiri); err != nil {
+		log.Printf("WARNING: Gagal menghapus klaim ringkasan pagi yang kalah: %v", err)
+	}
+	return false, nil
+}
+
+// Delete mengosongkan satu baris riwayat
+func (r *digestRepository) Delete(ctx context.Context, rowNumber int) error {
+	clearRange := fmt.Sprintf("RiwayatDigest!A%d:I%d", rowNumber, rowNumber)
+	_, err := r.db.Spreadsheets.Values.Clear(r.spreadsheetId, clearRange, &sheets.ClearValuesRequest{}).Do()
+	return err
+}
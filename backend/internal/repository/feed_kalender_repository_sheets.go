@@ -0,0 +1,73 @@
+// file: internal/repository/feed_kalender_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet TokenFeedKalender:
+// A=Username, B=TokenHash, C=DibuatPada, D=DicabutPada (RFC3339)
+type feedKalenderRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewFeedKalenderRepository(db *sheets.Service, spreadsheetId string) usecase.FeedKalenderRepository {
+	return &feedKalenderRepository{db, spreadsheetId}
+}
+
+func (r *feedKalenderRepository) FindAll(ctx context.Context) ([]domain.TokenFeedKalender, error) {
+	tokens := []domain.TokenFeedKalender{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "TokenFeedKalender!A2:D").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return tokens, nil
+		}
+		return nil, err
+	}
+
+	for i, row := range resp.Values {
+		hash := getStringFromCellByIndex(row, 1)
+		if hash == "" {
+			continue
+		}
+		tokens = append(tokens, domain.TokenFeedKalender{
+			RowNumber:   i + 2,
+			Username:    getStringFromCellByIndex(row, 0),
+			TokenHash:   hash,
+			DibuatPada:  parseWaktu(getStringFromCellByIndex(row, 2)),
+			DicabutPada: parseWaktu(getStringFromCellByIndex(row, 3)),
+		})
+	}
+	return tokens, nil
+}
+
+func (r *feedKalenderRepository) Save(ctx context.Context, t *domain.TokenFeedKalender) error {
+	row := []interface{}{t.Username, t.TokenHash, formatWaktu(t.DibuatPada), formatWaktu(t.DicabutPada)}
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{row}}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "TokenFeedKalender", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan token feed kalender ke sheet: %v", err)
+	}
+	return err
+}
+
+func (r *feedKalenderRepository) Revoke(ctx context.Context, rowNumber int, at time.Time) error {
+	if rowNumber < 2 {
+		return errors.New("nomor baris token feed kalender tidak valid")
+	}
+	updateRange := fmt.Sprintf("TokenFeedKalender!D%d", rowNumber)
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{{formatWaktu(at)}}}
+	_, err := r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
+	return err
+}
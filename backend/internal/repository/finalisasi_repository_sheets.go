@@ -0,0 +1,120 @@
+// file: internal/repository/finalisasi_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet FinalisasiAlpa (satu baris per kali finalisasi dijalankan):
+// A=Tanggal (YYYY-MM-DD), B=JumlahAlpa, C=DijalankanPada (RFC3339), D=Oleh
+//
+// Kolom sheet KunciFinalisasi: A=Pemilik, B=DiambilPada, C=KedaluwarsaPada (RFC3339).
+// Seperti klaim token reset, urutan append menentukan pemenang: kunci aktif yang barisnya
+// paling atas memegang finalisasi, dan semua proses melihat urutan yang sama.
+type finalisasiRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewFinalisasiRepository(db *sheets.Service, spreadsheetId string) usecase.FinalisasiRepository {
+	return &finalisasiRepository{db, spreadsheetId}
+}
+
+func (r *finalisasiRepository) FindAll(ctx context.Context) ([]domain.FinalisasiAlpa, error) {
+	hasil := []domain.FinalisasiAlpa{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "FinalisasiAlpa!A2:D").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return hasil, nil
+		}
+		return nil, err
+	}
+
+	for _, row := range resp.Values {
+		tanggal := getStringFromCellByIndex(row, 0)
+		if tanggal == "" {
+			continue
+		}
+		jumlah, _ := strconv.Atoi(getStringFromCellByIndex(row, 1))
+		hasil = append(hasil, domain.FinalisasiAlpa{
+			Tanggal:        tanggal,
+			JumlahAlpa:     jumlah,
+			DijalankanPada: parseWaktu(getStringFromCellByIndex(row, 2)),
+			Oleh:           getStringFromCellByIndex(row, 3),
+		})
+	}
+	return hasil, nil
+}
+
+// SaveAll mencatat hasil finalisasi beberapa tanggal sekaligus dalam satu append
+func (r *finalisasiRepository) SaveAll(ctx context.Context, list []domain.FinalisasiAlpa) error {
+	if len(list) == 0 {
+		return nil
+	}
+	var values [][]interface{}
+	for _, f := range list {
+		values = append(values, []interface{}{f.Tanggal, f.JumlahAlpa, formatWaktu(f.DijalankanPada), f.Oleh})
+	}
+	valueRange := &sheets.ValueRange{Values: values}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "FinalisasiAlpa", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan catatan finalisasi Alpa ke sheet: %v", err)
+	}
+	return err
+}
+
+func (r *finalisasiRepository) Kunci(ctx context.Context, pemilik string, at time.Time, ttl time.Duration) (bool, error) {
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{{pemilik, formatWaktu(at), formatWaktu(at.Add(ttl))}}}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "KunciFinalisasi", valueRange).
+		ValueInputOption("RAW").InsertDataOption("INSERT_ROWS").Do()
+	if err != nil {
+		return false, err
+	}
+
+	// Baca ulang setelah append: kunci yang belum kedaluwarsa dan paling atas yang menang
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "KunciFinalisasi!A2:C").Do()
+	if err != nil {
+		return false, err
+	}
+	for _, row := range resp.Values {
+		p := getStringFromCellByIndex(row, 0)
+		if p == "" || !at.Before(parseWaktu(getStringFromCellByIndex(row, 2))) {
+			continue // Baris yang sudah dilepas atau kunci yang pemiliknya mati
+		}
+		if p == pemilik {
+			return true, nil
+		}
+		// Kalah: hapus baris sendiri agar tidak menghalangi proses berikutnya
+		if err := r.Lepas(ctx, pemilik); err != nil {
+			log.Printf("WARNING: Gagal menghapus kunci finalisasi yang kalah: %v", err)
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("kunci finalisasi tidak ditemukan setelah disimpan")
+}
+
+// Lepas mengosongkan baris kunci milik pemilik
+func (r *finalisasiRepository) Lepas(ctx context.Context, pemilik string) error {
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "KunciFinalisasi!A2:A").Do()
+	if err != nil {
+		return err
+	}
+	for i, row := range resp.Values {
+		if getStringFromCellByIndex(row, 0) == pemilik {
+			clearRange := fmt.Sprintf("KunciFinalisasi!A%d:C%d", i+2, i+2)
+			_, err := r.db.Spreadsheets.Values.Clear(r.spreadsheetId, clearRange, &sheets.ClearValuesRequest{}).Do()
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// file: internal/repository/kalender_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet KalenderSekolah:
+// A=ID, B=TanggalMulai, C=TanggalSelesai, D=Jenis, E=Keterangan, F=DibuatOleh, G=DibuatPada (RFC3339)
+//
+// Sheet lama TanggalLibur (kolom A berisi tanggal) tetap dibaca sebagai libur satu hari.
+type kalenderRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewKalenderRepository(db *sheets.Service, spreadsheetId string) usecase.KalenderRepository {
+	return &kalenderRepository{db, spreadsheetId}
+}
+
+func kalenderToRow(k *domain.KalenderSekolah) []interface{} {
+	return []interface{}{k.ID, k.TanggalMulai, k.TanggalSelesai, k.Jenis, k.Keterangan, k.DibuatOleh, formatWaktu(k.DibuatPada)}
+}
+
+func (r *kalenderRepository) FindAll(ctx context.Context) ([]domain.KalenderSekolah, error) {
+	entri := []domain.KalenderSekolah{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "KalenderSekolah!A2:G").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return entri, nil
+		}
+		return nil, err
+	}
+
+	for i, row := range resp.Values {
+		id := getStringFromCellByIndex(row, 0)
+		if id == "" {
+			continue // Baris yang sudah dibersihkan
+		}
+		entri = append(entri, domain.KalenderSekolah{
+			RowNumber:      i + 2,
+			ID:             id,
+			TanggalMulai:   getStringFromCellByIndex(row, 1),
+			TanggalSelesai: getStringFromCellByIndex(row, 2),
+			Jenis:          getStringFromCellByIndex(row, 3),
+			Keterangan:     getStringFromCellByIndex(row, 4),
+			DibuatOleh:     getStringFromCellByIndex(row, 5),
+			DibuatPada:     parseWaktu(getStringFromCellByIndex(row, 6)),
+		})
+	}
+	return entri, nil
+}
+
+// FindTanggalLibur membaca sheet lama TanggalLibur (satu tanggal per baris)
+func (r *kalenderRepository) FindTanggalLibur(ctx context.Context) ([]string, error) {
+	tanggal := []string{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "TanggalLibur!A2:A").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return tanggal, nil
+		}
+		return nil, err
+	}
+	for _, row := range resp.Values {
+		if t := strings.TrimSpace(getStringFromCellByIndex(row, 0)); t != "" {
+			tanggal = append(tanggal, t)
+		}
+	}
+	return tanggal, nil
+}
+
+func (r *kalenderRepository) Save(ctx context.Context, k *domain.KalenderSekolah) error {
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{kalenderToRow(k)}}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "KalenderSekolah", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan entri kalender ke sheet: %v", err)
+	}
+	return err
+}
+
+// SaveAll menambahkan banyak entri kalender dalam satu append (dipakai impor)
+func (r *kalenderRepository) SaveAll(ctx context.Context, list []domain.KalenderSekolah) error {
+	if len(list) == 0 {
+		return nil
+	}
+	var values [][]interface{}
+	for i := range list {
+		values = append(values, kalenderToRow(&list[i]))
+	}
+	valueRange := &sheets.ValueRange{Values: values}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "KalenderSekolah", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan %d entri kalender ke sheet: %v", len(list), err)
+	}
+	return err
+}
+
+func (r *kalenderRepository) Update(ctx context.Context, k *domain.KalenderSekolah) error {
+	if k.RowNumber < 2 {
+		return errors.New("nomor baris kalender tidak valid")
+	}
+	updateRange := fmt.Sprintf("KalenderSekolah!A%d:G%d", k.RowNumber, k.RowNumber)
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{kalenderToRow(k)}}
+	_, err := r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
+	return err
+}
+
+func (r *kalenderRepository) Delete(ctx context.Context, rowNumber int) error {
+	if rowNumber < 2 {
+		return errors.New("nomor baris kalender tidak valid")
+	}
+	clearRange := fmt.Sprintf("KalenderSekolah!A%d:G%d", rowNumber, rowNumber)
+	_, err := r.db.Spreadsheets.Values.Clear(r.spreadsheetId, clearRange, &sheets.ClearValuesRequest{}).Do()
+	return err
+}
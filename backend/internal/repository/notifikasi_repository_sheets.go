@@ -0,0 +1,137 @@
+// file: internal/repository/notifikasi_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet PreferensiNotifikasi (satu baris per siswa):
+// A=NISN, B=Aktif, C=Kanal (dipisah koma), D=JamTenangMulai, E=JamTenangSelesai,
+// F=DiubahOleh, G=DiubahPada (RFC3339)
+//
+// Kolom sheet LogNotifikasi (hanya ditambah):
+// A=Waktu, B=NISN, C=Jenis, D=Kanal, E=Tujuan (disamarkan), F=Status, G=Percobaan, H=Error,
+// I=ID, J=BerikutnyaPada, K=Payload
+type notifikasiRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewNotifikasiRepository(db *sheets.Service, spreadsheetId string) usecase.NotifikasiRepository {
+	return &notifikasiRepository{db, spreadsheetId}
+}
+
+func (r *notifikasiRepository) FindPreferensi(ctx context.Context) ([]domain.PreferensiNotifikasi, error) {
+	hasil := []domain.PreferensiNotifikasi{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "PreferensiNotifikasi!A2:G").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return hasil, nil
+		}
+		return nil, err
+	}
+
+	for i, row := range resp.Values {
+		nisn := getStringFromCellByIndex(row, 0)
+		if nisn == "" {
+			continue
+		}
+		kanal := []string{}
+		for _, k := range strings.Split(getStringFromCellByIndex(row, 2), ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				kanal = append(kanal, k)
+			}
+		}
+		hasil = append(hasil, domain.PreferensiNotifikasi{
+			RowNumber:        i + 2,
+			NISN:             nisn,
+			Aktif:            getStringFromCellByIndex(row, 1) == "TRUE",
+			Kanal:            kanal,
+			JamTenangMulai:   getStringFromCellByIndex(row, 3),
+			JamTenangSelesai: getStringFromCellByIndex(row, 4),
+			DiubahOleh:       getStringFromCellByIndex(row, 5),
+			DiubahPada:       parseWaktu(getStringFromCellByIndex(row, 6)),
+		})
+	}
+	return hasil, nil
+}
+
+func (r *notifikasiRepository) SavePreferensi(ctx context.Context, p *domain.PreferensiNotifikasi) error {
+	row := []interface{}{
+		p.NISN, boolCell(p.Aktif), strings.Join(p.Kanal, ","), p.JamTenangMulai, p.JamTenangSelesai,
+		p.DiubahOleh, formatWaktu(p.DiubahPada),
+	}
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{row}}
+	if p.RowNumber >= 2 {
+		updateRange := fmt.Sprintf("PreferensiNotifikasi!A%d:G%d", p.RowNumber, p.RowNumber)
+		_, err := r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
+		return err
+	}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "PreferensiNotifikasi", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan preferensi notifikasi ke sheet: %v", err)
+	}
+	return err
+}
+
+func (r *notifikasiRepository) FindLog(ctx context.Context) ([]domain.LogNotifikasi, error) {
+	hasil := []domain.LogNotifikasi{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "LogNotifikasi!A2:K").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return hasil, nil
+		}
+		return nil, err
+	}
+
+	for _, row := range resp.Values {
+		nisn := getStringFromCellByIndex(row, 1)
+		if nisn == "" {
+			continue
+		}
+		percobaan, _ := strconv.Atoi(getStringFromCellByIndex(row, 6))
+		hasil = append(hasil, domain.LogNotifikasi{
+			Waktu:          parseWaktu(getStringFromCellByIndex(row, 0)),
+			NISN:           nisn,
+			Jenis:          getStringFromCellByIndex(row, 2),
+			Kanal:          getStringFromCellByIndex(row, 3),
+			Tujuan:         getStringFromCellByIndex(row, 4),
+			Status:         getStringFromCellByIndex(row, 5),
+			Percobaan:      percobaan,
+			Error:          getStringFromCellByIndex(row, 7),
+			ID:             getStringFromCellByIndex(row, 8),
+			BerikutnyaPada: parseWaktu(getStringFromCellByIndex(row, 9)),
+			Payload:        getStringFromCellByIndex(row, 10),
+		})
+	}
+	return hasil, nil
+}
+
+// SaveLog menambahkan beberapa baris log sekaligus dalam satu append
+func (r *notifikasiRepository) SaveLog(ctx context.Context, list []domain.LogNotifikasi) error {
+	if len(list) == 0 {
+		return nil
+	}
+	var values [][]interface{}
+	for _, l := range list {
+		values = append(values, []interface{}{
+			formatWaktu(l.Waktu), l.NISN, l.Jenis, l.Kanal, l.Tujuan, l.Status, l.Percobaan, l.Error,
+			l.ID, formatWaktu(l.BerikutnyaPada), l.Payload,
+		})
+	}
+	valueRange := &sheets.ValueRange{Values: values}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "LogNotifikasi", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan %d log notifikasi ke sheet: %v", len(list), err)
+	}
+	return err
+}
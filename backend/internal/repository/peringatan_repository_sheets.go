@@ -0,0 +1,86 @@
+// file: internal/repository/peringatan_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet PeringatanKehadiran (diganti seluruhnya setiap evaluasi):
+// A=ID, B=NISN, C=NamaLengkap, D=Kelas, E=Aturan, F=Tingkat, G=Pesan, H=TanggalMulai,
+// I=TanggalSelesai, J=Jumlah, K=PertamaMuncul (RFC3339), L=DievaluasiPada (RFC3339)
+type peringatanRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewPeringatanRepository(db *sheets.Service, spreadsheetId string) usecase.PeringatanRepository {
+	return &peringatanRepository{db, spreadsheetId}
+}
+
+func (r *peringatanRepository) FindAll(ctx context.Context) ([]domain.PeringatanKehadiran, error) {
+	hasil := []domain.PeringatanKehadiran{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "PeringatanKehadiran!A2:L").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return hasil, nil
+		}
+		return nil, err
+	}
+
+	for _, row := range resp.Values {
+		id := getStringFromCellByIndex(row, 0)
+		if id == "" {
+			continue
+		}
+		jumlah, _ := strconv.Atoi(getStringFromCellByIndex(row, 9))
+		hasil = append(hasil, domain.PeringatanKehadiran{
+			ID:             id,
+			NISN:           getStringFromCellByIndex(row, 1),
+			NamaLengkap:    getStringFromCellByIndex(row, 2),
+			Kelas:          getStringFromCellByIndex(row, 3),
+			Aturan:         getStringFromCellByIndex(row, 4),
+			Tingkat:        getStringFromCellByIndex(row, 5),
+			Pesan:          getStringFromCellByIndex(row, 6),
+			TanggalMulai:   getStringFromCellByIndex(row, 7),
+			TanggalSelesai: getStringFromCellByIndex(row, 8),
+			Jumlah:         jumlah,
+			PertamaMuncul:  parseWaktu(getStringFromCellByIndex(row, 10)),
+			DievaluasiPada: parseWaktu(getStringFromCellByIndex(row, 11)),
+		})
+	}
+	return hasil, nil
+}
+
+// ReplaceAll membersihkan isi sheet lalu menulis daftar baru mulai baris 2
+func (r *peringatanRepository) ReplaceAll(ctx context.Context, list []domain.PeringatanKehadiran) error {
+	_, err := r.db.Spreadsheets.Values.Clear(r.spreadsheetId, "PeringatanKehadiran!A2:L", &sheets.ClearValuesRequest{}).Do()
+	if err != nil {
+		log.Printf("Gagal membersihkan sheet PeringatanKehadiran: %v", err)
+		return err
+	}
+	if len(list) == 0 {
+		return nil
+	}
+
+	var values [][]interface{}
+	for _, p := range list {
+		values = append(values, []interface{}{
+			p.ID, p.NISN, p.NamaLengkap, p.Kelas, p.Aturan, p.Tingkat, p.Pesan,
+			p.TanggalMulai, p.TanggalSelesai, p.Jumlah, formatWaktu(p.PertamaMuncul), formatWaktu(p.DievaluasiPada),
+		})
+	}
+	valueRange := &sheets.ValueRange{Values: values}
+	_, err = r.db.Spreadsheets.Values.Update(r.spreadsheetId, "PeringatanKehadiran!A2", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan %d peringatan kehadiran ke sheet: %v", len(list), err)
+	}
+	return err
+}
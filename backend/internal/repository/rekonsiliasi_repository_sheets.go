@@ -0,0 +1,141 @@
+// file: internal/repository/rekonsiliasi_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+type rekonsiliasiRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+// NewRekonsiliasiRepository menyimpan data di sheet AliasNamaSiswa dan AntrianRekonsiliasi
+func NewRekonsiliasiRepository(db *sheets.Service, spreadsheetId string) usecase.RekonsiliasiRepository {
+	return &rekonsiliasiRepository{db, spreadsheetId}
+}
+
+func (r *rekonsiliasiRepository) GetAliases(ctx context.Context) (map[string]string, error) {
+	aliases := make(map[string]string)
+	readRange := "AliasNamaSiswa!A2:B" // A=NamaNormal, B=NISN
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, readRange).Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return aliases, nil // Sheet belum dibuat, anggap belum ada alias
+		}
+		return nil, err
+	}
+
+	for _, row := range resp.Values {
+		nama := getStringFromCellByIndex(row, 0)
+		nisn := getStringFromCellByIndex(row, 1)
+		if nama != "" && nisn != "" {
+			aliases[nama] = nisn
+		}
+	}
+	return aliases, nil
+}
+
+func (r *rekonsiliasiRepository) SaveAlias(ctx context.Context, alias *domain.AliasNamaSiswa) error {
+	var values [][]interface{}
+	row := []interface{}{alias.NamaNormal, alias.NISN, alias.DicatatOleh, alias.Timestamp}
+	values = append(values, row)
+
+	valueRange := &sheets.ValueRange{Values: values}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "AliasNamaSiswa", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan alias nama ke sheet: %v", err)
+	}
+	return err
+}
+
+func (r *rekonsiliasiRepository) GetQueue(ctx context.Context) ([]domain.RekonsiliasiNama, error) {
+	var queue []domain.RekonsiliasiNama
+	readRange := "AntrianRekonsiliasi!A2:H"
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, readRange).Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return queue, nil
+		}
+		return nil, err
+	}
+
+	for i, row := range resp.Values {
+		id := getStringFromCellByIndex(row, 0)
+		if id == "" {
+			continue
+		}
+		item := domain.RekonsiliasiNama{
+			RowNumber:        i + 2,
+			ID:               id,
+			Timestamp:        getStringFromCellByIndex(row, 1),
+			NamaInput:        getStringFromCellByIndex(row, 2),
+			KelasInput:       getStringFromCellByIndex(row, 3),
+			Status:           getStringFromCellByIndex(row, 5),
+			NISNTerpilih:     getStringFromCellByIndex(row, 6),
+			DiselesaikanOleh: getStringFromCellByIndex(row, 7),
+		}
+		// Kolom E menyimpan daftar kandidat dalam bentuk JSON
+		if raw := getStringFromCellByIndex(row, 4); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &item.Kandidat); err != nil {
+				log.Printf("WARNING: Kandidat rusak di AntrianRekonsiliasi baris %d: %v", i+2, err)
+			}
+		}
+		queue = append(queue, item)
+	}
+	return queue, nil
+}
+
+func rekonsiliasiToRow(item *domain.RekonsiliasiNama) ([]interface{}, error) {
+	kandidat, err := json.Marshal(item.Kandidat)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{
+		item.ID,               // A: ID
+		item.Timestamp,        // B: Timestamp
+		item.NamaInput,        // C: NamaInput
+		item.KelasInput,       // D: KelasInput
+		string(kandidat),      // E: Kandidat (JSON)
+		item.Status,           // F: Status
+		item.NISNTerpilih,     // G: NISNTerpilih
+		item.DiselesaikanOleh, // H: DiselesaikanOleh
+	}, nil
+}
+
+func (r *rekonsiliasiRepository) Enqueue(ctx context.Context, item *domain.RekonsiliasiNama) error {
+	row, err := rekonsiliasiToRow(item)
+	if err != nil {
+		return err
+	}
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{row}}
+	_, err = r.db.Spreadsheets.Values.Append(r.spreadsheetId, "AntrianRekonsiliasi", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan antrian rekonsiliasi ke sheet: %v", err)
+	}
+	return err
+}
+
+func (r *rekonsiliasiRepository) UpdateQueueItem(ctx context.Context, item *domain.RekonsiliasiNama) error {
+	if item.RowNumber < 2 {
+		return errors.New("nomor baris antrian rekonsiliasi tidak valid")
+	}
+	row, err := rekonsiliasiToRow(item)
+	if err != nil {
+		return err
+	}
+	updateRange := fmt.Sprintf("AntrianRekonsiliasi!A%d:H%d", item.RowNumber, item.RowNumber)
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{row}}
+	_, err = r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
+	return err
+}
@@ -0,0 +1,95 @@
+// file: internal/repository/relasi_wali_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet RelasiWaliSiswa:
+// A=Username (akun wali murid), B=SiswaNISN, C=Hubungan, D=DibuatPada (RFC3339)
+type relasiWaliRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewRelasiWaliRepository(db *sheets.Service, spreadsheetId string) usecase.RelasiWaliRepository {
+	return &relasiWaliRepository{db, spreadsheetId}
+}
+
+func (r *relasiWaliRepository) FindAll(ctx context.Context) ([]domain.RelasiWaliSiswa, error) {
+	relasi := []domain.RelasiWaliSiswa{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "RelasiWaliSiswa!A2:D").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return relasi, nil
+		}
+		return nil, err
+	}
+
+	for i, row := range resp.Values {
+		username := getStringFromCellByIndex(row, 0)
+		if username == "" {
+			continue // Baris yang sudah dibersihkan
+		}
+		relasi = append(relasi, domain.RelasiWaliSiswa{
+			RowNumber:  i + 2,
+			Username:   username,
+			SiswaNISN:  getStringFromCellByIndex(row, 1),
+			Hubungan:   getStringFromCellByIndex(row, 2),
+			DibuatPada: parseWaktu(getStringFromCellByIndex(row, 3)),
+		})
+	}
+	return relasi, nil
+}
+
+func (r *relasiWaliRepository) FindByUsername(ctx context.Context, username string) ([]domain.RelasiWaliSiswa, error) {
+	all, err := r.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hasil := []domain.RelasiWaliSiswa{}
+	for _, rel := range all {
+		if rel.Username == username {
+			hasil = append(hasil, rel)
+		}
+	}
+	return hasil, nil
+}
+
+func (r *relasiWaliRepository) Save(ctx context.Context, relasi *domain.RelasiWaliSiswa) error {
+	row := []interface{}{relasi.Username, relasi.SiswaNISN, relasi.Hubungan, formatWaktu(relasi.DibuatPada)}
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{row}}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "RelasiWaliSiswa", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan relasi wali-siswa ke sheet: %v", err)
+	}
+	return err
+}
+
+// Delete membersihkan baris relasi antara username dan NISN tertentu
+func (r *relasiWaliRepository) Delete(ctx context.Context, username, nisn string) error {
+	relasi, err := r.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	var ranges []string
+	for _, rel := range relasi {
+		if rel.SiswaNISN == nisn {
+			ranges = append(ranges, fmt.Sprintf("RelasiWaliSiswa!A%d:D%d", rel.RowNumber, rel.RowNumber))
+		}
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+	_, err = r.db.Spreadsheets.Values.BatchClear(r.spreadsheetId, &sheets.BatchClearValuesRequest{Ranges: ranges}).Do()
+	return err
+}
@@ -0,0 +1,176 @@
+// file: internal/repository/reset_token_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet TokenResetPassword:
+// A=TokenHash, B=Username, C=DibuatPada, D=KedaluwarsaPada, E=DipakaiPada (semua waktu dalam RFC3339)
+//
+// Kolom sheet KlaimTokenReset (hanya ditambah): A=TokenHash, B=ClaimID, C=Waktu.
+// Sheets tidak punya penulisan bersyarat, tetapi append diurutkan oleh Sheets: klaim yang
+// barisnya paling atas untuk satu token adalah pemenangnya, dilihat sama oleh semua instance.
+type resetTokenRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewResetTokenRepository(db *sheets.Service, spreadsheetId string) usecase.ResetTokenRepository {
+	return &resetTokenRepository{db, spreadsheetId}
+}
+
+func formatWaktu(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseWaktu(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func (r *resetTokenRepository) findAll(ctx context.Context) ([]domain.TokenReset, error) {
+	var tokens []domain.TokenReset
+	readRange := "TokenResetPassword!A2:E"
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, readRange).Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return tokens, nil
+		}
+		return nil, err
+	}
+
+	for i, row := range resp.Values {
+		hash := getStringFromCellByIndex(row, 0)
+		if hash == "" {
+			continue // Baris yang sudah dibersihkan
+		}
+		tokens = append(tokens, domain.TokenReset{
+			RowNumber: i + 2,
+			TokenHash: hash,
+			Username:  getStringFromCellByIndex(row, 1),
+			CreatedAt: parseWaktu(getStringFromCellByIndex(row, 2)),
+			ExpiresAt: parseWaktu(getStringFromCellByIndex(row, 3)),
+			UsedAt:    parseWaktu(getStringFromCellByIndex(row, 4)),
+		})
+	}
+	return tokens, nil
+}
+
+func (r *resetTokenRepository) Save(ctx context.Context, token *domain.TokenReset) error {
+	row := []interface{}{token.TokenHash, token.Username, formatWaktu(token.CreatedAt), formatWaktu(token.ExpiresAt), formatWaktu(token.UsedAt)}
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{row}}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "TokenResetPassword", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan token reset ke sheet: %v", err)
+	}
+	return err
+}
+
+func (r *resetTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*domain.TokenReset, error) {
+	tokens, err := r.findAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tokens {
+		if tokens[i].TokenHash == tokenHash {
+			return &tokens[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *resetTokenRepository) MarkUsed(ctx context.Context, rowNumber int, usedAt time.Time) error {
+	updateRange := fmt.Sprintf("TokenResetPassword!E%d", rowNumber)
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{{formatWaktu(usedAt)}}}
+	_, err := r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
+	return err
+}
+
+func (r *resetTokenRepository) Claim(ctx context.Context, tokenHash, claimID string, at time.Time) (bool, error) {
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{{tokenHash, claimID, formatWaktu(at)}}}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "KlaimTokenReset", valueRange).
+		ValueInputOption("RAW").InsertDataOption("INSERT_ROWS").Do()
+	if err != nil {
+		return false, err
+	}
+
+	// Baca ulang setelah append: hanya klaim pertama untuk token ini yang menang
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "KlaimTokenReset!A2:B").Do()
+	if err != nil {
+		return false, err
+	}
+	for _, row := range resp.Values {
+		if getStringFromCellByIndex(row, 0) == tokenHash {
+			return getStringFromCellByIndex(row, 1) == claimID, nil
+		}
+	}
+	return false, fmt.Errorf("klaim token reset tidak ditemukan setelah disimpan")
+}
+
+func (r *resetTokenRepository) CountCreatedSince(ctx context.Context, username string, since time.Time) (int, error) {
+	tokens, err := r.findAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, t := range tokens {
+		if t.Username == username && !t.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *resetTokenRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error) {
+	tokens, err := r.findAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var ranges []string
+	dihapus := make(map[string]bool)
+	for _, t := range tokens {
+		if t.ExpiresAt.Before(before) {
+			ranges = append(ranges, fmt.Sprintf("TokenResetPassword!A%d:E%d", t.RowNumber, t.RowNumber))
+			dihapus[t.TokenHash] = true
+		}
+	}
+	if len(ranges) == 0 {
+		return 0, nil
+	}
+	jumlah := len(ranges)
+
+	// Klaim milik token yang dihapus ikut dibersihkan
+	klaim, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "KlaimTokenReset!A2:A").Do()
+	if err == nil {
+		for i, row := range klaim.Values {
+			if dihapus[getStringFromCellByIndex(row, 0)] {
+				ranges = append(ranges, fmt.Sprintf("KlaimTokenReset!A%d:C%d", i+2, i+2))
+			}
+		}
+	} else if !strings.Contains(err.Error(), "Unable to parse range") {
+		return 0, err
+	}
+
+	_, err = r.db.Spreadsheets.Values.BatchClear(r.spreadsheetId, &sheets.BatchClearValuesRequest{Ranges: ranges}).Do()
+	if err != nil {
+		return 0, err
+	}
+	return jumlah, nil
+}
@@ -0,0 +1,181 @@
+// file: internal/repository/semester_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet Semester:
+// A=ID, B=Nama, C=TanggalMulai, D=TanggalSelesai, E=Status, F=DibuatOleh, G=DibuatPada,
+// H=DikunciOleh, I=DikunciPada, J=DibukaOleh, K=DibukaPada, L=AlasanBuka (waktu dalam RFC3339)
+//
+// Kolom sheet RekapSemester (salinan rekap saat semester dikunci):
+// A=SemesterID, B=NISN, C=NamaLengkap, D=Kelas, E=Hadir, F=Izin, G=Sakit, H=Alpa,
+// I=HariEfektif, J=AmbangKehadiran
+type semesterRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewSemesterRepository(db *sheets.Service, spreadsheetId string) usecase.SemesterRepository {
+	return &semesterRepository{db, spreadsheetId}
+}
+
+func semesterToRow(s *domain.Semester) []interface{} {
+	return []interface{}{
+		s.ID, s.Nama, s.TanggalMulai, s.TanggalSelesai, s.Status, s.DibuatOleh, formatWaktu(s.DibuatPada),
+		s.DikunciOleh, formatWaktu(s.DikunciPada), s.DibukaOleh, formatWaktu(s.DibukaPada), s.AlasanBuka,
+	}
+}
+
+func (r *semesterRepository) FindAll(ctx context.Context) ([]domain.Semester, error) {
+	hasil := []domain.Semester{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "Semester!A2:L").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return hasil, nil
+		}
+		return nil, err
+	}
+
+	for i, row := range resp.Values {
+		id := getStringFromCellByIndex(row, 0)
+		if id == "" {
+			continue // Baris yang sudah dibersihkan
+		}
+		hasil = append(hasil, domain.Semester{
+			RowNumber:      i + 2,
+			ID:             id,
+			Nama:           getStringFromCellByIndex(row, 1),
+			TanggalMulai:   getStringFromCellByIndex(row, 2),
+			TanggalSelesai: getStringFromCellByIndex(row, 3),
+			Status:         getStringFromCellByIndex(row, 4),
+			DibuatOleh:     getStringFromCellByIndex(row, 5),
+			DibuatPada:     parseWaktu(getStringFromCellByIndex(row, 6)),
+			DikunciOleh:    getStringFromCellByIndex(row, 7),
+			DikunciPada:    parseWaktu(getStringFromCellByIndex(row, 8)),
+			DibukaOleh:     getStringFromCellByIndex(row, 9),
+			DibukaPada:     parseWaktu(getStringFromCellByIndex(row, 10)),
+			AlasanBuka:     getStringFromCellByIndex(row, 11),
+		})
+	}
+	return hasil, nil
+}
+
+func (r *semesterRepository) Save(ctx context.Context, s *domain.Semester) error {
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{semesterToRow(s)}}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "Semester", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan semester ke sheet: %v", err)
+	}
+	return err
+}
+
+func (r *semesterRepository) Update(ctx context.Context, s *domain.Semester) error {
+	if s.RowNumber < 2 {
+		return errors.New("nomor baris semester tidak valid")
+	}
+	updateRange := fmt.Sprintf("Semester!A%d:L%d", s.RowNumber, s.RowNumber)
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{semesterToRow(s)}}
+	_, err := r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
+	return err
+}
+
+func (r *semesterRepository) Delete(ctx context.Context, rowNumber int) error {
+	if rowNumber < 2 {
+		return errors.New("nomor baris semester tidak valid")
+	}
+	clearRange := fmt.Sprintf("Semester!A%d:L%d", rowNumber, rowNumber)
+	_, err := r.db.Spreadsheets.Values.Clear(r.spreadsheetId, clearRange, &sheets.ClearValuesRequest{}).Do()
+	return err
+}
+
+// rekapSemester membaca semua baris RekapSemester beserta nomor barisnya
+func (r *semesterRepository) rekapSemester() ([][]interface{}, error) {
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "RekapSemester!A2:J").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return resp.Values, nil
+}
+
+func (r *semesterRepository) FindRekap(ctx context.Context, semesterID string) ([]domain.RekapSiswa, error) {
+	rows, err := r.rekapSemester()
+	if err != nil {
+		return nil, err
+	}
+	hasil := []domain.RekapSiswa{}
+	for _, row := range rows {
+		if getStringFromCellByIndex(row, 0) != semesterID {
+			continue
+		}
+		angka := func(i int) int {
+			n, _ := strconv.Atoi(getStringFromCellByIndex(row, i))
+			return n
+		}
+		ambang, _ := strconv.ParseFloat(getStringFromCellByIndex(row, 9), 64)
+		rekap := domain.RekapSiswa{
+			NISN:        getStringFromCellByIndex(row, 1),
+			NamaLengkap: getStringFromCellByIndex(row, 2),
+			Kelas:       getStringFromCellByIndex(row, 3),
+			Hadir:       angka(4),
+			Izin:        angka(5),
+			Sakit:       angka(6),
+			Alpa:        angka(7),
+		}
+		rekap.HitungPersentase(angka(8), ambang)
+		hasil = append(hasil, rekap)
+	}
+	return hasil, nil
+}
+
+// SaveRekap menyimpan salinan rekap satu semester dalam satu append
+func (r *semesterRepository) SaveRekap(ctx context.Context, semesterID string, list []domain.RekapSiswa) error {
+	if len(list) == 0 {
+		return nil
+	}
+	var values [][]interface{}
+	for _, s := range list {
+		values = append(values, []interface{}{
+			semesterID, s.NISN, s.NamaLengkap, s.Kelas, s.Hadir, s.Izin, s.Sakit, s.Alpa, s.HariEfektif, s.AmbangKehadiran,
+		})
+	}
+	valueRange := &sheets.ValueRange{Values: values}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "RekapSemester", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan %d baris rekap semester %s ke sheet: %v", len(list), semesterID, err)
+	}
+	return err
+}
+
+// DeleteRekap membersihkan semua baris salinan rekap milik satu semester
+func (r *semesterRepository) DeleteRekap(ctx context.Context, semesterID string) error {
+	rows, err := r.rekapSemester()
+	if err != nil {
+		return err
+	}
+	var ranges []string
+	for i, row := range rows {
+		if getStringFromCellByIndex(row, 0) == semesterID {
+			ranges = append(ranges, fmt.Sprintf("RekapSemester!A%d:J%d", i+2, i+2))
+		}
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+	_, err = r.db.Spreadsheets.Values.BatchClear(r.spreadsheetId, &sheets.BatchClearValuesRequest{Ranges: ranges}).Do()
+	return err
+}
@@ -0,0 +1,174 @@
+// file: internal/repository/sesi_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet SesiPengguna:
+// A=ID, B=Username, C=RefreshTokenHash, D=PreviousTokenHash, E=DibuatPada, F=KedaluwarsaPada,
+// G=TerakhirDipakai, H=DicabutPada, I=UserAgent, J=IP
+type sesiRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewSesiRepository(db *sheets.Service, spreadsheetId string) usecase.SesiRepository {
+	return &sesiRepository{db, spreadsheetId}
+}
+
+func sesiToRow(s *domain.SesiPengguna) []interface{} {
+	return []interface{}{
+		s.ID,
+		s.Username,
+		s.RefreshTokenHash,
+		s.PreviousTokenHash,
+		formatWaktu(s.CreatedAt),
+		formatWaktu(s.ExpiresAt),
+		formatWaktu(s.LastUsedAt),
+		formatWaktu(s.RevokedAt),
+		s.UserAgent,
+		s.IPAddress,
+	}
+}
+
+func (r *sesiRepository) findAll(ctx context.Context) ([]domain.SesiPengguna, error) {
+	var sessions []domain.SesiPengguna
+	readRange := "SesiPengguna!A2:J"
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, readRange).Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return sessions, nil
+		}
+		return nil, err
+	}
+
+	for i, row := range resp.Values {
+		id := getStringFromCellByIndex(row, 0)
+		if id == "" {
+			continue
+		}
+		sessions = append(sessions, domain.SesiPengguna{
+			RowNumber:         i + 2,
+			ID:                id,
+			Username:          getStringFromCellByIndex(row, 1),
+			RefreshTokenHash:  getStringFromCellByIndex(row, 2),
+			PreviousTokenHash: getStringFromCellByIndex(row, 3),
+			CreatedAt:         parseWaktu(getStringFromCellByIndex(row, 4)),
+			ExpiresAt:         parseWaktu(getStringFromCellByIndex(row, 5)),
+			LastUsedAt:        parseWaktu(getStringFromCellByIndex(row, 6)),
+			RevokedAt:         parseWaktu(getStringFromCellByIndex(row, 7)),
+			UserAgent:         getStringFromCellByIndex(row, 8),
+			IPAddress:         getStringFromCellByIndex(row, 9),
+		})
+	}
+	return sessions, nil
+}
+
+func (r *sesiRepository) Save(ctx context.Context, sesi *domain.SesiPengguna) error {
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{sesiToRow(sesi)}}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "SesiPengguna", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan sesi ke sheet: %v", err)
+	}
+	return err
+}
+
+func (r *sesiRepository) FindByID(ctx context.Context, id string) (*domain.SesiPengguna, error) {
+	sessions, err := r.findAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		if sessions[i].ID == id {
+			return &sessions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *sesiRepository) FindByUsername(ctx context.Context, username string) ([]domain.SesiPengguna, error) {
+	sessions, err := r.findAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []domain.SesiPengguna
+	for _, s := range sessions {
+		if s.Username == username {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (r *sesiRepository) Update(ctx context.Context, sesi *domain.SesiPengguna) error {
+	if sesi.RowNumber < 2 {
+		return errors.New("nomor baris sesi tidak valid")
+	}
+	updateRange := fmt.Sprintf("SesiPengguna!A%d:J%d", sesi.RowNumber, sesi.RowNumber)
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{sesiToRow(sesi)}}
+	_, err := r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
+	return err
+}
+
+func (r *sesiRepository) RevokeAllForUser(ctx context.Context, username string, at time.Time) ([]string, error) {
+	sessions, err := r.findAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []*sheets.ValueRange
+	var revoked []string
+	for _, s := range sessions {
+		if s.Username != username || !s.RevokedAt.IsZero() {
+			continue
+		}
+		data = append(data, &sheets.ValueRange{
+			Range:  fmt.Sprintf("SesiPengguna!H%d", s.RowNumber),
+			Values: [][]interface{}{{formatWaktu(at)}},
+		})
+		revoked = append(revoked, s.ID)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	req := &sheets.BatchUpdateValuesRequest{ValueInputOption: "RAW", Data: data}
+	if _, err := r.db.Spreadsheets.Values.BatchUpdate(r.spreadsheetId, req).Do(); err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+func (r *sesiRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error) {
+	sessions, err := r.findAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var ranges []string
+	for _, s := range sessions {
+		if s.ExpiresAt.Before(before) {
+			ranges = append(ranges, fmt.Sprintf("SesiPengguna!A%d:J%d", s.RowNumber, s.RowNumber))
+		}
+	}
+	if len(ranges) == 0 {
+		return 0, nil
+	}
+
+	_, err = r.db.Spreadsheets.Values.BatchClear(r.spreadsheetId, &sheets.BatchClearValuesRequest{Ranges: ranges}).Do()
+	if err != nil {
+		return 0, err
+	}
+	return len(ranges), nil
+}
@@ -139,6 +139,63 @@ func (r *siswaRepository) Update(ctx context.Context, nisn string, siswa *domain
 	return err
 }
 
+// siswaKeBarisLengkap menyusun kolom A sampai I DataSiswa. Kolom F sampai H tidak dikelola
+// aplikasi, jadi dikirim nil agar dilewati Sheets API dan isinya tidak berubah.
+func siswaKeBarisLengkap(s *domain.Siswa) []interface{} {
+	return []interface{}{s.NISN, s.NamaLengkap, s.Kelas, s.NomorTeleponOrtu, s.EmailOrtu, nil, nil, nil, s.NamaOrangTua}
+}
+
+// SaveAll menambahkan banyak siswa dalam satu append (dipakai impor)
+func (r *siswaRepository) SaveAll(ctx context.Context, list []domain.Siswa) error {
+	if len(list) == 0 {
+		return nil
+	}
+	var values [][]interface{}
+	for i := range list {
+		values = append(values, siswaKeBarisLengkap(&list[i]))
+	}
+	valueRange := &sheets.ValueRange{Values: values}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "DataSiswa", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan %d siswa ke sheet: %v", len(list), err)
+	}
+	return err
+}
+
+// UpdateAll memperbarui banyak siswa (dicari berdasarkan NISN) dengan satu pembacaan dan satu
+// batch update. Tidak ada yang ditulis jika salah satu NISN tidak ditemukan.
+func (r *siswaRepository) UpdateAll(ctx context.Context, list []domain.Siswa) error {
+	if len(list) == 0 {
+		return nil
+	}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "DataSiswa!A2:A").Do()
+	if err != nil {
+		return err
+	}
+	barisNISN := make(map[string]int, len(resp.Values))
+	for i, row := range resp.Values {
+		nisn := getStringFromCellByIndex(row, 0)
+		if _, ada := barisNISN[nisn]; nisn != "" && !ada {
+			barisNISN[nisn] = i + 2
+		}
+	}
+
+	var data []*sheets.ValueRange
+	for i := range list {
+		rowIndex, ok := barisNISN[list[i].NISN]
+		if !ok {
+			return fmt.Errorf("NISN %s tidak ditemukan untuk diupdate", list[i].NISN)
+		}
+		data = append(data, &sheets.ValueRange{
+			Range:  fmt.Sprintf("DataSiswa!A%d:I%d", rowIndex, rowIndex),
+			Values: [][]interface{}{siswaKeBarisLengkap(&list[i])},
+		})
+	}
+	req := &sheets.BatchUpdateValuesRequest{ValueInputOption: "RAW", Data: data}
+	_, err = r.db.Spreadsheets.Values.BatchUpdate(r.spreadsheetId, req).Do()
+	return err
+}
+
 // --- FUNGSI BARU UNTUK DELETE SISWA ---
 func (r *siswaRepository) Delete(ctx context.Context, nisn string) error {
 	// Implementasi delete di Google Sheets agak rumit.
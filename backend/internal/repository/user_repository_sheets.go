@@ -6,6 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"daarulilmi-presence/internal/domain"
 
@@ -22,9 +25,63 @@ func NewUserRepository(db *sheets.Service, spreadsheetId string) *userRepository
 	return &userRepository{db, spreadsheetId}
 }
 
+// findRowNumber mencari nomor baris di sheet DataPengguna untuk username tertentu
+func (r *userRepository) findRowNumber(ctx context.Context, username string) (int, error) {
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "DataPengguna!A2:A").Do()
+	if err != nil {
+		return -1, err
+	}
+	for i, row := range resp.Values {
+		if getStringFromCellByIndex(row, 0) == username {
+			return i + 2, nil
+		}
+	}
+	return -1, errors.New("tidak dapat menemukan pengguna untuk diupdate")
+}
+
+// counterCell menulis counter TOTP; 0 (belum pernah dipakai) ditulis kosong
+func counterCell(counter int64) string {
+	if counter == 0 {
+		return ""
+	}
+	return strconv.FormatInt(counter, 10)
+}
+
+// boolCell menulis nilai boolean ke sheet sebagai "TRUE" atau kosong
+func boolCell(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return ""
+}
+
+// rowToUser mengubah satu baris DataPengguna (kolom A sampai M) menjadi domain.User
+func rowToUser(row []interface{}) domain.User {
+	user := domain.User{
+		Username:           getStringFromCellByIndex(row, 0),
+		PasswordHash:       getStringFromCellByIndex(row, 1),
+		Role:               getStringFromCellByIndex(row, 2),
+		NamaLengkap:        getStringFromCellByIndex(row, 3),
+		SiswaNISN:          getStringFromCellByIndex(row, 4), // <-- TAMBAHKAN INI
+		Email:              getStringFromCellByIndex(row, 5),
+		LockedUntil:        parseWaktu(getStringFromCellByIndex(row, 6)),
+		TOTPSecret:         getStringFromCellByIndex(row, 7),
+		TOTPEnabled:        getStringFromCellByIndex(row, 8) == "TRUE",
+		Disabled:           getStringFromCellByIndex(row, 10) == "TRUE",
+		MustChangePassword: getStringFromCellByIndex(row, 11) == "TRUE",
+	}
+	if counter, err := strconv.ParseInt(getStringFromCellByIndex(row, 12), 10, 64); err == nil {
+		user.TOTPCounterTerakhir = counter
+	}
+	if codes := getStringFromCellByIndex(row, 9); codes != "" {
+		user.RecoveryCodeHashes = strings.Split(codes, ",")
+	}
+	return user
+}
+
 // FindByUsername adalah implementasi nyata untuk mencari user
 func (r *userRepository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
-	readRange := "DataPengguna!A2:E" // Baca sampai kolom E (SiswaNISN)
+	readRange := "DataPengguna!A2:M" // Baca sampai kolom M (CounterTOTP)
 	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, readRange).Do()
 	if err != nil {
 		return nil, err
@@ -32,26 +89,45 @@ func (r *userRepository) FindByUsername(ctx context.Context, username string) (*
 
 	for _, row := range resp.Values {
 		if len(row) > 0 && getStringFromCellByIndex(row, 0) == username {
-			user := &domain.User{
-				Username:     getStringFromCellByIndex(row, 0),
-				PasswordHash: getStringFromCellByIndex(row, 1),
-				Role:         getStringFromCellByIndex(row, 2),
-				NamaLengkap:  getStringFromCellByIndex(row, 3),
-				SiswaNISN:    getStringFromCellByIndex(row, 4), // <-- TAMBAHKAN INI
-			}
-			return user, nil
+			user := rowToUser(row)
+			return &user, nil
 		}
 	}
 	return nil, nil
 }
 
+// FindAll mengambil semua pengguna, dipakai oleh halaman manajemen pengguna
+func (r *userRepository) FindAll(ctx context.Context) ([]domain.User, error) {
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "DataPengguna!A2:M").Do()
+	if err != nil {
+		return nil, err
+	}
+
+	users := []domain.User{}
+	for _, row := range resp.Values {
+		if getStringFromCellByIndex(row, 0) == "" {
+			continue
+		}
+		users = append(users, rowToUser(row))
+	}
+	return users, nil
+}
+
+// userToRow mengubah domain.User menjadi satu baris DataPengguna (kolom A sampai M)
+func userToRow(user *domain.User) []interface{} {
+	return []interface{}{
+		user.Username, user.PasswordHash, user.Role, user.NamaLengkap, user.SiswaNISN, user.Email,
+		formatWaktu(user.LockedUntil), user.TOTPSecret, boolCell(user.TOTPEnabled), strings.Join(user.RecoveryCodeHashes, ","),
+		boolCell(user.Disabled), boolCell(user.MustChangePassword), counterCell(user.TOTPCounterTerakhir),
+	}
+}
+
 func (r *userRepository) Save(ctx context.Context, user *domain.User) error {
 	log.Println("--- FUNGSI REPOSITORY SAVE (APPEND) DIPANGGIL ---")
 	// Tentukan sheet dan baris data baru yang akan ditambahkan
 	writeRange := "DataPengguna"
 	var values [][]interface{}
-	row := []interface{}{user.Username, user.PasswordHash, user.Role}
-	values = append(values, row)
+	values = append(values, userToRow(user))
 
 	// Siapkan data untuk API
 	valueRange := &sheets.ValueRange{
@@ -67,33 +143,33 @@ func (r *userRepository) Save(ctx context.Context, user *domain.User) error {
 	return nil
 }
 
-func (r *userRepository) Update(ctx context.Context, currentUsername string, user *domain.User) error {
-	log.Println("--- FUNGSI REPOSITORY UPDATE DIPANGGIL ---")
-	// 1. Baca semua data untuk menemukan nomor baris yang benar
-	readRange := "DataPengguna!A:C" // Baca semua kolom untuk dapat nomor baris
-	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, readRange).Do()
+// SaveAll menambahkan banyak pengguna dalam satu panggilan API (dipakai provisioning massal)
+func (r *userRepository) SaveAll(ctx context.Context, users []domain.User) error {
+	var values [][]interface{}
+	for i := range users {
+		values = append(values, userToRow(&users[i]))
+	}
+	valueRange := &sheets.ValueRange{Values: values}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "DataPengguna", valueRange).ValueInputOption("RAW").Do()
 	if err != nil {
+		log.Printf("Gagal menyimpan %d pengguna ke sheet: %v", len(users), err)
 		return err
 	}
+	return nil
+}
 
-	rowIndex := -1
-	for i, row := range resp.Values {
-		if len(row) > 0 && row[0].(string) == currentUsername {
-			// Kita temukan barisnya! Ingat bahwa spreadsheet mulai dari 1, dan array dari 0.
-			// Jika data mulai dari A2, maka baris ke-i di array adalah baris ke i+2 di sheet.
-			rowIndex = i + 2
-			break
-		}
-	}
-
-	if rowIndex == -1 {
-		return errors.New("tidak dapat menemukan pengguna untuk diupdate")
+func (r *userRepository) Update(ctx context.Context, currentUsername string, user *domain.User) error {
+	log.Println("--- FUNGSI REPOSITORY UPDATE DIPANGGIL ---")
+	// 1. Cari nomor baris pengguna di sheet
+	rowIndex, err := r.findRowNumber(ctx, currentUsername)
+	if err != nil {
+		return err
 	}
 
-	// 2. Siapkan data baru dan panggil API Update
-	updateRange := fmt.Sprintf("DataPengguna!A%d:C%d", rowIndex, rowIndex)
+	// 2. Siapkan data baru dan panggil API Update (kolom A sampai F: data akun dan profil)
+	updateRange := fmt.Sprintf("DataPengguna!A%d:F%d", rowIndex, rowIndex)
 	var values [][]interface{}
-	row := []interface{}{user.Username, user.PasswordHash, user.Role}
+	row := []interface{}{user.Username, user.PasswordHash, user.Role, user.NamaLengkap, user.SiswaNISN, user.Email}
 	values = append(values, row)
 
 	valueRange := &sheets.ValueRange{
@@ -108,3 +184,47 @@ func (r *userRepository) Update(ctx context.Context, currentUsername string, use
 
 	return nil
 }
+
+// updateCell menulis satu sel di baris milik username, misal kolom "G"
+func (r *userRepository) updateCell(ctx context.Context, username, column string, value interface{}) error {
+	rowIndex, err := r.findRowNumber(ctx, username)
+	if err != nil {
+		return err
+	}
+	updateRange := fmt.Sprintf("DataPengguna!%s%d", column, rowIndex)
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{{value}}}
+	_, err = r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
+	return err
+}
+
+// UpdateLockout menulis waktu akhir penguncian akun di kolom G (kosongkan dengan zero time)
+func (r *userRepository) UpdateLockout(ctx context.Context, username string, until time.Time) error {
+	return r.updateCell(ctx, username, "G", formatWaktu(until))
+}
+
+// UpdateDisabled menandai akun nonaktif di kolom K
+func (r *userRepository) UpdateDisabled(ctx context.Context, username string, disabled bool) error {
+	return r.updateCell(ctx, username, "K", boolCell(disabled))
+}
+
+// UpdateMustChangePassword menandai akun wajib ganti password di kolom L
+func (r *userRepository) UpdateMustChangePassword(ctx context.Context, username string, must bool) error {
+	return r.updateCell(ctx, username, "L", boolCell(must))
+}
+
+// UpdateTOTPCounter menulis counter kode TOTP terakhir yang diterima di kolom M
+func (r *userRepository) UpdateTOTPCounter(ctx context.Context, username string, counter int64) error {
+	return r.updateCell(ctx, username, "M", counterCell(counter))
+}
+
+// UpdateTwoFactor menulis rahasia TOTP (H), status aktif (I) dan hash kode pemulihan (J)
+func (r *userRepository) UpdateTwoFactor(ctx context.Context, username, secret string, enabled bool, recoveryCodeHashes []string) error {
+	rowIndex, err := r.findRowNumber(ctx, username)
+	if err != nil {
+		return err
+	}
+	updateRange := fmt.Sprintf("DataPengguna!H%d:J%d", rowIndex, rowIndex)
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{{secret, boolCell(enabled), strings.Join(recoveryCodeHashes, ",")}}}
+	_, err = r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
+	return err
+}
@@ -0,0 +1,160 @@
+// file: internal/repository/webhook_repository_sheets.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/usecase"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Kolom sheet Webhook:
+// A=ID, B=URL, C=Events (dipisah koma), D=Secret, E=Aktif, F=Keterangan, G=DibuatOleh,
+// H=DibuatPada, I=DiubahPada (waktu dalam RFC3339)
+//
+// Kolom sheet PengirimanWebhook (satu baris per percobaan, hanya ditambah):
+// A=ID, B=WebhookID, C=EventID, D=Jenis, E=Percobaan, F=Status, G=KodeHTTP, H=Error,
+// I=DurasiMs, J=Waktu, K=BerikutnyaPada, L=Payload
+type webhookRepository struct {
+	db            *sheets.Service
+	spreadsheetId string
+}
+
+func NewWebhookRepository(db *sheets.Service, spreadsheetId string) usecase.WebhookRepository {
+	return &webhookRepository{db, spreadsheetId}
+}
+
+func webhookToRow(w *domain.Webhook) []interface{} {
+	return []interface{}{
+		w.ID, w.URL, strings.Join(w.Events, ","), w.Secret, boolCell(w.Aktif), w.Keterangan, w.DibuatOleh,
+		formatWaktu(w.DibuatPada), formatWaktu(w.DiubahPada),
+	}
+}
+
+func (r *webhookRepository) FindAll(ctx context.Context) ([]domain.Webhook, error) {
+	hasil := []domain.Webhook{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "Webhook!A2:I").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return hasil, nil
+		}
+		return nil, err
+	}
+
+	for i, row := range resp.Values {
+		id := getStringFromCellByIndex(row, 0)
+		if id == "" {
+			continue // Baris yang sudah dibersihkan
+		}
+		events := []string{}
+		for _, e := range strings.Split(getStringFromCellByIndex(row, 2), ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				events = append(events, e)
+			}
+		}
+		hasil = append(hasil, domain.Webhook{
+			RowNumber:  i + 2,
+			ID:         id,
+			URL:        getStringFromCellByIndex(row, 1),
+			Events:     events,
+			Secret:     getStringFromCellByIndex(row, 3),
+			Aktif:      getStringFromCellByIndex(row, 4) == "TRUE",
+			Keterangan: getStringFromCellByIndex(row, 5),
+			DibuatOleh: getStringFromCellByIndex(row, 6),
+			DibuatPada: parseWaktu(getStringFromCellByIndex(row, 7)),
+			DiubahPada: parseWaktu(getStringFromCellByIndex(row, 8)),
+		})
+	}
+	return hasil, nil
+}
+
+func (r *webhookRepository) Save(ctx context.Context, w *domain.Webhook) error {
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{webhookToRow(w)}}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "Webhook", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan webhook ke sheet: %v", err)
+	}
+	return err
+}
+
+func (r *webhookRepository) Update(ctx context.Context, w *domain.Webhook) error {
+	if w.RowNumber < 2 {
+		return errors.New("nomor baris webhook tidak valid")
+	}
+	updateRange := fmt.Sprintf("Webhook!A%d:I%d", w.RowNumber, w.RowNumber)
+	valueRange := &sheets.ValueRange{Values: [][]interface{}{webhookToRow(w)}}
+	_, err := r.db.Spreadsheets.Values.Update(r.spreadsheetId, updateRange, valueRange).ValueInputOption("RAW").Do()
+	return err
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, rowNumber int) error {
+	if rowNumber < 2 {
+		return errors.New("nomor baris webhook tidak valid")
+	}
+	clearRange := fmt.Sprintf("Webhook!A%d:I%d", rowNumber, rowNumber)
+	_, err := r.db.Spreadsheets.Values.Clear(r.spreadsheetId, clearRange, &sheets.ClearValuesRequest{}).Do()
+	return err
+}
+
+func (r *webhookRepository) FindPengiriman(ctx context.Context) ([]domain.PengirimanWebhook, error) {
+	hasil := []domain.PengirimanWebhook{}
+	resp, err := r.db.Spreadsheets.Values.Get(r.spreadsheetId, "PengirimanWebhook!A2:L").Do()
+	if err != nil {
+		if strings.Contains(err.Error(), "Unable to parse range") {
+			return hasil, nil
+		}
+		return nil, err
+	}
+
+	for _, row := range resp.Values {
+		id := getStringFromCellByIndex(row, 0)
+		if id == "" {
+			continue
+		}
+		percobaan, _ := strconv.Atoi(getStringFromCellByIndex(row, 4))
+		kode, _ := strconv.Atoi(getStringFromCellByIndex(row, 6))
+		durasi, _ := strconv.ParseInt(getStringFromCellByIndex(row, 8), 10, 64)
+		hasil = append(hasil, domain.PengirimanWebhook{
+			ID:             id,
+			WebhookID:      getStringFromCellByIndex(row, 1),
+			EventID:        getStringFromCellByIndex(row, 2),
+			Jenis:          getStringFromCellByIndex(row, 3),
+			Percobaan:      percobaan,
+			Status:         getStringFromCellByIndex(row, 5),
+			KodeHTTP:       kode,
+			Error:          getStringFromCellByIndex(row, 7),
+			DurasiMs:       durasi,
+			Waktu:          parseWaktu(getStringFromCellByIndex(row, 9)),
+			BerikutnyaPada: parseWaktu(getStringFromCellByIndex(row, 10)),
+			Payload:        getStringFromCellByIndex(row, 11),
+		})
+	}
+	return hasil, nil
+}
+
+// SavePengiriman menambahkan beberapa percobaan sekaligus dalam satu append
+func (r *webhookRepository) SavePengiriman(ctx context.Context, list []domain.PengirimanWebhook) error {
+	if len(list) == 0 {
+		return nil
+	}
+	var values [][]interface{}
+	for _, p := range list {
+		values = append(values, []interface{}{
+			p.ID, p.WebhookID, p.EventID, p.Jenis, p.Percobaan, p.Status, p.KodeHTTP, p.Error,
+			p.DurasiMs, formatWaktu(p.Waktu), formatWaktu(p.BerikutnyaPada), p.Payload,
+		})
+	}
+	valueRange := &sheets.ValueRange{Values: values}
+	_, err := r.db.Spreadsheets.Values.Append(r.spreadsheetId, "PengirimanWebhook", valueRange).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Gagal menyimpan %d log pengiriman webhook ke sheet: %v", len(list), err)
+	}
+	return err
+}
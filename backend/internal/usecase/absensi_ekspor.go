@@ -0,0 +1,130 @@
+// file: internal/usecase/absensi_ekspor.go
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// GetLogsForExport mengembalikan isi LogAbsensi apa adanya (urutan sheet) dalam rentang
+// tanggal, dilengkapi nama dan kelas siswa dari DataSiswa
+func (uc *absensiUsecase) GetLogsForExport(ctx context.Context, filter domain.FilterEkspor) ([]domain.LogAbsensiEkspor, error) {
+	if _, _, err := uc.rentangEkspor(filter, true); err != nil {
+		return nil, err
+	}
+	siswa, err := uc.siswaPerNISN(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := uc.absensiRepo.GetLogsByDateRange(ctx, filter.Mulai, filter.Selesai)
+	if err != nil {
+		return nil, err
+	}
+
+	hasil := []domain.LogAbsensiEkspor{}
+	for _, l := range logs {
+		s, ada := siswa[l.Username]
+		if filter.Kelas != "" && (!ada || s.Kelas != filter.Kelas) {
+			continue
+		}
+		baris := domain.LogAbsensiEkspor{LogAbsensi: l}
+		if ada {
+			baris.NamaLengkap = s.NamaLengkap
+			baris.Kelas = s.Kelas
+		}
+		hasil = append(hasil, baris)
+	}
+	return hasil, nil
+}
+
+// GetLeaveRequestsForExport mengembalikan pengajuan izin (setelah pencocokan nama) yang
+// rentang izinnya beririsan dengan filter. Tanpa tanggal, semua pengajuan ikut diekspor.
+func (uc *absensiUsecase) GetLeaveRequestsForExport(ctx context.Context, filter domain.FilterEkspor) ([]domain.IzinEkspor, error) {
+	start, end, err := uc.rentangEkspor(filter, false)
+	if err != nil {
+		return nil, err
+	}
+	siswa, err := uc.siswaPerNISN(ctx)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := uc.GetAllLeaveRequests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hasil := []domain.IzinEkspor{}
+	for _, req := range requests {
+		kelas := req.KelasInput
+		if s, ada := siswa[req.SiswaNISN]; ada {
+			kelas = s.Kelas
+		}
+		if filter.Kelas != "" && kelas != filter.Kelas {
+			continue
+		}
+		if !start.IsZero() {
+			mulai, err := clock.ParseTanggalFleksibel(uc.clock, req.TanggalMulai)
+			if err != nil {
+				continue
+			}
+			selesai, err := clock.ParseTanggalFleksibel(uc.clock, req.TanggalSelesai)
+			if err != nil || selesai.Before(mulai) {
+				selesai = mulai
+			}
+			if selesai.Before(start) || mulai.After(end) {
+				continue
+			}
+		}
+		hasil = append(hasil, domain.IzinEkspor{PengajuanIzinLengkap: req, Kelas: kelas})
+	}
+	return hasil, nil
+}
+
+// rentangEkspor memeriksa tanggal filter ekspor. Jika tidak wajib, keduanya boleh kosong
+// (hasilnya waktu nol); jika hanya salah satu diisi, rentangnya dianggap satu hari.
+func (uc *absensiUsecase) rentangEkspor(filter domain.FilterEkspor, wajib bool) (time.Time, time.Time, error) {
+	if filter.Mulai == "" && filter.Selesai == "" && !wajib {
+		return time.Time{}, time.Time{}, nil
+	}
+	mulai, selesai := filter.Mulai, filter.Selesai
+	if !wajib {
+		if mulai == "" {
+			mulai = selesai
+		}
+		if selesai == "" {
+			selesai = mulai
+		}
+	}
+	start, err := clock.ParseTanggal(uc.clock, mulai)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: tanggal mulai harus berformat YYYY-MM-DD", domain.ErrRentangTanggal)
+	}
+	end, err := clock.ParseTanggal(uc.clock, selesai)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: tanggal selesai harus berformat YYYY-MM-DD", domain.ErrRentangTanggal)
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: tanggal selesai sebelum tanggal mulai", domain.ErrRentangTanggal)
+	}
+	if end.After(start.AddDate(0, 0, maksHariRentang)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: maksimal %d hari", domain.ErrRentangTanggal, maksHariRentang)
+	}
+	return start, end, nil
+}
+
+// siswaPerNISN memetakan NISN ke data siswa
+func (uc *absensiUsecase) siswaPerNISN(ctx context.Context) (map[string]domain.Siswa, error) {
+	all, err := uc.siswaRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]domain.Siswa, len(all))
+	for _, s := range all {
+		m[s.NISN] = s
+	}
+	return m, nil
+}
@@ -0,0 +1,130 @@
+// file: internal/usecase/absensi_event.go
+package usecase
+
+import (
+	"context"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// PenerbitEvent menerima event kehadiran untuk diteruskan ke webhook atau notifikasi orang tua.
+// Terbitkan tidak boleh menunggu pengiriman, karena dipanggil di tengah permintaan scan.
+type PenerbitEvent interface {
+	Terbitkan(jenis string, data interface{})
+}
+
+type gabunganPenerbit []PenerbitEvent
+
+// GabungPenerbit meneruskan setiap event ke semua penerbit; penerbit nil dilewati
+func GabungPenerbit(daftar ...PenerbitEvent) PenerbitEvent {
+	var hasil gabunganPenerbit
+	for _, p := range daftar {
+		if p != nil {
+			hasil = append(hasil, p)
+		}
+	}
+	return hasil
+}
+
+func (g gabunganPenerbit) Terbitkan(jenis string, data interface{}) {
+	for _, p := range g {
+		p.Terbitkan(jenis, data)
+	}
+}
+
+// terbitkan mengirim event jika penerbit dipasang
+func (uc *absensiUsecase) terbitkan(jenis string, data interface{}) {
+	if uc.event != nil {
+		uc.event.Terbitkan(jenis, data)
+	}
+}
+
+// terbitkanPencatatan mengirim event untuk record yang dicatat atau diubah guru. Record Alpa
+// juga dikirim sebagai absensi.alpa agar penerima cukup berlangganan satu event untuk Alpa.
+func (uc *absensiUsecase) terbitkanPencatatan(jenis string, data domain.DataEventAbsensi) {
+	uc.terbitkan(jenis, data)
+	if data.Status == domain.StatusAlpa {
+		uc.terbitkan(domain.EventAbsensiAlpa, data)
+	}
+}
+
+// dataEventManual menyusun isi event dari record manual beserta kelas siswanya
+func dataEventManual(rowNumber int, data *domain.KehadiranManual, siswa *domain.Siswa) domain.DataEventAbsensi {
+	ev := domain.DataEventAbsensi{
+		RowNumber:   rowNumber,
+		NISN:        data.NISN,
+		NamaLengkap: data.NamaSiswa,
+		Status:      data.Status,
+		Timestamp:   data.Timestamp,
+		DicatatOleh: data.DicatatOleh,
+	}
+	if siswa != nil {
+		ev.Kelas = siswa.Kelas
+		if ev.NamaLengkap == "" {
+			ev.NamaLengkap = siswa.NamaLengkap
+		}
+	}
+	return ev
+}
+
+// PantauIzin membandingkan pengajuan izin dengan pemeriksaan sebelumnya dan menerbitkan
+// izin.diajukan untuk baris baru serta izin.berubah untuk status atau tanggal yang berubah.
+// Pengajuan masuk lewat Google Form, jadi perubahannya hanya bisa diketahui dengan membaca
+// sheet. Pemeriksaan pertama setelah server menyala hanya mencatat keadaan awal.
+func (uc *absensiUsecase) PantauIzin(ctx context.Context) (int, error) {
+	if uc.event == nil {
+		return 0, nil
+	}
+	uc.izinMu.Lock()
+	defer uc.izinMu.Unlock()
+
+	requests, err := uc.GetAllLeaveRequests(ctx)
+	if err != nil {
+		return 0, err
+	}
+	sekarang := make(map[string]domain.PengajuanIzinLengkap, len(requests))
+	for _, r := range requests {
+		// Timestamp Google Form dan nama cukup untuk mengenali baris walaupun ada baris dihapus
+		sekarang[r.Timestamp+"|"+r.NamaLengkap] = r
+	}
+	if uc.izinTerakhir == nil {
+		uc.izinTerakhir = sekarang
+		return 0, nil
+	}
+
+	siswaMap := uc.petaSiswa(ctx)
+	jumlah := 0
+	for _, r := range requests {
+		lama, ada := uc.izinTerakhir[r.Timestamp+"|"+r.NamaLengkap]
+		data := domain.DataEventIzin{PengajuanIzinLengkap: r}
+		if s, ok := siswaMap[r.SiswaNISN]; ok {
+			data.Kelas = s.Kelas
+		}
+		switch {
+		case !ada:
+			uc.terbitkan(domain.EventIzinDiajukan, data)
+		case lama.Status != r.Status || lama.TanggalMulai != r.TanggalMulai ||
+			lama.TanggalSelesai != r.TanggalSelesai || lama.SiswaNISN != r.SiswaNISN:
+			data.StatusSebelumnya = lama.Status
+			uc.terbitkan(domain.EventIzinBerubah, data)
+		default:
+			continue
+		}
+		jumlah++
+	}
+	uc.izinTerakhir = sekarang
+	return jumlah, nil
+}
+
+// petaSiswa memetakan NISN ke data siswa; jika gagal dibaca, event tetap dikirim tanpa kelas
+func (uc *absensiUsecase) petaSiswa(ctx context.Context) map[string]domain.Siswa {
+	hasil := make(map[string]domain.Siswa)
+	allSiswa, err := uc.siswaRepo.FindAll(ctx)
+	if err != nil {
+		return hasil
+	}
+	for _, s := range allSiswa {
+		hasil[s.NISN] = s
+	}
+	return hasil
+}
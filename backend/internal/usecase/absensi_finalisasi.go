@@ -0,0 +1,240 @@
+// file: internal/usecase/absensi_finalisasi.go
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// FinalisasiRepository mencatat tanggal yang sudah difinalisasi
+type FinalisasiRepository interface {
+	FindAll(ctx context.Context) ([]domain.FinalisasiAlpa, error)
+	SaveAll(ctx context.Context, list []domain.FinalisasiAlpa) error
+	// Kunci mengambil kunci finalisasi yang berlaku untuk semua proses (replika server,
+	// cmd/finalisasi). Mengembalikan false jika pemilik lain masih memegang kunci yang belum
+	// kedaluwarsa. Harus aman dipanggil bersamaan dari beberapa proses.
+	Kunci(ctx context.Context, pemilik string, at time.Time, ttl time.Duration) (bool, error)
+	Lepas(ctx context.Context, pemilik string) error
+}
+
+const (
+	// DefaultAlpaCutoff adalah batas jam Alpa jika tidak diatur (pukul 18.00)
+	DefaultAlpaCutoff = 18 * time.Hour
+	// Berapa hari ke belakang yang dikejar otomatis (misal setelah server mati).
+	// Rentang yang lebih lama di-backfill lewat endpoint admin atau cmd/finalisasi.
+	hariKejarFinalisasi = 7
+	// Batas lama kunci finalisasi dipegang, agar proses yang mati di tengah jalan tidak
+	// menghalangi finalisasi selamanya. Harus jauh lebih lama dari satu kali finalisasi.
+	kunciFinalisasiTTL = 30 * time.Minute
+)
+
+// tanggalTerakhirFinal mengembalikan tanggal terakhir yang sudah lewat batas jam Alpa:
+// hari ini jika sekarang sudah lewat batas, selain itu kemarin
+func (uc *absensiUsecase) tanggalTerakhirFinal() time.Time {
+	now := uc.clock.Now()
+	hariIni := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if now.Before(hariIni.Add(uc.alpaCutoff)) {
+		return hariIni.AddDate(0, 0, -1)
+	}
+	return hariIni
+}
+
+// jamAlpa adalah jam yang ditulis di timestamp record Alpa, yaitu batas jam Alpa
+func (uc *absensiUsecase) jamAlpa() string {
+	return time.Time{}.Add(uc.alpaCutoff).Format(clock.LayoutJam)
+}
+
+// kunciFinalisasi mengambil kunci finalisasi di sheet agar finalisasi tidak berjalan bersamaan
+// di proses lain. finalisasiMu hanya melindungi proses ini; kunci di sheet yang mencegah
+// replika server atau cmd/finalisasi menulis Alpa ganda untuk siswa yang sama. lepas harus
+// dipanggil jika ok bernilai true.
+func (uc *absensiUsecase) kunciFinalisasi(ctx context.Context) (lepas func(), ok bool, err error) {
+	pemilik, err := randomToken(8)
+	if err != nil {
+		return nil, false, err
+	}
+	ok, err = uc.finalisasiRepo.Kunci(ctx, pemilik, uc.clock.Now(), kunciFinalisasiTTL)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return func() {
+		// Konteks baru agar kunci tetap dilepas walau permintaan sudah dibatalkan
+		if err := uc.finalisasiRepo.Lepas(context.Background(), pemilik); err != nil {
+			log.Printf("WARNING: Gagal melepas kunci finalisasi (kedaluwarsa sendiri dalam %v): %v", kunciFinalisasiTTL, err)
+		}
+	}, true, nil
+}
+
+func (uc *absensiUsecase) FinalizeAlpa(ctx context.Context, startDate, endDate, by string) ([]domain.HasilFinalisasi, error) {
+	if !uc.finalisasiMu.TryLock() {
+		return nil, domain.ErrFinalisasiSedangJalan
+	}
+	defer uc.finalisasiMu.Unlock()
+
+	lepas, ok, err := uc.kunciFinalisasi(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.ErrFinalisasiSedangJalan
+	}
+	defer lepas()
+	return uc.finalisasi(ctx, startDate, endDate, by)
+}
+
+func (uc *absensiUsecase) FinalizeDueDays(ctx context.Context) ([]domain.HasilFinalisasi, error) {
+	// Jika finalisasi lain (misal backfill admin) sedang berjalan, tunggu giliran berikutnya
+	if !uc.finalisasiMu.TryLock() {
+		return nil, nil
+	}
+	defer uc.finalisasiMu.Unlock()
+
+	// Kunci di sheet hanya diambil jika memang ada hari yang perlu difinalisasi, agar job
+	// 15 menitan tidak menambah baris kunci setiap kali berjalan
+	mulai, err := uc.hariBelumFinal(ctx)
+	if err != nil || mulai.IsZero() {
+		return nil, err
+	}
+	lepas, ok, err := uc.kunciFinalisasi(ctx)
+	if err != nil || !ok {
+		return nil, err
+	}
+	defer lepas()
+
+	// Periksa ulang setelah memegang kunci: proses lain mungkin baru saja selesai
+	mulai, err = uc.hariBelumFinal(ctx)
+	if err != nil || mulai.IsZero() {
+		return nil, err
+	}
+	return uc.finalisasi(ctx, mulai.Format(clock.LayoutTanggal), uc.tanggalTerakhirFinal().Format(clock.LayoutTanggal), "sistem")
+}
+
+// hariBelumFinal mengembalikan hari sekolah pertama dalam jendela kejar yang belum
+// difinalisasi, atau zero time jika semuanya sudah
+func (uc *absensiUsecase) hariBelumFinal(ctx context.Context) (time.Time, error) {
+	sudah, err := uc.finalisasiRepo.FindAll(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	sudahFinal := make(map[string]bool)
+	for _, f := range sudah {
+		sudahFinal[f.Tanggal] = true
+	}
+
+	// Cari hari sekolah pertama dalam jendela kejar yang belum difinalisasi
+	akhir := uc.tanggalTerakhirFinal()
+	awalJendela := akhir.AddDate(0, 0, -(hariKejarFinalisasi - 1))
+	hari, err := uc.hariKalender(ctx, awalJendela.Format(clock.LayoutTanggal), akhir.Format(clock.LayoutTanggal))
+	if err != nil {
+		return time.Time{}, err
+	}
+	for d := awalJendela; !d.After(akhir); d = d.AddDate(0, 0, 1) {
+		tanggal := d.Format(clock.LayoutTanggal)
+		if hari[tanggal].HariSekolah && !sudahFinal[tanggal] {
+			return d, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+// finalisasi menulis record Alpa untuk setiap siswa yang belum punya status di setiap hari
+// sekolah dalam rentang. Siswa yang sudah tercatat (termasuk Alpa dari finalisasi sebelumnya)
+// dilewati, sehingga aman dijalankan berulang kali. Pemanggil wajib memegang finalisasiMu
+// dan kunci finalisasi di sheet.
+func (uc *absensiUsecase) finalisasi(ctx context.Context, startDate, endDate, by string) ([]domain.HasilFinalisasi, error) {
+	start, err := clock.ParseTanggal(uc.clock, startDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: tanggal mulai harus berformat YYYY-MM-DD", domain.ErrRentangTanggal)
+	}
+	end, err := clock.ParseTanggal(uc.clock, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: tanggal selesai harus berformat YYYY-MM-DD", domain.ErrRentangTanggal)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: tanggal selesai sebelum tanggal mulai", domain.ErrRentangTanggal)
+	}
+	if end.After(start.AddDate(0, 0, maksHariRentang)) {
+		return nil, fmt.Errorf("%w: maksimal %d hari sekali jalan", domain.ErrRentangTanggal, maksHariRentang)
+	}
+	if end.After(uc.tanggalTerakhirFinal()) {
+		return nil, fmt.Errorf("%w (pukul %s)", domain.ErrFinalisasiBelumWaktu, uc.jamAlpa()[:5])
+	}
+
+	allSiswa, err := uc.siswaRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hari, err := uc.hariKalender(ctx, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	statusMap, err := uc.statusHarian(ctx, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.clock.Now()
+	var records []domain.KehadiranManual
+	var catatan []domain.FinalisasiAlpa
+	hasil := []domain.HasilFinalisasi{}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		tanggal := d.Format(clock.LayoutTanggal)
+		h := domain.HasilFinalisasi{Tanggal: tanggal}
+		if !hari[tanggal].HariSekolah {
+			h.Dilewati = hari[tanggal].Keterangan
+			hasil = append(hasil, h)
+			continue
+		}
+
+		for _, siswa := range allSiswa {
+			if _, ada := statusMap[siswa.NISN][tanggal]; ada {
+				h.JumlahTercatat++
+				continue
+			}
+			nama := siswa.NamaLengkap
+			if nama == "" {
+				nama = siswa.NISN // Repository melewati baris tanpa nama
+			}
+			records = append(records, domain.KehadiranManual{
+				NISN:        siswa.NISN,
+				NamaSiswa:   nama,
+				Status:      domain.StatusAlpa,
+				Timestamp:   tanggal + " " + uc.jamAlpa(),
+				DicatatOleh: domain.DicatatOlehFinalisasi,
+			})
+			h.JumlahAlpa++
+		}
+		catatan = append(catatan, domain.FinalisasiAlpa{Tanggal: tanggal, JumlahAlpa: h.JumlahAlpa, DijalankanPada: now, Oleh: by})
+		hasil = append(hasil, h)
+	}
+
+	if len(records) > 0 {
+		if err := uc.absensiRepo.CreateBatchManualAttendance(ctx, records); err != nil {
+			return nil, err
+		}
+	}
+	if uc.event != nil {
+		kelas := make(map[string]string, len(allSiswa))
+		for _, siswa := range allSiswa {
+			kelas[siswa.NISN] = siswa.Kelas
+		}
+		for i := range records {
+			ev := dataEventManual(0, &records[i], nil)
+			ev.Kelas = kelas[records[i].NISN]
+			uc.terbitkan(domain.EventAbsensiAlpa, ev)
+		}
+	}
+	// Record Alpa sudah tersimpan; jika catatan gagal, tanggal ini hanya akan diperiksa ulang
+	// pada putaran berikutnya tanpa menulis Alpa ganda
+	if err := uc.finalisasiRepo.SaveAll(ctx, catatan); err != nil {
+		log.Printf("WARNING: Gagal mencatat finalisasi Alpa %s s.d. %s: %v", startDate, endDate, err)
+	}
+
+	log.Printf("INFO: Finalisasi Alpa %s s.d. %s oleh %s: %d record Alpa ditulis", startDate, endDate, by, len(records))
+	return hasil, nil
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// usecaseFinalisasiUji membuat usecase absensi dengan repository bersama, seperti satu replika
+// server atau satu kali cmd/finalisasi yang membaca spreadsheet yang sama
+func usecaseFinalisasiUji(absensi *absensiRepoUji, siswa *siswaRepoUji, final *finalisasiRepoUji, now time.Time) *absensiUsecase {
+	return NewAbsensiUsecase(absensi, siswa, newUserRepoUji(), &relasiRepoUji{}, final, nil, rekonsiliasiUji{}, kalenderUji{}, AbsensiUsecaseConfig{
+		Clock: clock.Fixed(now),
+	}).(*absensiUsecase)
+}
+
+func jumlahAlpa(absensi *absensiRepoUji) map[string]int {
+	absensi.mu.Lock()
+	defer absensi.mu.Unlock()
+	hasil := make(map[string]int)
+	for _, l := range absensi.logs {
+		if l.DicatatOleh == domain.DicatatOlehFinalisasi {
+			hasil[l.Username+" "+l.Timestamp[:10]]++
+		}
+	}
+	return hasil
+}
+
+func TestFinalisasiTidakGandaAntarProses(t *testing.T) {
+	loc, _ := clock.LoadLocation("")
+	// Selasa malam setelah batas jam Alpa; Senin dan Selasa hari sekolah
+	now := time.Date(2025, 3, 11, 19, 0, 0, 0, loc)
+	absensi := &absensiRepoUji{logs: []domain.LogAbsensi{{Timestamp: "2025-03-10 07:00:00", Username: "001", Status: domain.StatusHadir}}}
+	siswa := &siswaRepoUji{siswa: []domain.Siswa{{NISN: "001", NamaLengkap: "Ahmad"}, {NISN: "002", NamaLengkap: "Aisyah"}, {NISN: "003", NamaLengkap: "Bilal"}}}
+	final := &finalisasiRepoUji{}
+
+	// Job server, replika kedua dan cmd/finalisasi berjalan bersamaan
+	prosesList := []func() error{
+		func() error {
+			_, err := usecaseFinalisasiUji(absensi, siswa, final, now).FinalizeDueDays(context.Background())
+			return err
+		},
+		func() error {
+			_, err := usecaseFinalisasiUji(absensi, siswa, final, now).FinalizeDueDays(context.Background())
+			return err
+		},
+		func() error {
+			_, err := usecaseFinalisasiUji(absensi, siswa, final, now).FinalizeAlpa(context.Background(), "2025-03-10", "2025-03-11", "cmd/finalisasi")
+			if errors.Is(err, domain.ErrFinalisasiSedangJalan) {
+				return nil
+			}
+			return err
+		},
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(prosesList))
+	for i, proses := range prosesList {
+		wg.Add(1)
+		go func(i int, proses func() error) {
+			defer wg.Done()
+			errs[i] = proses()
+		}(i, proses)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("proses %d: %v", i, err)
+		}
+	}
+
+	for kunci, n := range jumlahAlpa(absensi) {
+		if n != 1 {
+			t.Errorf("Alpa %s ditulis %d kali", kunci, n)
+		}
+	}
+
+	// Semua proses selesai: finalisasi berikutnya tidak menulis apa pun dan kunci sudah dilepas
+	sebelum := len(jumlahAlpa(absensi))
+	if _, err := usecaseFinalisasiUji(absensi, siswa, final, now).FinalizeAlpa(context.Background(), "2025-03-10", "2025-03-11", "admin"); err != nil {
+		t.Fatalf("finalisasi ulang: %v", err)
+	}
+	// Jendela kejar 7 hari: 5 hari sekolah (5-7 dan 10-11 Maret) x 3 siswa, dikurangi Ahmad
+	// yang hadir tanggal 10
+	alpa := jumlahAlpa(absensi)
+	if len(alpa) != sebelum || len(alpa) != 14 {
+		t.Fatalf("jumlah siswa-hari Alpa = %d (sebelumnya %d), want 14", len(alpa), sebelum)
+	}
+}
+
+func TestKunciFinalisasiKedaluwarsa(t *testing.T) {
+	loc, _ := clock.LoadLocation("")
+	now := time.Date(2025, 3, 11, 19, 0, 0, 0, loc)
+	final := &finalisasiRepoUji{}
+
+	// Proses yang mati tanpa melepas kunci
+	if ok, _ := final.Kunci(context.Background(), "mati", now.Add(-time.Hour), kunciFinalisasiTTL); !ok {
+		t.Fatal("kunci pertama seharusnya didapat")
+	}
+	uc := usecaseFinalisasiUji(&absensiRepoUji{}, &siswaRepoUji{}, final, now)
+	if _, err := uc.FinalizeAlpa(context.Background(), "2025-03-10", "2025-03-10", "admin"); err != nil {
+		t.Fatalf("kunci kedaluwarsa masih menghalangi: %v", err)
+	}
+
+	// Kunci yang masih berlaku menghalangi
+	final.Kunci(context.Background(), "aktif", now, kunciFinalisasiTTL)
+	if _, err := uc.FinalizeAlpa(context.Background(), "2025-03-10", "2025-03-10", "admin"); !errors.Is(err, domain.ErrFinalisasiSedangJalan) {
+		t.Fatalf("err = %v, want ErrFinalisasiSedangJalan", err)
+	}
+}
@@ -0,0 +1,87 @@
+// file: internal/usecase/absensi_grid.go
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// GetGridBulanan menyusun tabel kehadiran siswa × tanggal untuk satu bulan, diurutkan per
+// kelas lalu nama. Angka rekap dan kode per tanggal memakai aturan yang sama dengan
+// GetRekapByDateRange: hanya hari sekolah yang dihitung.
+func (uc *absensiUsecase) GetGridBulanan(ctx context.Context, year, month int, kelas string) (*domain.GridBulanan, error) {
+	grid, _, err := uc.gridBulanan(ctx, year, month, kelas)
+	return grid, err
+}
+
+// gridBulanan menyusun grid bulanan dan ikut mengembalikan status harian yang dipakainya,
+// agar laporan bulanan bisa membaca jam scan tanpa membaca sheet dua kali
+func (uc *absensiUsecase) gridBulanan(ctx context.Context, year, month int, kelas string) (*domain.GridBulanan, map[string]map[string]domain.StatusHarian, error) {
+	if month < 1 || month > 12 {
+		return nil, nil, fmt.Errorf("%w: bulan harus 1 sampai 12", domain.ErrRentangTanggal)
+	}
+	awal := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, uc.clock.Location())
+	akhir := awal.AddDate(0, 1, -1)
+	startDate, endDate := awal.Format(clock.LayoutTanggal), akhir.Format(clock.LayoutTanggal)
+
+	allSiswa, err := uc.siswaRepo.FindAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	statusMap, err := uc.statusHarian(ctx, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	hari, err := uc.hariKalender(ctx, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	grid := &domain.GridBulanan{Tahun: year, Bulan: month, Kelas: kelas, Baris: []domain.BarisGrid{}}
+	hariIni := clock.Today(uc.clock)
+	for d := awal; !d.After(akhir); d = d.AddDate(0, 0, 1) {
+		h := hari[d.Format(clock.LayoutTanggal)]
+		grid.Hari = append(grid.Hari, h)
+		if h.HariSekolah && h.Tanggal <= hariIni {
+			grid.HariEfektif++
+		}
+	}
+
+	var siswaList []domain.Siswa
+	for _, s := range allSiswa {
+		if kelas == "" || s.Kelas == kelas {
+			siswaList = append(siswaList, s)
+		}
+	}
+	sort.SliceStable(siswaList, func(i, j int) bool {
+		if siswaList[i].Kelas != siswaList[j].Kelas {
+			return siswaList[i].Kelas < siswaList[j].Kelas
+		}
+		return siswaList[i].NamaLengkap < siswaList[j].NamaLengkap
+	})
+
+	for _, s := range siswaList {
+		baris := domain.BarisGrid{
+			Siswa: s,
+			Kode:  make([]string, len(grid.Hari)),
+			Rekap: domain.RekapSiswa{NISN: s.NISN, NamaLengkap: s.NamaLengkap, Kelas: s.Kelas},
+		}
+		for i, h := range grid.Hari {
+			st, ada := statusMap[s.NISN][h.Tanggal]
+			if !ada || !h.HariSekolah {
+				continue
+			}
+			baris.Kode[i] = domain.KodeStatus(st.Status)
+			baris.Rekap.Tambah(st.Status)
+		}
+		baris.Rekap.HitungPersentase(grid.HariEfektif, uc.ambang)
+		baris.PersenHadir = baris.Rekap.PersenHadir
+		grid.Baris = append(grid.Baris, baris)
+	}
+	return grid, statusMap, nil
+}
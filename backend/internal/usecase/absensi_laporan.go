@@ -0,0 +1,114 @@
+// file: internal/usecase/absensi_laporan.go
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// DefaultJamMasuk adalah jam masuk sekolah jika tidak diatur (pukul 07.00)
+const DefaultJamMasuk = 7 * time.Hour
+
+// GetLaporanBulanan melengkapi grid bulanan dengan statistik, keterlambatan dan alasan izin
+// setiap siswa untuk laporan PDF yang ditandatangani wali kelas
+func (uc *absensiUsecase) GetLaporanBulanan(ctx context.Context, year, month int, kelas string) (*domain.LaporanBulanan, error) {
+	grid, statusMap, err := uc.gridBulanan(ctx, year, month, kelas)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := uc.GetAllLeaveRequests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	awal, err := clock.ParseTanggal(uc.clock, grid.Hari[0].Tanggal)
+	if err != nil {
+		return nil, err
+	}
+	akhir := awal.AddDate(0, 1, -1)
+	izinPerSiswa := make(map[string][]domain.CatatanIzin)
+	for _, req := range requests {
+		if req.SiswaNISN == "" || req.SiswaNISN == domain.NISNTidakDitemukan {
+			continue
+		}
+		mulai, err := clock.ParseTanggalFleksibel(uc.clock, req.TanggalMulai)
+		if err != nil {
+			continue
+		}
+		selesai, err := clock.ParseTanggalFleksibel(uc.clock, req.TanggalSelesai)
+		if err != nil || selesai.Before(mulai) {
+			selesai = mulai
+		}
+		if selesai.Before(awal) || mulai.After(akhir) {
+			continue
+		}
+		izinPerSiswa[req.SiswaNISN] = append(izinPerSiswa[req.SiswaNISN], domain.CatatanIzin{
+			TanggalMulai:   mulai.Format(clock.LayoutTanggal),
+			TanggalSelesai: selesai.Format(clock.LayoutTanggal),
+			Alasan:         req.JenisIzin,
+			Status:         req.Status,
+		})
+	}
+
+	laporan := &domain.LaporanBulanan{
+		Tahun:       grid.Tahun,
+		Bulan:       grid.Bulan,
+		Kelas:       grid.Kelas,
+		Hari:        grid.Hari,
+		HariEfektif: grid.HariEfektif,
+		JamMasuk:    fmt.Sprintf("%02d:%02d", int(uc.jamMasuk.Hours()), int(uc.jamMasuk.Minutes())%60),
+		Siswa:       []domain.LaporanSiswa{},
+		DibuatPada:  uc.clock.Now(),
+	}
+	for _, baris := range grid.Baris {
+		ls := domain.LaporanSiswa{
+			BarisGrid: baris,
+			Statistik: domain.StatistikData{
+				TotalHadir:          baris.Rekap.Hadir,
+				TotalIzin:           baris.Rekap.Izin,
+				TotalSakit:          baris.Rekap.Sakit,
+				TotalAlpa:           baris.Rekap.Alpa,
+				JumlahSiswa:         1,
+				PersentaseKehadiran: baris.Rekap.PersentaseKehadiran,
+			},
+			Terlambat: []domain.Keterlambatan{},
+			Izin:      izinPerSiswa[baris.Siswa.NISN],
+		}
+		if ls.Izin == nil {
+			ls.Izin = []domain.CatatanIzin{}
+		}
+		for _, h := range grid.Hari {
+			if !h.HariSekolah {
+				continue
+			}
+			if t, ok := uc.keterlambatan(statusMap[baris.Siswa.NISN][h.Tanggal]); ok {
+				ls.Terlambat = append(ls.Terlambat, t)
+			}
+		}
+		laporan.Siswa = append(laporan.Siswa, ls)
+	}
+	return laporan, nil
+}
+
+// keterlambatan memeriksa apakah scan datang tercatat setelah jam masuk. Kehadiran yang
+// dicatat manual oleh guru dilewati karena jamnya adalah jam pencatatan, bukan jam datang.
+func (uc *absensiUsecase) keterlambatan(st domain.StatusHarian) (domain.Keterlambatan, bool) {
+	if st.Status != domain.StatusHadir || st.Sumber != domain.SumberLog || st.DicatatOleh != "" {
+		return domain.Keterlambatan{}, false
+	}
+	t, err := clock.ParseWaktu(uc.clock, st.Timestamp)
+	if err != nil {
+		return domain.Keterlambatan{}, false
+	}
+	batas := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Add(uc.jamMasuk)
+	// Terlambat dihitung per menit penuh, jadi 07:00:40 masih tepat waktu untuk jam masuk 07:00
+	menit := int(t.Sub(batas) / time.Minute)
+	if menit < 1 {
+		return domain.Keterlambatan{}, false
+	}
+	return domain.Keterlambatan{Tanggal: st.Tanggal, Jam: t.Format("15:04"), Menit: menit}, true
+}
@@ -0,0 +1,369 @@
+// file: internal/usecase/absensi_peringatan.go
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// PeringatanRepository menyimpan daftar peringatan hasil evaluasi terakhir
+type PeringatanRepository interface {
+	FindAll(ctx context.Context) ([]domain.PeringatanKehadiran, error)
+	// ReplaceAll mengganti seluruh daftar peringatan dengan hasil evaluasi baru
+	ReplaceAll(ctx context.Context, list []domain.PeringatanKehadiran) error
+}
+
+// AturanPeringatan berisi ambang setiap aturan peringatan dini. Nilai 0 mematikan aturan.
+type AturanPeringatan struct {
+	// Jumlah hari kalender ke belakang yang dievaluasi, sampai hari terakhir yang sudah difinalisasi
+	JendelaHari int
+	// Alpa sebanyak ini di hari sekolah berturut-turut
+	AlpaBerturut int
+	// Tidak hadir (Izin, Sakit, Alpa) sebanyak ini dalam jendela
+	AbsenMaks int
+	// Tidak hadir sebanyak ini pada hari yang sama (misal Senin) dalam jendela
+	PolaHari int
+	// Terlambat sebanyak ini dalam jendela
+	TerlambatMaks int
+}
+
+// DefaultAturanPeringatan adalah aturan bawaan: jendela 30 hari, Alpa 3 hari berturut-turut,
+// 5 kali tidak hadir, 3 kali tidak hadir di hari yang sama, dan 5 kali terlambat
+func DefaultAturanPeringatan() AturanPeringatan {
+	return AturanPeringatan{JendelaHari: 30, AlpaBerturut: 3, AbsenMaks: 5, PolaHari: 3, TerlambatMaks: 5}
+}
+
+var namaHariIndonesia = [...]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+func (uc *absensiUsecase) EvaluatePeringatan(ctx context.Context) (*domain.HasilEvaluasiPeringatan, error) {
+	if !uc.peringatanMu.TryLock() {
+		return nil, domain.ErrEvaluasiPeringatanJalan
+	}
+	defer uc.peringatanMu.Unlock()
+	return uc.evaluasiPeringatan(ctx, uc.tanggalTerakhirFinal())
+}
+
+func (uc *absensiUsecase) EvaluateDuePeringatan(ctx context.Context) (*domain.HasilEvaluasiPeringatan, error) {
+	if !uc.peringatanMu.TryLock() {
+		return nil, nil
+	}
+	defer uc.peringatanMu.Unlock()
+
+	akhir := uc.tanggalTerakhirFinal()
+	tanggal := akhir.Format(clock.LayoutTanggal)
+	if uc.peringatanTerakhir == tanggal {
+		return nil, nil
+	}
+
+	// Tunggu sampai Alpa hari terakhir ditulis oleh finalisasi, agar Alpa hari itu ikut dihitung
+	hari, err := uc.hariKalender(ctx, tanggal, tanggal)
+	if err != nil {
+		return nil, err
+	}
+	if hari[tanggal].HariSekolah {
+		sudah, err := uc.finalisasiRepo.FindAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		final := false
+		for _, f := range sudah {
+			if f.Tanggal == tanggal {
+				final = true
+				break
+			}
+		}
+		if !final {
+			return nil, nil
+		}
+	}
+
+	hasil, err := uc.evaluasiPeringatan(ctx, akhir)
+	if err != nil {
+		return nil, err
+	}
+	uc.peringatanTerakhir = tanggal
+	return hasil, nil
+}
+
+func (uc *absensiUsecase) GetPeringatan(ctx context.Context, filter domain.FilterPeringatan) ([]domain.PeringatanKehadiran, error) {
+	minimal := 0
+	if filter.Tingkat != "" {
+		minimal = domain.BobotTingkat(strings.ToLower(filter.Tingkat))
+		if minimal == 0 {
+			return nil, domain.ErrTingkatPeringatan
+		}
+	}
+
+	list, err := uc.peringatanRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hasil := []domain.PeringatanKehadiran{}
+	for _, p := range list {
+		if filter.Kelas != "" && p.Kelas != filter.Kelas {
+			continue
+		}
+		if filter.NISN != "" && p.NISN != filter.NISN {
+			continue
+		}
+		if domain.BobotTingkat(p.Tingkat) < minimal {
+			continue
+		}
+		hasil = append(hasil, p)
+	}
+	urutkanPeringatan(hasil)
+	return hasil, nil
+}
+
+// peringatanDashboard mengambil peringatan untuk dashboard wali kelas. Kegagalan hanya dicatat
+// agar dashboard kehadiran tetap tampil.
+func (uc *absensiUsecase) peringatanDashboard(ctx context.Context) []domain.PeringatanKehadiran {
+	list, err := uc.GetPeringatan(ctx, domain.FilterPeringatan{})
+	if err != nil {
+		log.Printf("WARNING: Gagal mengambil peringatan kehadiran untuk dashboard: %v", err)
+		return []domain.PeringatanKehadiran{}
+	}
+	return list
+}
+
+// evaluasiPeringatan menjalankan semua aturan untuk setiap siswa pada jendela yang berakhir di
+// tanggal akhir, lalu mengganti daftar peringatan tersimpan. Pemanggil wajib memegang peringatanMu.
+func (uc *absensiUsecase) evaluasiPeringatan(ctx context.Context, akhir time.Time) (*domain.HasilEvaluasiPeringatan, error) {
+	mulai := akhir.AddDate(0, 0, -(uc.aturan.JendelaHari - 1))
+	startDate, endDate := mulai.Format(clock.LayoutTanggal), akhir.Format(clock.LayoutTanggal)
+
+	allSiswa, err := uc.siswaRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hari, err := uc.hariKalender(ctx, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	statusMap, err := uc.statusHarian(ctx, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	lama, err := uc.peringatanRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pertamaMuncul := make(map[string]time.Time)
+	for _, p := range lama {
+		pertamaMuncul[p.ID] = p.PertamaMuncul
+	}
+
+	var hariSekolah []string
+	for d := mulai; !d.After(akhir); d = d.AddDate(0, 0, 1) {
+		if tanggal := d.Format(clock.LayoutTanggal); hari[tanggal].HariSekolah {
+			hariSekolah = append(hariSekolah, tanggal)
+		}
+	}
+
+	now := uc.clock.Now()
+	hasil := &domain.HasilEvaluasiPeringatan{TanggalMulai: startDate, TanggalSelesai: endDate, JumlahSiswa: len(allSiswa)}
+	list := []domain.PeringatanKehadiran{}
+	for _, siswa := range allSiswa {
+		for _, p := range uc.periksaAturan(hariSekolah, statusMap[siswa.NISN]) {
+			p.NISN, p.NamaLengkap, p.Kelas = siswa.NISN, siswa.NamaLengkap, siswa.Kelas
+			p.ID = hashToken(siswa.NISN + "|" + p.ID)[:16]
+			p.DievaluasiPada = now
+			if t, ada := pertamaMuncul[p.ID]; ada && !t.IsZero() {
+				p.PertamaMuncul = t
+			} else {
+				p.PertamaMuncul = now
+				hasil.Baru++
+			}
+			switch p.Tingkat {
+			case domain.TingkatTinggi:
+				hasil.Tinggi++
+			case domain.TingkatSedang:
+				hasil.Sedang++
+			default:
+				hasil.Rendah++
+			}
+			list = append(list, p)
+		}
+	}
+	urutkanPeringatan(list)
+
+	if err := uc.peringatanRepo.ReplaceAll(ctx, list); err != nil {
+		return nil, err
+	}
+	log.Printf("INFO: Evaluasi peringatan kehadiran %s s.d. %s: %d peringatan (%d baru)", startDate, endDate, len(list), hasil.Baru)
+	return hasil, nil
+}
+
+// periksaAturan menjalankan setiap aturan yang aktif untuk status harian satu siswa. Field ID
+// yang dikembalikan hanya kunci aturan; pemanggil menambahkan NISN dan identitas siswa.
+func (uc *absensiUsecase) periksaAturan(hariSekolah []string, status map[string]domain.StatusHarian) []domain.PeringatanKehadiran {
+	var hasil []domain.PeringatanKehadiran
+	if len(hariSekolah) == 0 {
+		return hasil
+	}
+	jendela := fmt.Sprintf("%d hari terakhir", uc.aturan.JendelaHari)
+
+	// Alpa berturut-turut: rangkaian terakhir yang mencapai ambang. Hari tanpa status memutus rangkaian.
+	if n := uc.aturan.AlpaBerturut; n > 0 {
+		awal, panjang := "", 0
+		var temuan *domain.PeringatanKehadiran
+		for i, tanggal := range hariSekolah {
+			if status[tanggal].Status != domain.StatusAlpa {
+				panjang = 0
+				continue
+			}
+			if panjang == 0 {
+				awal = tanggal
+			}
+			panjang++
+			if panjang < n {
+				continue
+			}
+			temuan = &domain.PeringatanKehadiran{
+				ID: domain.AturanAlpaBerturut, Aturan: domain.AturanAlpaBerturut, Tingkat: domain.TingkatSedang,
+				TanggalMulai: awal, TanggalSelesai: tanggal, Jumlah: panjang,
+				Pesan: fmt.Sprintf("Alpa %d hari sekolah berturut-turut", panjang),
+			}
+			// Masih berlangsung sampai hari sekolah terakhir: siswa belum kembali masuk
+			if i == len(hariSekolah)-1 {
+				temuan.Tingkat = domain.TingkatTinggi
+				temuan.Pesan += " dan belum masuk kembali"
+			}
+		}
+		if temuan != nil {
+			hasil = append(hasil, *temuan)
+		}
+	}
+
+	// Jumlah tidak hadir dalam jendela, per status
+	if n := uc.aturan.AbsenMaks; n > 0 {
+		rekap := domain.RekapSiswa{}
+		var tanggalAbsen []string
+		for _, tanggal := range hariSekolah {
+			if st := status[tanggal].Status; tidakHadir(st) {
+				rekap.Tambah(st)
+				tanggalAbsen = append(tanggalAbsen, tanggal)
+			}
+		}
+		if jumlah := len(tanggalAbsen); jumlah >= n {
+			p := domain.PeringatanKehadiran{
+				ID: domain.AturanAbsenJendela, Aturan: domain.AturanAbsenJendela, Tingkat: domain.TingkatSedang,
+				TanggalMulai: tanggalAbsen[0], TanggalSelesai: tanggalAbsen[jumlah-1], Jumlah: jumlah,
+				Pesan: fmt.Sprintf("Tidak hadir %d dari %d hari sekolah dalam %s (%s)", jumlah, len(hariSekolah), jendela, rincianAbsen(rekap)),
+			}
+			if jumlah >= 2*n {
+				p.Tingkat = domain.TingkatTinggi
+			}
+			hasil = append(hasil, p)
+		}
+	}
+
+	// Pola hari: tidak hadir pada hari yang sama minimal separuh kesempatan, dan jauh lebih
+	// sering daripada hari lain. Siswa yang absen setiap hari sudah tertangkap aturan lain.
+	if n := uc.aturan.PolaHari; n > 0 {
+		var total, absen [7]int
+		var rekap [7]domain.RekapSiswa
+		var tanggalAbsen [7][]string
+		totalSemua, absenSemua := 0, 0
+		for _, tanggal := range hariSekolah {
+			t, err := time.Parse(clock.LayoutTanggal, tanggal)
+			if err != nil {
+				continue
+			}
+			w := t.Weekday()
+			total[w]++
+			totalSemua++
+			if st := status[tanggal].Status; tidakHadir(st) {
+				absen[w]++
+				absenSemua++
+				rekap[w].Tambah(st)
+				tanggalAbsen[w] = append(tanggalAbsen[w], tanggal)
+			}
+		}
+		for w := range total {
+			if absen[w] < n || absen[w]*2 < total[w] {
+				continue
+			}
+			// Bandingkan tingkat absen hari ini dengan hari lainnya (minimal dua kali lipat)
+			totalLain, absenLain := totalSemua-total[w], absenSemua-absen[w]
+			if totalLain > 0 && absen[w]*totalLain < 2*absenLain*total[w] {
+				continue
+			}
+			p := domain.PeringatanKehadiran{
+				ID: domain.AturanPolaHari + "|" + namaHariIndonesia[w], Aturan: domain.AturanPolaHari, Tingkat: domain.TingkatRendah,
+				TanggalMulai: tanggalAbsen[w][0], TanggalSelesai: tanggalAbsen[w][len(tanggalAbsen[w])-1], Jumlah: absen[w],
+				Pesan: fmt.Sprintf("Tidak hadir %d dari %d hari %s dalam %s (%s)", absen[w], total[w], namaHariIndonesia[w], jendela, rincianAbsen(rekap[w])),
+			}
+			if absen[w] == total[w] {
+				p.Tingkat = domain.TingkatSedang
+			}
+			hasil = append(hasil, p)
+		}
+	}
+
+	// Terlambat berulang
+	if n := uc.aturan.TerlambatMaks; n > 0 {
+		var telat []domain.Keterlambatan
+		menit := 0
+		for _, tanggal := range hariSekolah {
+			if t, ok := uc.keterlambatan(status[tanggal]); ok {
+				telat = append(telat, t)
+				menit += t.Menit
+			}
+		}
+		if jumlah := len(telat); jumlah >= n {
+			p := domain.PeringatanKehadiran{
+				ID: domain.AturanTerlambat, Aturan: domain.AturanTerlambat, Tingkat: domain.TingkatRendah,
+				TanggalMulai: telat[0].Tanggal, TanggalSelesai: telat[jumlah-1].Tanggal, Jumlah: jumlah,
+				Pesan: fmt.Sprintf("Terlambat %d kali dalam %s, total %d menit", jumlah, jendela, menit),
+			}
+			if jumlah >= 2*n {
+				p.Tingkat = domain.TingkatSedang
+			}
+			hasil = append(hasil, p)
+		}
+	}
+	return hasil
+}
+
+func tidakHadir(status string) bool {
+	return status == domain.StatusIzin || status == domain.StatusSakit || status == domain.StatusAlpa
+}
+
+// rincianAbsen menulis jumlah per status, misal "Sakit 2, Alpa 1"
+func rincianAbsen(r domain.RekapSiswa) string {
+	var bagian []string
+	for _, s := range []struct {
+		nama   string
+		jumlah int
+	}{{domain.StatusAlpa, r.Alpa}, {domain.StatusSakit, r.Sakit}, {domain.StatusIzin, r.Izin}} {
+		if s.jumlah > 0 {
+			bagian = append(bagian, fmt.Sprintf("%s %d", s.nama, s.jumlah))
+		}
+	}
+	return strings.Join(bagian, ", ")
+}
+
+// urutkanPeringatan: tingkat tertinggi dulu, lalu kelas dan nama siswa
+func urutkanPeringatan(list []domain.PeringatanKehadiran) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if bi, bj := domain.BobotTingkat(a.Tingkat), domain.BobotTingkat(b.Tingkat); bi != bj {
+			return bi > bj
+		}
+		if a.Kelas != b.Kelas {
+			return a.Kelas < b.Kelas
+		}
+		if a.NamaLengkap != b.NamaLengkap {
+			return a.NamaLengkap < b.NamaLengkap
+		}
+		return a.Aturan < b.Aturan
+	})
+}
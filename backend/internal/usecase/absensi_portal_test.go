@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+func TestGetAttendanceForUserMemilihAnak(t *testing.T) {
+	ctx := context.Background()
+	users := newUserRepoUji(
+		domain.User{Username: "ibu.sari", Role: domain.RoleOrtu},
+		domain.User{Username: "0012345", Role: domain.RoleSiswa},
+	)
+	siswa := &siswaRepoUji{siswa: []domain.Siswa{{NISN: "001", NamaLengkap: "Ahmad"}, {NISN: "002", NamaLengkap: "Aisyah"}, {NISN: "003", NamaLengkap: "Bukan Anak"}}}
+	relasi := &relasiRepoUji{relasi: []domain.RelasiWaliSiswa{
+		{Username: "ibu.sari", SiswaNISN: "001"},
+		{Username: "ibu.sari", SiswaNISN: "002"},
+	}}
+	absensi := &absensiRepoUji{logs: []domain.LogAbsensi{
+		{Username: "001", Status: "Datang"},
+		{Username: "002", Status: "Datang"},
+		{Username: "002", Status: "Pulang"},
+		{Username: "003", Status: "Datang"},
+		{Username: "0012345", Status: "Datang"},
+	}}
+	uc := NewAbsensiUsecase(absensi, siswa, users, relasi, nil, nil, nil, nil, AbsensiUsecaseConfig{})
+
+	tests := []struct {
+		name     string
+		username string
+		nisn     string
+		want     int
+		wantErr  error
+	}{
+		{"anak pertama bila nisn kosong", "ibu.sari", "", 1, nil},
+		{"anak kedua dipilih", "ibu.sari", "002", 2, nil},
+		{"siswa lain ditolak", "ibu.sari", "003", 0, domain.ErrAnakTidakTertaut},
+		{"akun siswa melihat dirinya sendiri", "0012345", "003", 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs, err := uc.GetAttendanceForUser(ctx, tt.username, tt.nisn)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(logs) != tt.want {
+				t.Fatalf("jumlah log = %d, want %d", len(logs), tt.want)
+			}
+			for _, l := range logs {
+				if l.Username == "003" && tt.username != "0012345" {
+					t.Fatalf("data siswa lain bocor: %+v", l)
+				}
+			}
+		})
+	}
+}
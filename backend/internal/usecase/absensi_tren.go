@@ -0,0 +1,252 @@
+// file: internal/usecase/absensi_tren.go
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// Panjang rentang tren jika tanggal mulai tidak diisi
+const hariTrenBawaan = 30
+
+// anggotaSeri adalah siswa yang dihitung dalam satu seri tren
+type anggotaSeri struct {
+	nama  string
+	siswa []domain.Siswa
+}
+
+// GetTren menghitung statistik kehadiran per hari, minggu, atau bulan untuk seluruh sekolah
+// dan setiap kelas/tingkat. Titik tanpa hari efektif (libur atau belum berjalan) tidak
+// dikirim agar garis grafik tidak jatuh ke nol.
+func (uc *absensiUsecase) GetTren(ctx context.Context, filter domain.FilterTren) (*domain.TrenKehadiran, error) {
+	periode := strings.ToLower(strings.TrimSpace(filter.Periode))
+	switch periode {
+	case "":
+		periode = domain.PeriodeHarian
+	case domain.PeriodeHarian, domain.PeriodeMingguan, domain.PeriodeBulanan:
+	default:
+		return nil, fmt.Errorf("%w: periode harus harian, mingguan, atau bulanan", domain.ErrParameterTren)
+	}
+	kelompok := strings.ToLower(strings.TrimSpace(filter.Kelompok))
+	switch kelompok {
+	case "":
+		kelompok = domain.KelompokSekolah
+	case domain.KelompokSekolah, domain.KelompokKelas, domain.KelompokTingkat:
+	default:
+		return nil, fmt.Errorf("%w: kelompok harus sekolah, kelas, atau tingkat", domain.ErrParameterTren)
+	}
+
+	// Bawaan: 30 hari terakhir sampai hari ini
+	rentang := domain.FilterEkspor{Mulai: filter.Mulai, Selesai: filter.Selesai}
+	if rentang.Selesai == "" {
+		rentang.Selesai = clock.Today(uc.clock)
+	}
+	if rentang.Mulai == "" {
+		if end, err := clock.ParseTanggal(uc.clock, rentang.Selesai); err == nil {
+			rentang.Mulai = end.AddDate(0, 0, -(hariTrenBawaan - 1)).Format(clock.LayoutTanggal)
+		}
+	}
+	start, end, err := uc.rentangEkspor(rentang, true)
+	if err != nil {
+		return nil, err
+	}
+	panjang := int(end.Sub(start).Hours()/24+0.5) + 1
+	akhirSebelumnya := start.AddDate(0, 0, -1)
+	awalSebelumnya := akhirSebelumnya.AddDate(0, 0, -(panjang - 1))
+
+	allSiswa, err := uc.siswaRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Rentang sebelumnya ikut diambil sekali jalan untuk pembanding
+	awal, akhir := awalSebelumnya.Format(clock.LayoutTanggal), end.Format(clock.LayoutTanggal)
+	hari, err := uc.hariKalender(ctx, awal, akhir)
+	if err != nil {
+		return nil, err
+	}
+	statusMap, err := uc.statusHarian(ctx, awal, akhir)
+	if err != nil {
+		return nil, err
+	}
+
+	utama := anggotaSeri{nama: "Seluruh Sekolah"}
+	if filter.Kelas != "" {
+		utama.nama = "Kelas " + filter.Kelas
+	}
+	grup := make(map[string][]domain.Siswa)
+	for _, s := range allSiswa {
+		if filter.Kelas != "" && s.Kelas != filter.Kelas {
+			continue
+		}
+		utama.siswa = append(utama.siswa, s)
+		switch kelompok {
+		case domain.KelompokKelas:
+			nama := "Tanpa Kelas"
+			if s.Kelas != "" {
+				nama = "Kelas " + s.Kelas
+			}
+			grup[nama] = append(grup[nama], s)
+		case domain.KelompokTingkat:
+			nama := "Tingkat Lainnya"
+			if t := tingkatKelas(s.Kelas); t != "" {
+				nama = "Tingkat " + t
+			}
+			grup[nama] = append(grup[nama], s)
+		}
+	}
+	daftarSeri := []anggotaSeri{utama}
+	for _, nama := range urutNamaKelompok(grup) {
+		daftarSeri = append(daftarSeri, anggotaSeri{nama: nama, siswa: grup[nama]})
+	}
+
+	tren := &domain.TrenKehadiran{
+		Mulai:             start.Format(clock.LayoutTanggal),
+		Selesai:           end.Format(clock.LayoutTanggal),
+		Periode:           periode,
+		Kelompok:          kelompok,
+		MulaiSebelumnya:   awalSebelumnya.Format(clock.LayoutTanggal),
+		SelesaiSebelumnya: akhirSebelumnya.Format(clock.LayoutTanggal),
+		Seri:              []domain.SeriTren{},
+	}
+	potongan := bagiPeriode(start, end, periode)
+	for _, anggota := range daftarSeri {
+		seri := domain.SeriTren{
+			Nama:       anggota.nama,
+			Titik:      []domain.TitikTren{},
+			Total:      uc.statistikRentang(start, end, anggota.siswa, hari, statusMap),
+			Sebelumnya: uc.statistikRentang(awalSebelumnya, akhirSebelumnya, anggota.siswa, hari, statusMap),
+		}
+		if seri.Sebelumnya.HariEfektif > 0 {
+			seri.SelisihHadir = selisihPoin(seri.Total.PersenHadir, seri.Sebelumnya.PersenHadir)
+		}
+		for _, p := range potongan {
+			mulai, _ := clock.ParseTanggal(uc.clock, p.Mulai)
+			selesai, _ := clock.ParseTanggal(uc.clock, p.Selesai)
+			p.StatistikData = uc.statistikRentang(mulai, selesai, anggota.siswa, hari, statusMap)
+			if p.HariEfektif == 0 {
+				continue
+			}
+			if n := len(seri.Titik); n > 0 {
+				p.SelisihHadir = selisihPoin(p.PersenHadir, seri.Titik[n-1].PersenHadir)
+			}
+			seri.Titik = append(seri.Titik, p)
+		}
+		tren.Seri = append(tren.Seri, seri)
+	}
+	return tren, nil
+}
+
+// statistikRentang menghitung status siswa pada hari sekolah yang sudah berjalan dalam rentang,
+// dengan pembagi persentase hari efektif × jumlah siswa seperti statistik bulanan
+func (uc *absensiUsecase) statistikRentang(mulai, selesai time.Time, siswa []domain.Siswa, hari map[string]domain.HariKalender, statusMap map[string]map[string]domain.StatusHarian) domain.StatistikData {
+	hariIni := clock.Today(uc.clock)
+	var tanggal []string
+	for d := mulai; !d.After(selesai); d = d.AddDate(0, 0, 1) {
+		if t := d.Format(clock.LayoutTanggal); hari[t].HariSekolah && t <= hariIni {
+			tanggal = append(tanggal, t)
+		}
+	}
+
+	stats := domain.StatistikData{}
+	for _, s := range siswa {
+		rekap := domain.RekapSiswa{}
+		for _, t := range tanggal {
+			if st, ada := statusMap[s.NISN][t]; ada {
+				stats.Tambah(st.Status)
+				rekap.Tambah(st.Status)
+			}
+		}
+		rekap.HitungPersentase(len(tanggal), uc.ambang)
+		if rekap.DiBawahAmbang {
+			stats.JumlahDiBawahAmbang++
+		}
+	}
+	stats.HitungPersentase(len(tanggal), len(siswa), uc.ambang)
+	return stats
+}
+
+// bagiPeriode memecah rentang menjadi hari, minggu (Senin-Minggu, label minggu ISO), atau
+// bulan kalender. Potongan pertama dan terakhir dipotong mengikuti rentang.
+func bagiPeriode(start, end time.Time, periode string) []domain.TitikTren {
+	var titik []domain.TitikTren
+	for d := start; !d.After(end); {
+		akhir, label := d, d.Format(clock.LayoutTanggal)
+		switch periode {
+		case domain.PeriodeMingguan:
+			akhir = d.AddDate(0, 0, 6-(int(d.Weekday())+6)%7)
+			tahun, minggu := d.ISOWeek()
+			label = fmt.Sprintf("%d-W%02d", tahun, minggu)
+		case domain.PeriodeBulanan:
+			akhir = time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, d.Location())
+			label = d.Format("2006-01")
+		}
+		if akhir.After(end) {
+			akhir = end
+		}
+		titik = append(titik, domain.TitikTren{Label: label, Mulai: d.Format(clock.LayoutTanggal), Selesai: akhir.Format(clock.LayoutTanggal)})
+		d = akhir.AddDate(0, 0, 1)
+	}
+	return titik
+}
+
+// tingkatKelas mengambil tingkat dari nama kelas, misal "7A", "VII-B", "X IPA 1", dan
+// "Kelas 8 C". Angka romawi hanya diterima jika diikuti pemisah atau satu huruf rombel, agar
+// "IPA" tidak terbaca sebagai tingkat 1. Kosong jika nama kelas tidak diawali tingkat.
+func tingkatKelas(kelas string) string {
+	k := strings.ToLower(strings.TrimSpace(kelas))
+	k = strings.TrimSpace(strings.TrimPrefix(k, "kelas"))
+	if i := strings.IndexFunc(k, func(r rune) bool { return !unicode.IsDigit(r) }); i != 0 {
+		if i < 0 {
+			return k
+		}
+		return k[:i]
+	}
+
+	romawi := map[string]string{"i": "1", "ii": "2", "iii": "3", "iv": "4", "v": "5", "vi": "6",
+		"vii": "7", "viii": "8", "ix": "9", "x": "10", "xi": "11", "xii": "12"}
+	n := strings.IndexFunc(k, func(r rune) bool { return !strings.ContainsRune("ivx", r) })
+	if n < 0 {
+		return romawi[k]
+	}
+	sisa := []rune(k[n:])
+	if unicode.IsLetter(sisa[0]) && len(sisa) > 1 && unicode.IsLetter(sisa[1]) {
+		return ""
+	}
+	return romawi[k[:n]]
+}
+
+// urutNamaKelompok mengurutkan nama seri; "Tingkat 10" setelah "Tingkat 9"
+func urutNamaKelompok(grup map[string][]domain.Siswa) []string {
+	nama := make([]string, 0, len(grup))
+	for n := range grup {
+		nama = append(nama, n)
+	}
+	angka := func(s string) int {
+		n, err := strconv.Atoi(strings.TrimPrefix(s, "Tingkat "))
+		if err != nil {
+			return math.MaxInt
+		}
+		return n
+	}
+	sort.Slice(nama, func(i, j int) bool {
+		if a, b := angka(nama[i]), angka(nama[j]); a != b {
+			return a < b
+		}
+		return nama[i] < nama[j]
+	})
+	return nama
+}
+
+// selisihPoin adalah selisih dua persen dalam poin persen, dibulatkan satu desimal
+func selisihPoin(a, b float64) float64 {
+	return math.Round((a-b)*10) / 10
+}
@@ -8,20 +8,19 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"daarulilmi-presence/internal/clock"
 	"daarulilmi-presence/internal/domain" // Ganti dengan nama modul Anda
 
 	"github.com/skip2/go-qrcode"
 )
 
-// Definisikan secret key untuk payload QR, agar bisa divalidasi nanti
-const qrSecretKey = "daarulilmi-presence"
-
 // --- BUAT KONTRAK UNTUK REPOSITORY ABSENSI ---
 type AbsensiRepository interface {
 	RecordAttendance(ctx context.Context, username, status string) error
-	GetAttendanceForUser(ctx context.Context, username string) ([]domain.LogAbsensi, error)
+	GetAttendanceByNISN(ctx context.Context, nisn string) ([]domain.LogAbsensi, error)
 	GetAllLeaveRequests(ctx context.Context) ([]domain.PengajuanIzinLengkap, error)
 	GetTotalSiswa(ctx context.Context) (int, error)
 	GetTodaysAttendanceAndLeave(ctx context.Context) ([]domain.LogAbsensi, []domain.PengajuanIzinLengkap, error)
@@ -34,12 +33,10 @@ type AbsensiRepository interface {
 	GetAttendanceByRow(ctx context.Context, rowNumber int) (*domain.LogAbsensi, error)
 	GetAttendanceByDate(ctx context.Context, date string) ([]domain.LogAbsensi, error)
 	GetLeaveByDate(ctx context.Context, date string) ([]domain.PengajuanIzinLengkap, error)
-	GetHolidays(ctx context.Context) (map[string]bool, error)
 	GetAllLogsInMonth(ctx context.Context, year, month int) ([]domain.LogAbsensi, error)
-	GetLogsByDateRange(ctx context.Context, startDate, endDate string) ([]domain.LogAbsensi, []domain.PengajuanIzinLengkap, error)
+	GetLogsByDateRange(ctx context.Context, startDate, endDate string) ([]domain.LogAbsensi, error)
 	FindTodaysAttendanceLog(ctx context.Context, nisn string) (*domain.LogAbsensi, error)
 	UpdateClockOut(ctx context.Context, rowNumber int, clockOutTime string) error
-	GetAllLogsForUserInMonth(ctx context.Context, nisn string, year, month int) ([]domain.LogAbsensi, []domain.PengajuanIzinLengkap, error)
 }
 
 type SiswaRepository interface {
@@ -50,18 +47,95 @@ type SiswaRepository interface {
 	Delete(ctx context.Context, nisn string) error
 }
 
+// AbsensiUsecaseConfig berisi pengaturan usecase absensi yang diambil dari konfigurasi server
+type AbsensiUsecaseConfig struct {
+	// Secret key untuk payload QR, agar bisa divalidasi saat dipindai
+	QRSecretKey string
+	// Jam di zona waktu sekolah; nil berarti jam sistem di clock.DefaultTimezone
+	Clock clock.Clock
+	// Lama setelah tengah malam sebelum siswa tanpa kabar difinalisasi menjadi Alpa (bawaan 18.00)
+	AlpaCutoff time.Duration
+	// Lama setelah tengah malam sebelum scan datang dihitung terlambat (bawaan 07.00)
+	JamMasuk time.Duration
+	// Persen hadir minimal; siswa di bawahnya ditandai di rekap dan statistik (0 = tanpa ambang)
+	AmbangKehadiran float64
+	// Ambang aturan peringatan dini; JendelaHari 0 berarti memakai DefaultAturanPeringatan
+	Peringatan AturanPeringatan
+	// Penerima event kehadiran untuk webhook; nil berarti event tidak dikirim
+	Event PenerbitEvent
+}
+
+// clockAtauSistem memakai jam sistem di zona waktu sekolah bawaan jika Clock tidak diinjeksi.
+// Zona waktu server (time.Local) sengaja tidak dipakai karena di container biasanya UTC.
+func clockAtauSistem(c clock.Clock) clock.Clock {
+	if c != nil {
+		return c
+	}
+	loc, err := clock.LoadLocation(clock.DefaultTimezone)
+	if err != nil {
+		// Database zona waktu sudah disematkan di paket clock; WIB tidak mengenal DST
+		loc = time.FixedZone("WIB", 7*60*60)
+	}
+	return clock.New(loc)
+}
+
 type absensiUsecase struct {
-	absensiRepo AbsensiRepository
-	siswaRepo   SiswaRepository
-	userRepo    UserRepository
+	absensiRepo    AbsensiRepository
+	siswaRepo      SiswaRepository
+	userRepo       UserRepository
+	relasiRepo     RelasiWaliRepository
+	finalisasiRepo FinalisasiRepository
+	peringatanRepo PeringatanRepository
+	rekonsiliasi   domain.RekonsiliasiUsecase
+	kalender       domain.KalenderUsecase
+	qrSecretKey    string
+	clock          clock.Clock
+	alpaCutoff     time.Duration
+	jamMasuk       time.Duration
+	ambang         float64
+	aturan         AturanPeringatan
+	// Mencegah job terjadwal dan backfill admin menulis Alpa bersamaan
+	finalisasiMu sync.Mutex
+	// Mencegah dua evaluasi peringatan menulis bersamaan; peringatanTerakhir adalah tanggal
+	// akhir evaluasi terjadwal terakhir (YYYY-MM-DD)
+	peringatanMu       sync.Mutex
+	peringatanTerakhir string
+	event              PenerbitEvent
+	// Keadaan pengajuan izin pada pemeriksaan terakhir PantauIzin, dengan kunci timestamp|nama
+	izinMu       sync.Mutex
+	izinTerakhir map[string]domain.PengajuanIzinLengkap
 }
 
 // NewAbsensiUsecase adalah "pabrik" untuk usecase absensi
-func NewAbsensiUsecase(absensiRepo AbsensiRepository, siswaRepo SiswaRepository, userRepo UserRepository) domain.AbsensiUsecase {
+func NewAbsensiUsecase(absensiRepo AbsensiRepository, siswaRepo SiswaRepository, userRepo UserRepository, relasiRepo RelasiWaliRepository, finalisasiRepo FinalisasiRepository, peringatanRepo PeringatanRepository, rekonsiliasi domain.RekonsiliasiUsecase, kalender domain.KalenderUsecase, cfg AbsensiUsecaseConfig) domain.AbsensiUsecase {
+	alpaCutoff := cfg.AlpaCutoff
+	if alpaCutoff <= 0 {
+		alpaCutoff = DefaultAlpaCutoff
+	}
+	jamMasuk := cfg.JamMasuk
+	if jamMasuk <= 0 {
+		jamMasuk = DefaultJamMasuk
+	}
+	aturan := cfg.Peringatan
+	if aturan.JendelaHari <= 0 {
+		aturan = DefaultAturanPeringatan()
+	}
 	return &absensiUsecase{
-		absensiRepo: absensiRepo,
-		siswaRepo:   siswaRepo,
-		userRepo:    userRepo,
+		absensiRepo:    absensiRepo,
+		siswaRepo:      siswaRepo,
+		userRepo:       userRepo,
+		relasiRepo:     relasiRepo,
+		finalisasiRepo: finalisasiRepo,
+		peringatanRepo: peringatanRepo,
+		rekonsiliasi:   rekonsiliasi,
+		kalender:       kalender,
+		qrSecretKey:    cfg.QRSecretKey,
+		clock:          clockAtauSistem(cfg.Clock),
+		alpaCutoff:     alpaCutoff,
+		jamMasuk:       jamMasuk,
+		ambang:         cfg.AmbangKehadiran,
+		aturan:         aturan,
+		event:          cfg.Event,
 	}
 }
 
@@ -71,7 +145,14 @@ func (uc *absensiUsecase) DeleteAttendance(ctx context.Context, rowNumber int) e
 
 func (uc *absensiUsecase) UpdateAttendance(ctx context.Context, rowNumber int, data *domain.KehadiranManual) error {
 	// Di sini bisa ditambahkan validasi data sebelum dikirim ke repository
-	return uc.absensiRepo.UpdateAttendance(ctx, rowNumber, data)
+	if err := uc.absensiRepo.UpdateAttendance(ctx, rowNumber, data); err != nil {
+		return err
+	}
+	if uc.event != nil {
+		siswa, _ := uc.siswaRepo.FindByNISN(ctx, data.NISN)
+		uc.terbitkanPencatatan(domain.EventAbsensiDiubah, dataEventManual(rowNumber, data, siswa))
+	}
+	return nil
 }
 
 func (uc *absensiUsecase) GetAttendanceByRow(ctx context.Context, rowNumber int) (*domain.LogAbsensi, error) {
@@ -94,126 +175,94 @@ func (uc *absensiUsecase) GetSmartDashboardData(ctx context.Context, username st
 
 	// Jika tanggal kosong, gunakan hari ini
 	if dateStr == "" {
-		dateStr = time.Now().Format("2006-01-02")
+		dateStr = clock.Today(uc.clock)
 	}
-	targetDate, err := time.Parse("2006-01-02", dateStr)
-	if err != nil {
+	if _, err := clock.ParseTanggal(uc.clock, dateStr); err != nil {
 		return nil, fmt.Errorf("format tanggal salah: %v", err)
 	}
 
-	// Cek hari libur
-	holidays, err := uc.absensiRepo.GetHolidays(ctx)
+	// Cek hari libur menurut kalender sekolah
+	hari, err := uc.hariKalender(ctx, dateStr, dateStr)
 	if err != nil {
 		return nil, err
 	}
-	if holidays[dateStr] {
-		return &domain.SmartDashboardData{NamaLengkapUser: namaLengkapUser, IsHoliday: true, HolidayDescription: "Tanggal Merah"}, nil
-	}
-	if targetDate.Weekday() == time.Saturday || targetDate.Weekday() == time.Sunday {
-		return &domain.SmartDashboardData{NamaLengkapUser: namaLengkapUser, IsHoliday: true, HolidayDescription: "Akhir Pekan"}, nil
+	if h := hari[dateStr]; !h.HariSekolah {
+		return &domain.SmartDashboardData{
+			NamaLengkapUser:    namaLengkapUser,
+			IsHoliday:          true,
+			HolidayDescription: h.Keterangan,
+			Peringatan:         uc.peringatanDashboard(ctx),
+		}, nil
 	}
 
-	// Ambil semua data mentah
 	allSiswa, err := uc.siswaRepo.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	hadirList, err := uc.absensiRepo.GetAttendanceByDate(ctx, dateStr)
+	// Status per siswa dibaca dari fakta yang sama dengan rekap dan statistik bulanan.
+	// Alpa hanya muncul jika sudah ditulis oleh finalisasi setelah batas jam Alpa.
+	statusMap, err := uc.statusHarian(ctx, dateStr, dateStr)
 	if err != nil {
 		return nil, err
 	}
 
-	izinList, err := uc.absensiRepo.GetLeaveByDate(ctx, dateStr)
-	if err != nil {
-		return nil, err
-	}
-
-	// --- LOGIKA BARU: Proses data manual & izin terlebih dahulu ---
-	manualStatusMap := make(map[string]domain.LogAbsensi)
-	izinMap := make(map[string]domain.PengajuanIzinLengkap)
-
-	// Proses data izin dari GForm
-	for _, log := range izinList {
-		izinMap[log.SiswaNISN] = log
-	}
-
-	// Proses SEMUA log absensi (dari QR dan manual), cari yang paling baru untuk setiap siswa
-	for _, log := range hadirList {
-		nisn := log.Username
-		if existingLog, ok := manualStatusMap[nisn]; !ok || log.Timestamp > existingLog.Timestamp {
-			manualStatusMap[nisn] = log
-		}
+	data := &domain.SmartDashboardData{
+		NamaLengkapUser: namaLengkapUser,
+		IsHoliday:       false,
+		SetengahHari:    hari[dateStr].SetengahHari,
+		KeteranganHari:  hari[dateStr].Keterangan,
+		TotalSiswa:      len(allSiswa),
+		Peringatan:      uc.peringatanDashboard(ctx),
 	}
-
-	var daftarStatusSiswa []domain.SiswaStatus
-	totalHadir := 0
-	totalIzinSakit := 0
-	isAfter6PM := time.Now().Hour() >= 18 && dateStr == time.Now().Format("2006-01-02")
-
 	for _, siswa := range allSiswa {
 		statusSiswa := domain.SiswaStatus{
 			NISN:        siswa.NISN,
 			NamaLengkap: siswa.NamaLengkap,
 			Kelas:       siswa.Kelas,
+			Status:      domain.StatusBelumAdaKabar,
+			Keterangan:  "-",
 		}
 
-		// --- PRIORITAS BARU ---
-		// 1. Cek apakah ada status manual (termasuk Hadir dari QR)
-		if dataManual, found := manualStatusMap[siswa.NISN]; found {
-			statusSiswa.Status = dataManual.Status
-			statusSiswa.RowNumber = dataManual.RowNumber
-			parts := strings.Split(dataManual.Timestamp, " ")
-			if len(parts) == 2 {
-				statusSiswa.Keterangan = fmt.Sprintf("Dicatat pukul %s", parts[1])
-			} else {
-				statusSiswa.Keterangan = "Tercatat"
-			}
-
-			// 2. Jika tidak ada, baru cek dari GForm Izin
-		} else if dataIzin, found := izinMap[siswa.NISN]; found {
-			statusSiswa.Status = dataIzin.JenisIzin
-			statusSiswa.RowNumber = dataIzin.RowNumber
-			statusSiswa.Keterangan = "Surat/Form diterima"
-			// 3. Jika tidak ada sama sekali, tentukan statusnya
-		} else {
-			if isAfter6PM {
-				statusSiswa.Status = "Alpa"
+		if st, found := statusMap[siswa.NISN][dateStr]; found {
+			statusSiswa.Status = st.Status
+			statusSiswa.RowNumber = st.RowNumber
+			switch {
+			case st.Sumber == domain.SumberIzin:
+				statusSiswa.Keterangan = "Surat/Form diterima"
+			case st.DicatatOleh == domain.DicatatOlehFinalisasi:
 				statusSiswa.Keterangan = "Tidak ada konfirmasi"
-			} else {
-				statusSiswa.Status = "Belum Ada Kabar"
-				statusSiswa.Keterangan = "-"
+			default:
+				parts := strings.Split(st.Timestamp, " ")
+				if len(parts) == 2 {
+					statusSiswa.Keterangan = fmt.Sprintf("Dicatat pukul %s", parts[1])
+				} else {
+					statusSiswa.Keterangan = "Tercatat"
+				}
 			}
 		}
-		daftarStatusSiswa = append(daftarStatusSiswa, statusSiswa)
-	}
 
-	// Hitung ulang total statistik berdasarkan status final
-	for _, s := range daftarStatusSiswa {
-		switch strings.ToLower(s.Status) {
-		case "hadir":
-			totalHadir++
-		case "izin", "sakit":
-			totalIzinSakit++
+		switch statusSiswa.Status {
+		case domain.StatusHadir:
+			data.TotalHadir++
+		case domain.StatusIzin, domain.StatusSakit:
+			data.TotalIzin++
+		case domain.StatusAlpa:
+			data.TotalAlpa++
+		default:
+			data.TotalBelumAdaKabar++
 		}
+		data.DaftarStatusSiswa = append(data.DaftarStatusSiswa, statusSiswa)
 	}
 
-	return &domain.SmartDashboardData{
-		NamaLengkapUser:    namaLengkapUser,
-		IsHoliday:          false,
-		TotalSiswa:         len(allSiswa),
-		TotalHadir:         totalHadir,
-		TotalIzin:          totalIzinSakit,
-		TotalBelumAdaKabar: len(allSiswa) - totalHadir - totalIzinSakit,
-		DaftarStatusSiswa:  daftarStatusSiswa,
-	}, nil
+	return data, nil
 }
 
 // GenerateQR adalah implementasi logika pembuatan QR code
 func (uc *absensiUsecase) GenerateQR(ctx context.Context, qrType string) ([]byte, error) {
 	// Buat payload: berisi secret key dan timestamp saat ini.
 	// Ini untuk memastikan QR code valid dan tidak bisa digunakan berulang kali di lain hari.
-	payload := fmt.Sprintf("%s:%d:%s", qrSecretKey, time.Now().Unix(), qrType)
+	payload := fmt.Sprintf("%s:%d:%s", uc.qrSecretKey, uc.clock.Now().Unix(), qrType)
 
 	// Generate QR code dari payload menjadi gambar PNG dengan ukuran 256x256 pixel.
 	// qrcode.Encode akan mengembalikan byte slice dari gambar PNG.
@@ -234,7 +283,7 @@ func (uc *absensiUsecase) VerifyAndRecordScan(ctx context.Context, qrData string
 	}
 
 	// 2. Validasi secret key
-	if parts[0] != qrSecretKey {
+	if parts[0] != uc.qrSecretKey {
 		return "", errors.New("QR code tidak valid: kunci tidak cocok")
 	}
 
@@ -244,7 +293,7 @@ func (uc *absensiUsecase) VerifyAndRecordScan(ctx context.Context, qrData string
 		return "", errors.New("QR code tidak valid: timestamp rusak")
 	}
 
-	if time.Now().Unix()-qrTimestamp > 60 {
+	if uc.clock.Now().Unix()-qrTimestamp > 60 {
 		return "", errors.New("QR code sudah kedaluwarsa")
 	}
 
@@ -267,13 +316,14 @@ func (uc *absensiUsecase) VerifyAndRecordScan(ctx context.Context, qrData string
 			NISN:        siswa.NISN,
 			NamaSiswa:   siswa.NamaLengkap,
 			Status:      "Hadir",
-			Timestamp:   time.Now().Format("2006-01-02 15:04:05"),
+			Timestamp:   clock.Timestamp(uc.clock),
 			DicatatOleh: "Sistem QR",
 		}
 		err = uc.absensiRepo.CreateManualAttendance(ctx, data)
 		if err != nil {
 			return "", err
 		}
+		uc.terbitkan(domain.EventAbsensiMasuk, dataEventManual(0, data, siswa))
 		return fmt.Sprintf("Absensi Masuk untuk %s berhasil!", siswa.NamaLengkap), nil
 
 	case "pulang":
@@ -289,11 +339,21 @@ func (uc *absensiUsecase) VerifyAndRecordScan(ctx context.Context, qrData string
 		}
 
 		// Update data absensi yang sudah ada
-		clockOutTime := time.Now().Format("15:04:05")
+		clockOutTime := uc.clock.Now().Format(clock.LayoutJam)
 		err = uc.absensiRepo.UpdateClockOut(ctx, existingLog.RowNumber, clockOutTime)
 		if err != nil {
 			return "", err
 		}
+		uc.terbitkan(domain.EventAbsensiPulang, domain.DataEventAbsensi{
+			RowNumber:       existingLog.RowNumber,
+			NISN:            siswa.NISN,
+			NamaLengkap:     siswa.NamaLengkap,
+			Kelas:           siswa.Kelas,
+			Status:          existingLog.Status,
+			Timestamp:       existingLog.Timestamp,
+			TimestampPulang: clockOutTime,
+			DicatatOleh:     existingLog.DicatatOleh,
+		})
 		return fmt.Sprintf("Absensi Pulang untuk %s berhasil!", siswa.NamaLengkap), nil
 	}
 
@@ -301,13 +361,38 @@ func (uc *absensiUsecase) VerifyAndRecordScan(ctx context.Context, qrData string
 
 }
 
-func (uc *absensiUsecase) GetAttendanceForUser(ctx context.Context, username string) ([]domain.LogAbsensi, error) {
-	// Logika bisnisnya sederhana, hanya meneruskan permintaan ke repository
-	return uc.absensiRepo.GetAttendanceForUser(ctx, username)
+// GetAttendanceForUser mengambil riwayat kehadiran untuk portal. Wali murid memilih anak lewat
+// nisn (kosong berarti anak pertama); akun lain melihat siswa yang tertaut ke akunnya.
+func (uc *absensiUsecase) GetAttendanceForUser(ctx context.Context, username, nisn string) ([]domain.LogAbsensi, error) {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("pengguna tidak ditemukan")
+	}
+	if user.Role == domain.RoleOrtu {
+		siswa, err := uc.resolveAnak(ctx, user, nisn)
+		if err != nil {
+			return nil, err
+		}
+		return uc.absensiRepo.GetAttendanceByNISN(ctx, siswa.NISN)
+	}
+	// Akun siswa: NISN tertaut, atau username itu sendiri untuk akun lama
+	return uc.absensiRepo.GetAttendanceByNISN(ctx, keteranganAtau(user.SiswaNISN, user.Username))
 }
 
 func (uc *absensiUsecase) GetAllLeaveRequests(ctx context.Context) ([]domain.PengajuanIzinLengkap, error) {
-	return uc.absensiRepo.GetAllLeaveRequests(ctx)
+	requests, err := uc.absensiRepo.GetAllLeaveRequests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nama yang diketik berbeda di Google Form dicocokkan ulang agar tidak hilang dari dashboard
+	if err := uc.rekonsiliasi.ResolveLeaveRequests(ctx, requests); err != nil {
+		log.Printf("WARNING: Gagal mencocokkan nama pengajuan izin: %v", err)
+	}
+	return requests, nil
 }
 
 func (uc *absensiUsecase) GetDashboardData(ctx context.Context, username string) (*domain.DashboardData, error) {
@@ -327,21 +412,37 @@ func (uc *absensiUsecase) GetDashboardData(ctx context.Context, username string)
 	if err != nil {
 		return nil, err
 	}
-
-	totalHadir := len(logHadir)
-	totalIzin := len(logIzin)
+	if err := uc.rekonsiliasi.ResolveLeaveRequests(ctx, logIzin); err != nil {
+		log.Printf("WARNING: Gagal mencocokkan nama pengajuan izin: %v", err)
+	}
 
 	data := &domain.DashboardData{
 		NamaLengkap:         user.NamaLengkap,
 		Username:            username,
 		TotalSiswa:          totalSiswa,
-		TotalHadirHariIni:   totalHadir,
-		TotalIzinHariIni:    totalIzin,
-		TotalBelumAdaKabar:  totalSiswa - totalHadir - totalIzin,
 		LogKehadiranHariIni: logHadir,
 		SiswaIzinHariIni:    logIzin,
 	}
 
+	// Hitungan dibaca dari status harian yang sama dengan dashboard pintar, sehingga baris
+	// Alpa hasil finalisasi tidak ikut terhitung hadir
+	today := clock.Today(uc.clock)
+	statusMap, err := uc.statusHarian(ctx, today, today)
+	if err != nil {
+		return nil, err
+	}
+	for _, perTanggal := range statusMap {
+		switch perTanggal[today].Status {
+		case domain.StatusHadir:
+			data.TotalHadirHariIni++
+		case domain.StatusIzin, domain.StatusSakit:
+			data.TotalIzinHariIni++
+		case domain.StatusAlpa:
+			data.TotalAlpaHariIni++
+		}
+	}
+	data.TotalBelumAdaKabar = max(totalSiswa-data.TotalHadirHariIni-data.TotalIzinHariIni-data.TotalAlpaHariIni, 0)
+
 	return data, nil
 }
 
@@ -405,18 +506,28 @@ func (uc *absensiUsecase) CreateManualAttendance(ctx context.Context, data *doma
 		if data.Timestamp == "" {
 			data.Timestamp = existingLog.Timestamp
 		}
-		return uc.absensiRepo.UpdateAttendance(ctx, existingLog.RowNumber, data)
+		if err := uc.absensiRepo.UpdateAttendance(ctx, existingLog.RowNumber, data); err != nil {
+			return err
+		}
+		uc.terbitkanPencatatan(domain.EventAbsensiDicatat, dataEventManual(existingLog.RowNumber, data, siswa))
+		return nil
 	}
 
 	// Jika log belum ada, lakukan CREATE (buat baris baru)
 	log.Printf("INFO: Log belum ada. Melakukan CREATE.")
 	if data.Timestamp == "" {
-		data.Timestamp = time.Now().Format("2006-01-02 15:04:05")
+		data.Timestamp = clock.Timestamp(uc.clock)
 	}
-	return uc.absensiRepo.CreateManualAttendance(ctx, data)
+	if err := uc.absensiRepo.CreateManualAttendance(ctx, data); err != nil {
+		return err
+	}
+	uc.terbitkanPencatatan(domain.EventAbsensiDicatat, dataEventManual(0, data, siswa))
+	return nil
 }
 
 func (uc *absensiUsecase) CreateBatchManualAttendance(ctx context.Context, data []domain.KehadiranManual) error {
+	// Siswa yang ditemukan, untuk isi event setelah data tersimpan
+	ditemukan := make(map[int]*domain.Siswa)
 	// Lakukan perulangan untuk setiap data siswa yang dikirim dari frontend
 	for i := range data {
 		// Ambil NamaSiswa berdasarkan NISN
@@ -427,34 +538,62 @@ func (uc *absensiUsecase) CreateBatchManualAttendance(ctx context.Context, data
 			log.Printf("WARNING: NISN siswa %s tidak ditemukan, data tidak dicatat.", data[i].NISN)
 			continue // Lanjutkan ke siswa berikutnya
 		}
+		ditemukan[i] = siswa
 		// Sisipkan data yang hilang
 		data[i].NamaSiswa = siswa.NamaLengkap
 		data[i].DicatatOleh = "Manual Wali Kelas (Massal)"
+		if data[i].Timestamp == "" {
+			data[i].Timestamp = clock.Timestamp(uc.clock)
+		}
 	}
 
 	// Kirim data yang sudah diperkaya ke repository
-	return uc.absensiRepo.CreateBatchManualAttendance(ctx, data)
+	if err := uc.absensiRepo.CreateBatchManualAttendance(ctx, data); err != nil {
+		return err
+	}
+	for i := range data {
+		if siswa, ok := ditemukan[i]; ok {
+			uc.terbitkanPencatatan(domain.EventAbsensiDicatat, dataEventManual(0, &data[i], siswa))
+		}
+	}
+	return nil
 }
 
 func (uc *absensiUsecase) GetMonthlyStats(ctx context.Context, year, month int) (*domain.StatistikData, error) {
-	allLogs, err := uc.absensiRepo.GetAllLogsInMonth(ctx, year, month)
+	awal := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, uc.clock.Location())
+	akhir := awal.AddDate(0, 1, -1)
+	statusMap, err := uc.statusHarian(ctx, awal.Format(clock.LayoutTanggal), akhir.Format(clock.LayoutTanggal))
+	if err != nil {
+		return nil, err
+	}
+	hari, err := uc.hariKalender(ctx, awal.Format(clock.LayoutTanggal), akhir.Format(clock.LayoutTanggal))
+	if err != nil {
+		return nil, err
+	}
+
+	allSiswa, err := uc.siswaRepo.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	// Hanya hari sekolah yang dihitung, sama seperti rekap dan portal. Siswa yang tidak lagi
+	// ada di DataSiswa tidak ikut dihitung agar pembagi persentase sesuai.
+	efektif := uc.hariEfektif(hari)
 	stats := &domain.StatistikData{}
-	for _, log := range allLogs {
-		switch strings.ToLower(log.Status) {
-		case "hadir":
-			stats.TotalHadir++
-		case "izin":
-			stats.TotalIzin++
-		case "sakit":
-			stats.TotalSakit++
-		case "alpa":
-			stats.TotalAlpa++
+	for _, siswa := range allSiswa {
+		rekap := domain.RekapSiswa{}
+		for tanggal, st := range statusMap[siswa.NISN] {
+			if hari[tanggal].HariSekolah {
+				stats.Tambah(st.Status)
+				rekap.Tambah(st.Status)
+			}
+		}
+		rekap.HitungPersentase(efektif, uc.ambang)
+		if rekap.DiBawahAmbang {
+			stats.JumlahDiBawahAmbang++
 		}
 	}
+	stats.HitungPersentase(efektif, len(allSiswa), uc.ambang)
 	return stats, nil
 }
 
@@ -464,163 +603,228 @@ func (uc *absensiUsecase) GetRekapByDateRange(ctx context.Context, startDate, en
 		return nil, err
 	}
 
-	hadirLogs, izinLogs, err := uc.absensiRepo.GetLogsByDateRange(ctx, startDate, endDate)
+	// Alpa dihitung dari record yang ditulis finalisasi, bukan dari selisih hari kerja,
+	// sehingga rekap sama dengan dashboard harian dan statistik bulanan
+	statusMap, err := uc.statusHarian(ctx, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
-
-	holidays, err := uc.absensiRepo.GetHolidays(ctx)
+	hari, err := uc.hariKalender(ctx, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
 
-	// --- LOGIKA BARU: HITUNG HARI KERJA ---
-	start, _ := time.Parse("2006-01-02", startDate)
-	end, _ := time.Parse("2006-01-02", endDate)
-	totalHariKerja := 0
-	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
-		dateStr := d.Format("2006-01-02")
-		isWeekend := d.Weekday() == time.Saturday || d.Weekday() == time.Sunday
-		isHoliday := holidays[dateStr]
-		if !isWeekend && !isHoliday {
-			totalHariKerja++
-		}
-	}
-	// --- AKHIR LOGIKA BARU ---
-
-	rekapMap := make(map[string]*domain.RekapSiswa)
+	efektif := uc.hariEfektif(hari)
+	var rekapList []domain.RekapSiswa
 	for _, siswa := range allSiswa {
-		rekapMap[siswa.NISN] = &domain.RekapSiswa{
+		rekap := domain.RekapSiswa{
 			NISN:        siswa.NISN,
 			NamaLengkap: siswa.NamaLengkap,
+			Kelas:       siswa.Kelas,
 		}
+		for tanggal, st := range statusMap[siswa.NISN] {
+			if !hari[tanggal].HariSekolah {
+				continue
+			}
+			rekap.Tambah(st.Status)
+		}
+		rekap.HitungPersentase(efektif, uc.ambang)
+		rekapList = append(rekapList, rekap)
 	}
 
-	for _, log := range hadirLogs {
-		if rekap, ok := rekapMap[log.Username]; ok {
-			rekap.Hadir++
-		}
+	return rekapList, nil
+}
+
+// GetLinkedChildren mengembalikan daftar anak yang tertaut ke akun wali murid
+func (uc *absensiUsecase) GetLinkedChildren(ctx context.Context, username string) ([]domain.AnakWali, error) {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
 	}
-	for _, log := range izinLogs {
-		if rekap, ok := rekapMap[log.SiswaNISN]; ok {
-			status := strings.ToLower(log.JenisIzin)
-			if status == "sakit" {
-				rekap.Sakit++
-			} else {
-				rekap.Izin++
-			}
+	if user == nil {
+		return nil, errors.New("pengguna tidak ditemukan")
+	}
+
+	relasi, err := daftarAnak(ctx, uc.relasiRepo, user)
+	if err != nil {
+		return nil, err
+	}
+	anak := []domain.AnakWali{}
+	for _, rel := range relasi {
+		siswa, err := uc.siswaRepo.FindByNISN(ctx, rel.SiswaNISN)
+		if err != nil {
+			return nil, err
 		}
+		if siswa == nil {
+			log.Printf("WARNING: Siswa %s yang tertaut ke %s tidak ada di DataSiswa", rel.SiswaNISN, username)
+			continue
+		}
+		anak = append(anak, domain.AnakWali{Siswa: *siswa, Hubungan: rel.Hubungan})
 	}
+	return anak, nil
+}
 
-	var rekapList []domain.RekapSiswa
-	for _, rekap := range rekapMap {
-		// Hitung Alpa
-		totalAbsenTercatat := rekap.Hadir + rekap.Izin + rekap.Sakit
-		rekap.Alpa = totalHariKerja - totalAbsenTercatat
-		if rekap.Alpa < 0 {
-			rekap.Alpa = 0
-		} // Pastikan tidak negatif
+// resolveAnak memilih siswa yang datanya ditampilkan di portal. Jika nisn kosong, dipakai anak
+// pertama; jika diisi, NISN tersebut harus benar-benar tertaut ke akun ini.
+func (uc *absensiUsecase) resolveAnak(ctx context.Context, user *domain.User, nisn string) (*domain.Siswa, error) {
+	relasi, err := daftarAnak(ctx, uc.relasiRepo, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(relasi) == 0 {
+		log.Println("WARNING: Akun ini tidak terhubung dengan data siswa.")
+		return nil, errors.New("akun ini tidak terhubung dengan data siswa")
+	}
 
-		rekapList = append(rekapList, *rekap)
+	target := relasi[0].SiswaNISN
+	if nisn != "" {
+		target = ""
+		for _, rel := range relasi {
+			if rel.SiswaNISN == nisn {
+				target = nisn
+				break
+			}
+		}
+		if target == "" {
+			log.Printf("WARNING: %s mencoba melihat data siswa %s yang tidak tertaut", user.Username, nisn)
+			return nil, domain.ErrAnakTidakTertaut
+		}
 	}
 
-	return rekapList, nil
+	siswa, err := uc.siswaRepo.FindByNISN(ctx, target)
+	if err != nil || siswa == nil {
+		log.Printf("ERROR saat mencari siswa dengan NISN %s: %v", target, err)
+		return nil, errors.New("data siswa terkait tidak ditemukan")
+	}
+	return siswa, nil
 }
 
-// --- TAMBAHKAN FUNGSI BARU INI ---
-func (uc *absensiUsecase) GetPortalDashboardData(ctx context.Context, username string, year, month int) (*domain.PortalDashboardData, error) {
+// GetPortalDashboardData mengumpulkan kalender dan statistik bulanan satu anak untuk portal wali murid
+func (uc *absensiUsecase) GetPortalDashboardData(ctx context.Context, username, nisn string, year, month int) (*domain.PortalDashboardData, error) {
 	log.Println("--- [USECASE START] GetPortalDashboardData ---")
 	log.Printf("Mencari data untuk user: %s, Tahun: %d, Bulan: %d", username, year, month)
 
-	// Langkah 1: Dapatkan data user (wali murid) dan NISN anaknya
+	// Langkah 1: Dapatkan data user (wali murid)
 	user, err := uc.userRepo.FindByUsername(ctx, username)
 	if err != nil || user == nil {
 		log.Printf("ERROR saat mencari user: %v", err)
 		return nil, errors.New("pengguna tidak ditemukan")
 	}
-	log.Printf("User ditemukan. Nama: %s, Role: %s, SiswaNISN: %s", user.NamaLengkap, user.Role, user.SiswaNISN)
-
-	if user.SiswaNISN == "" {
-		log.Println("WARNING: Akun ini tidak terhubung dengan data siswa (SiswaNISN kosong).")
-		return nil, errors.New("akun ini tidak terhubung dengan data siswa")
-	}
+	log.Printf("User ditemukan. Nama: %s, Role: %s", user.NamaLengkap, user.Role)
 
-	// Langkah 2: Dapatkan data siswa terkait
-	siswa, err := uc.siswaRepo.FindByNISN(ctx, user.SiswaNISN)
-	if err != nil || siswa == nil {
-		log.Printf("ERROR saat mencari siswa dengan NISN %s: %v", user.SiswaNISN, err)
-		return nil, errors.New("data siswa terkait tidak ditemukan")
+	// Langkah 2: Dapatkan data siswa (anak) yang dipilih
+	siswa, err := uc.resolveAnak(ctx, user, nisn)
+	if err != nil {
+		return nil, err
 	}
 	log.Printf("Siswa terkait ditemukan: %s", siswa.NamaLengkap)
 
-	hadirLogs, izinLogs, err := uc.absensiRepo.GetAllLogsForUserInMonth(ctx, siswa.NISN, year, month)
+	awal := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, uc.clock.Location())
+	akhir := awal.AddDate(0, 1, -1)
+	statusMap, err := uc.statusHarian(ctx, awal.Format(clock.LayoutTanggal), akhir.Format(clock.LayoutTanggal))
 	if err != nil {
-		log.Printf("ERROR saat mengambil log bulan ini: %v", err)
+		log.Printf("ERROR saat mengambil status bulan ini: %v", err)
 		return nil, err
 	}
-	log.Printf("Data ditemukan: %d log kehadiran, %d log izin.", len(hadirLogs), len(izinLogs))
+	statusAnak := statusMap[siswa.NISN]
+	log.Printf("Data ditemukan: %d hari dengan status.", len(statusAnak))
 
-	holidays, _ := uc.absensiRepo.GetHolidays(ctx)
+	hari, err := uc.hariKalender(ctx, awal.Format(clock.LayoutTanggal), akhir.Format(clock.LayoutTanggal))
+	if err != nil {
+		return nil, err
+	}
 
-	events := []domain.CalendarEvent{}
+	events := append(eventStatus(statusAnak), eventHari(hari, awal, akhir)...)
 	stats := &domain.StatistikData{}
+	for tanggal, st := range statusAnak {
+		if hari[tanggal].HariSekolah {
+			stats.Tambah(st.Status)
+		}
+	}
+	stats.HitungPersentase(uc.hariEfektif(hari), 1, uc.ambang)
 
-	for _, log := range hadirLogs {
-		// title := log.Status
-		// color := "#198754" // Warna hijau untuk hadir
+	log.Println("--- [USECASE END] Berhasil mengumpulkan data. ---")
 
-		// 1. Selalu buat event untuk "Hadir"
+	return &domain.PortalDashboardData{
+		Siswa:          siswa,
+		Events:         events,
+		StatistikBulan: stats,
+	}, nil
+}
+
+// GetKalenderWali mengumpulkan event kehadiran semua anak wali murid dan event hari libur
+// dalam rentang tanggal, untuk feed kalender (.ics) wali murid
+func (uc *absensiUsecase) GetKalenderWali(ctx context.Context, username, startDate, endDate string) ([]domain.KalenderAnak, []domain.CalendarEvent, error) {
+	anak, err := uc.GetLinkedChildren(ctx, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	awal, err := clock.ParseTanggal(uc.clock, startDate)
+	if err != nil {
+		return nil, nil, domain.ErrRentangTanggal
+	}
+	akhir, err := clock.ParseTanggal(uc.clock, endDate)
+	if err != nil {
+		return nil, nil, domain.ErrRentangTanggal
+	}
+	statusMap, err := uc.statusHarian(ctx, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	hari, err := uc.hariKalender(ctx, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kalender := []domain.KalenderAnak{}
+	for _, a := range anak {
+		kalender = append(kalender, domain.KalenderAnak{Siswa: a.Siswa, Events: eventStatus(statusMap[a.NISN])})
+	}
+	return kalender, eventHari(hari, awal, akhir), nil
+}
+
+// eventStatus membuat event kalender dari status harian satu anak, termasuk jam pulang
+func eventStatus(statusAnak map[string]domain.StatusHarian) []domain.CalendarEvent {
+	events := []domain.CalendarEvent{}
+	for _, tanggal := range urutTanggal(statusAnak) {
+		st := statusAnak[tanggal]
 		events = append(events, domain.CalendarEvent{
-			Title: "Hadir",
-			Start: strings.Split(log.Timestamp, " ")[0],
-			Color: "#198754", // Warna hijau
+			Title: st.Status,
+			Start: tanggal,
+			Color: warnaStatus(st.Status),
 		})
 
 		// Cek apakah ada data jam pulang
-		if log.TimestampPulang != "" {
+		if st.TimestampPulang != "" {
 			title := "Pulang"
 			// Coba format jamnya menjadi HH:MM
-			t, err := time.Parse("15:04:05", log.TimestampPulang)
+			t, err := time.Parse(clock.LayoutJam, st.TimestampPulang)
 			if err == nil {
 				title = fmt.Sprintf("Pulang (%s)", t.Format("15:04"))
 			}
 			events = append(events, domain.CalendarEvent{
 				Title: title,
-				Start: strings.Split(log.Timestamp, " ")[0],
+				Start: tanggal,
 				Color: "#0d6efd",
 			})
 		}
-		stats.TotalHadir++
 	}
-	for _, log := range izinLogs {
-		events = append(events, domain.CalendarEvent{
-			Title: log.JenisIzin, Start: log.TanggalMulai, Color: "#ffc107",
-		})
-		if strings.ToLower(log.JenisIzin) == "sakit" {
-			stats.TotalSakit++
-		} else {
-			stats.TotalIzin++
-		}
-	}
-
-	// Tambahkan hari libur ke event kalender
-	firstDayOfMonth := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
+	return events
+}
 
-	for d := firstDayOfMonth; !d.After(lastDayOfMonth); d = d.AddDate(0, 0, 1) {
-		dateStr := d.Format("2006-01-02")
-		if holidays[dateStr] {
-			events = append(events, domain.CalendarEvent{Title: "Libur Nasional", Start: dateStr, Color: "#6c757d"})
-		} else if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
-			events = append(events, domain.CalendarEvent{Title: "Akhir Pekan", Start: dateStr, Color: "#adb5bd"})
+// eventHari membuat event kalender untuk hari libur dan hari sekolah khusus (setengah hari)
+func eventHari(hari map[string]domain.HariKalender, awal, akhir time.Time) []domain.CalendarEvent {
+	events := []domain.CalendarEvent{}
+	for d := awal; !d.After(akhir); d = d.AddDate(0, 0, 1) {
+		h := hari[d.Format(clock.LayoutTanggal)]
+		switch {
+		case !h.HariSekolah && h.Keterangan == domain.KeteranganAkhirPekan:
+			events = append(events, domain.CalendarEvent{Title: h.Keterangan, Start: h.Tanggal, Color: "#adb5bd"})
+		case !h.HariSekolah:
+			events = append(events, domain.CalendarEvent{Title: h.Keterangan, Start: h.Tanggal, Color: "#6c757d"})
+		case h.SetengahHari:
+			events = append(events, domain.CalendarEvent{Title: h.Keterangan, Start: h.Tanggal, Color: "#0dcaf0"})
 		}
 	}
-
-	log.Println("--- [USECASE END] Berhasil mengumpulkan data. ---")
-
-	return &domain.PortalDashboardData{
-		Siswa:          siswa,
-		Events:         events,
-		StatistikBulan: stats,
-	}, nil
+	return events
 }
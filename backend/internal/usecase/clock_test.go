@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+func TestClockAtauSistemMemakaiZonaSekolah(t *testing.T) {
+	asal := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = asal }()
+
+	c := clockAtauSistem(nil)
+	if c.Location().String() != clock.DefaultTimezone {
+		t.Fatalf("zona waktu bawaan = %s, want %s", c.Location(), clock.DefaultTimezone)
+	}
+}
+
+func TestPencatatanManualSekitarTengahMalam(t *testing.T) {
+	asal := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = asal }()
+	loc, _ := clock.LoadLocation(clock.DefaultTimezone)
+
+	tests := []struct {
+		name    string
+		utc     time.Time
+		tanggal string
+	}{
+		{"23:59 WIB", time.Date(2025, 3, 10, 16, 59, 0, 0, time.UTC), "2025-03-10 23:59"},
+		{"00:01 WIB", time.Date(2025, 3, 10, 17, 1, 0, 0, time.UTC), "2025-03-11 00:01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			absensi := &absensiRepoUji{}
+			siswa := &siswaRepoUji{siswa: []domain.Siswa{{NISN: "001", NamaLengkap: "Ahmad", Kelas: "7A"}}}
+			uc := NewAbsensiUsecase(absensi, siswa, newUserRepoUji(), &relasiRepoUji{}, nil, nil, nil, nil, AbsensiUsecaseConfig{
+				Clock: clock.Fixed(tt.utc.In(loc)),
+			})
+
+			err := uc.CreateBatchManualAttendance(context.Background(), []domain.KehadiranManual{{NISN: "001", Status: domain.StatusHadir}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(absensi.logs) != 1 || !strings.HasPrefix(absensi.logs[0].Timestamp, tt.tanggal) {
+				t.Fatalf("timestamp = %v, want diawali %s (WIB, bukan UTC)", absensi.logs, tt.tanggal)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// digestRepoUji meniru sheet RiwayatDigest beserta urutan append klaimnya
+type digestRepoUji struct {
+	mu    sync.Mutex
+	baris []domain.RiwayatDigest
+	klaim []string
+	// Jika diisi, FindAll menunggu sampai semua replika selesai membaca riwayat
+	serentak *sync.WaitGroup
+}
+
+func (r *digestRepoUji) FindAll(ctx context.Context) ([]domain.RiwayatDigest, error) {
+	r.mu.Lock()
+	hasil := []domain.RiwayatDigest{}
+	for i, d := range r.baris {
+		if d.Tanggal != "" {
+			d.RowNumber = i + 2
+			hasil = append(hasil, d)
+		}
+	}
+	serentak := r.serentak
+	r.mu.Unlock()
+	if serentak != nil {
+		serentak.Done()
+		serentak.Wait()
+	}
+	return hasil, nil
+}
+
+func (r *digestRepoUji) Save(ctx context.Context, d *domain.RiwayatDigest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d.RowNumber >= 2 {
+		r.baris[d.RowNumber-2] = *d
+		return nil
+	}
+	r.baris = append(r.baris, *d)
+	r.klaim = append(r.klaim, "")
+	return nil
+}
+
+func (r *digestRepoUji) Klaim(ctx context.Context, d *domain.RiwayatDigest, klaimID string) (bool, error) {
+	r.mu.Lock()
+	r.baris = append(r.baris, *d)
+	r.klaim = append(r.klaim, klaimID)
+	r.mu.Unlock()
+	// Beri kesempatan replika lain menambah klaim sebelum dibaca ulang
+	time.Sleep(time.Millisecond)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pertama := -1
+	for i, b := range r.baris {
+		if b.Tanggal != d.Tanggal {
+			continue
+		}
+		if pertama < 0 {
+			pertama = i
+		}
+		if r.klaim[i] == klaimID {
+			if i == pertama {
+				d.RowNumber = i + 2
+				return true, nil
+			}
+			r.baris[i], r.klaim[i] = domain.RiwayatDigest{}, ""
+			return false, nil
+		}
+	}
+	return false, errors.New("klaim tidak ditemukan")
+}
+
+func (r *digestRepoUji) Delete(ctx context.Context, rowNumber int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.baris[rowNumber-2], r.klaim[rowNumber-2] = domain.RiwayatDigest{}, ""
+	return nil
+}
+
+// dashboardUji mengembalikan status siswa yang sama untuk setiap tanggal
+type dashboardUji struct {
+	domain.AbsensiUsecase
+	mu    sync.Mutex
+	siswa []domain.SiswaStatus
+	gagal error
+}
+
+func (d *dashboardUji) GetSmartDashboardData(ctx context.Context, username, dateStr string) (*domain.SmartDashboardData, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.gagal != nil {
+		return nil, d.gagal
+	}
+	return &domain.SmartDashboardData{TotalSiswa: len(d.siswa), TotalBelumAdaKabar: len(d.siswa), DaftarStatusSiswa: d.siswa}, nil
+}
+
+// mailerUji mencatat alamat tujuan setiap email
+type mailerUji struct {
+	mu     sync.Mutex
+	tujuan []string
+}
+
+func (m *mailerUji) Send(ctx context.Context, msg domain.EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tujuan = append(m.tujuan, msg.To)
+	return nil
+}
+
+func (m *mailerUji) jumlah() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.tujuan)
+}
+
+func penggunaDigestUji() *userRepoUji {
+	return newUserRepoUji(
+		domain.User{Username: "admin", Role: domain.RoleAdmin, Email: "admin@example.com"},
+		domain.User{Username: "admin.lama", Role: domain.RoleAdmin, Disabled: true},
+		domain.User{Username: "ustadzah.aisyah", Role: domain.RoleWaliKelas, Email: "aisyah@example.com"},
+		domain.User{Username: "ustadz.hasan", Role: domain.RoleWaliKelas, Email: "hasan@example.com"},
+		domain.User{Username: "ustadz.cuti", Role: domain.RoleWaliKelas, Disabled: true},
+		domain.User{Username: "ortu1", Role: domain.RoleOrtu},
+	)
+}
+
+func TestDigestPenerima(t *testing.T) {
+	tests := []struct {
+		name      string
+		waliKelas []string
+		ingin     []string
+	}{
+		{"wali kelas aktif", []string{"ustadzah.aisyah", "ustadz.hasan"}, []string{"ustadzah.aisyah", "ustadz.hasan"}},
+		{"tidak diatur", nil, []string{"admin"}},
+		{"sebagian nonaktif", []string{"ustadz.cuti", "ustadz.hasan"}, []string{"ustadz.hasan"}},
+		{"semua nonaktif", []string{"ustadz.cuti"}, []string{"admin"}},
+		{"bukan wali kelas", []string{"ortu1"}, []string{"admin"}},
+		{"akun tidak ada", []string{"salah.ketik"}, []string{"admin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			absensi := &dashboardUji{siswa: []domain.SiswaStatus{{NISN: "0012345678", NamaLengkap: "Ahmad", Kelas: "7A", Status: domain.StatusBelumAdaKabar}}}
+			cfg := DigestUsecaseConfig{WaliKelas: map[string][]string{"7A": tt.waliKelas}}
+			uc := NewDigestUsecase(&digestRepoUji{}, absensi, penggunaDigestUji(), &mailerUji{}, nil, cfg)
+			digest, err := uc.Pratinjau(context.Background(), "2026-03-10")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(digest.Kelas) != 1 || !reflect.DeepEqual(digest.Kelas[0].Penerima, tt.ingin) {
+				t.Errorf("kelas = %+v, ingin penerima %v", digest.Kelas, tt.ingin)
+			}
+		})
+	}
+}
+
+func TestPeriksaWaliKelas(t *testing.T) {
+	tests := []struct {
+		name      string
+		waliKelas map[string][]string
+		valid     bool
+	}{
+		{"kosong", nil, true},
+		{"wali kelas", map[string][]string{"7A": {"ustadzah.aisyah"}, "7B": {"ustadz.hasan"}}, true},
+		// Akun nonaktif hanya diperingatkan karena bisa diaktifkan lagi tanpa restart
+		{"nonaktif", map[string][]string{"7A": {"ustadz.cuti"}}, true},
+		{"akun tidak ada", map[string][]string{"7A": {"ustadzah.aisyah", "salah.ketik"}}, false},
+		{"admin", map[string][]string{"7A": {"admin"}}, false},
+		{"wali murid", map[string][]string{"7A": {"ortu1"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewDigestUsecase(&digestRepoUji{}, &dashboardUji{}, penggunaDigestUji(), &mailerUji{}, nil, DigestUsecaseConfig{WaliKelas: tt.waliKelas})
+			err := uc.PeriksaWaliKelas(context.Background())
+			if (err == nil) != tt.valid {
+				t.Fatalf("err = %v, ingin valid = %v", err, tt.valid)
+			}
+			if err != nil && !errors.Is(err, domain.ErrDigestWaliKelasTidakValid) {
+				t.Errorf("err = %v, ingin ErrDigestWaliKelasTidakValid", err)
+			}
+		})
+	}
+}
+
+// replikaDigest membuat beberapa instance yang berbagi sheet dan mailer yang sama
+func replikaDigest(n int, absensi *dashboardUji) ([]domain.DigestUsecase, *digestRepoUji, *mailerUji) {
+	repo, mailer := &digestRepoUji{}, &mailerUji{}
+	jam := &jamUji{t: time.Date(2026, 3, 10, 8, 5, 0, 0, time.UTC)}
+	cfg := DigestUsecaseConfig{
+		Clock:      jam,
+		Jam:        8 * time.Hour,
+		AlpaCutoff: 18 * time.Hour,
+		WaliKelas:  map[string][]string{"7A": {"ustadzah.aisyah"}},
+	}
+	users := penggunaDigestUji()
+	var replika []domain.DigestUsecase
+	for i := 0; i < n; i++ {
+		replika = append(replika, NewDigestUsecase(repo, absensi, users, mailer, nil, cfg))
+	}
+	return replika, repo, mailer
+}
+
+func TestDigestTerjadwalSekaliUntukSemuaReplika(t *testing.T) {
+	absensi := &dashboardUji{siswa: []domain.SiswaStatus{{NISN: "0012345678", NamaLengkap: "Ahmad", Kelas: "7A", Status: domain.StatusBelumAdaKabar}}}
+	replika, repo, mailer := replikaDigest(3, absensi)
+	// Semua replika melihat riwayat yang masih kosong sebelum ada yang mengirim
+	repo.serentak = &sync.WaitGroup{}
+	repo.serentak.Add(len(replika))
+
+	var wg sync.WaitGroup
+	for _, uc := range replika {
+		wg.Add(1)
+		go func(uc domain.DigestUsecase) {
+			defer wg.Done()
+			if _, err := uc.KirimTerjadwal(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}(uc)
+	}
+	wg.Wait()
+	repo.mu.Lock()
+	repo.serentak = nil
+	repo.mu.Unlock()
+
+	if n := mailer.jumlah(); n != 1 {
+		t.Errorf("email terkirim = %d, ingin 1", n)
+	}
+	riwayat, _ := repo.FindAll(context.Background())
+	if len(riwayat) != 1 || riwayat[0].EmailTerkirim != 1 || riwayat[0].DikirimOleh != domain.DigestOlehSistem {
+		t.Errorf("riwayat = %+v", riwayat)
+	}
+}
+
+func TestDigestTerjadwalGagalMelepasKlaim(t *testing.T) {
+	absensi := &dashboardUji{
+		siswa: []domain.SiswaStatus{{NISN: "0012345678", NamaLengkap: "Ahmad", Kelas: "7A", Status: domain.StatusBelumAdaKabar}},
+		gagal: errors.New("sheet tidak bisa dibaca"),
+	}
+	replika, repo, mailer := replikaDigest(2, absensi)
+
+	if _, err := replika[0].KirimTerjadwal(context.Background()); err == nil {
+		t.Fatal("galat membaca dashboard tidak diteruskan")
+	}
+	if riwayat, _ := repo.FindAll(context.Background()); len(riwayat) != 0 {
+		t.Fatalf("klaim yang gagal masih tercatat: %+v", riwayat)
+	}
+
+	// Replika lain mengambil alih setelah sheet pulih
+	absensi.mu.Lock()
+	absensi.gagal = nil
+	absensi.mu.Unlock()
+	hasil, err := replika[1].KirimTerjadwal(context.Background())
+	if err != nil || hasil == nil {
+		t.Fatalf("hasil = %v, err = %v", hasil, err)
+	}
+	if n := mailer.jumlah(); n != 1 {
+		t.Errorf("email terkirim = %d, ingin 1", n)
+	}
+}
@@ -0,0 +1,392 @@
+// file: internal/usecase/digest_usecase.go
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/laporan"
+)
+
+// DigestRepository menyimpan riwayat pengiriman ringkasan pagi agar ringkasan terjadwal tidak
+// terkirim dua kali setelah server restart
+type DigestRepository interface {
+	FindAll(ctx context.Context) ([]domain.RiwayatDigest, error)
+	// Save menambah baris baru (RowNumber 0) atau menimpa baris yang ada
+	Save(ctx context.Context, r *domain.RiwayatDigest) error
+	// Klaim menambah baris riwayat untuk r.Tanggal sebelum ringkasan terjadwal dikirim dan
+	// mengembalikan true hanya jika baris itu yang pertama untuk tanggal tersebut, sehingga
+	// hanya satu replika server yang mengirim. Nomor baris pemenang diisi ke r; baris yang
+	// kalah dihapus lagi. Harus aman dipanggil bersamaan dari beberapa proses.
+	Klaim(ctx context.Context, r *domain.RiwayatDigest, klaimID string) (bool, error)
+	Delete(ctx context.Context, rowNumber int) error
+}
+
+// DigestUsecaseConfig berisi jadwal dan penerima ringkasan pagi
+type DigestUsecaseConfig struct {
+	// Jam di zona waktu sekolah; nil berarti jam sistem di clock.DefaultTimezone
+	Clock clock.Clock
+	// Lama setelah tengah malam saat ringkasan terjadwal dikirim; 0 berarti jadwal mati
+	Jam time.Duration
+	// Setelah batas jam Alpa siswa tanpa kabar sudah dicatat Alpa, jadi ringkasan terjadwal
+	// yang terlewat (misal server mati pagi hari) tidak dikirim lagi
+	AlpaCutoff time.Duration
+	// Username wali kelas per kelas; kelas di luar daftar, atau yang semua akun wali kelasnya
+	// nonaktif, dikirim ke admin
+	WaliKelas map[string][]string
+	// Kirim permintaan konfirmasi ke orang tua lewat notifikasi
+	KirimOrtu bool
+	// Alamat publik aplikasi untuk tautan dashboard di email
+	PublicBaseURL string
+}
+
+type digestUsecase struct {
+	repo          DigestRepository
+	absensi       domain.AbsensiUsecase
+	userRepo      UserRepository
+	mailer        Mailer
+	notifikasi    domain.NotifikasiUsecase
+	clock         clock.Clock
+	jam           time.Duration
+	alpaCutoff    time.Duration
+	waliKelas     map[string][]string
+	kirimOrtu     bool
+	publicBaseURL string
+
+	mu       sync.Mutex
+	terakhir string // Tanggal terakhir yang sudah diputuskan oleh jadwal
+}
+
+// NewDigestUsecase adalah "pabrik" untuk usecase ringkasan pagi. notifikasi boleh nil jika
+// orang tua tidak perlu dihubungi.
+func NewDigestUsecase(repo DigestRepository, absensi domain.AbsensiUsecase, userRepo UserRepository, mailer Mailer, notifikasi domain.NotifikasiUsecase, cfg DigestUsecaseConfig) domain.DigestUsecase {
+	return &digestUsecase{
+		repo:          repo,
+		absensi:       absensi,
+		userRepo:      userRepo,
+		mailer:        mailer,
+		notifikasi:    notifikasi,
+		clock:         clockAtauSistem(cfg.Clock),
+		jam:           cfg.Jam,
+		alpaCutoff:    cfg.AlpaCutoff,
+		waliKelas:     cfg.WaliKelas,
+		kirimOrtu:     cfg.KirimOrtu,
+		publicBaseURL: cfg.PublicBaseURL,
+	}
+}
+
+// penerimaKelas mengembalikan fungsi penerima ringkasan per kelas: akun wali kelas aktif dari
+// konfigurasi, atau admin aktif jika kelas itu tidak punya
+func (uc *digestUsecase) penerimaKelas(ctx context.Context) (func(kelas string) []string, error) {
+	users, err := uc.userRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	akun := make(map[string]domain.User, len(users))
+	admin := []string{}
+	for _, u := range users {
+		akun[u.Username] = u
+		if u.Role == domain.RoleAdmin && !u.Disabled {
+			admin = append(admin, u.Username)
+		}
+	}
+	return func(kelas string) []string {
+		penerima := []string{}
+		for _, username := range uc.waliKelas[kelas] {
+			if u, ada := akun[username]; ada && u.Role == domain.RoleWaliKelas && !u.Disabled {
+				penerima = append(penerima, username)
+			}
+		}
+		if len(penerima) == 0 {
+			return admin
+		}
+		return penerima
+	}, nil
+}
+
+func (uc *digestUsecase) PeriksaWaliKelas(ctx context.Context) error {
+	if len(uc.waliKelas) == 0 {
+		return nil
+	}
+	users, err := uc.userRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	akun := make(map[string]domain.User, len(users))
+	for _, u := range users {
+		akun[u.Username] = u
+	}
+	daftarKelas := make([]string, 0, len(uc.waliKelas))
+	for kelas := range uc.waliKelas {
+		daftarKelas = append(daftarKelas, kelas)
+	}
+	sort.Strings(daftarKelas)
+
+	var salah []string
+	for _, kelas := range daftarKelas {
+		for _, username := range uc.waliKelas[kelas] {
+			u, ada := akun[username]
+			switch {
+			case !ada:
+				salah = append(salah, fmt.Sprintf("kelas %s: akun %q tidak ditemukan", kelas, username))
+			case u.Role != domain.RoleWaliKelas:
+				salah = append(salah, fmt.Sprintf("kelas %s: akun %q berperan %s, bukan %s", kelas, username, u.Role, domain.RoleWaliKelas))
+			case u.Disabled:
+				// Bisa diaktifkan lagi tanpa restart; selama nonaktif ringkasannya ke admin
+				log.Printf("WARNING: Wali kelas %s untuk ringkasan pagi kelas %s sedang nonaktif", username, kelas)
+			}
+		}
+	}
+	if len(salah) > 0 {
+		return fmt.Errorf("%w: %s", domain.ErrDigestWaliKelasTidakValid, strings.Join(salah, "; "))
+	}
+	return nil
+}
+
+func (uc *digestUsecase) Pratinjau(ctx context.Context, tanggal string) (*domain.DigestHarian, error) {
+	if tanggal == "" {
+		tanggal = clock.Today(uc.clock)
+	}
+	if _, err := clock.ParseTanggal(uc.clock, tanggal); err != nil {
+		return nil, fmt.Errorf("%w: tanggal harus berformat YYYY-MM-DD", domain.ErrRentangTanggal)
+	}
+	// Status siswa memakai logika yang sama dengan dashboard wali kelas
+	data, err := uc.absensi.GetSmartDashboardData(ctx, "", tanggal)
+	if err != nil {
+		return nil, err
+	}
+	digest := &domain.DigestHarian{
+		Tanggal:            tanggal,
+		IsHoliday:          data.IsHoliday,
+		HolidayDescription: data.HolidayDescription,
+		TotalSiswa:         data.TotalSiswa,
+		TotalBelumAdaKabar: data.TotalBelumAdaKabar,
+		Kelas:              []domain.DigestKelas{},
+	}
+	if data.IsHoliday {
+		return digest, nil
+	}
+
+	perKelas := make(map[string][]domain.SiswaStatus)
+	for _, s := range data.DaftarStatusSiswa {
+		if s.Status == domain.StatusBelumAdaKabar {
+			perKelas[s.Kelas] = append(perKelas[s.Kelas], s)
+		}
+	}
+	if len(perKelas) == 0 {
+		return digest, nil
+	}
+	penerima, err := uc.penerimaKelas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for kelas, daftar := range perKelas {
+		sort.Slice(daftar, func(i, j int) bool { return daftar[i].NamaLengkap < daftar[j].NamaLengkap })
+		digest.Kelas = append(digest.Kelas, domain.DigestKelas{Kelas: kelas, Penerima: penerima(kelas), BelumAdaKabar: daftar})
+	}
+	sort.Slice(digest.Kelas, func(i, j int) bool { return digest.Kelas[i].Kelas < digest.Kelas[j].Kelas })
+	return digest, nil
+}
+
+func (uc *digestUsecase) Kirim(ctx context.Context, by string) (*domain.HasilDigest, error) {
+	return uc.kirim(ctx, by, 0)
+}
+
+// kirim mengirim ringkasan hari ini; rowNumber adalah baris klaim pengiriman terjadwal yang
+// ditimpa dengan hasilnya, atau 0 untuk menambah baris riwayat baru
+func (uc *digestUsecase) kirim(ctx context.Context, by string, rowNumber int) (*domain.HasilDigest, error) {
+	now := uc.clock.Now()
+	digest, err := uc.Pratinjau(ctx, now.Format(clock.LayoutTanggal))
+	if err != nil {
+		return nil, err
+	}
+	if digest.IsHoliday {
+		return nil, domain.ErrDigestHariLibur
+	}
+
+	hasil := &domain.HasilDigest{
+		DigestHarian: *digest,
+		Riwayat: domain.RiwayatDigest{
+			RowNumber:          rowNumber,
+			Tanggal:            digest.Tanggal,
+			DikirimPada:        now,
+			DikirimOleh:        by,
+			JumlahKelas:        len(digest.Kelas),
+			TotalBelumAdaKabar: digest.TotalBelumAdaKabar,
+		},
+		Gagal: []string{},
+	}
+
+	// Orang tua dihubungi lebih dulu agar email guru bisa menyebutkannya
+	if uc.kirimOrtu && uc.notifikasi != nil {
+		var daftar []domain.SiswaStatus
+		for _, k := range digest.Kelas {
+			daftar = append(daftar, k.BelumAdaKabar...)
+		}
+		hasil.Riwayat.PesanOrtu = uc.notifikasi.MintaKonfirmasi(ctx, daftar)
+	}
+
+	// Satu email per penerima berisi semua kelasnya, agar admin yang menampung banyak kelas
+	// tidak menerima email bertubi-tubi
+	kelasPenerima := make(map[string][]domain.DigestKelas)
+	var urutan []string
+	for _, k := range digest.Kelas {
+		for _, username := range k.Penerima {
+			if _, ada := kelasPenerima[username]; !ada {
+				urutan = append(urutan, username)
+			}
+			kelasPenerima[username] = append(kelasPenerima[username], k)
+		}
+	}
+	for _, username := range urutan {
+		if err := uc.kirimEmail(ctx, username, kelasPenerima[username], now, hasil.Riwayat.PesanOrtu > 0); err != nil {
+			log.Printf("WARNING: Ringkasan pagi untuk %s gagal dikirim: %v", username, err)
+			hasil.Gagal = append(hasil.Gagal, fmt.Sprintf("%s: %v", username, err))
+			hasil.Riwayat.EmailGagal++
+			continue
+		}
+		hasil.Riwayat.EmailTerkirim++
+	}
+
+	if err := uc.repo.Save(ctx, &hasil.Riwayat); err != nil {
+		log.Printf("WARNING: Gagal mencatat riwayat ringkasan pagi: %v", err)
+	}
+	log.Printf("INFO: Ringkasan pagi %s dikirim oleh %s: %d siswa belum ada kabar di %d kelas, %d email, %d orang tua",
+		digest.Tanggal, by, digest.TotalBelumAdaKabar, len(digest.Kelas), hasil.Riwayat.EmailTerkirim, hasil.Riwayat.PesanOrtu)
+	return hasil, nil
+}
+
+// barisDigest adalah satu baris tabel siswa di email ringkasan
+type barisDigest struct {
+	No          int
+	NamaLengkap string
+	NISN        string
+}
+
+// kelasDigest adalah satu kelas di email ringkasan
+type kelasDigest struct {
+	Kelas string
+	Siswa []barisDigest
+}
+
+func (uc *digestUsecase) kirimEmail(ctx context.Context, username string, daftar []domain.DigestKelas, now time.Time, ortuDihubungi bool) error {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Disabled {
+		return fmt.Errorf("akun tidak ditemukan atau nonaktif")
+	}
+	if user.Email == "" {
+		return fmt.Errorf("akun belum punya email")
+	}
+
+	kelas := make([]kelasDigest, 0, len(daftar))
+	for _, k := range daftar {
+		kd := kelasDigest{Kelas: k.Kelas}
+		for i, s := range k.BelumAdaKabar {
+			kd.Siswa = append(kd.Siswa, barisDigest{No: i + 1, NamaLengkap: s.NamaLengkap, NISN: s.NISN})
+		}
+		kelas = append(kelas, kd)
+	}
+	tanggal := laporan.TanggalIndonesia(now.Format(clock.LayoutTanggal))
+	msg, err := renderEmail("ringkasan_pagi", user.Email, "Ringkasan Pagi Siswa Belum Ada Kabar - "+tanggal, map[string]interface{}{
+		"NamaLengkap":   keteranganAtau(user.NamaLengkap, user.Username),
+		"Hari":          namaHariIndonesia[now.Weekday()],
+		"Tanggal":       tanggal,
+		"Jam":           now.Format("15:04"),
+		"Kelas":         kelas,
+		"OrtuDihubungi": ortuDihubungi,
+		"DashboardURL":  uc.publicBaseURL + "/dashboard",
+	})
+	if err != nil {
+		return err
+	}
+	return uc.mailer.Send(ctx, msg)
+}
+
+func (uc *digestUsecase) KirimTerjadwal(ctx context.Context) (*domain.HasilDigest, error) {
+	if uc.jam <= 0 || !uc.mu.TryLock() {
+		return nil, nil
+	}
+	defer uc.mu.Unlock()
+
+	now := uc.clock.Now()
+	tanggal := now.Format(clock.LayoutTanggal)
+	if uc.terakhir == tanggal {
+		return nil, nil
+	}
+	tengahMalam := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	lewat := now.Sub(tengahMalam)
+	if lewat < uc.jam {
+		return nil, nil
+	}
+	if uc.alpaCutoff > 0 && lewat >= uc.alpaCutoff {
+		uc.terakhir = tanggal
+		return nil, nil
+	}
+
+	riwayat, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range riwayat {
+		if r.Tanggal == tanggal {
+			// Sudah dikirim (oleh jadwal sebelum restart, replika lain, atau admin)
+			uc.terakhir = tanggal
+			return nil, nil
+		}
+	}
+
+	// Klaim tanggal ini sebelum mengirim agar replika lain yang memeriksa riwayat bersamaan
+	// tidak ikut mengirim
+	klaimID, err := randomToken(8)
+	if err != nil {
+		return nil, err
+	}
+	klaim := &domain.RiwayatDigest{Tanggal: tanggal, DikirimPada: now, DikirimOleh: domain.DigestOlehSistem}
+	ok, err := uc.repo.Klaim(ctx, klaim, klaimID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		uc.terakhir = tanggal
+		return nil, nil
+	}
+
+	hasil, err := uc.kirim(ctx, domain.DigestOlehSistem, klaim.RowNumber)
+	if err != nil {
+		// Belum ada yang terkirim: lepas klaim agar tanggal ini bisa dicoba lagi dan tidak
+		// tercatat di riwayat
+		if errHapus := uc.repo.Delete(ctx, klaim.RowNumber); errHapus != nil {
+			log.Printf("WARNING: Gagal melepas klaim ringkasan pagi %s: %v", tanggal, errHapus)
+		}
+		if errors.Is(err, domain.ErrDigestHariLibur) {
+			uc.terakhir = tanggal
+			return nil, nil
+		}
+		return nil, err
+	}
+	uc.terakhir = tanggal
+	return hasil, nil
+}
+
+func (uc *digestUsecase) GetRiwayat(ctx context.Context) ([]domain.RiwayatDigest, error) {
+	list, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hasil := make([]domain.RiwayatDigest, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		hasil = append(hasil, list[i])
+	}
+	return hasil, nil
+}
@@ -0,0 +1,42 @@
+// file: internal/usecase/email_template.go
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// Mailer adalah kontrak pengirim email (SMTP, file/log untuk pengembangan, dll.)
+type Mailer interface {
+	Send(ctx context.Context, msg domain.EmailMessage) error
+}
+
+//go:embed templates/email/*
+var emailTemplateFS embed.FS
+
+var (
+	emailTextTemplates = texttemplate.Must(texttemplate.ParseFS(emailTemplateFS, "templates/email/*.txt"))
+	emailHTMLTemplates = htmltemplate.Must(htmltemplate.ParseFS(emailTemplateFS, "templates/email/*.html"))
+)
+
+// renderEmail menyusun email dari pasangan template <name>.txt dan <name>.html
+func renderEmail(name, to, subject string, data interface{}) (domain.EmailMessage, error) {
+	var text, html bytes.Buffer
+	if err := emailTextTemplates.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return domain.EmailMessage{}, err
+	}
+	if err := emailHTMLTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return domain.EmailMessage{}, err
+	}
+	return domain.EmailMessage{
+		To:       to,
+		Subject:  subject,
+		TextBody: text.String(),
+		HTMLBody: html.String(),
+	}, nil
+}
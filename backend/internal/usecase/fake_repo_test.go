@@ -0,0 +1,546 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// jamUji adalah Clock yang bisa dimajukan dari dalam pengujian
+type jamUji struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (j *jamUji) Now() time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.t
+}
+
+func (j *jamUji) Location() *time.Location { return j.t.Location() }
+
+func (j *jamUji) maju(d time.Duration) {
+	j.mu.Lock()
+	j.t = j.t.Add(d)
+	j.mu.Unlock()
+}
+
+// userRepoUji menyimpan pengguna di memori
+type userRepoUji struct {
+	mu    sync.Mutex
+	users map[string]domain.User
+}
+
+func newUserRepoUji(users ...domain.User) *userRepoUji {
+	r := &userRepoUji{users: make(map[string]domain.User)}
+	for _, u := range users {
+		r.users[u.Username] = u
+	}
+	return r
+}
+
+func (r *userRepoUji) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[username]
+	if !ok {
+		return nil, nil
+	}
+	return &u, nil
+}
+
+func (r *userRepoUji) FindAll(ctx context.Context) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []domain.User
+	for _, u := range r.users {
+		list = append(list, u)
+	}
+	return list, nil
+}
+
+func (r *userRepoUji) Save(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.Username] = *user
+	return nil
+}
+
+func (r *userRepoUji) SaveAll(ctx context.Context, users []domain.User) error {
+	for i := range users {
+		r.Save(ctx, &users[i])
+	}
+	return nil
+}
+
+func (r *userRepoUji) Update(ctx context.Context, currentUsername string, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, currentUsername)
+	r.users[user.Username] = *user
+	return nil
+}
+
+func (r *userRepoUji) ubah(username string, fn func(u *domain.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[username]
+	if !ok {
+		return errors.New("pengguna tidak ditemukan")
+	}
+	fn(&u)
+	r.users[username] = u
+	return nil
+}
+
+func (r *userRepoUji) UpdateLockout(ctx context.Context, username string, until time.Time) error {
+	return r.ubah(username, func(u *domain.User) { u.LockedUntil = until })
+}
+
+func (r *userRepoUji) UpdateTwoFactor(ctx context.Context, username, secret string, enabled bool, recoveryCodeHashes []string) error {
+	return r.ubah(username, func(u *domain.User) {
+		u.TOTPSecret, u.TOTPEnabled, u.RecoveryCodeHashes = secret, enabled, recoveryCodeHashes
+	})
+}
+
+func (r *userRepoUji) UpdateTOTPCounter(ctx context.Context, username string, counter int64) error {
+	return r.ubah(username, func(u *domain.User) { u.TOTPCounterTerakhir = counter })
+}
+
+func (r *userRepoUji) UpdateDisabled(ctx context.Context, username string, disabled bool) error {
+	return r.ubah(username, func(u *domain.User) { u.Disabled = disabled })
+}
+
+func (r *userRepoUji) UpdateMustChangePassword(ctx context.Context, username string, must bool) error {
+	return r.ubah(username, func(u *domain.User) { u.MustChangePassword = must })
+}
+
+// resetTokenRepoUji meniru urutan append sheet KlaimTokenReset
+type resetTokenRepoUji struct {
+	mu     sync.Mutex
+	tokens []domain.TokenReset
+	klaim  [][2]string
+}
+
+func (r *resetTokenRepoUji) Save(ctx context.Context, token *domain.TokenReset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.RowNumber = len(r.tokens) + 2
+	r.tokens = append(r.tokens, *token)
+	return nil
+}
+
+func (r *resetTokenRepoUji) FindByHash(ctx context.Context, tokenHash string) (*domain.TokenReset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *resetTokenRepoUji) MarkUsed(ctx context.Context, rowNumber int, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[rowNumber-2].UsedAt = usedAt
+	return nil
+}
+
+func (r *resetTokenRepoUji) Claim(ctx context.Context, tokenHash, claimID string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	r.klaim = append(r.klaim, [2]string{tokenHash, claimID})
+	r.mu.Unlock()
+	// Beri kesempatan klaim lain masuk sebelum dibaca ulang, seperti dua instance server
+	time.Sleep(time.Millisecond)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range r.klaim {
+		if k[0] == tokenHash {
+			return k[1] == claimID, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *resetTokenRepoUji) CountCreatedSince(ctx context.Context, username string, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, t := range r.tokens {
+		if t.Username == username && !t.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *resetTokenRepoUji) DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error) {
+	return 0, nil
+}
+
+// sesiRepoUji menyimpan sesi di memori
+type sesiRepoUji struct {
+	mu   sync.Mutex
+	sesi map[string]domain.SesiPengguna
+}
+
+func newSesiRepoUji() *sesiRepoUji {
+	return &sesiRepoUji{sesi: make(map[string]domain.SesiPengguna)}
+}
+
+func (r *sesiRepoUji) Save(ctx context.Context, s *domain.SesiPengguna) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sesi[s.ID] = *s
+	return nil
+}
+
+func (r *sesiRepoUji) FindByID(ctx context.Context, id string) (*domain.SesiPengguna, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sesi[id]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (r *sesiRepoUji) FindByUsername(ctx context.Context, username string) ([]domain.SesiPengguna, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []domain.SesiPengguna
+	for _, s := range r.sesi {
+		if s.Username == username {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+func (r *sesiRepoUji) Update(ctx context.Context, s *domain.SesiPengguna) error {
+	return r.Save(ctx, s)
+}
+
+func (r *sesiRepoUji) RevokeAllForUser(ctx context.Context, username string, at time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for id, s := range r.sesi {
+		if s.Username == username && s.RevokedAt.IsZero() {
+			s.RevokedAt = at
+			r.sesi[id] = s
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *sesiRepoUji) DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error) {
+	return 0, nil
+}
+
+// auditRepoUji mencatat entri audit di memori
+type auditRepoUji struct {
+	mu      sync.Mutex
+	entries []domain.AuditLog
+}
+
+func (r *auditRepoUji) Save(ctx context.Context, entry *domain.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *auditRepoUji) jumlah(event string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, e := range r.entries {
+		if e.Event == event {
+			n++
+		}
+	}
+	return n
+}
+
+// siswaRepoUji menyimpan data siswa di memori dengan urutan tetap
+type siswaRepoUji struct {
+	mu    sync.Mutex
+	siswa []domain.Siswa
+	// Jumlah panggilan tulis, untuk memeriksa penulisan massal
+	tulis int
+}
+
+func (r *siswaRepoUji) FindAll(ctx context.Context) ([]domain.Siswa, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.Siswa{}, r.siswa...), nil
+}
+
+func (r *siswaRepoUji) FindByNISN(ctx context.Context, nisn string) (*domain.Siswa, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.siswa {
+		if s.NISN == nisn {
+			return &s, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *siswaRepoUji) Save(ctx context.Context, siswa *domain.Siswa) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tulis++
+	r.siswa = append(r.siswa, *siswa)
+	return nil
+}
+
+func (r *siswaRepoUji) Update(ctx context.Context, nisn string, siswa *domain.Siswa) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tulis++
+	for i, s := range r.siswa {
+		if s.NISN == nisn {
+			r.siswa[i] = *siswa
+			return nil
+		}
+	}
+	return errors.New("siswa tidak ditemukan")
+}
+
+func (r *siswaRepoUji) SaveAll(ctx context.Context, list []domain.Siswa) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tulis++
+	r.siswa = append(r.siswa, list...)
+	return nil
+}
+
+func (r *siswaRepoUji) UpdateAll(ctx context.Context, list []domain.Siswa) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tulis++
+	indeks := make(map[string]int, len(r.siswa))
+	for i, s := range r.siswa {
+		indeks[s.NISN] = i
+	}
+	for _, baru := range list {
+		if _, ok := indeks[baru.NISN]; !ok {
+			return fmt.Errorf("NISN %s tidak ditemukan untuk diupdate", baru.NISN)
+		}
+	}
+	for _, baru := range list {
+		r.siswa[indeks[baru.NISN]] = baru
+	}
+	return nil
+}
+
+func (r *siswaRepoUji) Delete(ctx context.Context, nisn string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tulis++
+	for i, s := range r.siswa {
+		if s.NISN == nisn {
+			r.siswa = append(r.siswa[:i], r.siswa[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// relasiRepoUji menyimpan tautan wali murid di memori
+type relasiRepoUji struct {
+	relasi []domain.RelasiWaliSiswa
+}
+
+func (r *relasiRepoUji) FindAll(ctx context.Context) ([]domain.RelasiWaliSiswa, error) {
+	return r.relasi, nil
+}
+
+func (r *relasiRepoUji) FindByUsername(ctx context.Context, username string) ([]domain.RelasiWaliSiswa, error) {
+	var hasil []domain.RelasiWaliSiswa
+	for _, rel := range r.relasi {
+		if rel.Username == username {
+			hasil = append(hasil, rel)
+		}
+	}
+	return hasil, nil
+}
+
+func (r *relasiRepoUji) Save(ctx context.Context, rel *domain.RelasiWaliSiswa) error {
+	r.relasi = append(r.relasi, *rel)
+	return nil
+}
+
+func (r *relasiRepoUji) Delete(ctx context.Context, username, nisn string) error {
+	return nil
+}
+
+// absensiRepoUji hanya mengisi method yang dipakai pengujian; method lain dari interface
+// yang tertanam akan panic jika terpanggil
+type absensiRepoUji struct {
+	AbsensiRepository
+	mu   sync.Mutex
+	logs []domain.LogAbsensi
+	izin []domain.PengajuanIzinLengkap
+	// Jumlah baris DataSiswa yang dilaporkan GetTotalSiswa
+	totalSiswa int
+}
+
+func (r *absensiRepoUji) GetTotalSiswa(ctx context.Context) (int, error) {
+	return r.totalSiswa, nil
+}
+
+// GetTodaysAttendanceAndLeave mengembalikan semua baris mentah, termasuk Alpa hasil finalisasi.
+// Pengujian yang memakainya hanya mengisi baris hari ini.
+func (r *absensiRepoUji) GetTodaysAttendanceAndLeave(ctx context.Context) ([]domain.LogAbsensi, []domain.PengajuanIzinLengkap, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.LogAbsensi{}, r.logs...), append([]domain.PengajuanIzinLengkap{}, r.izin...), nil
+}
+
+func (r *absensiRepoUji) GetAttendanceByNISN(ctx context.Context, nisn string) ([]domain.LogAbsensi, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var hasil []domain.LogAbsensi
+	for _, l := range r.logs {
+		if l.Username == nisn {
+			hasil = append(hasil, l)
+		}
+	}
+	return hasil, nil
+}
+
+func (r *absensiRepoUji) GetLogsByDateRange(ctx context.Context, startDate, endDate string) ([]domain.LogAbsensi, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var hasil []domain.LogAbsensi
+	for _, l := range r.logs {
+		if tanggal := l.Timestamp[:10]; tanggal >= startDate && tanggal <= endDate {
+			hasil = append(hasil, l)
+		}
+	}
+	return hasil, nil
+}
+
+func (r *absensiRepoUji) GetAllLeaveRequests(ctx context.Context) ([]domain.PengajuanIzinLengkap, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.PengajuanIzinLengkap{}, r.izin...), nil
+}
+
+func (r *absensiRepoUji) CreateBatchManualAttendance(ctx context.Context, data []domain.KehadiranManual) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range data {
+		r.logs = append(r.logs, domain.LogAbsensi{
+			RowNumber:   len(r.logs) + 2,
+			Timestamp:   d.Timestamp,
+			Username:    d.NISN,
+			Status:      d.Status,
+			DicatatOleh: d.DicatatOleh,
+		})
+	}
+	return nil
+}
+
+// kalenderUji menganggap Senin sampai Jumat hari sekolah, kecuali tanggal di libur
+type kalenderUji struct {
+	domain.KalenderUsecase
+	libur map[string]string
+}
+
+func (k kalenderUji) GetHari(ctx context.Context, startDate, endDate string) ([]domain.HariKalender, error) {
+	start, _ := time.Parse("2006-01-02", startDate)
+	end, _ := time.Parse("2006-01-02", endDate)
+	var hasil []domain.HariKalender
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		tanggal := d.Format("2006-01-02")
+		h := domain.HariKalender{Tanggal: tanggal, HariSekolah: d.Weekday() != time.Saturday && d.Weekday() != time.Sunday}
+		if ket, ok := k.libur[tanggal]; ok {
+			h.HariSekolah, h.Keterangan = false, ket
+		}
+		hasil = append(hasil, h)
+	}
+	return hasil, nil
+}
+
+// rekonsiliasiUji tidak mengubah pengajuan izin
+type rekonsiliasiUji struct {
+	domain.RekonsiliasiUsecase
+}
+
+func (rekonsiliasiUji) ResolveLeaveRequests(ctx context.Context, requests []domain.PengajuanIzinLengkap) error {
+	return nil
+}
+
+// finalisasiRepoUji meniru sheet FinalisasiAlpa dan urutan append sheet KunciFinalisasi
+type finalisasiRepoUji struct {
+	mu    sync.Mutex
+	list  []domain.FinalisasiAlpa
+	kunci []struct {
+		pemilik string
+		sampai  time.Time
+	}
+}
+
+func (r *finalisasiRepoUji) FindAll(ctx context.Context) ([]domain.FinalisasiAlpa, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.FinalisasiAlpa{}, r.list...), nil
+}
+
+func (r *finalisasiRepoUji) SaveAll(ctx context.Context, list []domain.FinalisasiAlpa) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.list = append(r.list, list...)
+	return nil
+}
+
+func (r *finalisasiRepoUji) Kunci(ctx context.Context, pemilik string, at time.Time, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	r.kunci = append(r.kunci, struct {
+		pemilik string
+		sampai  time.Time
+	}{pemilik, at.Add(ttl)})
+	r.mu.Unlock()
+	// Beri kesempatan proses lain menambah kunci sebelum dibaca ulang
+	time.Sleep(time.Millisecond)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range r.kunci {
+		if k.pemilik == "" || !at.Before(k.sampai) {
+			continue
+		}
+		if k.pemilik == pemilik {
+			return true, nil
+		}
+		r.lepas(pemilik)
+		return false, nil
+	}
+	return false, errors.New("kunci tidak ditemukan")
+}
+
+func (r *finalisasiRepoUji) Lepas(ctx context.Context, pemilik string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lepas(pemilik)
+	return nil
+}
+
+func (r *finalisasiRepoUji) lepas(pemilik string) {
+	for i := range r.kunci {
+		if r.kunci[i].pemilik == pemilik {
+			r.kunci[i].pemilik = ""
+		}
+	}
+}
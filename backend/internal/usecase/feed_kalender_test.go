@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// feedRepoUji menyimpan token di memori dan menghitung pembacaan sheet
+type feedRepoUji struct {
+	mu     sync.Mutex
+	tokens []domain.TokenFeedKalender
+	bacaan int
+}
+
+func (r *feedRepoUji) FindAll(ctx context.Context) ([]domain.TokenFeedKalender, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bacaan++
+	return append([]domain.TokenFeedKalender(nil), r.tokens...), nil
+}
+
+func (r *feedRepoUji) Save(ctx context.Context, t *domain.TokenFeedKalender) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t.RowNumber = len(r.tokens) + 2
+	r.tokens = append(r.tokens, *t)
+	return nil
+}
+
+func (r *feedRepoUji) Revoke(ctx context.Context, rowNumber int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[rowNumber-2].DicabutPada = at
+	return nil
+}
+
+func (r *feedRepoUji) jumlahBacaan() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bacaan
+}
+
+// kalenderWaliUji mengembalikan kalender kosong untuk setiap wali murid
+type kalenderWaliUji struct {
+	domain.AbsensiUsecase
+}
+
+func (kalenderWaliUji) GetKalenderWali(ctx context.Context, username, startDate, endDate string) ([]domain.KalenderAnak, []domain.CalendarEvent, error) {
+	return nil, nil, nil
+}
+
+// replikaFeed membuat dua instance yang berbagi sheet yang sama, seperti dua replika server
+func replikaFeed(t *testing.T) (a, b *feedKalenderUsecase, repo *feedRepoUji, users *userRepoUji, jam *jamUji) {
+	t.Helper()
+	repo = &feedRepoUji{}
+	users = newUserRepoUji(domain.User{Username: "ortu1", Role: domain.RoleOrtu})
+	jam = &jamUji{t: time.Date(2026, 3, 10, 7, 0, 0, 0, time.UTC)}
+	cfg := FeedKalenderUsecaseConfig{PublicBaseURL: "https://presence.example.com", Clock: jam}
+	a = NewFeedKalenderUsecase(repo, users, kalenderWaliUji{}, cfg).(*feedKalenderUsecase)
+	b = NewFeedKalenderUsecase(repo, users, kalenderWaliUji{}, cfg).(*feedKalenderUsecase)
+	return a, b, repo, users, jam
+}
+
+func tokenDariURL(t *testing.T, info *domain.InfoFeedKalender) string {
+	t.Helper()
+	token := info.URL[len("https://presence.example.com"+feedPathAwal) : len(info.URL)-len(feedEkstensi)]
+	if len(token) != 64 {
+		t.Fatalf("token %q tidak diharapkan", token)
+	}
+	return token
+}
+
+func TestFeedTokenDicabutDiReplikaLain(t *testing.T) {
+	ctx := context.Background()
+	a, b, _, _, jam := replikaFeed(t)
+
+	info, err := a.Regenerate(ctx, "ortu1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := tokenDariURL(t, info)
+	if _, err := b.Feed(ctx, token, "10.0.0.1"); err != nil {
+		t.Fatalf("feed di replika lain: %v", err)
+	}
+
+	if err := a.Revoke(ctx, "ortu1", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Feed(ctx, token, "10.0.0.1"); !errors.Is(err, domain.ErrFeedKalenderTidakValid) {
+		t.Fatalf("replika yang mencabut: err = %v, ingin ErrFeedKalenderTidakValid", err)
+	}
+
+	// Replika lain masih memegang daftar lama paling lama feedTabelTTL, tidak selama feedCacheTTL
+	jam.maju(feedTabelTTL + time.Second)
+	if _, err := b.Feed(ctx, token, "10.0.0.1"); !errors.Is(err, domain.ErrFeedKalenderTidakValid) {
+		t.Fatalf("replika lain: err = %v, ingin ErrFeedKalenderTidakValid", err)
+	}
+}
+
+func TestFeedAkunDinonaktifkan(t *testing.T) {
+	ctx := context.Background()
+	a, _, _, users, jam := replikaFeed(t)
+
+	info, err := a.Regenerate(ctx, "ortu1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := tokenDariURL(t, info)
+	if _, err := a.Feed(ctx, token, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	users.ubah("ortu1", func(u *domain.User) { u.Disabled = true })
+	jam.maju(feedTabelTTL + time.Second)
+	if _, err := a.Feed(ctx, token, "10.0.0.1"); !errors.Is(err, domain.ErrFeedKalenderTidakValid) {
+		t.Fatalf("err = %v, ingin ErrFeedKalenderTidakValid", err)
+	}
+}
+
+func TestFeedTokenBaruDariReplikaLain(t *testing.T) {
+	ctx := context.Background()
+	a, b, _, _, jam := replikaFeed(t)
+
+	// b sudah memuat daftar token sebelum a membuat token baru
+	if _, err := b.Feed(ctx, "belum-ada", "10.0.0.1"); !errors.Is(err, domain.ErrFeedKalenderTidakValid) {
+		t.Fatalf("err = %v", err)
+	}
+	info, err := a.Regenerate(ctx, "ortu1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jam.maju(feedTabelJeda + time.Second)
+	if _, err := b.Feed(ctx, tokenDariURL(t, info), "10.0.0.2"); err != nil {
+		t.Fatalf("token baru belum dikenali replika lain: %v", err)
+	}
+}
+
+func TestFeedTokenTidakDikenal(t *testing.T) {
+	ctx := context.Background()
+	a, _, repo, _, jam := replikaFeed(t)
+
+	for i := 0; i < feedGagalMaks; i++ {
+		if _, err := a.Feed(ctx, "tebakan", "203.0.113.9"); !errors.Is(err, domain.ErrFeedKalenderTidakValid) {
+			t.Fatalf("percobaan %d: err = %v", i+1, err)
+		}
+	}
+	// Tebakan beruntun tidak membaca sheet setiap kali
+	if n := repo.jumlahBacaan(); n != 1 {
+		t.Errorf("sheet dibaca %d kali, ingin 1", n)
+	}
+	if _, err := a.Feed(ctx, "tebakan", "203.0.113.9"); !errors.Is(err, domain.ErrFeedKalenderDibatasi) {
+		t.Fatalf("err = %v, ingin ErrFeedKalenderDibatasi", err)
+	}
+	// IP lain tidak ikut dibatasi
+	if _, err := a.Feed(ctx, "tebakan", "203.0.113.10"); !errors.Is(err, domain.ErrFeedKalenderTidakValid) {
+		t.Fatalf("IP lain: err = %v", err)
+	}
+
+	jam.maju(feedGagalJendela)
+	if _, err := a.Feed(ctx, "tebakan", "203.0.113.9"); !errors.Is(err, domain.ErrFeedKalenderTidakValid) {
+		t.Fatalf("setelah jendela: err = %v, ingin ErrFeedKalenderTidakValid", err)
+	}
+}
@@ -0,0 +1,377 @@
+// file: internal/usecase/feed_kalender_usecase.go
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/ical"
+)
+
+// FeedKalenderRepository menyimpan token langganan kalender wali murid (dalam bentuk hash)
+type FeedKalenderRepository interface {
+	FindAll(ctx context.Context) ([]domain.TokenFeedKalender, error)
+	Save(ctx context.Context, t *domain.TokenFeedKalender) error
+	Revoke(ctx context.Context, rowNumber int, at time.Time) error
+}
+
+const (
+	// Rentang event di feed: riwayat beberapa bulan terakhir dan libur yang akan datang
+	feedHariLalu  = 120
+	feedHariDepan = 90
+	// Aplikasi kalender mengambil ulang feed cukup sering; hasilnya disimpan sebentar agar
+	// tidak setiap permintaan membaca Google Sheets
+	feedCacheTTL = 15 * time.Minute
+	// Daftar token dan akun nonaktif dibaca ulang paling lambat setiap feedTabelTTL, sehingga
+	// token yang dicabut atau akun yang dinonaktifkan lewat replika lain berhenti dilayani
+	// dalam waktu itu. Token yang tidak dikenal memicu pembacaan ulang paling sering sekali
+	// per feedTabelJeda, agar tautan yang baru dibuat di replika lain langsung bisa dipakai.
+	feedTabelTTL  = time.Minute
+	feedTabelJeda = 10 * time.Second
+	// Satu IP yang terlalu sering meminta token tidak dikenal ditolak sementara
+	feedGagalMaks    = 10
+	feedGagalJendela = 10 * time.Minute
+	feedRefresh      = 6 * time.Hour
+	feedPathAwal     = "/feed/kalender/"
+	feedEkstensi     = ".ics"
+	feedNamaAplik    = "Presensi Daarul Ilmi"
+)
+
+// FeedKalenderUsecaseConfig berisi pengaturan feed kalender dari konfigurasi server
+type FeedKalenderUsecaseConfig struct {
+	// Alamat publik server, dipakai untuk URL langganan dan UID event
+	PublicBaseURL string
+	// Jam di zona waktu sekolah; nil berarti jam sistem di clock.DefaultTimezone
+	Clock clock.Clock
+}
+
+type feedKalenderUsecase struct {
+	repo          FeedKalenderRepository
+	userRepo      UserRepository
+	absensi       domain.AbsensiUsecase
+	publicBaseURL string
+	host          string
+	clock         clock.Clock
+
+	cacheMu sync.Mutex
+	cache   map[string]feedCache // Kunci: hash token
+
+	tabelMu      sync.Mutex
+	tabel        map[string]string // Hash token aktif -> username pemilik
+	nonaktif     map[string]bool   // Username akun yang dinonaktifkan
+	tabelDibaca  time.Time
+	gagalMu      sync.Mutex
+	gagal        map[string]feedGagal // Kunci: IP peminta
+	gagalDibuang time.Time
+}
+
+type feedCache struct {
+	isi        []byte
+	kedaluarsa time.Time
+}
+
+// feedGagal mencatat permintaan token tidak dikenal dari satu IP dalam satu jendela
+type feedGagal struct {
+	jumlah int
+	awal   time.Time
+}
+
+// NewFeedKalenderUsecase adalah "pabrik" untuk usecase feed kalender wali murid
+func NewFeedKalenderUsecase(repo FeedKalenderRepository, userRepo UserRepository, absensi domain.AbsensiUsecase, cfg FeedKalenderUsecaseConfig) domain.FeedKalenderUsecase {
+	base := strings.TrimRight(cfg.PublicBaseURL, "/")
+	host := "presence.local"
+	if u, err := url.Parse(base); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return &feedKalenderUsecase{
+		repo:          repo,
+		userRepo:      userRepo,
+		absensi:       absensi,
+		publicBaseURL: base,
+		host:          host,
+		clock:         clockAtauSistem(cfg.Clock),
+		cache:         make(map[string]feedCache),
+		gagal:         make(map[string]feedGagal),
+	}
+}
+
+// tokenAktif mengembalikan token yang masih berlaku milik username, jika ada
+func (uc *feedKalenderUsecase) tokenAktif(ctx context.Context, username string) ([]domain.TokenFeedKalender, error) {
+	tokens, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var aktif []domain.TokenFeedKalender
+	for _, t := range tokens {
+		if t.Username == username && t.Aktif() {
+			aktif = append(aktif, t)
+		}
+	}
+	return aktif, nil
+}
+
+func (uc *feedKalenderUsecase) GetInfo(ctx context.Context, username string) (*domain.InfoFeedKalender, error) {
+	aktif, err := uc.tokenAktif(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if len(aktif) == 0 {
+		return &domain.InfoFeedKalender{}, nil
+	}
+	return &domain.InfoFeedKalender{Aktif: true, DibuatPada: aktif[len(aktif)-1].DibuatPada}, nil
+}
+
+func (uc *feedKalenderUsecase) Regenerate(ctx context.Context, username string) (*domain.InfoFeedKalender, error) {
+	// Tautan lama berhenti bekerja begitu tautan baru dibuat
+	if err := uc.cabut(ctx, username); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(b)
+	now := uc.clock.Now()
+	err := uc.repo.Save(ctx, &domain.TokenFeedKalender{
+		Username:   username,
+		TokenHash:  hashToken(token),
+		DibuatPada: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("INFO: Tautan kalender baru dibuat untuk %s", username)
+	return &domain.InfoFeedKalender{
+		Aktif:      true,
+		URL:        uc.publicBaseURL + feedPathAwal + token + feedEkstensi,
+		DibuatPada: now,
+	}, nil
+}
+
+func (uc *feedKalenderUsecase) Revoke(ctx context.Context, username, by string) error {
+	if err := uc.cabut(ctx, username); err != nil {
+		return err
+	}
+	log.Printf("INFO: Tautan kalender milik %s dicabut oleh %s", username, by)
+	return nil
+}
+
+// cabut mencabut semua token aktif milik username dan mengosongkan cache feed
+func (uc *feedKalenderUsecase) cabut(ctx context.Context, username string) error {
+	aktif, err := uc.tokenAktif(ctx, username)
+	if err != nil {
+		return err
+	}
+	now := uc.clock.Now()
+	for _, t := range aktif {
+		if err := uc.repo.Revoke(ctx, t.RowNumber, now); err != nil {
+			return err
+		}
+	}
+	uc.cacheMu.Lock()
+	for _, t := range aktif {
+		delete(uc.cache, t.TokenHash)
+	}
+	uc.cacheMu.Unlock()
+	uc.tabelMu.Lock()
+	uc.tabelDibaca = time.Time{}
+	uc.tabelMu.Unlock()
+	return nil
+}
+
+func (uc *feedKalenderUsecase) Feed(ctx context.Context, token, ip string) ([]byte, error) {
+	hash := hashToken(token)
+	now := uc.clock.Now()
+	if uc.dibatasi(ip, now) {
+		return nil, domain.ErrFeedKalenderDibatasi
+	}
+
+	// Token selalu dicocokkan dengan daftar terbaru, juga saat isi feed masih ada di cache
+	pemilik, err := uc.pemilikToken(ctx, hash, now)
+	if err != nil {
+		return nil, err
+	}
+	if pemilik == "" {
+		uc.catatGagal(ip, now)
+		return nil, domain.ErrFeedKalenderTidakValid
+	}
+
+	uc.cacheMu.Lock()
+	if c, ok := uc.cache[hash]; ok && now.Before(c.kedaluarsa) {
+		uc.cacheMu.Unlock()
+		return c.isi, nil
+	}
+	uc.cacheMu.Unlock()
+
+	isi, err := uc.buatFeed(ctx, pemilik, now)
+	if err != nil {
+		return nil, err
+	}
+	uc.cacheMu.Lock()
+	for k, c := range uc.cache {
+		if !now.Before(c.kedaluarsa) {
+			delete(uc.cache, k)
+		}
+	}
+	uc.cache[hash] = feedCache{isi: isi, kedaluarsa: now.Add(feedCacheTTL)}
+	uc.cacheMu.Unlock()
+	return isi, nil
+}
+
+// pemilikToken mengembalikan username pemilik token aktif, atau string kosong jika token
+// tidak dikenal, sudah dicabut, atau akunnya dinonaktifkan admin
+func (uc *feedKalenderUsecase) pemilikToken(ctx context.Context, hash string, now time.Time) (string, error) {
+	uc.tabelMu.Lock()
+	defer uc.tabelMu.Unlock()
+
+	if now.Sub(uc.tabelDibaca) > feedTabelTTL {
+		if err := uc.muatTabel(ctx, now); err != nil {
+			return "", err
+		}
+	}
+	pemilik, ok := uc.tabel[hash]
+	if !ok && now.Sub(uc.tabelDibaca) > feedTabelJeda {
+		if err := uc.muatTabel(ctx, now); err != nil {
+			return "", err
+		}
+		pemilik, ok = uc.tabel[hash]
+	}
+	if !ok || uc.nonaktif[pemilik] {
+		return "", nil
+	}
+	return pemilik, nil
+}
+
+// muatTabel membaca ulang token aktif dan akun nonaktif. Dipanggil dengan tabelMu terkunci.
+func (uc *feedKalenderUsecase) muatTabel(ctx context.Context, now time.Time) error {
+	tokens, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	users, err := uc.userRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	tabel := make(map[string]string)
+	for _, t := range tokens {
+		if t.Aktif() {
+			tabel[t.TokenHash] = t.Username
+		}
+	}
+	nonaktif := make(map[string]bool)
+	for _, u := range users {
+		if u.Disabled {
+			nonaktif[u.Username] = true
+		}
+	}
+	uc.tabel, uc.nonaktif, uc.tabelDibaca = tabel, nonaktif, now
+	return nil
+}
+
+// dibatasi memeriksa apakah IP sudah terlalu sering meminta token tidak dikenal
+func (uc *feedKalenderUsecase) dibatasi(ip string, now time.Time) bool {
+	uc.gagalMu.Lock()
+	defer uc.gagalMu.Unlock()
+	g, ok := uc.gagal[ip]
+	return ok && now.Sub(g.awal) < feedGagalJendela && g.jumlah >= feedGagalMaks
+}
+
+func (uc *feedKalenderUsecase) catatGagal(ip string, now time.Time) {
+	uc.gagalMu.Lock()
+	defer uc.gagalMu.Unlock()
+	g := uc.gagal[ip]
+	if now.Sub(g.awal) >= feedGagalJendela {
+		g = feedGagal{awal: now}
+	}
+	g.jumlah++
+	uc.gagal[ip] = g
+	if now.Sub(uc.gagalDibuang) > feedGagalJendela {
+		for k, lama := range uc.gagal {
+			if now.Sub(lama.awal) >= feedGagalJendela {
+				delete(uc.gagal, k)
+			}
+		}
+		uc.gagalDibuang = now
+	}
+}
+
+// buatFeed menyusun file .ics berisi event kehadiran setiap anak dan hari libur sekolah,
+// sama dengan yang tampil di kalender portal wali murid
+func (uc *feedKalenderUsecase) buatFeed(ctx context.Context, username string, now time.Time) ([]byte, error) {
+	hariIni := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, uc.clock.Location())
+	mulai := hariIni.AddDate(0, 0, -feedHariLalu).Format(clock.LayoutTanggal)
+	selesai := hariIni.AddDate(0, 0, feedHariDepan).Format(clock.LayoutTanggal)
+	anak, libur, err := uc.absensi.GetKalenderWali(ctx, username, mulai, selesai)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []ical.Event
+	for _, a := range anak {
+		urutan := make(map[string]int) // Event ke berapa pada tanggal yang sama, untuk UID yang stabil
+		for _, ev := range a.Events {
+			t, err := clock.ParseTanggal(uc.clock, ev.Start)
+			if err != nil {
+				continue
+			}
+			urutan[ev.Start]++
+			summary := ev.Title
+			if len(anak) > 1 {
+				summary = a.Siswa.NamaLengkap + ": " + ev.Title
+			}
+			events = append(events, ical.Event{
+				UID:         fmt.Sprintf("%s-%s-%d@%s", a.Siswa.NISN, ev.Start, urutan[ev.Start], uc.host),
+				Summary:     summary,
+				Deskripsi:   a.Siswa.NamaLengkap,
+				Mulai:       t,
+				Selesai:     t,
+				SehariPenuh: true,
+			})
+		}
+	}
+	for _, ev := range libur {
+		// Akhir pekan biasa tidak perlu memenuhi kalender ponsel
+		if ev.Title == domain.KeteranganAkhirPekan {
+			continue
+		}
+		t, err := clock.ParseTanggal(uc.clock, ev.Start)
+		if err != nil {
+			continue
+		}
+		events = append(events, ical.Event{
+			UID:         fmt.Sprintf("hari-%s@%s", ev.Start, uc.host),
+			Summary:     ev.Title,
+			Mulai:       t,
+			Selesai:     t,
+			SehariPenuh: true,
+		})
+	}
+
+	nama := feedNamaAplik
+	if len(anak) == 1 {
+		nama = fmt.Sprintf("Kehadiran %s (%s)", anak[0].Siswa.NamaLengkap, feedNamaAplik)
+	}
+	var buf bytes.Buffer
+	err = ical.Tulis(&buf, ical.Kalender{
+		ProdID:  "-//Daarul Ilmi//Presensi//ID",
+		Nama:    nama,
+		Zona:    uc.clock.Location().String(),
+		Refresh: feedRefresh,
+		Events:  events,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,288 @@
+// file: internal/usecase/kalender_impor.go
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/ical"
+)
+
+// Impor membaca daftar libur dari file .ics atau CSV, lalu membandingkannya dengan kalender:
+// baris baru ditambahkan, baris yang sama persis dilewati, dan baris yang beririsan dengan
+// entri lain hanya disimpan jika admin memilih SertakanKonflik setelah melihat pratinjau.
+func (uc *kalenderUsecase) Impor(ctx context.Context, req domain.ImporKalenderRequest, by string) (*domain.HasilImporKalender, error) {
+	jenis := strings.TrimSpace(req.Jenis)
+	if jenis == "" {
+		jenis = domain.KalenderLibur
+	}
+	if !domain.IsValidJenisKalender(jenis) {
+		return nil, fmt.Errorf("%w: jenis %q tidak dikenal", domain.ErrKalenderTidakValid, jenis)
+	}
+
+	var items []domain.ImporKalenderItem
+	var err error
+	switch strings.ToLower(strings.TrimSpace(req.Format)) {
+	case domain.FormatImporICS:
+		items, err = uc.bacaICS(req.Data, jenis)
+	case domain.FormatImporCSV:
+		items, err = uc.bacaCSV(req.Data, jenis)
+	default:
+		return nil, fmt.Errorf("%w: format harus %q atau %q", domain.ErrFormatImporKalender, domain.FormatImporICS, domain.FormatImporCSV)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entri, err := uc.muatEntri(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hasil := &domain.HasilImporKalender{Diterapkan: req.Terapkan}
+	dalamFile := make(map[string]bool)
+	var simpan []domain.KalenderSekolah
+	now := uc.clock.Now()
+	for i := range items {
+		it := &items[i]
+		if it.Status == domain.ImporDitolak {
+			hasil.JumlahDitolak++
+			continue
+		}
+
+		kunci := it.TanggalMulai + "|" + it.TanggalSelesai + "|" + it.Jenis
+		if dalamFile[kunci] {
+			it.Status = domain.ImporDuplikat
+			it.Alasan = "sama dengan baris lain di file ini"
+			hasil.JumlahDuplikat++
+			continue
+		}
+		dalamFile[kunci] = true
+		bandingkan(it, entri)
+
+		switch it.Status {
+		case domain.ImporBaru:
+			hasil.JumlahBaru++
+		case domain.ImporDuplikat:
+			hasil.JumlahDuplikat++
+			continue
+		case domain.ImporKonflik:
+			hasil.JumlahKonflik++
+			if !req.SertakanKonflik {
+				continue
+			}
+		}
+
+		if req.Terapkan {
+			it.Disimpan = true
+			simpan = append(simpan, domain.KalenderSekolah{
+				ID:             idBaru("KAL"),
+				TanggalMulai:   it.TanggalMulai,
+				TanggalSelesai: it.TanggalSelesai,
+				Jenis:          it.Jenis,
+				Keterangan:     it.Keterangan,
+				DibuatOleh:     by,
+				DibuatPada:     now,
+			})
+		}
+	}
+
+	if len(simpan) > 0 {
+		if err := uc.repo.SaveAll(ctx, simpan); err != nil {
+			return nil, err
+		}
+		log.Printf("INFO: %d entri kalender diimpor dari file %s oleh %s", len(simpan), req.Format, by)
+	}
+	hasil.JumlahDisimpan = len(simpan)
+	hasil.Items = items
+	return hasil, nil
+}
+
+// bandingkan menentukan status satu baris impor terhadap entri kalender yang sudah ada
+func bandingkan(it *domain.ImporKalenderItem, entri []domain.KalenderSekolah) {
+	it.Status = domain.ImporBaru
+	for _, e := range entri {
+		if e.TanggalSelesai < it.TanggalMulai || e.TanggalMulai > it.TanggalSelesai {
+			continue
+		}
+		sama := e.TanggalMulai == it.TanggalMulai && e.TanggalSelesai == it.TanggalSelesai && e.Jenis == it.Jenis
+		switch {
+		case sama && e.HanyaBaca:
+			// Tanggal lama tanpa keterangan: entri impor menjadi nama hari liburnya
+			it.Alasan = fmt.Sprintf("memberi keterangan pada %q dari sheet TanggalLibur", e.Keterangan)
+		case sama:
+			it.Status = domain.ImporDuplikat
+			it.Alasan = "sudah ada di kalender"
+			it.Bentrok = []domain.KalenderSekolah{e}
+			return
+		default:
+			it.Status = domain.ImporKonflik
+			it.Alasan = "beririsan dengan entri kalender yang sudah ada"
+			it.Bentrok = append(it.Bentrok, e)
+		}
+	}
+}
+
+// bacaICS mengubah setiap VEVENT menjadi satu baris impor
+func (uc *kalenderUsecase) bacaICS(data []byte, jenis string) ([]domain.ImporKalenderItem, error) {
+	events, err := ical.Parse(bytes.NewReader(data), uc.clock.Location())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrFormatImporKalender, err)
+	}
+
+	items := []domain.ImporKalenderItem{}
+	for _, ev := range events {
+		it := domain.ImporKalenderItem{
+			Baris:      ev.Baris,
+			Jenis:      jenis,
+			Keterangan: keteranganAtau(ev.Summary, ev.Deskripsi),
+		}
+		switch {
+		case ev.Err != nil:
+			it.Status = domain.ImporDitolak
+			it.Alasan = ev.Err.Error()
+		case ev.Dibatalkan():
+			it.Status = domain.ImporDitolak
+			it.Alasan = "event dibatalkan (STATUS:CANCELLED)"
+		default:
+			uc.isiRentang(&it, ev.Mulai, ev.Selesai)
+			if it.Status == "" && ev.Berulang {
+				it.Alasan = "event berulang, hanya kemunculan pertama yang diimpor"
+			}
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+// Nama kolom CSV yang dikenali (huruf kecil). Tanpa baris judul, urutannya
+// tanggal_mulai, tanggal_selesai, keterangan, jenis.
+var kolomCSVKalender = map[string][]string{
+	"mulai":      {"tanggal_mulai", "tanggal mulai", "mulai", "tanggal", "date", "start"},
+	"selesai":    {"tanggal_selesai", "tanggal selesai", "selesai", "end"},
+	"keterangan": {"keterangan", "nama", "nama libur", "summary", "description"},
+	"jenis":      {"jenis", "type"},
+}
+
+// bacaCSV membaca CSV (pemisah koma atau titik koma dari Excel) menjadi baris impor
+func (uc *kalenderUsecase) bacaCSV(data []byte, jenis string) ([]domain.ImporKalenderItem, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	barisPertama, _, _ := bytes.Cut(data, []byte("\n"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(barisPertama, []byte(";")) > bytes.Count(barisPertama, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	kolom := map[string]int{"mulai": 0, "selesai": 1, "keterangan": 2, "jenis": 3}
+	items := []domain.ImporKalenderItem{}
+	for nomor := 1; ; nomor++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: baris %d: %v", domain.ErrFormatImporKalender, nomor, err)
+		}
+		ambil := func(nama string) string {
+			if i, ok := kolom[nama]; ok && i >= 0 && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		if nomor == 1 {
+			if _, err := parseTanggalCSV(uc.clock, ambil("mulai")); err != nil {
+				kolom = bacaJudulCSV(row)
+				if kolom["mulai"] < 0 {
+					return nil, fmt.Errorf("%w: kolom tanggal mulai tidak ditemukan di baris judul", domain.ErrFormatImporKalender)
+				}
+				continue
+			}
+		}
+		if strings.Join(row, "") == "" {
+			continue
+		}
+
+		it := domain.ImporKalenderItem{
+			Baris:      nomor,
+			Jenis:      jenis,
+			Keterangan: ambil("keterangan"),
+		}
+		if j := strings.ToLower(ambil("jenis")); j != "" {
+			it.Jenis = j
+		}
+		mulai, errMulai := parseTanggalCSV(uc.clock, ambil("mulai"))
+		selesai := mulai
+		var errSelesai error
+		if s := ambil("selesai"); s != "" {
+			selesai, errSelesai = parseTanggalCSV(uc.clock, s)
+		}
+		switch {
+		case !domain.IsValidJenisKalender(it.Jenis):
+			it.Status = domain.ImporDitolak
+			it.Alasan = fmt.Sprintf("jenis %q tidak dikenal", it.Jenis)
+		case errMulai != nil:
+			it.Status = domain.ImporDitolak
+			it.Alasan = fmt.Sprintf("tanggal mulai %q tidak valid", ambil("mulai"))
+		case errSelesai != nil:
+			it.Status = domain.ImporDitolak
+			it.Alasan = fmt.Sprintf("tanggal selesai %q tidak valid", ambil("selesai"))
+		default:
+			uc.isiRentang(&it, mulai, selesai)
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+// bacaJudulCSV memetakan nama kolom di baris judul ke indeksnya (-1 jika tidak ada)
+func bacaJudulCSV(row []string) map[string]int {
+	kolom := make(map[string]int)
+	for nama, alias := range kolomCSVKalender {
+		kolom[nama] = -1
+		for i, judul := range row {
+			judul = strings.ToLower(strings.TrimSpace(judul))
+			for _, a := range alias {
+				if judul == a {
+					kolom[nama] = i
+				}
+			}
+		}
+	}
+	return kolom
+}
+
+// parseTanggalCSV menerima YYYY-MM-DD atau DD/MM/YYYY (format tanggal Excel Indonesia)
+func parseTanggalCSV(c clock.Clock, s string) (time.Time, error) {
+	for _, layout := range []string{clock.LayoutTanggal, "2/1/2006", "02/01/2006", "2-1-2006", "02-01-2006"} {
+		if t, err := time.ParseInLocation(layout, s, c.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("tanggal %q tidak valid", s)
+}
+
+// isiRentang mengisi tanggal baris impor, atau menolaknya jika rentangnya tidak masuk akal
+func (uc *kalenderUsecase) isiRentang(it *domain.ImporKalenderItem, mulai, selesai time.Time) {
+	it.TanggalMulai = mulai.Format(clock.LayoutTanggal)
+	it.TanggalSelesai = selesai.Format(clock.LayoutTanggal)
+	switch {
+	case selesai.Before(mulai):
+		it.Status = domain.ImporDitolak
+		it.Alasan = "tanggal selesai sebelum tanggal mulai"
+	case selesai.After(mulai.AddDate(0, 0, maksHariRentang)):
+		it.Status = domain.ImporDitolak
+		it.Alasan = fmt.Sprintf("rentang lebih dari %d hari", maksHariRentang)
+	}
+}
@@ -0,0 +1,271 @@
+// file: internal/usecase/kalender_usecase.go
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// KalenderRepository menyimpan entri kalender sekolah
+type KalenderRepository interface {
+	FindAll(ctx context.Context) ([]domain.KalenderSekolah, error)
+	// FindTanggalLibur membaca sheet lama TanggalLibur yang hanya berisi tanggal
+	FindTanggalLibur(ctx context.Context) ([]string, error)
+	Save(ctx context.Context, k *domain.KalenderSekolah) error
+	SaveAll(ctx context.Context, list []domain.KalenderSekolah) error
+	Update(ctx context.Context, k *domain.KalenderSekolah) error
+	Delete(ctx context.Context, rowNumber int) error
+}
+
+// KalenderUsecaseConfig berisi aturan hari sekolah dari konfigurasi server
+type KalenderUsecaseConfig struct {
+	// Hari libur setiap minggu; nil berarti Sabtu dan Minggu
+	HariLiburMingguan []time.Weekday
+	// Jam di zona waktu sekolah; nil berarti jam sistem di clock.DefaultTimezone
+	Clock clock.Clock
+}
+
+type kalenderUsecase struct {
+	repo          KalenderRepository
+	liburMingguan map[time.Weekday]bool
+	clock         clock.Clock
+}
+
+// NewKalenderUsecase adalah "pabrik" untuk usecase kalender sekolah
+func NewKalenderUsecase(repo KalenderRepository, cfg KalenderUsecaseConfig) domain.KalenderUsecase {
+	hariLibur := cfg.HariLiburMingguan
+	if hariLibur == nil {
+		hariLibur = []time.Weekday{time.Saturday, time.Sunday}
+	}
+	liburMingguan := make(map[time.Weekday]bool)
+	for _, d := range hariLibur {
+		liburMingguan[d] = true
+	}
+	return &kalenderUsecase{
+		repo:          repo,
+		liburMingguan: liburMingguan,
+		clock:         clockAtauSistem(cfg.Clock),
+	}
+}
+
+// muatEntri membaca semua entri kalender, termasuk tanggal dari sheet lama TanggalLibur
+func (uc *kalenderUsecase) muatEntri(ctx context.Context) ([]domain.KalenderSekolah, error) {
+	entri, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lama, err := uc.repo.FindTanggalLibur(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range lama {
+		t, err := clock.ParseTanggalFleksibel(uc.clock, s)
+		if err != nil {
+			log.Printf("WARNING: Tanggal '%s' di sheet TanggalLibur tidak dikenali, dilewati", s)
+			continue
+		}
+		tanggal := t.Format(clock.LayoutTanggal)
+		entri = append(entri, domain.KalenderSekolah{
+			ID:             "TanggalLibur-" + tanggal,
+			TanggalMulai:   tanggal,
+			TanggalSelesai: tanggal,
+			Jenis:          domain.KalenderLibur,
+			Keterangan:     domain.KeteranganTanggalMerah,
+			HanyaBaca:      true,
+		})
+	}
+	return entri, nil
+}
+
+// hari menerapkan aturan hari sekolah untuk satu tanggal. Urutannya: libur mingguan,
+// lalu entri libur, lalu masuk pengganti (mengalahkan libur), lalu setengah hari.
+func (uc *kalenderUsecase) hari(t time.Time, entri []domain.KalenderSekolah) domain.HariKalender {
+	tanggal := t.Format(clock.LayoutTanggal)
+	h := domain.HariKalender{Tanggal: tanggal, HariSekolah: !uc.liburMingguan[t.Weekday()]}
+	if !h.HariSekolah {
+		h.Keterangan = domain.KeteranganAkhirPekan
+	}
+
+	var libur, pengganti, setengah *domain.KalenderSekolah
+	for i := range entri {
+		e := &entri[i]
+		if tanggal < e.TanggalMulai || tanggal > e.TanggalSelesai {
+			continue
+		}
+		switch e.Jenis {
+		case domain.KalenderLibur:
+			// Entri bernama mengalahkan tanggal tanpa keterangan dari sheet lama TanggalLibur
+			if libur == nil || libur.HanyaBaca {
+				libur = e
+			}
+		case domain.KalenderMasukPengganti:
+			pengganti = e
+		case domain.KalenderSetengahHari:
+			setengah = e
+		}
+	}
+
+	switch {
+	case pengganti != nil:
+		h.HariSekolah = true
+		h.Keterangan = keteranganAtau(pengganti.Keterangan, "Masuk Pengganti")
+	case libur != nil:
+		h.HariSekolah = false
+		h.Keterangan = keteranganAtau(libur.Keterangan, "Libur")
+	}
+	if setengah != nil && h.HariSekolah {
+		h.SetengahHari = true
+		h.Keterangan = keteranganAtau(setengah.Keterangan, "Setengah Hari")
+	}
+	return h
+}
+
+func keteranganAtau(keterangan, bawaan string) string {
+	if keterangan == "" {
+		return bawaan
+	}
+	return keterangan
+}
+
+// parseRentang membaca rentang tanggal YYYY-MM-DD. Tanggal selesai kosong berarti satu hari.
+func (uc *kalenderUsecase) parseRentang(startDate, endDate string) (time.Time, time.Time, error) {
+	if endDate == "" {
+		endDate = startDate
+	}
+	start, err := clock.ParseTanggal(uc.clock, startDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: tanggal mulai harus berformat YYYY-MM-DD", domain.ErrRentangTanggal)
+	}
+	end, err := clock.ParseTanggal(uc.clock, endDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: tanggal selesai harus berformat YYYY-MM-DD", domain.ErrRentangTanggal)
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: tanggal selesai sebelum tanggal mulai", domain.ErrRentangTanggal)
+	}
+	if end.After(start.AddDate(0, 0, maksHariRentang)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: maksimal %d hari", domain.ErrRentangTanggal, maksHariRentang)
+	}
+	return start, end, nil
+}
+
+func (uc *kalenderUsecase) GetHari(ctx context.Context, startDate, endDate string) ([]domain.HariKalender, error) {
+	start, end, err := uc.parseRentang(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	entri, err := uc.muatEntri(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var hasil []domain.HariKalender
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		hasil = append(hasil, uc.hari(d, entri))
+	}
+	return hasil, nil
+}
+
+func (uc *kalenderUsecase) List(ctx context.Context, startDate, endDate string) ([]domain.KalenderSekolah, error) {
+	entri, err := uc.muatEntri(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hasil := []domain.KalenderSekolah{}
+	for _, e := range entri {
+		// Ambil entri yang beririsan dengan rentang (batas kosong berarti tidak dibatasi)
+		if startDate != "" && e.TanggalSelesai < startDate {
+			continue
+		}
+		if endDate != "" && e.TanggalMulai > endDate {
+			continue
+		}
+		hasil = append(hasil, e)
+	}
+	sort.SliceStable(hasil, func(i, j int) bool { return hasil[i].TanggalMulai < hasil[j].TanggalMulai })
+	return hasil, nil
+}
+
+// validasi memeriksa data dari admin dan mengisinya ke entri
+func (uc *kalenderUsecase) validasi(req domain.KalenderRequest, k *domain.KalenderSekolah) error {
+	jenis := strings.TrimSpace(req.Jenis)
+	if !domain.IsValidJenisKalender(jenis) {
+		return fmt.Errorf("%w: jenis harus %q, %q, atau %q", domain.ErrKalenderTidakValid,
+			domain.KalenderLibur, domain.KalenderSetengahHari, domain.KalenderMasukPengganti)
+	}
+	start, end, err := uc.parseRentang(req.TanggalMulai, req.TanggalSelesai)
+	if err != nil {
+		return err
+	}
+	k.TanggalMulai = start.Format(clock.LayoutTanggal)
+	k.TanggalSelesai = end.Format(clock.LayoutTanggal)
+	k.Jenis = jenis
+	k.Keterangan = strings.TrimSpace(req.Keterangan)
+	return nil
+}
+
+func (uc *kalenderUsecase) Create(ctx context.Context, req domain.KalenderRequest, by string) (*domain.KalenderSekolah, error) {
+	k := &domain.KalenderSekolah{
+		ID:         idBaru("KAL"),
+		DibuatOleh: by,
+		DibuatPada: uc.clock.Now(),
+	}
+	if err := uc.validasi(req, k); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.Save(ctx, k); err != nil {
+		return nil, err
+	}
+	log.Printf("INFO: Entri kalender %s (%s, %s s.d. %s) dibuat oleh %s", k.ID, k.Jenis, k.TanggalMulai, k.TanggalSelesai, by)
+	return k, nil
+}
+
+// cari mengembalikan entri kalender yang bisa diubah berdasarkan ID
+func (uc *kalenderUsecase) cari(ctx context.Context, id string) (*domain.KalenderSekolah, error) {
+	if strings.HasPrefix(id, "TanggalLibur-") {
+		return nil, domain.ErrKalenderHanyaBaca
+	}
+	entri, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entri {
+		if entri[i].ID == id {
+			return &entri[i], nil
+		}
+	}
+	return nil, domain.ErrKalenderTidakDitemukan
+}
+
+func (uc *kalenderUsecase) Update(ctx context.Context, id string, req domain.KalenderRequest, by string) (*domain.KalenderSekolah, error) {
+	k, err := uc.cari(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.validasi(req, k); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.Update(ctx, k); err != nil {
+		return nil, err
+	}
+	log.Printf("INFO: Entri kalender %s diubah oleh %s", k.ID, by)
+	return k, nil
+}
+
+func (uc *kalenderUsecase) Delete(ctx context.Context, id, by string) error {
+	k, err := uc.cari(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.repo.Delete(ctx, k.RowNumber); err != nil {
+		return err
+	}
+	log.Printf("INFO: Entri kalender %s (%s, %s s.d. %s) dihapus oleh %s", k.ID, k.Jenis, k.TanggalMulai, k.TanggalSelesai, by)
+	return nil
+}
@@ -0,0 +1,195 @@
+// file: internal/usecase/login_guard.go
+package usecase
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// AuditRepository mencatat kejadian keamanan (login gagal, akun terkunci, dll.)
+type AuditRepository interface {
+	Save(ctx context.Context, entry *domain.AuditLog) error
+}
+
+// LoginGuardConfig mengatur perlindungan brute-force pada login
+type LoginGuardConfig struct {
+	// Jendela waktu untuk menghitung kegagalan berturut-turut; hitungan direset setelah lewat
+	FailureWindow time.Duration
+	// Jumlah kegagalan sebelum percobaan berikutnya mulai diperlambat
+	DelayAfter int
+	// Jeda pertama setelah DelayAfter, berlipat dua untuk setiap kegagalan berikutnya
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jumlah kegagalan per username sebelum akun dikunci sementara
+	MaxFailuresPerUser int
+	LockoutDuration    time.Duration
+	// Jumlah kegagalan per IP (untuk username apa pun) sebelum IP diblokir sementara
+	MaxFailuresPerIP int
+	IPBlockDuration  time.Duration
+}
+
+// DefaultLoginGuardConfig adalah nilai bawaan yang aman untuk sekolah
+func DefaultLoginGuardConfig() LoginGuardConfig {
+	return LoginGuardConfig{
+		FailureWindow:      15 * time.Minute,
+		DelayAfter:         3,
+		BaseDelay:          2 * time.Second,
+		MaxDelay:           30 * time.Second,
+		MaxFailuresPerUser: 5,
+		LockoutDuration:    15 * time.Minute,
+		MaxFailuresPerIP:   20,
+		IPBlockDuration:    15 * time.Minute,
+	}
+}
+
+type attemptState struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+	// Percobaan yang ditunda sudah diaudit sejak kegagalan terakhir. Penundaan berikutnya tidak
+	// dicatat lagi agar brute-force tidak berubah menjadi banjir tulisan ke sheet audit.
+	ditundaDiaudit bool
+}
+
+// loginGuard menghitung percobaan login gagal per username dan per IP di memori.
+// Penguncian akun juga disimpan di DataPengguna agar berlaku di semua instance.
+type loginGuard struct {
+	cfg    LoginGuardConfig
+	mu     sync.Mutex
+	byUser map[string]*attemptState
+	byIP   map[string]*attemptState
+}
+
+func newLoginGuard(cfg LoginGuardConfig) *loginGuard {
+	return &loginGuard{
+		cfg:    cfg,
+		byUser: make(map[string]*attemptState),
+		byIP:   make(map[string]*attemptState),
+	}
+}
+
+func userKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// state mengembalikan catatan percobaan, dan mereset hitungan yang sudah lewat jendela waktu
+func (g *loginGuard) state(m map[string]*attemptState, key string, now time.Time) *attemptState {
+	st, ok := m[key]
+	if !ok {
+		st = &attemptState{}
+		m[key] = st
+	}
+	if st.failures > 0 && now.Sub(st.lastFailure) > g.cfg.FailureWindow && now.After(st.blockedUntil) {
+		*st = attemptState{}
+	}
+	return st
+}
+
+// waitFor menghitung berapa lama lagi percobaan berikutnya boleh dilakukan
+func (g *loginGuard) waitFor(st *attemptState, now time.Time) time.Duration {
+	if now.Before(st.blockedUntil) {
+		return st.blockedUntil.Sub(now)
+	}
+	if g.cfg.DelayAfter <= 0 || st.failures < g.cfg.DelayAfter {
+		return 0
+	}
+	delay := g.cfg.BaseDelay
+	for i := g.cfg.DelayAfter; i < st.failures && delay < g.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, g.cfg.MaxDelay)
+	if next := st.lastFailure.Add(delay); now.Before(next) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+// check mengembalikan error jika username atau IP ini sedang diperlambat/diblokir, dan true
+// jika penundaan ini yang pertama sejak kegagalan terakhir sehingga perlu diaudit
+func (g *loginGuard) check(username, ip string, now time.Time) (*domain.LoginDitundaError, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	userState := g.state(g.byUser, userKey(username), now)
+	wait := g.waitFor(userState, now)
+	audit := wait > 0 && g.tandaiDitunda(userState)
+	if ip != "" {
+		ipState := g.state(g.byIP, ip, now)
+		if w := g.waitFor(ipState, now); w > 0 {
+			wait = max(wait, w)
+			audit = g.tandaiDitunda(ipState) || audit
+		}
+	}
+	if wait > 0 {
+		return &domain.LoginDitundaError{RetryAfter: wait}, audit
+	}
+	return nil, false
+}
+
+// tandaiDitunda mengembalikan true hanya untuk penundaan pertama sejak kegagalan terakhir
+func (g *loginGuard) tandaiDitunda(st *attemptState) bool {
+	pertama := !st.ditundaDiaudit
+	st.ditundaDiaudit = true
+	return pertama
+}
+
+// tandaiTerkunci dipakai untuk akun yang terkunci di DataPengguna (bisa oleh instance lain),
+// agar hanya percobaan pertama di instance ini yang diaudit
+func (g *loginGuard) tandaiTerkunci(username string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.tandaiDitunda(g.state(g.byUser, userKey(username), now))
+}
+
+// recordFailure mencatat kegagalan dan mengembalikan true jika akun harus dikunci
+func (g *loginGuard) recordFailure(username, ip string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.prune(now)
+
+	if ip != "" {
+		ipState := g.state(g.byIP, ip, now)
+		ipState.failures++
+		ipState.lastFailure = now
+		ipState.ditundaDiaudit = false
+		if g.cfg.MaxFailuresPerIP > 0 && ipState.failures >= g.cfg.MaxFailuresPerIP {
+			ipState.blockedUntil = now.Add(g.cfg.IPBlockDuration)
+		}
+	}
+
+	userState := g.state(g.byUser, userKey(username), now)
+	userState.failures++
+	userState.lastFailure = now
+	userState.ditundaDiaudit = false
+	if g.cfg.MaxFailuresPerUser > 0 && userState.failures >= g.cfg.MaxFailuresPerUser {
+		userState.blockedUntil = now.Add(g.cfg.LockoutDuration)
+		return true
+	}
+	return false
+}
+
+// prune membuang catatan lama agar map tidak terus membesar oleh username/IP acak
+func (g *loginGuard) prune(now time.Time) {
+	const maxEntries = 10000
+	for _, m := range []map[string]*attemptState{g.byUser, g.byIP} {
+		if len(m) < maxEntries {
+			continue
+		}
+		for key, st := range m {
+			if now.Sub(st.lastFailure) > g.cfg.FailureWindow && now.After(st.blockedUntil) {
+				delete(m, key)
+			}
+		}
+	}
+}
+
+// reset menghapus catatan kegagalan username (setelah login berhasil atau dibuka admin)
+func (g *loginGuard) reset(username string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.byUser, userKey(username))
+}
@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginGuardWaitFor(t *testing.T) {
+	g := newLoginGuard(DefaultLoginGuardConfig())
+	t0 := time.Date(2025, 3, 10, 7, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		st   attemptState
+		now  time.Time
+		want time.Duration
+	}{
+		{"belum ada kegagalan", attemptState{}, t0, 0},
+		{"di bawah batas jeda", attemptState{failures: 2, lastFailure: t0}, t0, 0},
+		{"jeda pertama", attemptState{failures: 3, lastFailure: t0}, t0, 2 * time.Second},
+		{"jeda berlipat", attemptState{failures: 5, lastFailure: t0}, t0, 8 * time.Second},
+		{"jeda maksimum", attemptState{failures: 19, lastFailure: t0}, t0, 30 * time.Second},
+		{"jeda sudah lewat", attemptState{failures: 3, lastFailure: t0}, t0.Add(3 * time.Second), 0},
+		{"diblokir", attemptState{failures: 1, blockedUntil: t0.Add(time.Minute)}, t0, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := tt.st
+			if got := g.waitFor(&st, tt.now); got != tt.want {
+				t.Errorf("waitFor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginDitundaHanyaDiauditSekali(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("benar"), bcrypt.MinCost)
+	jam := &jamUji{t: time.Date(2025, 3, 10, 7, 0, 0, 0, time.UTC)}
+	audit := &auditRepoUji{}
+	guard := DefaultLoginGuardConfig()
+	guard.DelayAfter = 1
+	guard.BaseDelay = time.Minute
+	uc := NewUserUsecase(newUserRepoUji(domain.User{Username: "budi", PasswordHash: string(hash)}), &resetTokenRepoUji{}, newSesiRepoUji(), audit, nil, nil, nil, UserUsecaseConfig{
+		JWTSecret:  []byte("rahasia"),
+		LoginGuard: guard,
+		Clock:      jam,
+	})
+	client := domain.ClientInfo{IPAddress: "203.0.113.7"}
+
+	if _, err := uc.Login(ctx, "budi", "salah", client); !errors.Is(err, domain.ErrKredensialSalah) {
+		t.Fatalf("login pertama: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		var ditunda *domain.LoginDitundaError
+		if _, err := uc.Login(ctx, "budi", "salah", client); !errors.As(err, &ditunda) {
+			t.Fatalf("percobaan %d tidak ditunda: %v", i, err)
+		}
+	}
+	if n := audit.jumlah(domain.AuditLoginDitunda); n != 1 {
+		t.Fatalf("login ditunda diaudit %d kali, want 1", n)
+	}
+
+	// Kegagalan baru setelah jeda lewat membuka episode penundaan baru
+	jam.maju(2 * time.Minute)
+	uc.Login(ctx, "budi", "salah", client)
+	uc.Login(ctx, "budi", "salah", client)
+	uc.Login(ctx, "budi", "salah", client)
+	if n := audit.jumlah(domain.AuditLoginDitunda); n != 2 {
+		t.Fatalf("login ditunda diaudit %d kali, want 2", n)
+	}
+}
+
+func TestLoginUlangTidakMeresetBatasTebakanKode(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("benar"), bcrypt.MinCost)
+	// Challenge token divalidasi dengan jam sistem, jadi jam uji mengikuti waktu sekarang
+	jam := &jamUji{t: time.Now()}
+	users := newUserRepoUji(domain.User{Username: "guru", PasswordHash: string(hash), TOTPSecret: rahasiaRFC6238, TOTPEnabled: true})
+	guard := DefaultLoginGuardConfig()
+	guard.DelayAfter = 100 // Hanya penguncian akun yang diuji
+	uc := NewUserUsecase(users, &resetTokenRepoUji{}, newSesiRepoUji(), &auditRepoUji{}, nil, nil, nil, UserUsecaseConfig{
+		JWTSecret:  []byte("rahasia"),
+		LoginGuard: guard,
+		Clock:      jam,
+	})
+	client := domain.ClientInfo{IPAddress: "203.0.113.7"}
+
+	// Kode salah yang pasti tidak cocok dengan jendela waktu mana pun
+	salah := "000000"
+	for d := int64(-1); d <= 1; d++ {
+		if k, _ := totpCode(rahasiaRFC6238, uint64(jam.Now().Unix()/30+d)); k == salah {
+			salah = "111111"
+		}
+	}
+
+	tebakan := 0
+	for siklus := 0; tebakan < guard.MaxFailuresPerUser; siklus++ {
+		res, err := uc.Login(ctx, "guru", "benar", client)
+		if err != nil {
+			t.Fatalf("siklus %d: login dengan password benar ditolak setelah %d tebakan: %v", siklus, tebakan, err)
+		}
+		for i := 0; i < 2 && tebakan < guard.MaxFailuresPerUser; i++ {
+			if _, err := uc.VerifyTwoFactorLogin(ctx, res.ChallengeToken, salah, client); !errors.Is(err, domain.ErrKodeTidakValid) {
+				t.Fatalf("tebakan %d: %v", tebakan+1, err)
+			}
+			tebakan++
+		}
+	}
+
+	var ditunda *domain.LoginDitundaError
+	if _, err := uc.Login(ctx, "guru", "benar", client); !errors.As(err, &ditunda) || ditunda.RetryAfter < guard.LockoutDuration-time.Second {
+		t.Fatalf("akun tidak terkunci setelah %d tebakan kode di beberapa login: %v", tebakan, err)
+	}
+	// Penguncian juga tersimpan untuk instance lain
+	if u, _ := users.FindByUsername(ctx, "guru"); !jam.Now().Before(u.LockedUntil) {
+		t.Errorf("penguncian tidak disimpan: LockedUntil = %v", u.LockedUntil)
+	}
+}
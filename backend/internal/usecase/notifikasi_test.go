@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// notifikasiRepoUji menyimpan preferensi dan log notifikasi di memori
+type notifikasiRepoUji struct {
+	mu         sync.Mutex
+	preferensi []domain.PreferensiNotifikasi
+	log        []domain.LogNotifikasi
+}
+
+func (r *notifikasiRepoUji) FindPreferensi(ctx context.Context) ([]domain.PreferensiNotifikasi, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.PreferensiNotifikasi(nil), r.preferensi...), nil
+}
+
+func (r *notifikasiRepoUji) SavePreferensi(ctx context.Context, p *domain.PreferensiNotifikasi) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.preferensi = append(r.preferensi, *p)
+	return nil
+}
+
+func (r *notifikasiRepoUji) FindLog(ctx context.Context) ([]domain.LogNotifikasi, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.LogNotifikasi(nil), r.log...), nil
+}
+
+func (r *notifikasiRepoUji) SaveLog(ctx context.Context, list []domain.LogNotifikasi) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = append(r.log, list...)
+	return nil
+}
+
+func (r *notifikasiRepoUji) terakhir() domain.LogNotifikasi {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.log[len(r.log)-1]
+}
+
+// kanalUji mencatat pesan yang dikirim; gagal diisi untuk mensimulasikan gateway yang mati
+type kanalUji struct {
+	terkirim []domain.PesanNotifikasi
+	gagal    error
+}
+
+func (k *kanalUji) Kirim(ctx context.Context, pesan domain.PesanNotifikasi) error {
+	if k.gagal != nil {
+		return k.gagal
+	}
+	k.terkirim = append(k.terkirim, pesan)
+	return nil
+}
+
+func TestJamTenang(t *testing.T) {
+	hari := func(d, jam, menit int) time.Time { return time.Date(2026, 3, d, jam, menit, 0, 0, time.UTC) }
+	tests := []struct {
+		name          string
+		mulai, sampai string
+		now           time.Time
+		tenang        bool
+		selesai       time.Time
+	}{
+		{"tidak diatur", "", "", hari(10, 23, 0), false, time.Time{}},
+		{"hanya mulai", "21:00", "", hari(10, 23, 0), false, time.Time{}},
+		{"format salah", "9 malam", "06:00", hari(10, 23, 0), false, time.Time{}},
+		{"siang di dalam", "12:00", "13:00", hari(10, 12, 30), true, hari(10, 13, 0)},
+		{"siang tepat mulai", "12:00", "13:00", hari(10, 12, 0), true, hari(10, 13, 0)},
+		{"siang tepat selesai", "12:00", "13:00", hari(10, 13, 0), false, time.Time{}},
+		{"siang di luar", "12:00", "13:00", hari(10, 7, 0), false, time.Time{}},
+		{"malam sebelum tengah malam", "21:00", "06:00", hari(10, 22, 15), true, hari(11, 6, 0)},
+		{"malam tepat mulai", "21:00", "06:00", hari(10, 21, 0), true, hari(11, 6, 0)},
+		{"malam setelah tengah malam", "21:00", "06:00", hari(11, 2, 0), true, hari(11, 6, 0)},
+		{"malam tepat selesai", "21:00", "06:00", hari(11, 6, 0), false, time.Time{}},
+		{"malam di luar", "21:00", "06:00", hari(10, 15, 0), false, time.Time{}},
+		{"malam akhir bulan", "21:00", "06:00", time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC), true, time.Date(2026, 4, 1, 6, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := domain.PreferensiNotifikasi{JamTenangMulai: tt.mulai, JamTenangSelesai: tt.sampai}
+			selesai, tenang := jamTenang(p, tt.now)
+			if tenang != tt.tenang || !selesai.Equal(tt.selesai) {
+				t.Errorf("jamTenang(%s) = %v, %v; ingin %v, %v", tt.now.Format("2006-01-02 15:04"), selesai, tenang, tt.selesai, tt.tenang)
+			}
+		})
+	}
+}
+
+// notifikasiUji membuat usecase dengan satu siswa yang mengaktifkan notifikasi WhatsApp
+func notifikasiUji(repo *notifikasiRepoUji, kanal *kanalUji, jam *jamUji) *notifikasiUsecase {
+	siswa := &siswaRepoUji{siswa: []domain.Siswa{{NISN: "0012345678", NamaLengkap: "Ahmad", Kelas: "7A", NomorTeleponOrtu: "081234567890"}}}
+	cfg := NotifikasiUsecaseConfig{Clock: jam, Kanal: map[string]KanalNotifikasi{domain.KanalWhatsApp: kanal}}
+	return NewNotifikasiUsecase(repo, siswa, nil, nil, cfg).(*notifikasiUsecase)
+}
+
+func eventMasukUji(jam *jamUji) domain.EventWebhook {
+	return domain.EventWebhook{Jenis: domain.EventAbsensiMasuk, Waktu: jam.Now(), Data: domain.DataEventAbsensi{
+		NISN: "0012345678", NamaLengkap: "Ahmad", Kelas: "7A", Status: "Hadir", Timestamp: jam.Now().Format(clock.LayoutWaktu),
+	}}
+}
+
+func TestNotifikasiJamTenangDilanjutkanSetelahRestart(t *testing.T) {
+	ctx := context.Background()
+	jam := &jamUji{t: time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC)}
+	repo := &notifikasiRepoUji{preferensi: []domain.PreferensiNotifikasi{{
+		NISN: "0012345678", Aktif: true, Kanal: []string{domain.KanalWhatsApp}, JamTenangMulai: "21:00", JamTenangSelesai: "06:00",
+	}}}
+	kanal := &kanalUji{}
+
+	uc := notifikasiUji(repo, kanal, jam)
+	uc.prosesEvent(ctx, eventMasukUji(jam))
+	uc.simpanLog(ctx)
+	if l := repo.terakhir(); l.Status != domain.NotifikasiDitunda || !l.BerikutnyaPada.Equal(time.Date(2026, 3, 11, 6, 0, 0, 0, time.UTC)) {
+		t.Fatalf("log = %+v, ingin ditunda sampai 06:00", l)
+	}
+
+	// Server restart sebelum jam tenang selesai
+	baru := notifikasiUji(repo, kanal, jam)
+	baru.muatMenunggu(ctx)
+	if len(baru.menunggu) != 1 {
+		t.Fatalf("pesan dilanjutkan = %d, ingin 1", len(baru.menunggu))
+	}
+	baru.prosesMenunggu(ctx)
+	if len(kanal.terkirim) != 0 {
+		t.Fatal("pesan terkirim sebelum jam tenang selesai")
+	}
+
+	jam.maju(8*time.Hour + time.Minute)
+	baru.prosesMenunggu(ctx)
+	baru.simpanLog(ctx)
+	if len(kanal.terkirim) != 1 || kanal.terkirim[0].Tujuan != "081234567890" {
+		t.Fatalf("terkirim = %+v", kanal.terkirim)
+	}
+	if l := repo.terakhir(); l.Status != domain.NotifikasiTerkirim || l.Percobaan != 1 {
+		t.Errorf("log terakhir = %+v", l)
+	}
+
+	// Pesan yang sudah terkirim tidak dilanjutkan lagi
+	lagi := notifikasiUji(repo, kanal, jam)
+	lagi.muatMenunggu(ctx)
+	if len(lagi.menunggu) != 0 {
+		t.Errorf("pesan dilanjutkan = %d, ingin 0", len(lagi.menunggu))
+	}
+}
+
+func TestNotifikasiGagalDilanjutkanSetelahRestart(t *testing.T) {
+	jam := &jamUji{t: time.Date(2026, 3, 10, 7, 0, 0, 0, time.UTC)}
+	repo := &notifikasiRepoUji{preferensi: []domain.PreferensiNotifikasi{{NISN: "0012345678", Aktif: true, Kanal: []string{domain.KanalWhatsApp}}}}
+
+	tests := []struct {
+		name      string
+		gagal     error
+		berhenti  bool
+		percobaan int
+		jeda      time.Duration
+	}{
+		{"gateway gagal", errors.New("gateway mati"), false, 1, time.Minute},
+		// Percobaan yang terputus karena server berhenti tidak dihitung
+		{"server berhenti", nil, true, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.log = nil
+			kanal := &kanalUji{gagal: tt.gagal}
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.berhenti {
+				cancel()
+			}
+			defer cancel()
+
+			uc := notifikasiUji(repo, kanal, jam)
+			uc.prosesEvent(ctx, eventMasukUji(jam))
+			uc.simpanLog(context.Background())
+			l := repo.terakhir()
+			if l.Status != domain.NotifikasiGagal || l.Percobaan != tt.percobaan || !l.BerikutnyaPada.Equal(jam.Now().Add(tt.jeda)) {
+				t.Fatalf("log = %+v", l)
+			}
+
+			kanal.gagal = nil
+			baru := notifikasiUji(repo, kanal, jam)
+			baru.muatMenunggu(context.Background())
+			jam.maju(tt.jeda)
+			baru.prosesMenunggu(context.Background())
+			if len(kanal.terkirim) != 1 {
+				t.Fatalf("terkirim setelah restart = %d, ingin 1", len(kanal.terkirim))
+			}
+		})
+	}
+}
@@ -0,0 +1,780 @@
+// file: internal/usecase/notifikasi_usecase.go
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/laporan"
+)
+
+// NotifikasiRepository menyimpan preferensi notifikasi per siswa dan log pengiriman
+type NotifikasiRepository interface {
+	FindPreferensi(ctx context.Context) ([]domain.PreferensiNotifikasi, error)
+	// SavePreferensi menambah baris baru (RowNumber 0) atau menimpa baris yang ada
+	SavePreferensi(ctx context.Context, p *domain.PreferensiNotifikasi) error
+	FindLog(ctx context.Context) ([]domain.LogNotifikasi, error)
+	SaveLog(ctx context.Context, list []domain.LogNotifikasi) error
+}
+
+// KanalNotifikasi adalah pengirim pesan ke orang tua: gateway WhatsApp, gateway SMS, email,
+// atau log untuk uji coba
+type KanalNotifikasi interface {
+	Kirim(ctx context.Context, pesan domain.PesanNotifikasi) error
+}
+
+//go:embed templates/notifikasi/*
+var notifikasiTemplateFS embed.FS
+
+var notifikasiTemplates = texttemplate.Must(texttemplate.ParseFS(notifikasiTemplateFS, "templates/notifikasi/*.txt"))
+
+const (
+	ukuranAntrianNotifikasi = 2000
+	// Preferensi dan data siswa dibaca ulang dari sheet paling lama setiap interval ini
+	cachePreferensi = time.Minute
+	cacheSiswa      = 10 * time.Minute
+	// Interval pemeriksaan pesan tertunda dan penyimpanan log
+	intervalNotifikasi = 10 * time.Second
+	// Batas waktu satu percobaan kirim
+	batasKirimNotifikasi = 15 * time.Second
+	batasLogNotifikasi   = 100
+	maksLogNotifikasi    = 1000
+)
+
+// DefaultJedaUlangNotifikasi adalah jeda sebelum percobaan ke-2, ke-3, dan seterusnya
+func DefaultJedaUlangNotifikasi() []time.Duration {
+	return []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+}
+
+// NotifikasiUsecaseConfig berisi kanal yang dipasang dan aturan pengiriman
+type NotifikasiUsecaseConfig struct {
+	// Jam di zona waktu sekolah; nil berarti jam sistem di clock.DefaultTimezone
+	Clock clock.Clock
+	// Kanal yang dipasang menurut nama (whatsapp, sms, email, log); kosong berarti notifikasi mati
+	Kanal map[string]KanalNotifikasi
+	// Nama sekolah di akhir pesan
+	NamaSekolah string
+	// Batas percobaan kirim per pesan (bawaan 4)
+	MaksPercobaan int
+	// Jeda percobaan ulang; kosong berarti DefaultJedaUlangNotifikasi. Jeda terakhir dipakai
+	// lagi jika percobaan lebih banyak dari daftar.
+	JedaUlang []time.Duration
+}
+
+// tugasNotifikasi adalah satu pesan ke satu kanal yang menunggu dikirim
+type tugasNotifikasi struct {
+	id        string
+	nisn      string
+	jenis     string
+	kanal     string
+	pesan     domain.PesanNotifikasi
+	percobaan int
+	pada      time.Time
+	// JSON muatanNotifikasi, untuk menyusun ulang pesan setelah server restart
+	muatan string
+}
+
+// muatanNotifikasi adalah event asal pesan yang disimpan di log pesan gagal/ditunda. Kontak
+// orang tua tidak ikut disimpan dan dibaca lagi dari data siswa saat pesan dilanjutkan.
+type muatanNotifikasi struct {
+	Jenis string                  `json:"jenis"`
+	Waktu time.Time               `json:"waktu"`
+	Data  domain.DataEventAbsensi `json:"data"`
+}
+
+type notifikasiUsecase struct {
+	repo          NotifikasiRepository
+	siswaRepo     SiswaRepository
+	relasiRepo    RelasiWaliRepository
+	userRepo      UserRepository
+	clock         clock.Clock
+	kanal         map[string]KanalNotifikasi
+	namaSekolah   string
+	maksPercobaan int
+	jedaUlang     []time.Duration
+	antrian       chan domain.EventWebhook
+
+	// Salinan preferensi dan data siswa agar setiap scan tidak membaca sheet
+	mu               sync.Mutex
+	preferensi       map[string]domain.PreferensiNotifikasi
+	preferensiDimuat time.Time
+	siswa            map[string]domain.Siswa
+	siswaDimuat      time.Time
+	logMu            sync.Mutex
+	logBaru          []domain.LogNotifikasi
+	menunggu         []tugasNotifikasi // hanya disentuh goroutine Jalankan
+}
+
+// NewNotifikasiUsecase adalah "pabrik" untuk usecase notifikasi orang tua. Pesan hanya
+// dikirim selama Jalankan berjalan di goroutine tersendiri.
+func NewNotifikasiUsecase(repo NotifikasiRepository, siswaRepo SiswaRepository, relasiRepo RelasiWaliRepository, userRepo UserRepository, cfg NotifikasiUsecaseConfig) domain.NotifikasiUsecase {
+	maks := cfg.MaksPercobaan
+	if maks <= 0 {
+		maks = 4
+	}
+	jeda := cfg.JedaUlang
+	if len(jeda) == 0 {
+		jeda = DefaultJedaUlangNotifikasi()
+	}
+	kanal := cfg.Kanal
+	if kanal == nil {
+		kanal = map[string]KanalNotifikasi{}
+	}
+	return &notifikasiUsecase{
+		repo:          repo,
+		siswaRepo:     siswaRepo,
+		relasiRepo:    relasiRepo,
+		userRepo:      userRepo,
+		clock:         clockAtauSistem(cfg.Clock),
+		kanal:         kanal,
+		namaSekolah:   cfg.NamaSekolah,
+		maksPercobaan: maks,
+		jedaUlang:     jeda,
+		antrian:       make(chan domain.EventWebhook, ukuranAntrianNotifikasi),
+	}
+}
+
+func (uc *notifikasiUsecase) KanalTersedia() []string {
+	hasil := []string{}
+	for _, k := range []string{domain.KanalWhatsApp, domain.KanalSMS, domain.KanalEmail, domain.KanalLog} {
+		if _, ada := uc.kanal[k]; ada {
+			hasil = append(hasil, k)
+		}
+	}
+	return hasil
+}
+
+// --- PREFERENSI ---
+
+func (uc *notifikasiUsecase) muatPreferensi(ctx context.Context) (map[string]domain.PreferensiNotifikasi, error) {
+	list, err := uc.repo.FindPreferensi(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hasil := make(map[string]domain.PreferensiNotifikasi, len(list))
+	for _, p := range list {
+		hasil[p.NISN] = p
+	}
+	uc.mu.Lock()
+	uc.preferensi = hasil
+	uc.preferensiDimuat = time.Now()
+	uc.mu.Unlock()
+	return hasil, nil
+}
+
+// preferensiSiswa mengembalikan preferensi dari salinan, dibaca ulang jika sudah lama.
+// Siswa tanpa baris preferensi dianggap belum mengaktifkan notifikasi.
+func (uc *notifikasiUsecase) preferensiSiswa(ctx context.Context, nisn string) domain.PreferensiNotifikasi {
+	uc.mu.Lock()
+	daftar, dimuat := uc.preferensi, uc.preferensiDimuat
+	uc.mu.Unlock()
+	if time.Since(dimuat) >= cachePreferensi {
+		baru, err := uc.muatPreferensi(ctx)
+		if err != nil {
+			log.Printf("WARNING: Gagal membaca preferensi notifikasi: %v", err)
+		} else {
+			daftar = baru
+		}
+	}
+	if p, ada := daftar[nisn]; ada {
+		return p
+	}
+	return domain.PreferensiNotifikasi{NISN: nisn, Kanal: []string{}}
+}
+
+func (uc *notifikasiUsecase) GetPreferensi(ctx context.Context, nisn string) (*domain.PreferensiNotifikasi, error) {
+	siswa, err := uc.siswaRepo.FindByNISN(ctx, nisn)
+	if err != nil {
+		return nil, err
+	}
+	if siswa == nil {
+		return nil, domain.ErrSiswaTidakDitemukan
+	}
+	daftar, err := uc.muatPreferensi(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p, ada := daftar[nisn]; ada {
+		return &p, nil
+	}
+	return &domain.PreferensiNotifikasi{NISN: nisn, Kanal: []string{}}, nil
+}
+
+// validasi memeriksa perubahan preferensi dan mengisinya ke p
+func (uc *notifikasiUsecase) validasi(req domain.PreferensiNotifikasiRequest, p *domain.PreferensiNotifikasi) error {
+	kanal := []string{}
+	sudah := make(map[string]bool)
+	for _, k := range req.Kanal {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" || sudah[k] {
+			continue
+		}
+		if _, ada := uc.kanal[k]; !ada {
+			return fmt.Errorf("%w: kanal %q tidak tersedia (pilihan: %s)", domain.ErrPreferensiNotifikasiTidakValid,
+				k, strings.Join(uc.KanalTersedia(), ", "))
+		}
+		sudah[k] = true
+		kanal = append(kanal, k)
+	}
+	if req.Aktif && len(kanal) == 0 {
+		return fmt.Errorf("%w: pilih minimal satu kanal untuk mengaktifkan notifikasi", domain.ErrPreferensiNotifikasiTidakValid)
+	}
+	mulai, selesai := strings.TrimSpace(req.JamTenangMulai), strings.TrimSpace(req.JamTenangSelesai)
+	if (mulai == "") != (selesai == "") {
+		return fmt.Errorf("%w: jam tenang mulai dan selesai harus diisi keduanya atau dikosongkan", domain.ErrPreferensiNotifikasiTidakValid)
+	}
+	if mulai != "" {
+		m, err1 := parseJamMenit(mulai)
+		s, err2 := parseJamMenit(selesai)
+		if err1 != nil || err2 != nil {
+			return fmt.Errorf("%w: jam tenang harus berformat HH:MM", domain.ErrPreferensiNotifikasiTidakValid)
+		}
+		if m == s {
+			return fmt.Errorf("%w: jam tenang mulai dan selesai tidak boleh sama", domain.ErrPreferensiNotifikasiTidakValid)
+		}
+	}
+	p.Aktif = req.Aktif
+	p.Kanal = kanal
+	p.JamTenangMulai = mulai
+	p.JamTenangSelesai = selesai
+	return nil
+}
+
+func (uc *notifikasiUsecase) SetPreferensi(ctx context.Context, nisn string, req domain.PreferensiNotifikasiRequest, by string) (*domain.PreferensiNotifikasi, error) {
+	p, err := uc.GetPreferensi(ctx, nisn)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.validasi(req, p); err != nil {
+		return nil, err
+	}
+	p.DiubahOleh = by
+	p.DiubahPada = uc.clock.Now()
+	if err := uc.repo.SavePreferensi(ctx, p); err != nil {
+		return nil, err
+	}
+	// Paksa salinan dibaca ulang agar baris baru mendapat nomor barisnya
+	uc.mu.Lock()
+	uc.preferensiDimuat = time.Time{}
+	uc.mu.Unlock()
+	log.Printf("INFO: Preferensi notifikasi %s diubah oleh %s (aktif: %t, kanal: %s)", nisn, by, p.Aktif, strings.Join(p.Kanal, ","))
+	return p, nil
+}
+
+// anakWali mengembalikan NISN anak yang tertaut ke akun wali murid
+func (uc *notifikasiUsecase) anakWali(ctx context.Context, username string) ([]domain.RelasiWaliSiswa, error) {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return daftarAnak(ctx, uc.relasiRepo, user)
+}
+
+func (uc *notifikasiUsecase) GetPreferensiWali(ctx context.Context, username string) ([]domain.PreferensiAnak, error) {
+	relasi, err := uc.anakWali(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	daftar, err := uc.muatPreferensi(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hasil := []domain.PreferensiAnak{}
+	for _, rel := range relasi {
+		siswa, err := uc.siswaRepo.FindByNISN(ctx, rel.SiswaNISN)
+		if err != nil || siswa == nil {
+			continue
+		}
+		p, ada := daftar[rel.SiswaNISN]
+		if !ada {
+			p = domain.PreferensiNotifikasi{NISN: rel.SiswaNISN, Kanal: []string{}}
+		}
+		hasil = append(hasil, domain.PreferensiAnak{
+			PreferensiNotifikasi: p,
+			NamaLengkap:          siswa.NamaLengkap,
+			NomorTeleponOrtu:     siswa.NomorTeleponOrtu,
+			EmailOrtu:            siswa.EmailOrtu,
+		})
+	}
+	return hasil, nil
+}
+
+func (uc *notifikasiUsecase) SetPreferensiWali(ctx context.Context, username, nisn string, req domain.PreferensiNotifikasiRequest) (*domain.PreferensiNotifikasi, error) {
+	relasi, err := uc.anakWali(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range relasi {
+		if rel.SiswaNISN == nisn {
+			return uc.SetPreferensi(ctx, nisn, req, username)
+		}
+	}
+	log.Printf("WARNING: %s mencoba mengubah notifikasi siswa %s yang tidak tertaut", username, nisn)
+	return nil, domain.ErrAnakTidakTertaut
+}
+
+func (uc *notifikasiUsecase) GetLog(ctx context.Context, filter domain.FilterLogNotifikasi) ([]domain.LogNotifikasi, error) {
+	list, err := uc.repo.FindLog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	uc.logMu.Lock()
+	list = append(list, uc.logBaru...)
+	uc.logMu.Unlock()
+
+	batas := filter.Batas
+	if batas <= 0 {
+		batas = batasLogNotifikasi
+	}
+	if batas > maksLogNotifikasi {
+		batas = maksLogNotifikasi
+	}
+	hasil := []domain.LogNotifikasi{}
+	for i := len(list) - 1; i >= 0 && len(hasil) < batas; i-- {
+		l := list[i]
+		if filter.NISN != "" && l.NISN != filter.NISN {
+			continue
+		}
+		if filter.Status != "" && l.Status != filter.Status {
+			continue
+		}
+		hasil = append(hasil, l)
+	}
+	return hasil, nil
+}
+
+// --- PENGIRIMAN ---
+
+func (uc *notifikasiUsecase) Terbitkan(jenis string, data interface{}) {
+	if len(uc.kanal) == 0 || (jenis != domain.EventAbsensiMasuk && jenis != domain.EventAbsensiPulang) {
+		return
+	}
+	select {
+	case uc.antrian <- domain.EventWebhook{Jenis: jenis, Waktu: uc.clock.Now(), Data: data}:
+	default:
+		log.Printf("WARNING: Antrian notifikasi penuh, pesan %s dibuang", jenis)
+	}
+}
+
+// MintaKonfirmasi mengantrikan pesan ke orang tua siswa yang belum ada kabar. Hanya keluarga
+// yang mengaktifkan notifikasi yang dihitung; jam tenang dan percobaan ulang sama dengan
+// pesan masuk/pulang.
+func (uc *notifikasiUsecase) MintaKonfirmasi(ctx context.Context, daftar []domain.SiswaStatus) int {
+	if len(uc.kanal) == 0 {
+		return 0
+	}
+	now := uc.clock.Now()
+	jumlah := 0
+	for _, s := range daftar {
+		if !uc.preferensiSiswa(ctx, s.NISN).Aktif {
+			continue
+		}
+		data := domain.DataEventAbsensi{
+			NISN:        s.NISN,
+			NamaLengkap: s.NamaLengkap,
+			Kelas:       s.Kelas,
+			Status:      s.Status,
+			Timestamp:   now.Format(clock.LayoutWaktu),
+		}
+		select {
+		case uc.antrian <- domain.EventWebhook{Jenis: domain.JenisKonfirmasiKehadiran, Waktu: now, Data: data}:
+			jumlah++
+		default:
+			log.Printf("WARNING: Antrian notifikasi penuh, permintaan konfirmasi %s dibuang", s.NISN)
+		}
+	}
+	return jumlah
+}
+
+func (uc *notifikasiUsecase) Jalankan(ctx context.Context) {
+	if len(uc.kanal) == 0 {
+		return
+	}
+	log.Printf("INFO: Notifikasi orang tua aktif lewat kanal %s", strings.Join(uc.KanalTersedia(), ", "))
+	uc.muatMenunggu(ctx)
+	ticker := time.NewTicker(intervalNotifikasi)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// Event yang belum sempat diproses ikut dicatat sebagai pesan tertunda
+			for selesai := false; !selesai; {
+				select {
+				case ev := <-uc.antrian:
+					uc.prosesEvent(ctx, ev)
+				default:
+					selesai = true
+				}
+			}
+			uc.simpanLog(context.Background())
+			return
+		case ev := <-uc.antrian:
+			uc.prosesEvent(ctx, ev)
+		case <-ticker.C:
+			uc.prosesMenunggu(ctx)
+			uc.simpanLog(ctx)
+		}
+	}
+}
+
+// dataSiswa mengambil data siswa dari salinan; siswa baru dibaca langsung dari sheet
+func (uc *notifikasiUsecase) dataSiswa(ctx context.Context, nisn string) *domain.Siswa {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if time.Since(uc.siswaDimuat) >= cacheSiswa {
+		if list, err := uc.siswaRepo.FindAll(ctx); err == nil {
+			uc.siswa = make(map[string]domain.Siswa, len(list))
+			for _, s := range list {
+				uc.siswa[s.NISN] = s
+			}
+			uc.siswaDimuat = time.Now()
+		} else {
+			log.Printf("WARNING: Gagal membaca data siswa untuk notifikasi: %v", err)
+		}
+	}
+	if s, ada := uc.siswa[nisn]; ada {
+		return &s
+	}
+	s, err := uc.siswaRepo.FindByNISN(ctx, nisn)
+	if err != nil || s == nil {
+		return nil
+	}
+	return s
+}
+
+// isiPesan menyusun pesan dari template <jenis>.txt
+func (uc *notifikasiUsecase) isiPesan(jenis string, ev domain.DataEventAbsensi, siswa *domain.Siswa, waktu time.Time) (domain.PesanNotifikasi, error) {
+	nama, judul := "masuk.txt", "masuk"
+	jam := waktu.Format("15:04")
+	if t, err := clock.ParseWaktu(uc.clock, ev.Timestamp); err == nil {
+		waktu, jam = t, t.Format("15:04")
+	}
+	switch jenis {
+	case domain.EventAbsensiPulang:
+		nama, judul = "pulang.txt", "pulang"
+		if len(ev.TimestampPulang) >= 5 {
+			jam = ev.TimestampPulang[:5]
+		}
+	case domain.JenisKonfirmasiKehadiran:
+		nama = "konfirmasi.txt"
+	}
+	orangTua := strings.TrimSpace(siswa.NamaOrangTua)
+	if orangTua == "" {
+		orangTua = "Bapak/Ibu"
+	}
+	data := map[string]string{
+		"NamaOrangTua": orangTua,
+		"NamaSiswa":    siswa.NamaLengkap,
+		"Kelas":        siswa.Kelas,
+		"Hari":         namaHariIndonesia[waktu.Weekday()],
+		"Tanggal":      laporan.TanggalIndonesia(waktu.Format(clock.LayoutTanggal)),
+		"Jam":          jam,
+		"NamaSekolah":  keteranganAtau(uc.namaSekolah, "Daarul Ilmi"),
+	}
+	var isi bytes.Buffer
+	if err := notifikasiTemplates.ExecuteTemplate(&isi, nama, data); err != nil {
+		return domain.PesanNotifikasi{}, err
+	}
+	subjek := fmt.Sprintf("%s sudah %s (%s)", siswa.NamaLengkap, judul, jam)
+	if jenis == domain.JenisKonfirmasiKehadiran {
+		subjek = fmt.Sprintf("Mohon konfirmasi kehadiran %s (%s)", siswa.NamaLengkap, data["Tanggal"])
+	}
+	return domain.PesanNotifikasi{Subjek: subjek, Isi: isi.String()}, nil
+}
+
+// tujuanKanal mengambil kontak orang tua yang sesuai dengan kanal
+func tujuanKanal(kanal string, siswa *domain.Siswa) string {
+	switch kanal {
+	case domain.KanalWhatsApp, domain.KanalSMS:
+		return strings.TrimSpace(siswa.NomorTeleponOrtu)
+	case domain.KanalEmail:
+		return strings.TrimSpace(siswa.EmailOrtu)
+	}
+	// Kanal log menerima kontak apa saja yang ada
+	return keteranganAtau(strings.TrimSpace(siswa.NomorTeleponOrtu), strings.TrimSpace(siswa.EmailOrtu))
+}
+
+func (uc *notifikasiUsecase) prosesEvent(ctx context.Context, ev domain.EventWebhook) {
+	data, ok := ev.Data.(domain.DataEventAbsensi)
+	if !ok {
+		return
+	}
+	pref := uc.preferensiSiswa(ctx, data.NISN)
+	if !pref.Aktif {
+		return
+	}
+	siswa := uc.dataSiswa(ctx, data.NISN)
+	if siswa == nil {
+		log.Printf("WARNING: Notifikasi %s untuk NISN %s dilewati, data siswa tidak ditemukan", ev.Jenis, data.NISN)
+		return
+	}
+	pesan, err := uc.isiPesan(ev.Jenis, data, siswa, ev.Waktu)
+	if err != nil {
+		log.Printf("ERROR menyusun notifikasi %s: %v", ev.Jenis, err)
+		return
+	}
+	muatan, err := json.Marshal(muatanNotifikasi{Jenis: ev.Jenis, Waktu: ev.Waktu, Data: data})
+	if err != nil {
+		log.Printf("ERROR membuat JSON notifikasi %s: %v", ev.Jenis, err)
+		return
+	}
+
+	for _, k := range pref.Kanal {
+		t := tugasNotifikasi{
+			id:     idBaru("NTF") + "-" + k,
+			nisn:   data.NISN,
+			jenis:  ev.Jenis,
+			kanal:  k,
+			pesan:  pesan,
+			muatan: string(muatan),
+		}
+		t.pesan.Tujuan = tujuanKanal(k, siswa)
+		if _, ada := uc.kanal[k]; !ada || t.pesan.Tujuan == "" {
+			alasan := "kontak orang tua untuk kanal ini kosong"
+			if !ada {
+				alasan = "kanal tidak dipasang di server"
+			}
+			uc.catat(t, domain.NotifikasiMenyerah, alasan)
+			continue
+		}
+		if selesai, tenang := jamTenang(pref, uc.clock.Now()); tenang {
+			t.pada = selesai
+			uc.menunggu = append(uc.menunggu, t)
+			uc.catat(t, domain.NotifikasiDitunda, "jam tenang sampai "+selesai.Format("15:04"))
+			continue
+		}
+		uc.kirim(ctx, t)
+	}
+}
+
+// prosesMenunggu mengirim pesan yang sudah waktunya (selesai jam tenang atau percobaan ulang).
+// Preferensi diperiksa lagi karena keluarga bisa mematikan notifikasi sementara itu.
+func (uc *notifikasiUsecase) prosesMenunggu(ctx context.Context) {
+	if len(uc.menunggu) == 0 {
+		return
+	}
+	now := uc.clock.Now()
+	var jatuhTempo []tugasNotifikasi
+	sisa := uc.menunggu[:0]
+	for _, t := range uc.menunggu {
+		if t.pada.After(now) {
+			sisa = append(sisa, t)
+		} else {
+			jatuhTempo = append(jatuhTempo, t)
+		}
+	}
+	uc.menunggu = sisa
+
+	for _, t := range jatuhTempo {
+		pref := uc.preferensiSiswa(ctx, t.nisn)
+		dipilih := false
+		for _, k := range pref.Kanal {
+			dipilih = dipilih || k == t.kanal
+		}
+		if !pref.Aktif || !dipilih {
+			uc.catat(t, domain.NotifikasiMenyerah, "notifikasi dimatikan keluarga sebelum terkirim")
+			continue
+		}
+		if _, ada := uc.kanal[t.kanal]; !ada {
+			uc.catat(t, domain.NotifikasiMenyerah, "kanal tidak dipasang di server")
+			continue
+		}
+		if selesai, tenang := jamTenang(pref, now); tenang {
+			t.pada = selesai
+			uc.menunggu = append(uc.menunggu, t)
+			continue
+		}
+		uc.kirim(ctx, t)
+	}
+}
+
+// kirim melakukan satu percobaan dan menjadwalkan percobaan ulang jika gagal. Jika server
+// berhenti, percobaan itu tidak dihitung dan pesan dicatat tertunda agar dilanjutkan
+// muatMenunggu setelah server hidup lagi.
+func (uc *notifikasiUsecase) kirim(ctx context.Context, t tugasNotifikasi) {
+	var err error
+	if ctx.Err() == nil {
+		kirimCtx, cancel := context.WithTimeout(ctx, batasKirimNotifikasi)
+		err = uc.kanal[t.kanal].Kirim(kirimCtx, t.pesan)
+		cancel()
+		if err == nil {
+			t.percobaan++
+			uc.catat(t, domain.NotifikasiTerkirim, "")
+			return
+		}
+	}
+	if ctx.Err() != nil {
+		t.pada = uc.clock.Now()
+		uc.menunggu = append(uc.menunggu, t)
+		uc.catat(t, domain.NotifikasiGagal, "server berhenti sebelum pesan terkirim")
+		return
+	}
+	t.percobaan++
+	if t.percobaan < uc.maksPercobaan {
+		jeda := uc.jedaUlang[len(uc.jedaUlang)-1]
+		if t.percobaan-1 < len(uc.jedaUlang) {
+			jeda = uc.jedaUlang[t.percobaan-1]
+		}
+		t.pada = uc.clock.Now().Add(jeda)
+		uc.menunggu = append(uc.menunggu, t)
+		uc.catat(t, domain.NotifikasiGagal, err.Error())
+		return
+	}
+	log.Printf("WARNING: Notifikasi %s ke %s (%s) gagal setelah %d percobaan: %v", t.jenis, t.nisn, t.kanal, t.percobaan, err)
+	uc.catat(t, domain.NotifikasiMenyerah, err.Error())
+}
+
+// catat memasukkan hasil ke antrian log; tujuan disamarkan karena log bisa dibaca admin lain
+func (uc *notifikasiUsecase) catat(t tugasNotifikasi, status, pesanError string) {
+	if len(pesanError) > 200 {
+		pesanError = pesanError[:200]
+	}
+	l := domain.LogNotifikasi{
+		ID:        t.id,
+		Waktu:     uc.clock.Now(),
+		NISN:      t.nisn,
+		Jenis:     t.jenis,
+		Kanal:     t.kanal,
+		Tujuan:    samarkanTujuan(t.pesan.Tujuan),
+		Status:    status,
+		Percobaan: t.percobaan,
+		Error:     pesanError,
+	}
+	if status == domain.NotifikasiGagal || status == domain.NotifikasiDitunda {
+		l.BerikutnyaPada, l.Payload = t.pada, t.muatan
+	}
+	uc.logMu.Lock()
+	uc.logBaru = append(uc.logBaru, l)
+	uc.logMu.Unlock()
+}
+
+// muatMenunggu melanjutkan pesan yang gagal atau ditunda jam tenang sebelum server restart.
+// Percobaan terakhir setiap pesan ada di baris paling bawah; pesan disusun ulang dari event
+// asalnya dengan kontak orang tua yang terbaru.
+func (uc *notifikasiUsecase) muatMenunggu(ctx context.Context) {
+	list, err := uc.repo.FindLog(ctx)
+	if err != nil {
+		log.Printf("WARNING: Gagal membaca log notifikasi, pesan tertunda lama dilewati: %v", err)
+		return
+	}
+	terakhir := make(map[string]domain.LogNotifikasi)
+	var urutan []string
+	for _, l := range list {
+		if l.ID == "" {
+			continue
+		}
+		if _, ada := terakhir[l.ID]; !ada {
+			urutan = append(urutan, l.ID)
+		}
+		terakhir[l.ID] = l
+	}
+	for _, id := range urutan {
+		l := terakhir[id]
+		if (l.Status != domain.NotifikasiGagal && l.Status != domain.NotifikasiDitunda) || l.BerikutnyaPada.IsZero() || l.Payload == "" {
+			continue
+		}
+		t := tugasNotifikasi{id: l.ID, nisn: l.NISN, jenis: l.Jenis, kanal: l.Kanal, percobaan: l.Percobaan, pada: l.BerikutnyaPada, muatan: l.Payload}
+		var m muatanNotifikasi
+		if err := json.Unmarshal([]byte(l.Payload), &m); err != nil {
+			uc.catat(t, domain.NotifikasiMenyerah, "isi pesan tertunda tidak bisa dibaca")
+			continue
+		}
+		siswa := uc.dataSiswa(ctx, l.NISN)
+		if siswa == nil {
+			uc.catat(t, domain.NotifikasiMenyerah, "data siswa tidak ditemukan")
+			continue
+		}
+		if t.pesan, err = uc.isiPesan(m.Jenis, m.Data, siswa, m.Waktu); err != nil {
+			uc.catat(t, domain.NotifikasiMenyerah, err.Error())
+			continue
+		}
+		if t.pesan.Tujuan = tujuanKanal(t.kanal, siswa); t.pesan.Tujuan == "" {
+			uc.catat(t, domain.NotifikasiMenyerah, "kontak orang tua untuk kanal ini kosong")
+			continue
+		}
+		uc.menunggu = append(uc.menunggu, t)
+	}
+	if len(uc.menunggu) > 0 {
+		log.Printf("INFO: %d notifikasi tertunda dilanjutkan", len(uc.menunggu))
+	}
+}
+
+// simpanLog menulis log yang belum tersimpan dalam satu append; jika gagal dicoba lagi nanti
+func (uc *notifikasiUsecase) simpanLog(ctx context.Context) {
+	uc.logMu.Lock()
+	list := uc.logBaru
+	uc.logBaru = nil
+	uc.logMu.Unlock()
+	if len(list) == 0 {
+		return
+	}
+	if err := uc.repo.SaveLog(ctx, list); err != nil {
+		uc.logMu.Lock()
+		uc.logBaru = append(list, uc.logBaru...)
+		uc.logMu.Unlock()
+	}
+}
+
+// jamTenang memeriksa apakah now berada di jam tenang keluarga dan mengembalikan kapan jam
+// tenang itu selesai. Jam tenang boleh melewati tengah malam, misal 21:00 s.d. 06:00.
+func jamTenang(p domain.PreferensiNotifikasi, now time.Time) (time.Time, bool) {
+	if p.JamTenangMulai == "" || p.JamTenangSelesai == "" {
+		return time.Time{}, false
+	}
+	mulai, err1 := parseJamMenit(p.JamTenangMulai)
+	selesai, err2 := parseJamMenit(p.JamTenangSelesai)
+	if err1 != nil || err2 != nil || mulai == selesai {
+		return time.Time{}, false
+	}
+	menit := now.Hour()*60 + now.Minute()
+	tengahMalam := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	akhirHariIni := tengahMalam.Add(time.Duration(selesai) * time.Minute)
+	if mulai < selesai {
+		if menit >= mulai && menit < selesai {
+			return akhirHariIni, true
+		}
+		return time.Time{}, false
+	}
+	// Melewati tengah malam
+	switch {
+	case menit >= mulai:
+		return akhirHariIni.AddDate(0, 0, 1), true
+	case menit < selesai:
+		return akhirHariIni, true
+	}
+	return time.Time{}, false
+}
+
+// parseJamMenit membaca "HH:MM" menjadi menit sejak tengah malam
+func parseJamMenit(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// samarkanTujuan menyisakan awal dan akhir kontak, misal "0812****789" atau "ay***@gmail.com"
+func samarkanTujuan(s string) string {
+	if i := strings.Index(s, "@"); i >= 0 {
+		nama := s[:i]
+		if len(nama) > 2 {
+			nama = nama[:2] + strings.Repeat("*", len(nama)-2)
+		}
+		return nama + s[i:]
+	}
+	if len(s) <= 7 {
+		return s
+	}
+	return s[:4] + strings.Repeat("*", len(s)-7) + s[len(s)-3:]
+}
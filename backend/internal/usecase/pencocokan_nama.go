@@ -0,0 +1,202 @@
+// file: internal/usecase/pencocokan_nama.go
+package usecase
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+const (
+	// Skor minimal agar nama otomatis dianggap cocok tanpa perlu keputusan admin
+	skorCocokOtomatis = 0.9
+	// Selisih minimal antara kandidat terbaik dan kandidat kedua agar tidak dianggap ambigu
+	selisihKandidatMinimal = 0.1
+	// Skor minimal agar seorang siswa ditampilkan sebagai kandidat di antrian rekonsiliasi
+	skorKandidatMinimal = 0.5
+	// Jumlah kandidat maksimal yang disimpan per antrian
+	jumlahKandidatMaksimal = 5
+)
+
+// Kamus singkatan dan variasi ejaan nama yang umum di formulir orang tua
+var singkatanNama = map[string]string{
+	"m":         "muhammad",
+	"mh":        "muhammad",
+	"mhd":       "muhammad",
+	"muh":       "muhammad",
+	"moh":       "muhammad",
+	"mohd":      "muhammad",
+	"moch":      "muhammad",
+	"mochamad":  "muhammad",
+	"mochammad": "muhammad",
+	"mohamad":   "muhammad",
+	"mohammad":  "muhammad",
+	"muhamad":   "muhammad",
+	"muhammed":  "muhammad",
+	"achmad":    "ahmad",
+	"akhmad":    "ahmad",
+	"ahmed":     "ahmad",
+	"abd":       "abdul",
+	"abdl":      "abdul",
+	"st":        "siti",
+}
+
+// normalisasiNama menyeragamkan nama: huruf kecil, tanpa tanda baca, spasi tunggal, singkatan dibakukan
+func normalisasiNama(nama string) string {
+	return strings.Join(tokenNama(nama), " ")
+}
+
+func tokenNama(nama string) []string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case r == '\'' || r == '`' || r == '’':
+			return -1 // "Ma'ruf" -> "maruf"
+		default:
+			return ' '
+		}
+	}, nama)
+
+	var tokens []string
+	for _, t := range strings.Fields(cleaned) {
+		if baku, ok := singkatanNama[t]; ok {
+			t = baku
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+// normalisasiKelas menyeragamkan penulisan kelas, misal "X-A", "10 a" dan "x a" menjadi "xa"
+func normalisasiKelas(kelas string) string {
+	k := strings.ToLower(kelas)
+	k = strings.NewReplacer("kelas", "", "-", "", " ", "", ".", "").Replace(k)
+	for angka, romawi := range map[string]string{"12": "xii", "11": "xi", "10": "x", "9": "ix", "8": "viii", "7": "vii"} {
+		if strings.HasPrefix(k, angka) {
+			k = romawi + strings.TrimPrefix(k, angka)
+			break
+		}
+	}
+	return k
+}
+
+// jarakEdit menghitung Levenshtein distance antara dua string
+func jarakEdit(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// kemiripan mengembalikan nilai 0..1 berdasarkan jarak edit
+func kemiripan(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	maxLen := max(len([]rune(a)), len([]rune(b)))
+	if maxLen == 0 {
+		return 0
+	}
+	return 1 - float64(jarakEdit(a, b))/float64(maxLen)
+}
+
+// kemiripanToken membandingkan satu kata, dengan memperlakukan inisial ("r" untuk "rizki") secara khusus
+func kemiripanToken(input, kandidat string) float64 {
+	if len([]rune(input)) == 1 {
+		if strings.HasPrefix(kandidat, input) {
+			return 0.9
+		}
+		return 0
+	}
+	return kemiripan(input, kandidat)
+}
+
+// rataRataTerbaik menghitung rata-rata kemiripan terbaik setiap token `dari` terhadap token `ke`
+func rataRataTerbaik(dari, ke []string) float64 {
+	if len(dari) == 0 || len(ke) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, a := range dari {
+		best := 0.0
+		for _, b := range ke {
+			if s := kemiripanToken(a, b); s > best {
+				best = s
+			}
+		}
+		total += best
+	}
+	return total / float64(len(dari))
+}
+
+// skorNama menilai seberapa mirip nama yang diketik dengan nama resmi siswa (0..1)
+func skorNama(input, resmi string) float64 {
+	tokIn, tokResmi := tokenNama(input), tokenNama(resmi)
+	if len(tokIn) == 0 || len(tokResmi) == 0 {
+		return 0
+	}
+	utuh := kemiripan(strings.Join(tokIn, " "), strings.Join(tokResmi, " "))
+	// Bobot lebih besar ke arah input -> resmi, karena orang tua sering hanya mengetik sebagian nama
+	perKata := 0.7*rataRataTerbaik(tokIn, tokResmi) + 0.3*rataRataTerbaik(tokResmi, tokIn)
+	return max(utuh, perKata)
+}
+
+// cariKandidatSiswa mengurutkan siswa berdasarkan kemiripan nama, dengan kelas sebagai petunjuk tambahan
+func cariKandidatSiswa(nama, kelas string, allSiswa []domain.Siswa) []domain.KandidatSiswa {
+	kelasNormal := normalisasiKelas(kelas)
+	var kandidat []domain.KandidatSiswa
+	for _, siswa := range allSiswa {
+		skor := skorNama(nama, siswa.NamaLengkap)
+		if kelasNormal != "" && siswa.Kelas != "" {
+			if normalisasiKelas(siswa.Kelas) == kelasNormal {
+				skor += 0.1
+			} else {
+				skor -= 0.1
+			}
+		}
+		skor = min(max(skor, 0), 1)
+		if skor < skorKandidatMinimal {
+			continue
+		}
+		kandidat = append(kandidat, domain.KandidatSiswa{
+			NISN:        siswa.NISN,
+			NamaLengkap: siswa.NamaLengkap,
+			Kelas:       siswa.Kelas,
+			Skor:        float64(int(skor*1000)) / 1000,
+		})
+	}
+
+	sort.SliceStable(kandidat, func(i, j int) bool { return kandidat[i].Skor > kandidat[j].Skor })
+	if len(kandidat) > jumlahKandidatMaksimal {
+		kandidat = kandidat[:jumlahKandidatMaksimal]
+	}
+	return kandidat
+}
+
+// cocokDenganYakin bernilai true jika kandidat terbaik cukup tinggi dan jelas unggul dari kandidat kedua
+func cocokDenganYakin(kandidat []domain.KandidatSiswa) bool {
+	if len(kandidat) == 0 || kandidat[0].Skor < skorCocokOtomatis {
+		return false
+	}
+	if len(kandidat) > 1 && kandidat[0].Skor-kandidat[1].Skor < selisihKandidatMinimal {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"testing"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+func TestNormalisasiNama(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"Muhammad Rizki", "muhammad rizki"},
+		{"M. Rizki", "muhammad rizki"},
+		{"Mhd  Rizki", "muhammad rizki"},
+		{"Moch. Ma'ruf", "muhammad maruf"},
+		{"Achmad-Fauzi", "ahmad fauzi"},
+		{"St. Aisyah", "siti aisyah"},
+		{"  ", ""},
+	}
+	for _, tt := range tests {
+		if got := normalisasiNama(tt.input); got != tt.want {
+			t.Errorf("normalisasiNama(%q) = %q, ingin %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNormalisasiKelas(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"X-A", "xa"},
+		{"10 a", "xa"},
+		{"x a", "xa"},
+		{"Kelas 7B", "viib"},
+		{"12.IPA", "xiiipa"},
+	}
+	for _, tt := range tests {
+		if got := normalisasiKelas(tt.input); got != tt.want {
+			t.Errorf("normalisasiKelas(%q) = %q, ingin %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestJarakEdit(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"rizki", "rizky", 1},
+		{"kitten", "sitting", 3},
+		{"aisyah", "aisyah", 0},
+		{"fauzi", "fauziah", 2},
+	}
+	for _, tt := range tests {
+		if got := jarakEdit(tt.a, tt.b); got != tt.want {
+			t.Errorf("jarakEdit(%q, %q) = %d, ingin %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSkorNama(t *testing.T) {
+	tests := []struct {
+		name         string
+		input, resmi string
+		min, max     float64
+	}{
+		{"sama persis", "Muhammad Rizki", "Muhammad Rizki", 1, 1},
+		{"singkatan", "M. Rizki", "Muhammad Rizki", 1, 1},
+		{"salah ketik satu huruf", "Muhammad Rizky", "Muhammad Rizki", 0.9, 1},
+		{"inisial masih perlu keputusan admin", "Muhammad R", "Muhammad Rizki", 0.8, 0.9},
+		{"sebagian nama", "Aisyah", "Siti Aisyah Putri", 0.7, 0.9},
+		{"nama lain", "Budi Santoso", "Siti Aisyah", 0, 0.5},
+		{"kosong", "", "Siti Aisyah", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := skorNama(tt.input, tt.resmi)
+			if got < tt.min || got > tt.max {
+				t.Errorf("skorNama(%q, %q) = %.3f, ingin antara %.2f dan %.2f", tt.input, tt.resmi, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestCariKandidatSiswa(t *testing.T) {
+	allSiswa := []domain.Siswa{
+		{NISN: "1", NamaLengkap: "Muhammad Rizki", Kelas: "X-A"},
+		{NISN: "2", NamaLengkap: "Muhammad Rizky", Kelas: "X-B"},
+		{NISN: "3", NamaLengkap: "Siti Aisyah", Kelas: "X-A"},
+	}
+	tests := []struct {
+		name      string
+		nama      string
+		kelas     string
+		wantNISN  string
+		wantYakin bool
+	}{
+		{"kelas memecah nama kembar", "M. Rizki", "10 A", "1", true},
+		{"tanpa kelas nama kembar ambigu", "Muhammad Rizk", "", "1", false},
+		{"nama unik", "Siti Aisyah", "", "3", true},
+		{"tidak ada kandidat", "Budi Santoso", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kandidat := cariKandidatSiswa(tt.nama, tt.kelas, allSiswa)
+			if tt.wantNISN == "" {
+				if len(kandidat) != 0 {
+					t.Fatalf("ingin tanpa kandidat, dapat %+v", kandidat)
+				}
+			} else if len(kandidat) == 0 || kandidat[0].NISN != tt.wantNISN {
+				t.Fatalf("kandidat terbaik ingin NISN %s, dapat %+v", tt.wantNISN, kandidat)
+			}
+			if got := cocokDenganYakin(kandidat); got != tt.wantYakin {
+				t.Errorf("cocokDenganYakin = %v, ingin %v (kandidat %+v)", got, tt.wantYakin, kandidat)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// file: internal/usecase/rekonsiliasi_usecase.go
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// RekonsiliasiRepository menyimpan alias nama yang sudah diputuskan dan antrian nama yang ambigu
+type RekonsiliasiRepository interface {
+	GetAliases(ctx context.Context) (map[string]string, error)
+	SaveAlias(ctx context.Context, alias *domain.AliasNamaSiswa) error
+	GetQueue(ctx context.Context) ([]domain.RekonsiliasiNama, error)
+	Enqueue(ctx context.Context, item *domain.RekonsiliasiNama) error
+	UpdateQueueItem(ctx context.Context, item *domain.RekonsiliasiNama) error
+}
+
+type rekonsiliasiUsecase struct {
+	repo      RekonsiliasiRepository
+	siswaRepo SiswaRepository
+	clock     clock.Clock
+}
+
+// NewRekonsiliasiUsecase adalah "pabrik" untuk usecase rekonsiliasi nama
+func NewRekonsiliasiUsecase(repo RekonsiliasiRepository, siswaRepo SiswaRepository, clk clock.Clock) domain.RekonsiliasiUsecase {
+	return &rekonsiliasiUsecase{
+		repo:      repo,
+		siswaRepo: siswaRepo,
+		clock:     clockAtauSistem(clk),
+	}
+}
+
+// ResolveLeaveRequests mengisi NISN untuk pengajuan izin yang namanya tidak cocok persis.
+// Urutannya: alias dari keputusan admin, lalu pencocokan otomatis, dan sisanya masuk antrian.
+func (uc *rekonsiliasiUsecase) ResolveLeaveRequests(ctx context.Context, requests []domain.PengajuanIzinLengkap) error {
+	perluDicocokkan := false
+	for _, req := range requests {
+		if req.SiswaNISN == domain.NISNTidakDitemukan {
+			perluDicocokkan = true
+			break
+		}
+	}
+	if !perluDicocokkan {
+		return nil
+	}
+
+	allSiswa, err := uc.siswaRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	nisnToNama := make(map[string]string)
+	for _, siswa := range allSiswa {
+		nisnToNama[siswa.NISN] = siswa.NamaLengkap
+	}
+
+	aliases, err := uc.repo.GetAliases(ctx)
+	if err != nil {
+		return err
+	}
+
+	queue, err := uc.repo.GetQueue(ctx)
+	if err != nil {
+		return err
+	}
+	sudahDiantrikan := make(map[string]bool)
+	for _, item := range queue {
+		if item.Status == domain.RekonsiliasiMenunggu {
+			sudahDiantrikan[normalisasiNama(item.NamaInput)] = true
+		}
+	}
+
+	for i := range requests {
+		req := &requests[i]
+		if req.SiswaNISN != domain.NISNTidakDitemukan {
+			continue
+		}
+		namaNormal := normalisasiNama(req.NamaLengkap)
+
+		// 1. Nama ini pernah diputuskan admin
+		if nisn, ok := aliases[namaNormal]; ok {
+			req.SiswaNISN = nisn
+			if nama, ok := nisnToNama[nisn]; ok {
+				req.NamaLengkap = nama
+			}
+			continue
+		}
+
+		// 2. Coba cocokkan otomatis
+		kandidat := cariKandidatSiswa(req.NamaLengkap, req.KelasInput, allSiswa)
+		if cocokDenganYakin(kandidat) {
+			log.Printf("INFO: Nama '%s' dicocokkan otomatis ke %s (%s, skor %.2f)", req.NamaLengkap, kandidat[0].NamaLengkap, kandidat[0].NISN, kandidat[0].Skor)
+			req.SiswaNISN = kandidat[0].NISN
+			req.NamaLengkap = kandidat[0].NamaLengkap
+			continue
+		}
+
+		// 3. Ambigu: masukkan ke antrian (sekali saja per nama)
+		if sudahDiantrikan[namaNormal] {
+			continue
+		}
+		item := &domain.RekonsiliasiNama{
+			ID:         idBaru("REK"),
+			Timestamp:  clock.Timestamp(uc.clock),
+			NamaInput:  req.NamaLengkap,
+			KelasInput: req.KelasInput,
+			Kandidat:   kandidat,
+			Status:     domain.RekonsiliasiMenunggu,
+		}
+		if err := uc.repo.Enqueue(ctx, item); err != nil {
+			log.Printf("WARNING: Gagal memasukkan '%s' ke antrian rekonsiliasi: %v", req.NamaLengkap, err)
+			continue
+		}
+		sudahDiantrikan[namaNormal] = true
+	}
+	return nil
+}
+
+func (uc *rekonsiliasiUsecase) GetQueue(ctx context.Context, status string) ([]domain.RekonsiliasiNama, error) {
+	queue, err := uc.repo.GetQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		return queue, nil
+	}
+
+	var filtered []domain.RekonsiliasiNama
+	for _, item := range queue {
+		if item.Status == status {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
+
+// ResolveQueueItem mencatat keputusan admin dan mengingatnya sebagai alias untuk baris-baris berikutnya
+func (uc *rekonsiliasiUsecase) ResolveQueueItem(ctx context.Context, id, nisn, resolvedBy string) error {
+	siswa, err := uc.siswaRepo.FindByNISN(ctx, nisn)
+	if err != nil {
+		return err
+	}
+	if siswa == nil {
+		return errors.New("NISN siswa tidak ditemukan")
+	}
+
+	queue, err := uc.repo.GetQueue(ctx)
+	if err != nil {
+		return err
+	}
+
+	var target *domain.RekonsiliasiNama
+	for i := range queue {
+		if queue[i].ID == id {
+			target = &queue[i]
+			break
+		}
+	}
+	if target == nil {
+		return errors.New("antrian rekonsiliasi tidak ditemukan")
+	}
+	if target.Status == domain.RekonsiliasiSelesai {
+		return errors.New("antrian rekonsiliasi sudah diselesaikan")
+	}
+
+	namaNormal := normalisasiNama(target.NamaInput)
+	err = uc.repo.SaveAlias(ctx, &domain.AliasNamaSiswa{
+		NamaNormal:  namaNormal,
+		NISN:        siswa.NISN,
+		DicatatOleh: resolvedBy,
+		Timestamp:   clock.Timestamp(uc.clock),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Tutup juga antrian lain yang namanya sama setelah dinormalisasi
+	for i := range queue {
+		item := &queue[i]
+		if item.Status != domain.RekonsiliasiMenunggu || normalisasiNama(item.NamaInput) != namaNormal {
+			continue
+		}
+		item.Status = domain.RekonsiliasiSelesai
+		item.NISNTerpilih = siswa.NISN
+		item.DiselesaikanOleh = resolvedBy
+		if err := uc.repo.UpdateQueueItem(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
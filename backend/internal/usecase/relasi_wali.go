@@ -0,0 +1,46 @@
+// file: internal/usecase/relasi_wali.go
+package usecase
+
+import (
+	"context"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// RelasiWaliRepository menyimpan tautan banyak-ke-banyak antara akun wali murid dan siswa
+type RelasiWaliRepository interface {
+	FindAll(ctx context.Context) ([]domain.RelasiWaliSiswa, error)
+	FindByUsername(ctx context.Context, username string) ([]domain.RelasiWaliSiswa, error)
+	Save(ctx context.Context, relasi *domain.RelasiWaliSiswa) error
+	Delete(ctx context.Context, username, nisn string) error
+}
+
+// daftarAnak mengembalikan semua siswa yang tertaut ke akun. Kolom SiswaNISN di DataPengguna
+// (tautan lama, hanya satu anak) tetap dihitung agar akun lama tidak perlu dimigrasi.
+func daftarAnak(ctx context.Context, relasiRepo RelasiWaliRepository, user *domain.User) ([]domain.RelasiWaliSiswa, error) {
+	relasi, err := relasiRepo.FindByUsername(ctx, user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	hasil := []domain.RelasiWaliSiswa{}
+	sudahAda := make(map[string]bool)
+	if user.SiswaNISN != "" {
+		hasil = append(hasil, domain.RelasiWaliSiswa{Username: user.Username, SiswaNISN: user.SiswaNISN})
+		sudahAda[user.SiswaNISN] = true
+	}
+	for _, rel := range relasi {
+		if sudahAda[rel.SiswaNISN] {
+			// Tautan lama tanpa keterangan hubungan dilengkapi dari sheet relasi
+			for i := range hasil {
+				if hasil[i].SiswaNISN == rel.SiswaNISN && hasil[i].Hubungan == "" {
+					hasil[i].Hubungan = rel.Hubungan
+				}
+			}
+			continue
+		}
+		sudahAda[rel.SiswaNISN] = true
+		hasil = append(hasil, rel)
+	}
+	return hasil, nil
+}
@@ -0,0 +1,263 @@
+// file: internal/usecase/semester_usecase.go
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// SemesterRepository menyimpan semester dan salinan rekap semester yang sudah dikunci
+type SemesterRepository interface {
+	FindAll(ctx context.Context) ([]domain.Semester, error)
+	Save(ctx context.Context, s *domain.Semester) error
+	Update(ctx context.Context, s *domain.Semester) error
+	Delete(ctx context.Context, rowNumber int) error
+	FindRekap(ctx context.Context, semesterID string) ([]domain.RekapSiswa, error)
+	SaveRekap(ctx context.Context, semesterID string, list []domain.RekapSiswa) error
+	DeleteRekap(ctx context.Context, semesterID string) error
+}
+
+// SemesterUsecaseConfig berisi pengaturan usecase semester dari konfigurasi server
+type SemesterUsecaseConfig struct {
+	// Jam di zona waktu sekolah; nil berarti jam sistem di clock.DefaultTimezone
+	Clock clock.Clock
+}
+
+type semesterUsecase struct {
+	repo    SemesterRepository
+	absensi domain.AbsensiUsecase
+	clock   clock.Clock
+}
+
+// NewSemesterUsecase adalah "pabrik" untuk usecase semester. Rekap semester terbuka dihitung
+// dengan rekap absensi biasa sehingga angkanya sama dengan halaman rekap.
+func NewSemesterUsecase(repo SemesterRepository, absensi domain.AbsensiUsecase, cfg SemesterUsecaseConfig) domain.SemesterUsecase {
+	return &semesterUsecase{
+		repo:    repo,
+		absensi: absensi,
+		clock:   clockAtauSistem(cfg.Clock),
+	}
+}
+
+func (uc *semesterUsecase) List(ctx context.Context) ([]domain.Semester, error) {
+	list, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Semester terbaru di atas
+	sort.SliceStable(list, func(i, j int) bool { return list[i].TanggalMulai > list[j].TanggalMulai })
+	return list, nil
+}
+
+// validasi memeriksa data dari admin dan mengisinya ke semester. Rentang semester tidak boleh
+// beririsan dengan semester lain agar satu hari tidak terhitung di dua raport.
+func (uc *semesterUsecase) validasi(req domain.SemesterRequest, s *domain.Semester, semua []domain.Semester) error {
+	nama := strings.TrimSpace(req.Nama)
+	if nama == "" {
+		return fmt.Errorf("%w: nama semester wajib diisi", domain.ErrSemesterTidakValid)
+	}
+	start, err := clock.ParseTanggal(uc.clock, req.TanggalMulai)
+	if err != nil {
+		return fmt.Errorf("%w: tanggal mulai harus berformat YYYY-MM-DD", domain.ErrRentangTanggal)
+	}
+	end, err := clock.ParseTanggal(uc.clock, req.TanggalSelesai)
+	if err != nil {
+		return fmt.Errorf("%w: tanggal selesai harus berformat YYYY-MM-DD", domain.ErrRentangTanggal)
+	}
+	if end.Before(start) {
+		return fmt.Errorf("%w: tanggal selesai sebelum tanggal mulai", domain.ErrRentangTanggal)
+	}
+	if end.After(start.AddDate(0, 0, maksHariRentang)) {
+		return fmt.Errorf("%w: maksimal %d hari", domain.ErrRentangTanggal, maksHariRentang)
+	}
+	mulai, selesai := start.Format(clock.LayoutTanggal), end.Format(clock.LayoutTanggal)
+	for _, lain := range semua {
+		if lain.ID == s.ID {
+			continue
+		}
+		if strings.EqualFold(lain.Nama, nama) {
+			return fmt.Errorf("%w: nama %q sudah dipakai", domain.ErrSemesterTidakValid, nama)
+		}
+		if mulai <= lain.TanggalSelesai && selesai >= lain.TanggalMulai {
+			return fmt.Errorf("%w: rentang beririsan dengan semester %s (%s s.d. %s)", domain.ErrSemesterTidakValid,
+				lain.Nama, lain.TanggalMulai, lain.TanggalSelesai)
+		}
+	}
+	s.Nama = nama
+	s.TanggalMulai = mulai
+	s.TanggalSelesai = selesai
+	return nil
+}
+
+func (uc *semesterUsecase) Create(ctx context.Context, req domain.SemesterRequest, by string) (*domain.Semester, error) {
+	semua, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s := &domain.Semester{
+		ID:         idBaru("SEM"),
+		Status:     domain.SemesterTerbuka,
+		DibuatOleh: by,
+		DibuatPada: uc.clock.Now(),
+	}
+	if err := uc.validasi(req, s, semua); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.Save(ctx, s); err != nil {
+		return nil, err
+	}
+	log.Printf("INFO: Semester %s (%s, %s s.d. %s) dibuat oleh %s", s.ID, s.Nama, s.TanggalMulai, s.TanggalSelesai, by)
+	return s, nil
+}
+
+// cari mengembalikan semester berdasarkan ID beserta daftar semua semester
+func (uc *semesterUsecase) cari(ctx context.Context, id string) (*domain.Semester, []domain.Semester, error) {
+	semua, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range semua {
+		if semua[i].ID == id {
+			return &semua[i], semua, nil
+		}
+	}
+	return nil, nil, domain.ErrSemesterTidakDitemukan
+}
+
+func (uc *semesterUsecase) Update(ctx context.Context, id string, req domain.SemesterRequest, by string) (*domain.Semester, error) {
+	s, semua, err := uc.cari(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Status == domain.SemesterTerkunci {
+		return nil, domain.ErrSemesterTerkunci
+	}
+	if err := uc.validasi(req, s, semua); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.Update(ctx, s); err != nil {
+		return nil, err
+	}
+	log.Printf("INFO: Semester %s diubah menjadi %s (%s s.d. %s) oleh %s", s.ID, s.Nama, s.TanggalMulai, s.TanggalSelesai, by)
+	return s, nil
+}
+
+func (uc *semesterUsecase) Delete(ctx context.Context, id, by string) error {
+	s, _, err := uc.cari(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.Status == domain.SemesterTerkunci {
+		return domain.ErrSemesterTerkunci
+	}
+	if err := uc.repo.Delete(ctx, s.RowNumber); err != nil {
+		return err
+	}
+	log.Printf("INFO: Semester %s (%s) dihapus oleh %s", s.ID, s.Nama, by)
+	return nil
+}
+
+// hitungRekap menghitung rekap langsung dari LogAbsensi untuk seluruh rentang semester
+func (uc *semesterUsecase) hitungRekap(ctx context.Context, s *domain.Semester) ([]domain.RekapSiswa, error) {
+	return uc.absensi.GetRekapByDateRange(ctx, s.TanggalMulai, s.TanggalSelesai)
+}
+
+func (uc *semesterUsecase) GetRekap(ctx context.Context, id, kelas string) (*domain.RekapSemester, error) {
+	s, _, err := uc.cari(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	terkunci := s.Status == domain.SemesterTerkunci
+	var list []domain.RekapSiswa
+	if terkunci {
+		list, err = uc.repo.FindRekap(ctx, s.ID)
+	} else {
+		list, err = uc.hitungRekap(ctx, s)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kelas = strings.TrimSpace(kelas)
+	siswa := []domain.RekapSiswa{}
+	for _, r := range list {
+		if kelas != "" && normalisasiKelas(r.Kelas) != normalisasiKelas(kelas) {
+			continue
+		}
+		siswa = append(siswa, r)
+	}
+	sort.SliceStable(siswa, func(i, j int) bool {
+		if siswa[i].Kelas != siswa[j].Kelas {
+			return siswa[i].Kelas < siswa[j].Kelas
+		}
+		return siswa[i].NamaLengkap < siswa[j].NamaLengkap
+	})
+	return &domain.RekapSemester{Semester: *s, Terkunci: terkunci, Siswa: siswa}, nil
+}
+
+// Lock menyimpan salinan rekap saat ini lalu menandai semester terkunci. Salinan lama (dari
+// penguncian sebelumnya) dihapus dulu agar tidak tercampur.
+func (uc *semesterUsecase) Lock(ctx context.Context, id, by string) (*domain.RekapSemester, error) {
+	s, _, err := uc.cari(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Status == domain.SemesterTerkunci {
+		return nil, domain.ErrSemesterTerkunci
+	}
+	list, err := uc.hitungRekap(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.DeleteRekap(ctx, s.ID); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.SaveRekap(ctx, s.ID, list); err != nil {
+		return nil, err
+	}
+	s.Status = domain.SemesterTerkunci
+	s.DikunciOleh = by
+	s.DikunciPada = uc.clock.Now()
+	if err := uc.repo.Update(ctx, s); err != nil {
+		return nil, err
+	}
+	if hariIni := clock.Today(uc.clock); s.TanggalSelesai >= hariIni {
+		log.Printf("WARNING: Semester %s dikunci sebelum berakhir (%s); hari setelah %s tidak masuk rekap", s.ID, s.TanggalSelesai, hariIni)
+	}
+	log.Printf("INFO: Semester %s (%s) dikunci dengan %d siswa oleh %s", s.ID, s.Nama, len(list), by)
+	return uc.GetRekap(ctx, s.ID, "")
+}
+
+// Unlock membuka kunci semester; alasan dicatat karena raport yang sudah terbit bisa berubah
+func (uc *semesterUsecase) Unlock(ctx context.Context, id string, req domain.BukaSemesterRequest, by string) (*domain.Semester, error) {
+	alasan := strings.TrimSpace(req.Alasan)
+	if alasan == "" {
+		return nil, fmt.Errorf("%w: alasan membuka kunci wajib diisi", domain.ErrSemesterTidakValid)
+	}
+	s, _, err := uc.cari(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Status != domain.SemesterTerkunci {
+		return nil, domain.ErrSemesterBelumTerkunci
+	}
+	s.Status = domain.SemesterTerbuka
+	s.DibukaOleh = by
+	s.DibukaPada = uc.clock.Now()
+	s.AlasanBuka = alasan
+	if err := uc.repo.Update(ctx, s); err != nil {
+		return nil, err
+	}
+	// Salinan tidak dipakai lagi selama semester terbuka; dikunci ulang akan membuat salinan baru
+	if err := uc.repo.DeleteRekap(ctx, s.ID); err != nil {
+		log.Printf("WARNING: Gagal menghapus salinan rekap semester %s: %v", s.ID, err)
+	}
+	log.Printf("INFO: Kunci semester %s (%s) dibuka oleh %s, alasan: %s", s.ID, s.Nama, by, alasan)
+	return s, nil
+}
@@ -0,0 +1,289 @@
+// file: internal/usecase/siswa_impor.go
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// Nama kolom CSV siswa yang dikenali (huruf kecil). Tanpa baris judul, urutannya nisn,
+// nama_lengkap, kelas, nomor_telepon_ortu, email_ortu, nama_orang_tua (sama dengan hasil ekspor).
+var kolomCSVSiswa = map[string][]string{
+	"nisn":    {"nisn"},
+	"nama":    {"nama_lengkap", "nama lengkap", "nama", "nama siswa"},
+	"kelas":   {"kelas"},
+	"telepon": {"nomor_telepon_ortu", "nomor telepon ortu", "telepon", "no hp", "no_hp", "whatsapp"},
+	"email":   {"email_ortu", "email ortu", "email"},
+	"ortu":    {"nama_orang_tua", "nama orang tua", "nama ortu", "nama wali"},
+}
+
+// Impor membaca CSV siswa dan memvalidasi setiap baris: format NISN, NISN ganda di file, dan
+// kelas yang tidak dikenal. NISN yang sudah terdaftar diperbarui, sisanya ditambahkan. Tanpa
+// terapkan hasilnya hanya pratinjau; dengan terapkan, data baru disimpan jika tidak ada satu
+// pun baris yang salah, supaya file setengah jadi tidak pernah masuk ke DataSiswa.
+func (uc *siswaUsecase) Impor(ctx context.Context, data []byte, terapkan bool, by string) (*domain.HasilImporSiswa, error) {
+	rows, kolom, err := bacaCSVSiswa(data)
+	if err != nil {
+		return nil, err
+	}
+
+	semua, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	terdaftar := make(map[string]domain.Siswa, len(semua))
+	for _, s := range semua {
+		terdaftar[s.NISN] = s
+	}
+	kelasDikenal := uc.kelasDikenal(semua)
+
+	hasil := &domain.HasilImporSiswa{Baris: []domain.ImporSiswaBaris{}}
+	barisNISN := make(map[string]int)
+	for _, row := range rows {
+		b := domain.ImporSiswaBaris{Baris: row.nomor}
+		ambil := func(nama string) (string, bool) {
+			i, ok := kolom[nama]
+			if !ok || i < 0 {
+				return "", false
+			}
+			if i >= len(row.sel) {
+				return "", false
+			}
+			return bukaTeksAman(strings.TrimSpace(row.sel[i])), true
+		}
+
+		nisn, _ := ambil("nisn")
+		nama, _ := ambil("nama")
+		kelas, _ := ambil("kelas")
+		b.Siswa = domain.Siswa{NISN: nisn, NamaLengkap: nama, Kelas: kelas}
+
+		switch {
+		case nisn == "":
+			b.Galat = append(b.Galat, "NISN wajib diisi")
+		case !nisnValid(nisn):
+			pesan := fmt.Sprintf("NISN %q harus 10 digit angka", nisn)
+			if len(nisn) < 10 && angkaSemua(nisn) {
+				pesan += " (angka 0 di depan mungkin terhapus oleh Excel)"
+			}
+			b.Galat = append(b.Galat, pesan)
+		default:
+			if sebelumnya, ada := barisNISN[nisn]; ada {
+				b.Galat = append(b.Galat, fmt.Sprintf("NISN %s sudah dipakai di baris %d", nisn, sebelumnya))
+			} else {
+				barisNISN[nisn] = row.nomor
+			}
+		}
+		if nama == "" {
+			b.Galat = append(b.Galat, "nama lengkap wajib diisi")
+		}
+		if kelas == "" {
+			b.Galat = append(b.Galat, "kelas wajib diisi")
+		} else if resmi, ok := kelasDikenal[normalisasiKelas(kelas)]; ok {
+			b.Siswa.Kelas = resmi
+		} else if len(uc.daftarKelas) > 0 {
+			b.Galat = append(b.Galat, fmt.Sprintf("kelas %q tidak ada di daftar kelas sekolah", kelas))
+		} else {
+			b.Peringatan = append(b.Peringatan, fmt.Sprintf("kelas %q belum dipakai siswa mana pun", kelas))
+		}
+
+		lama, ada := terdaftar[nisn]
+		// Kolom yang tidak ada di file (atau baris yang lebih pendek) tidak mengubah data lama
+		if v, ok := ambil("telepon"); ok {
+			b.Siswa.NomorTeleponOrtu = v
+		} else if ada {
+			b.Siswa.NomorTeleponOrtu = lama.NomorTeleponOrtu
+		}
+		if v, ok := ambil("email"); ok {
+			b.Siswa.EmailOrtu = v
+		} else if ada {
+			b.Siswa.EmailOrtu = lama.EmailOrtu
+		}
+		if v, ok := ambil("ortu"); ok {
+			b.Siswa.NamaOrangTua = v
+		} else if ada {
+			b.Siswa.NamaOrangTua = lama.NamaOrangTua
+		}
+		if b.Siswa.EmailOrtu != "" && !strings.Contains(b.Siswa.EmailOrtu, "@") {
+			b.Galat = append(b.Galat, fmt.Sprintf("email ortu %q tidak valid", b.Siswa.EmailOrtu))
+		}
+
+		if len(b.Galat) > 0 {
+			hasil.JumlahGalat++
+			hasil.Baris = append(hasil.Baris, b)
+			continue
+		}
+		switch {
+		case !ada:
+			b.Aksi = domain.AksiImporTambah
+			hasil.JumlahTambah++
+		case lama.NamaLengkap == b.Siswa.NamaLengkap && lama.Kelas == b.Siswa.Kelas &&
+			lama.NomorTeleponOrtu == b.Siswa.NomorTeleponOrtu && lama.EmailOrtu == b.Siswa.EmailOrtu &&
+			lama.NamaOrangTua == b.Siswa.NamaOrangTua:
+			b.Aksi = domain.AksiImporTetap
+			hasil.JumlahTetap++
+		default:
+			b.Aksi = domain.AksiImporUbah
+			if lama.Kelas != b.Siswa.Kelas {
+				b.Peringatan = append(b.Peringatan, fmt.Sprintf("pindah kelas dari %s ke %s", lama.Kelas, b.Siswa.Kelas))
+			}
+			hasil.JumlahUbah++
+		}
+		hasil.Baris = append(hasil.Baris, b)
+	}
+
+	if !terapkan {
+		return hasil, nil
+	}
+	if hasil.JumlahGalat > 0 {
+		hasil.Message = domain.ErrImporSiswaGalat.Error()
+		return hasil, domain.ErrImporSiswaGalat
+	}
+
+	// Semua perubahan ditulis dengan dua panggilan API agar daftar siswa yang panjang tidak
+	// melewati kuota Google Sheets. Jika penambahan gagal setelah pembaruan berhasil, impor
+	// yang sama cukup diunggah ulang: baris yang sudah diperbarui terbaca "tetap".
+	var tambah, ubah []domain.Siswa
+	for _, b := range hasil.Baris {
+		switch b.Aksi {
+		case domain.AksiImporTambah:
+			tambah = append(tambah, b.Siswa)
+		case domain.AksiImporUbah:
+			ubah = append(ubah, b.Siswa)
+		}
+	}
+	if err := uc.repo.UpdateAll(ctx, ubah); err != nil {
+		log.Printf("ERROR: Impor siswa oleh %s gagal memperbarui %d siswa: %v", by, len(ubah), err)
+		return nil, fmt.Errorf("gagal memperbarui data siswa: %w", err)
+	}
+	if err := uc.repo.SaveAll(ctx, tambah); err != nil {
+		log.Printf("ERROR: Impor siswa oleh %s gagal menambahkan %d siswa: %v", by, len(tambah), err)
+		return nil, fmt.Errorf("gagal menambahkan siswa baru: %w", err)
+	}
+	hasil.Diterapkan = true
+	log.Printf("INFO: Impor siswa oleh %s: %d ditambahkan, %d diperbarui", by, hasil.JumlahTambah, hasil.JumlahUbah)
+	return hasil, nil
+}
+
+// kelasDikenal memetakan bentuk normal nama kelas ke penulisan resminya. Daftar dari
+// konfigurasi dipakai jika ada; jika tidak, kelas diambil dari siswa yang sudah terdaftar.
+func (uc *siswaUsecase) kelasDikenal(semua []domain.Siswa) map[string]string {
+	dikenal := make(map[string]string)
+	if len(uc.daftarKelas) > 0 {
+		for _, k := range uc.daftarKelas {
+			dikenal[normalisasiKelas(k)] = strings.TrimSpace(k)
+		}
+		return dikenal
+	}
+	// Diurutkan agar penulisan yang dipilih untuk kelas yang sama selalu sama
+	kelas := make([]string, 0, len(semua))
+	for _, s := range semua {
+		if k := strings.TrimSpace(s.Kelas); k != "" {
+			kelas = append(kelas, k)
+		}
+	}
+	sort.Strings(kelas)
+	for _, k := range kelas {
+		if _, ok := dikenal[normalisasiKelas(k)]; !ok {
+			dikenal[normalisasiKelas(k)] = k
+		}
+	}
+	return dikenal
+}
+
+type barisCSVSiswa struct {
+	nomor int
+	sel   []string
+}
+
+// bacaCSVSiswa membaca CSV (pemisah koma atau titik koma dari Excel) dan menentukan letak kolom
+func bacaCSVSiswa(data []byte) ([]barisCSVSiswa, map[string]int, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	barisPertama, _, _ := bytes.Cut(data, []byte("\n"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(barisPertama, []byte(";")) > bytes.Count(barisPertama, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	kolom := map[string]int{"nisn": 0, "nama": 1, "kelas": 2, "telepon": 3, "email": 4, "ortu": 5}
+	var rows []barisCSVSiswa
+	for nomor := 1; ; nomor++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: baris %d: %v", domain.ErrFormatImporSiswa, nomor, err)
+		}
+		if nomor == 1 && len(row) > 0 && !angkaSemua(strings.TrimSpace(row[0])) {
+			kolom = bacaJudulCSVSiswa(row)
+			for _, wajib := range []string{"nisn", "nama", "kelas"} {
+				if kolom[wajib] < 0 {
+					return nil, nil, fmt.Errorf("%w: kolom %s tidak ditemukan di baris judul", domain.ErrFormatImporSiswa, kolomCSVSiswa[wajib][0])
+				}
+			}
+			continue
+		}
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		rows = append(rows, barisCSVSiswa{nomor: nomor, sel: row})
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("%w: tidak ada baris data siswa", domain.ErrFormatImporSiswa)
+	}
+	return rows, kolom, nil
+}
+
+// bacaJudulCSVSiswa memetakan nama kolom di baris judul ke indeksnya (-1 jika tidak ada)
+func bacaJudulCSVSiswa(row []string) map[string]int {
+	kolom := make(map[string]int)
+	for nama, alias := range kolomCSVSiswa {
+		kolom[nama] = -1
+		for i, judul := range row {
+			judul = strings.ToLower(strings.TrimSpace(judul))
+			for _, a := range alias {
+				if judul == a {
+					kolom[nama] = i
+				}
+			}
+		}
+	}
+	return kolom
+}
+
+// bukaTeksAman membuang tanda kutip yang ditambahkan ekspor CSV di depan isian yang bisa dibaca
+// Excel sebagai rumus (lihat laporan.teksAman), agar hasil ekspor bisa diimpor kembali apa adanya
+func bukaTeksAman(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@", rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// nisnValid memeriksa bahwa NISN terdiri dari tepat 10 digit angka
+func nisnValid(nisn string) bool {
+	return len(nisn) == 10 && angkaSemua(nisn)
+}
+
+func angkaSemua(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
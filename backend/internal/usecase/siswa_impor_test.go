@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"daarulilmi-presence/internal/domain"
+	"daarulilmi-presence/internal/laporan"
+)
+
+func siswaImporUji(siswa ...domain.Siswa) (*siswaUsecase, *siswaRepoUji) {
+	repo := &siswaRepoUji{siswa: siswa}
+	return NewSiswaUsecase(repo, SiswaUsecaseConfig{DaftarKelas: []string{"7A", "7B"}}).(*siswaUsecase), repo
+}
+
+func TestImporSiswaValidasi(t *testing.T) {
+	uc, _ := siswaImporUji(domain.Siswa{NISN: "0012345678", NamaLengkap: "Ahmad", Kelas: "7A"})
+
+	tests := []struct {
+		name  string
+		baris string
+		aksi  string
+		galat string
+	}{
+		{"siswa baru", "0012345679,Budi,7B", domain.AksiImporTambah, ""},
+		{"kelas ditulis beda", "0012345679,Budi,7 b", domain.AksiImporTambah, ""},
+		{"tidak berubah", "0012345678,Ahmad,7A", domain.AksiImporTetap, ""},
+		{"pindah kelas", "0012345678,Ahmad,7B", domain.AksiImporUbah, ""},
+		{"nama orang tua baru", "0012345678,Ahmad,7A,,,Bapak Ahmad", domain.AksiImporUbah, ""},
+		{"nol di depan terhapus", "12345678,Budi,7A", "", "angka 0 di depan mungkin terhapus"},
+		{"nama kosong", "0012345679,,7A", "", "nama lengkap wajib diisi"},
+		{"kelas tidak dikenal", "0012345679,Budi,9C", "", "tidak ada di daftar kelas"},
+		{"email tidak valid", "0012345679,Budi,7A,,budi.example.com", "", "email ortu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasil, err := uc.Impor(context.Background(), []byte(tt.baris), false, "admin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := hasil.Baris[0]
+			if tt.galat != "" {
+				if !strings.Contains(strings.Join(b.Galat, "; "), tt.galat) {
+					t.Errorf("galat = %v, ingin memuat %q", b.Galat, tt.galat)
+				}
+				return
+			}
+			if len(b.Galat) > 0 || b.Aksi != tt.aksi {
+				t.Errorf("aksi = %q, galat = %v; ingin %q", b.Aksi, b.Galat, tt.aksi)
+			}
+		})
+	}
+}
+
+func TestImporSiswaHasilEksporTidakBerubah(t *testing.T) {
+	awal := []domain.Siswa{
+		{NISN: "0012345678", NamaLengkap: "=HYPERLINK(\"x\")", Kelas: "7A", NomorTeleponOrtu: "+6281234567890", NamaOrangTua: "Ibu Siti"},
+		{NISN: "0012345679", NamaLengkap: "Budi", Kelas: "7B", EmailOrtu: "ortu@example.com", NamaOrangTua: "@wali"},
+	}
+	uc, _ := siswaImporUji(awal...)
+
+	var buf bytes.Buffer
+	if err := laporan.SiswaCSV(&buf, awal); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"'=HYPERLINK(""x"")"`) || !strings.Contains(buf.String(), "'+62812") {
+		t.Fatalf("isian yang bisa dibaca sebagai rumus tidak diamankan:\n%s", buf.String())
+	}
+
+	hasil, err := uc.Impor(context.Background(), buf.Bytes(), false, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasil.JumlahTetap != len(awal) {
+		for _, b := range hasil.Baris {
+			t.Logf("baris %d: %s %+v %v", b.Baris, b.Aksi, b.Siswa, b.Galat)
+		}
+		t.Fatalf("jumlah tetap = %d, ingin %d", hasil.JumlahTetap, len(awal))
+	}
+}
+
+func TestImporSiswaTerapkanSekaligus(t *testing.T) {
+	uc, repo := siswaImporUji(
+		domain.Siswa{NISN: "0012345678", NamaLengkap: "Ahmad", Kelas: "7A", NamaOrangTua: "Bapak Ahmad"},
+		domain.Siswa{NISN: "0012345679", NamaLengkap: "Budi", Kelas: "7A"},
+	)
+
+	var csv strings.Builder
+	csv.WriteString("nisn;nama_lengkap;kelas\n")
+	csv.WriteString("0012345678;Ahmad;7B\n")
+	csv.WriteString("0012345679;Budi;7B\n")
+	for _, nisn := range []string{"0012345680", "0012345681", "0012345682"} {
+		csv.WriteString(nisn + ";Siswa Baru;7A\n")
+	}
+
+	hasil, err := uc.Impor(context.Background(), []byte(csv.String()), true, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasil.Diterapkan || hasil.JumlahUbah != 2 || hasil.JumlahTambah != 3 {
+		t.Fatalf("hasil = %+v", hasil)
+	}
+	// Satu batch update dan satu append, berapa pun jumlah barisnya
+	if repo.tulis != 2 {
+		t.Errorf("jumlah penulisan = %d, ingin 2", repo.tulis)
+	}
+	semua, _ := repo.FindAll(context.Background())
+	if len(semua) != 5 {
+		t.Fatalf("jumlah siswa = %d, ingin 5", len(semua))
+	}
+	// Kolom yang tidak ada di file tidak menghapus data lama
+	if semua[0].Kelas != "7B" || semua[0].NamaOrangTua != "Bapak Ahmad" {
+		t.Errorf("siswa pertama = %+v", semua[0])
+	}
+}
+
+func TestImporSiswaGalatTidakMenulis(t *testing.T) {
+	uc, repo := siswaImporUji(domain.Siswa{NISN: "0012345678", NamaLengkap: "Ahmad", Kelas: "7A"})
+
+	data := "nisn,nama_lengkap,kelas\n0012345678,Ahmad,7B\n0012345679,,7A\n"
+	_, err := uc.Impor(context.Background(), []byte(data), true, "admin")
+	if !errors.Is(err, domain.ErrImporSiswaGalat) {
+		t.Fatalf("err = %v, ingin ErrImporSiswaGalat", err)
+	}
+	if repo.tulis != 0 {
+		t.Errorf("jumlah penulisan = %d, ingin 0", repo.tulis)
+	}
+}
+
+func TestBukaTeksAman(t *testing.T) {
+	tests := map[string]string{
+		"'=1+1":    "=1+1",
+		"'+62812":  "+62812",
+		"'-":       "-",
+		"'@wali":   "@wali",
+		"'Ahmad":   "'Ahmad",
+		"O'Connor": "O'Connor",
+		"'":        "'",
+		"":         "",
+	}
+	for in, want := range tests {
+		if got := bukaTeksAman(in); got != want {
+			t.Errorf("bukaTeksAman(%q) = %q, ingin %q", in, got, want)
+		}
+	}
+}
@@ -8,12 +8,19 @@ import (
 	"daarulilmi-presence/internal/domain" // Ganti dengan nama modul Anda
 )
 
+// SiswaUsecaseConfig berisi pengaturan data siswa dari konfigurasi sekolah
+type SiswaUsecaseConfig struct {
+	// Daftar kelas resmi; kosong berarti kelas yang dikenal diambil dari data siswa yang ada
+	DaftarKelas []string
+}
+
 type siswaUsecase struct {
-	repo domain.SiswaRepository
+	repo        domain.SiswaRepository
+	daftarKelas []string
 }
 
-func NewSiswaUsecase(repo domain.SiswaRepository) domain.SiswaUsecase {
-	return &siswaUsecase{repo}
+func NewSiswaUsecase(repo domain.SiswaRepository, cfg SiswaUsecaseConfig) domain.SiswaUsecase {
+	return &siswaUsecase{repo: repo, daftarKelas: cfg.DaftarKelas}
 }
 
 func (uc *siswaUsecase) GetAll(ctx context.Context) ([]domain.Siswa, error) {
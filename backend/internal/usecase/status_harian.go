@@ -0,0 +1,170 @@
+// file: internal/usecase/status_harian.go
+package usecase
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// Batas panjang rentang tanggal yang diproses sekali jalan
+const maksHariRentang = 366
+
+// hariKalender mengambil aturan hari sekolah dari kalender sekolah untuk rentang tanggal,
+// dikunci per tanggal (YYYY-MM-DD)
+func (uc *absensiUsecase) hariKalender(ctx context.Context, startDate, endDate string) (map[string]domain.HariKalender, error) {
+	list, err := uc.kalender.GetHari(ctx, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	hari := make(map[string]domain.HariKalender, len(list))
+	for _, h := range list {
+		hari[h.Tanggal] = h
+	}
+	return hari, nil
+}
+
+// hariEfektif menghitung hari sekolah yang sudah berjalan (sampai hari ini), yaitu pembagi
+// semua persentase kehadiran
+func (uc *absensiUsecase) hariEfektif(hari map[string]domain.HariKalender) int {
+	hariIni := clock.Today(uc.clock)
+	n := 0
+	for tanggal, h := range hari {
+		if h.HariSekolah && tanggal <= hariIni {
+			n++
+		}
+	}
+	return n
+}
+
+// normalisasiStatus menyeragamkan penulisan status di LogAbsensi, misal "hadir" menjadi "Hadir"
+func normalisasiStatus(status string) string {
+	for _, s := range []string{domain.StatusHadir, domain.StatusIzin, domain.StatusSakit, domain.StatusAlpa} {
+		if strings.EqualFold(strings.TrimSpace(status), s) {
+			return s
+		}
+	}
+	return status
+}
+
+// peringkatStatus menentukan status mana yang menang jika satu siswa punya beberapa catatan
+// di hari yang sama: catatan guru/QR mengalahkan izin, dan izin yang disetujui belakangan
+// mengalahkan Alpa yang ditulis otomatis oleh finalisasi
+func peringkatStatus(st domain.StatusHarian) int {
+	switch {
+	case st.Sumber == domain.SumberIzin:
+		return 2
+	case st.DicatatOleh == domain.DicatatOlehFinalisasi:
+		return 1
+	default:
+		return 3
+	}
+}
+
+// warnaStatus adalah warna event kalender portal untuk setiap status
+func warnaStatus(status string) string {
+	switch status {
+	case domain.StatusHadir:
+		return "#198754"
+	case domain.StatusIzin, domain.StatusSakit:
+		return "#ffc107"
+	case domain.StatusAlpa:
+		return "#dc3545"
+	default:
+		return "#6c757d"
+	}
+}
+
+func urutTanggal(m map[string]domain.StatusHarian) []string {
+	tanggal := make([]string, 0, len(m))
+	for t := range m {
+		tanggal = append(tanggal, t)
+	}
+	sort.Strings(tanggal)
+	return tanggal
+}
+
+// statusHarian menggabungkan LogAbsensi dan pengajuan izin yang disetujui menjadi satu status
+// per siswa per tanggal (map[NISN][YYYY-MM-DD]). Dashboard harian, rekap, statistik bulanan,
+// dan portal wali murid semuanya membaca hasil fungsi ini.
+func (uc *absensiUsecase) statusHarian(ctx context.Context, startDate, endDate string) (map[string]map[string]domain.StatusHarian, error) {
+	start, err := clock.ParseTanggal(uc.clock, startDate)
+	if err != nil {
+		return nil, domain.ErrRentangTanggal
+	}
+	end, err := clock.ParseTanggal(uc.clock, endDate)
+	if err != nil {
+		return nil, domain.ErrRentangTanggal
+	}
+
+	logs, err := uc.absensiRepo.GetLogsByDateRange(ctx, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := uc.GetAllLeaveRequests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hasil := make(map[string]map[string]domain.StatusHarian)
+	simpan := func(st domain.StatusHarian) {
+		perTanggal, ok := hasil[st.NISN]
+		if !ok {
+			perTanggal = make(map[string]domain.StatusHarian)
+			hasil[st.NISN] = perTanggal
+		}
+		lama, ada := perTanggal[st.Tanggal]
+		if !ada || peringkatStatus(st) > peringkatStatus(lama) ||
+			(peringkatStatus(st) == peringkatStatus(lama) && st.Timestamp > lama.Timestamp) {
+			perTanggal[st.Tanggal] = st
+		}
+	}
+
+	for _, l := range logs {
+		simpan(domain.StatusHarian{
+			NISN:            l.Username,
+			Tanggal:         strings.SplitN(l.Timestamp, " ", 2)[0],
+			Status:          normalisasiStatus(l.Status),
+			Sumber:          domain.SumberLog,
+			RowNumber:       l.RowNumber,
+			Timestamp:       l.Timestamp,
+			TimestampPulang: l.TimestampPulang,
+			DicatatOleh:     l.DicatatOleh,
+		})
+	}
+
+	for _, req := range requests {
+		if !domain.IzinDisetujui(req.Status) || req.SiswaNISN == "" || req.SiswaNISN == domain.NISNTidakDitemukan {
+			continue
+		}
+		mulai, err := clock.ParseTanggalFleksibel(uc.clock, req.TanggalMulai)
+		if err != nil {
+			continue
+		}
+		selesai, err := clock.ParseTanggalFleksibel(uc.clock, req.TanggalSelesai)
+		if err != nil || selesai.Before(mulai) {
+			selesai = mulai
+		}
+		if mulai.Before(start) {
+			mulai = start
+		}
+		if selesai.After(end) {
+			selesai = end
+		}
+		for d := mulai; !d.After(selesai); d = d.AddDate(0, 0, 1) {
+			simpan(domain.StatusHarian{
+				NISN:      req.SiswaNISN,
+				Tanggal:   d.Format(clock.LayoutTanggal),
+				Status:    domain.StatusDariJenisIzin(req.JenisIzin),
+				Sumber:    domain.SumberIzin,
+				RowNumber: req.RowNumber,
+				Timestamp: req.Timestamp,
+			})
+		}
+	}
+
+	return hasil, nil
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+func TestStatusHarianPrioritas(t *testing.T) {
+	alpa := domain.LogAbsensi{Timestamp: "2025-03-10 18:00:00", Username: "001", Status: domain.StatusAlpa, DicatatOleh: domain.DicatatOlehFinalisasi}
+	hadir := domain.LogAbsensi{Timestamp: "2025-03-10 07:05:00", Username: "001", Status: "hadir", DicatatOleh: "Sistem QR"}
+	alpaGuru := domain.LogAbsensi{Timestamp: "2025-03-10 09:00:00", Username: "001", Status: domain.StatusAlpa, DicatatOleh: "Manual Wali Kelas"}
+	izin := func(status, jenis string) domain.PengajuanIzinLengkap {
+		return domain.PengajuanIzinLengkap{Timestamp: "2025-03-10 06:00:00", SiswaNISN: "001", JenisIzin: jenis, TanggalMulai: "3/10/2025", TanggalSelesai: "3/10/2025", Status: status}
+	}
+
+	tests := []struct {
+		name       string
+		logs       []domain.LogAbsensi
+		izin       []domain.PengajuanIzinLengkap
+		want       string
+		wantSumber string
+	}{
+		{"hanya scan, status dinormalisasi", []domain.LogAbsensi{hadir}, nil, domain.StatusHadir, domain.SumberLog},
+		{"izin disetujui mengalahkan Alpa finalisasi", []domain.LogAbsensi{alpa}, []domain.PengajuanIzinLengkap{izin("Disetujui", "Sakit")}, domain.StatusSakit, domain.SumberIzin},
+		{"izin ditolak tidak menghapus Alpa", []domain.LogAbsensi{alpa}, []domain.PengajuanIzinLengkap{izin("Ditolak", "Izin")}, domain.StatusAlpa, domain.SumberLog},
+		{"izin menunggu diabaikan", nil, []domain.PengajuanIzinLengkap{izin("Menunggu", "Izin")}, "", ""},
+		{"catatan guru mengalahkan izin", []domain.LogAbsensi{alpaGuru}, []domain.PengajuanIzinLengkap{izin("Disetujui", "Izin")}, domain.StatusAlpa, domain.SumberLog},
+		{"scan mengalahkan Alpa finalisasi", []domain.LogAbsensi{alpa, hadir}, nil, domain.StatusHadir, domain.SumberLog},
+		{"catatan setingkat: yang terbaru menang", []domain.LogAbsensi{hadir, alpaGuru}, nil, domain.StatusAlpa, domain.SumberLog},
+	}
+	loc, _ := clock.LoadLocation("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			absensi := &absensiRepoUji{logs: tt.logs, izin: tt.izin}
+			uc := NewAbsensiUsecase(absensi, &siswaRepoUji{}, newUserRepoUji(), &relasiRepoUji{}, nil, nil, rekonsiliasiUji{}, kalenderUji{}, AbsensiUsecaseConfig{
+				Clock: clock.Fixed(time.Date(2025, 3, 11, 8, 0, 0, 0, loc)),
+			}).(*absensiUsecase)
+
+			statusMap, err := uc.statusHarian(context.Background(), "2025-03-10", "2025-03-10")
+			if err != nil {
+				t.Fatal(err)
+			}
+			st, ada := statusMap["001"]["2025-03-10"]
+			if tt.want == "" {
+				if ada {
+					t.Fatalf("status = %+v, want tidak ada", st)
+				}
+				return
+			}
+			if st.Status != tt.want || st.Sumber != tt.wantSumber {
+				t.Fatalf("status = %s (%s), want %s (%s)", st.Status, st.Sumber, tt.want, tt.wantSumber)
+			}
+		})
+	}
+}
+
+func TestDashboardDataMengikutiStatusHarian(t *testing.T) {
+	logs := []domain.LogAbsensi{
+		{Timestamp: "2025-03-10 07:05:00", Username: "001", Status: domain.StatusHadir, DicatatOleh: "Sistem QR"},
+		{Timestamp: "2025-03-10 07:10:00", Username: "002", Status: domain.StatusSakit, DicatatOleh: "Manual Wali Kelas"},
+		{Timestamp: "2025-03-10 10:00:00", Username: "003", Status: domain.StatusAlpa, DicatatOleh: domain.DicatatOlehFinalisasi},
+		{Timestamp: "2025-03-10 10:00:00", Username: "004", Status: domain.StatusAlpa, DicatatOleh: domain.DicatatOlehFinalisasi},
+		// Scan terlambat setelah finalisasi menggantikan Alpa, bukan menambah hitungan
+		{Timestamp: "2025-03-10 10:30:00", Username: "004", Status: domain.StatusHadir, DicatatOleh: "Sistem QR"},
+	}
+	loc, _ := clock.LoadLocation("")
+	absensi := &absensiRepoUji{logs: logs, totalSiswa: 6}
+	users := newUserRepoUji(domain.User{Username: "walikelas", NamaLengkap: "Bu Guru"})
+	uc := NewAbsensiUsecase(absensi, &siswaRepoUji{}, users, &relasiRepoUji{}, nil, nil, rekonsiliasiUji{}, kalenderUji{}, AbsensiUsecaseConfig{
+		Clock: clock.Fixed(time.Date(2025, 3, 10, 11, 0, 0, 0, loc)),
+	})
+
+	data, err := uc.GetDashboardData(context.Background(), "walikelas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := [4]int{data.TotalHadirHariIni, data.TotalIzinHariIni, data.TotalAlpaHariIni, data.TotalBelumAdaKabar}
+	if want := [4]int{2, 1, 1, 2}; got != want {
+		t.Errorf("hadir, izin, alpa, belum ada kabar = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// Rahasia dan kode dari vektor uji RFC 6238 (SHA-1, 6 digit)
+const rahasiaRFC6238 = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := totpCode(rahasiaRFC6238, uint64(tt.unix/30))
+		if err != nil || got != tt.want {
+			t.Errorf("totpCode(T=%d) = %q, %v, want %q", tt.unix, got, err, tt.want)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	counter := now.Unix() / 30
+	kode := func(c int64) string {
+		k, _ := totpCode(rahasiaRFC6238, uint64(c))
+		return k
+	}
+
+	tests := []struct {
+		name        string
+		code        string
+		terakhir    int64
+		wantCounter int64
+		wantOK      bool
+	}{
+		{"kode sekarang", kode(counter), 0, counter, true},
+		{"kode dengan spasi", kode(counter)[:3] + " " + kode(counter)[3:], 0, counter, true},
+		{"jam HP tertinggal satu langkah", kode(counter - 1), 0, counter - 1, true},
+		{"jam HP maju satu langkah", kode(counter + 1), 0, counter + 1, true},
+		{"di luar toleransi", kode(counter - 2), 0, 0, false},
+		{"kode sama dipakai ulang", kode(counter), counter, 0, false},
+		{"kode lebih lama dari yang terakhir", kode(counter - 1), counter, 0, false},
+		{"kode berikutnya setelah yang terakhir", kode(counter + 1), counter, counter + 1, true},
+		{"panjang salah", "12345", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := verifyTOTP(rahasiaRFC6238, tt.code, now, tt.terakhir)
+			if ok != tt.wantOK || got != tt.wantCounter {
+				t.Errorf("verifyTOTP = %d, %v, want %d, %v", got, ok, tt.wantCounter, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestVerifyTwoFactorLogin(t *testing.T) {
+	ctx := context.Background()
+	// Challenge token divalidasi dengan jam sistem, jadi jam uji mengikuti waktu sekarang
+	jam := &jamUji{t: time.Now()}
+	users := newUserRepoUji(domain.User{Username: "guru", TOTPSecret: rahasiaRFC6238, TOTPEnabled: true})
+	uc := NewUserUsecase(users, &resetTokenRepoUji{}, newSesiRepoUji(), &auditRepoUji{}, nil, nil, nil, UserUsecaseConfig{
+		JWTSecret:  []byte("rahasia"),
+		LoginGuard: DefaultLoginGuardConfig(),
+		Clock:      jam,
+	}).(*userUsecase)
+	client := domain.ClientInfo{IPAddress: "203.0.113.7"}
+	challenge, _ := uc.generateChallengeToken("guru", challengeLogin)
+	kode, _ := totpCode(rahasiaRFC6238, uint64(jam.Now().Unix()/30))
+
+	t.Run("terkunci di sheet", func(t *testing.T) {
+		// Dikunci oleh instance lain; catatan di memori instance ini kosong
+		users.UpdateLockout(ctx, "guru", jam.Now().Add(10*time.Minute))
+		defer users.UpdateLockout(ctx, "guru", time.Time{})
+
+		var ditunda *domain.LoginDitundaError
+		if _, err := uc.VerifyTwoFactorLogin(ctx, challenge, kode, client); !errors.As(err, &ditunda) || !ditunda.Terkunci {
+			t.Fatalf("akun terkunci masih bisa menebak kode: %v", err)
+		}
+	})
+
+	t.Run("kode tidak bisa dipakai ulang", func(t *testing.T) {
+		if _, err := uc.VerifyTwoFactorLogin(ctx, challenge, kode, client); err != nil {
+			t.Fatalf("kode pertama ditolak: %v", err)
+		}
+		if _, err := uc.VerifyTwoFactorLogin(ctx, challenge, kode, client); !errors.Is(err, domain.ErrKodeTidakValid) {
+			t.Fatalf("kode yang sama diterima dua kali: %v", err)
+		}
+	})
+}
@@ -0,0 +1,313 @@
+// file: internal/usecase/user_admin.go
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"daarulilmi-presence/internal/domain"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Huruf untuk password sementara, tanpa karakter yang mudah tertukar (0/O, 1/l/I)
+const hurufPasswordSementara = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const panjangPasswordSementara = 10
+
+// generatePassword membuat password acak untuk akun yang dibuatkan admin
+func generatePassword() (string, error) {
+	var sb strings.Builder
+	jumlahHuruf := big.NewInt(int64(len(hurufPasswordSementara)))
+	for i := 0; i < panjangPasswordSementara; i++ {
+		n, err := rand.Int(rand.Reader, jumlahHuruf)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(hurufPasswordSementara[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// ListUsers mengambil daftar pengguna sesuai filter peran, status dan kata kunci
+func (uc *userUsecase) ListUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, error) {
+	users, err := uc.userRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	hasil := []domain.User{}
+	for _, u := range users {
+		if filter.Role != "" && u.Role != filter.Role {
+			continue
+		}
+		if filter.Status == "aktif" && u.Disabled || filter.Status == "nonaktif" && !u.Disabled {
+			continue
+		}
+		if query != "" {
+			teks := strings.ToLower(strings.Join([]string{u.Username, u.NamaLengkap, u.Email, u.SiswaNISN}, " "))
+			if !strings.Contains(teks, query) {
+				continue
+			}
+		}
+		hasil = append(hasil, u)
+	}
+	return hasil, nil
+}
+
+// CreateUser membuat akun dengan peran tertentu. Jika password tidak diisi, sistem membuatkan
+// password sementara. Akun yang dibuat admin selalu wajib ganti password saat login pertama.
+func (uc *userUsecase) CreateUser(ctx context.Context, req domain.CreateUserRequest, createdBy string) (*domain.UserBaru, error) {
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		return nil, errors.New("username wajib diisi")
+	}
+	if !domain.IsValidRole(req.Role) {
+		return nil, fmt.Errorf("peran '%s' tidak dikenal", req.Role)
+	}
+
+	existing, err := uc.userRepo.FindByUsername(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("username sudah digunakan")
+	}
+
+	// Akun siswa memakai NISN sebagai username
+	if req.Role == domain.RoleSiswa && req.SiswaNISN == "" {
+		req.SiswaNISN = req.Username
+	}
+	if req.SiswaNISN != "" {
+		if req.Role != domain.RoleSiswa && req.Role != domain.RoleOrtu {
+			return nil, errors.New("hanya akun siswa atau wali murid yang bisa ditautkan ke siswa")
+		}
+		if err := uc.ensureSiswaExists(ctx, req.SiswaNISN); err != nil {
+			return nil, err
+		}
+	}
+
+	hasil := &domain.UserBaru{}
+	password := req.Password
+	if password == "" {
+		password, err = generatePassword()
+		if err != nil {
+			return nil, err
+		}
+		hasil.PasswordSementara = password
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &domain.User{
+		Username:           req.Username,
+		PasswordHash:       string(hashedPassword),
+		Role:               req.Role,
+		NamaLengkap:        strings.TrimSpace(req.NamaLengkap),
+		SiswaNISN:          req.SiswaNISN,
+		Email:              strings.TrimSpace(req.Email),
+		MustChangePassword: true,
+	}
+	if err := uc.userRepo.Save(ctx, user); err != nil {
+		return nil, err
+	}
+	uc.audit(ctx, domain.AuditPenggunaDibuat, user.Username, "", fmt.Sprintf("peran %s, dibuat oleh %s", user.Role, createdBy))
+
+	hasil.User = user
+	return hasil, nil
+}
+
+func (uc *userUsecase) ensureSiswaExists(ctx context.Context, nisn string) error {
+	siswa, err := uc.siswaRepo.FindByNISN(ctx, nisn)
+	if err != nil {
+		return err
+	}
+	if siswa == nil {
+		return fmt.Errorf("siswa dengan NISN %s tidak ditemukan", nisn)
+	}
+	return nil
+}
+
+// findUserForAdmin mencari pengguna yang akan diubah admin
+func (uc *userUsecase) findUserForAdmin(ctx context.Context, username string) (*domain.User, error) {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("pengguna tidak ditemukan")
+	}
+	return user, nil
+}
+
+// UpdateUserByAdmin mengubah peran dan profil pengguna. Jika peran berubah, semua sesinya
+// diakhiri agar token dengan peran lama tidak bisa dipakai lagi.
+func (uc *userUsecase) UpdateUserByAdmin(ctx context.Context, username string, req domain.UpdateUserRequest, updatedBy string) (*domain.User, error) {
+	user, err := uc.findUserForAdmin(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	roleBerubah := req.Role != "" && req.Role != user.Role
+	if roleBerubah {
+		if !domain.IsValidRole(req.Role) {
+			return nil, fmt.Errorf("peran '%s' tidak dikenal", req.Role)
+		}
+		if user.Username == updatedBy {
+			return nil, errors.New("tidak bisa mengubah peran akun sendiri")
+		}
+		user.Role = req.Role
+	}
+	if req.NamaLengkap != "" {
+		user.NamaLengkap = strings.TrimSpace(req.NamaLengkap)
+	}
+	if req.Email != "" {
+		user.Email = strings.TrimSpace(req.Email)
+	}
+
+	if err := uc.userRepo.Update(ctx, user.Username, user); err != nil {
+		return nil, err
+	}
+
+	detail := "profil diubah oleh " + updatedBy
+	if roleBerubah {
+		detail = fmt.Sprintf("peran menjadi %s oleh %s", user.Role, updatedBy)
+		if _, err := uc.LogoutAll(ctx, user.Username); err != nil {
+			return nil, err
+		}
+	}
+	uc.audit(ctx, domain.AuditPenggunaDiubah, user.Username, "", detail)
+	return user, nil
+}
+
+// LinkParentToStudent menautkan akun wali murid ke seorang siswa. Satu wali bisa ditautkan
+// ke beberapa anak; tautan pertama juga disimpan di kolom SiswaNISN agar email orang tua
+// di DataSiswa tetap bisa dipakai untuk reset password.
+func (uc *userUsecase) LinkParentToStudent(ctx context.Context, username, nisn, hubungan, linkedBy string) error {
+	user, err := uc.findUserForAdmin(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user.Role != domain.RoleOrtu {
+		return errors.New("hanya akun wali murid yang bisa ditautkan ke siswa")
+	}
+	if err := uc.ensureSiswaExists(ctx, nisn); err != nil {
+		return err
+	}
+
+	anak, err := daftarAnak(ctx, uc.relasiRepo, user)
+	if err != nil {
+		return err
+	}
+	for _, rel := range anak {
+		if rel.SiswaNISN == nisn {
+			return errors.New("akun ini sudah tertaut ke siswa tersebut")
+		}
+	}
+
+	err = uc.relasiRepo.Save(ctx, &domain.RelasiWaliSiswa{
+		Username:   user.Username,
+		SiswaNISN:  nisn,
+		Hubungan:   strings.TrimSpace(hubungan),
+		DibuatPada: uc.clock.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if user.SiswaNISN == "" {
+		user.SiswaNISN = nisn
+		if err := uc.userRepo.Update(ctx, user.Username, user); err != nil {
+			return err
+		}
+	}
+	uc.audit(ctx, domain.AuditPenggunaDiubah, user.Username, "", fmt.Sprintf("ditautkan ke siswa %s oleh %s", nisn, linkedBy))
+	return nil
+}
+
+// UnlinkParentFromStudent melepas tautan wali murid dari seorang siswa
+func (uc *userUsecase) UnlinkParentFromStudent(ctx context.Context, username, nisn, unlinkedBy string) error {
+	user, err := uc.findUserForAdmin(ctx, username)
+	if err != nil {
+		return err
+	}
+	if err := uc.relasiRepo.Delete(ctx, user.Username, nisn); err != nil {
+		return err
+	}
+
+	// Jika yang dilepas adalah tautan utama, ganti dengan anak lain yang masih tertaut
+	if user.SiswaNISN == nisn {
+		user.SiswaNISN = ""
+		sisa, err := uc.relasiRepo.FindByUsername(ctx, user.Username)
+		if err != nil {
+			return err
+		}
+		if len(sisa) > 0 {
+			user.SiswaNISN = sisa[0].SiswaNISN
+		}
+		if err := uc.userRepo.Update(ctx, user.Username, user); err != nil {
+			return err
+		}
+	}
+	uc.audit(ctx, domain.AuditPenggunaDiubah, user.Username, "", fmt.Sprintf("tautan ke siswa %s dilepas oleh %s", nisn, unlinkedBy))
+	return nil
+}
+
+// ListLinkedStudents menampilkan semua siswa yang tertaut ke akun (untuk halaman admin)
+func (uc *userUsecase) ListLinkedStudents(ctx context.Context, username string) ([]domain.RelasiWaliSiswa, error) {
+	user, err := uc.findUserForAdmin(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return daftarAnak(ctx, uc.relasiRepo, user)
+}
+
+// SetUserDisabled menonaktifkan atau mengaktifkan kembali akun. Akun yang dinonaktifkan
+// langsung kehilangan semua sesinya.
+func (uc *userUsecase) SetUserDisabled(ctx context.Context, username string, disabled bool, changedBy string) error {
+	user, err := uc.findUserForAdmin(ctx, username)
+	if err != nil {
+		return err
+	}
+	if disabled && user.Username == changedBy {
+		return errors.New("tidak bisa menonaktifkan akun sendiri")
+	}
+
+	if err := uc.userRepo.UpdateDisabled(ctx, user.Username, disabled); err != nil {
+		return err
+	}
+	if !disabled {
+		uc.audit(ctx, domain.AuditAkunAktif, user.Username, "", "diaktifkan oleh "+changedBy)
+		return nil
+	}
+
+	if _, err := uc.LogoutAll(ctx, user.Username); err != nil {
+		return err
+	}
+	uc.audit(ctx, domain.AuditAkunNonaktif, user.Username, "", "dinonaktifkan oleh "+changedBy)
+	return nil
+}
+
+// ForcePasswordReset mewajibkan pengguna mengganti password: semua sesinya diakhiri dan
+// setelah login berikutnya hanya rute ganti password yang bisa diakses.
+func (uc *userUsecase) ForcePasswordReset(ctx context.Context, username, requestedBy string) error {
+	user, err := uc.findUserForAdmin(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.userRepo.UpdateMustChangePassword(ctx, user.Username, true); err != nil {
+		return err
+	}
+	if _, err := uc.LogoutAll(ctx, user.Username); err != nil {
+		return err
+	}
+	uc.audit(ctx, domain.AuditWajibGantiPassword, user.Username, "", "diminta oleh "+requestedBy)
+	return nil
+}
@@ -0,0 +1,218 @@
+// file: internal/usecase/user_provisioning.go
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"runtime"
+	"sync"
+
+	"daarulilmi-presence/internal/domain"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+//go:embed templates/kredensial/*
+var slipTemplateFS embed.FS
+
+var slipTemplate = htmltemplate.Must(htmltemplate.ParseFS(slipTemplateFS, "templates/kredensial/slip.html"))
+
+// ProvisionAccounts membuat akun siswa (username = NISN) dan akun wali murid
+// (username = "ortu" + NISN, tertaut ke siswa) untuk semua siswa, atau hanya satu kelas.
+// Akun yang sudah ada dilewati dan password-nya tidak diubah, sehingga aman dijalankan ulang
+// setelah ada siswa baru. Password awal acak dan wajib diganti saat login pertama.
+func (uc *userUsecase) ProvisionAccounts(ctx context.Context, kelas, provisionedBy string) (*domain.HasilProvisioning, error) {
+	allSiswa, err := uc.siswaRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existingUsers, err := uc.userRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	relasi, err := uc.relasiRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usernameDipakai := make(map[string]bool)
+	ortuTertaut := make(map[string]string) // NISN -> username wali murid yang sudah ada
+	for _, u := range existingUsers {
+		usernameDipakai[u.Username] = true
+		if u.Role == domain.RoleOrtu && u.SiswaNISN != "" {
+			ortuTertaut[u.SiswaNISN] = u.Username
+		}
+	}
+	// Kakak-adik yang walinya sudah punya akun tidak dibuatkan akun wali baru
+	for _, rel := range relasi {
+		if _, ok := ortuTertaut[rel.SiswaNISN]; !ok {
+			ortuTertaut[rel.SiswaNISN] = rel.Username
+		}
+	}
+
+	hasil := &domain.HasilProvisioning{
+		Kelas:    kelas,
+		LoginURL: uc.publicBaseURL + "/",
+		Keluarga: []domain.KredensialKeluarga{},
+	}
+	var akunBaru []domain.User
+	var passwordBaru []string // Sejajar dengan akunBaru, di-hash setelah semua akun terkumpul
+	passwordDipakai := make(map[string]bool)
+
+	buatAkun := func(username, role string, siswa domain.Siswa, nama string) (domain.KredensialAkun, error) {
+		// Pastikan setiap akun dalam satu batch mendapat password yang berbeda
+		var password string
+		var err error
+		for password == "" || passwordDipakai[password] {
+			if password, err = generatePassword(); err != nil {
+				return domain.KredensialAkun{}, err
+			}
+		}
+		passwordDipakai[password] = true
+
+		email := ""
+		if role == domain.RoleOrtu {
+			email = siswa.EmailOrtu
+		}
+		akunBaru = append(akunBaru, domain.User{
+			Username:           username,
+			Role:               role,
+			NamaLengkap:        nama,
+			SiswaNISN:          siswa.NISN,
+			Email:              email,
+			MustChangePassword: true,
+		})
+		passwordBaru = append(passwordBaru, password)
+		usernameDipakai[username] = true
+		return domain.KredensialAkun{Username: username, Password: password, Dibuat: true}, nil
+	}
+
+	for _, siswa := range allSiswa {
+		if siswa.NISN == "" {
+			continue
+		}
+		if kelas != "" && normalisasiKelas(siswa.Kelas) != normalisasiKelas(kelas) {
+			continue
+		}
+
+		keluarga := domain.KredensialKeluarga{Siswa: siswa}
+
+		if usernameDipakai[siswa.NISN] {
+			keluarga.AkunSiswa = domain.KredensialAkun{Username: siswa.NISN}
+			hasil.JumlahDilewati++
+		} else {
+			if keluarga.AkunSiswa, err = buatAkun(siswa.NISN, domain.RoleSiswa, siswa, siswa.NamaLengkap); err != nil {
+				return nil, err
+			}
+			hasil.JumlahDibuat++
+		}
+
+		usernameOrtu := domain.PrefixUsernameOrtu + siswa.NISN
+		if existing, ok := ortuTertaut[siswa.NISN]; ok {
+			keluarga.AkunOrtu = domain.KredensialAkun{Username: existing}
+			hasil.JumlahDilewati++
+		} else if usernameDipakai[usernameOrtu] {
+			keluarga.AkunOrtu = domain.KredensialAkun{Username: usernameOrtu}
+			hasil.JumlahDilewati++
+		} else {
+			if keluarga.AkunOrtu, err = buatAkun(usernameOrtu, domain.RoleOrtu, siswa, siswa.NamaOrangTua); err != nil {
+				return nil, err
+			}
+			hasil.JumlahDibuat++
+		}
+
+		hasil.Keluarga = append(hasil.Keluarga, keluarga)
+	}
+
+	hashes, err := hashPasswordParalel(ctx, passwordBaru)
+	if err != nil {
+		return nil, err
+	}
+	for i := range akunBaru {
+		akunBaru[i].PasswordHash = hashes[i]
+	}
+
+	// Simpan sekaligus agar tidak terkena batas kuota tulis Google Sheets
+	if len(akunBaru) > 0 {
+		if err := uc.userRepo.SaveAll(ctx, akunBaru); err != nil {
+			return nil, err
+		}
+	}
+
+	cakupan := "semua kelas"
+	if kelas != "" {
+		cakupan = "kelas " + kelas
+	}
+	uc.audit(ctx, domain.AuditPenggunaDibuat, provisionedBy, "", fmt.Sprintf("provisioning %s: %d akun dibuat, %d sudah ada", cakupan, hasil.JumlahDibuat, hasil.JumlahDilewati))
+	log.Printf("INFO: Provisioning %s selesai, %d akun dibuat, %d dilewati", cakupan, hasil.JumlahDibuat, hasil.JumlahDilewati)
+	return hasil, nil
+}
+
+// hashPasswordParalel meng-hash password dengan bcrypt memakai paling banyak satu worker per
+// CPU. Satu kelas bisa berisi puluhan akun dan bcrypt sengaja lambat, jadi hashing berurutan
+// membuat request provisioning menunggu terlalu lama. Berhenti lebih awal jika ctx dibatalkan.
+func hashPasswordParalel(ctx context.Context, passwords []string) ([]string, error) {
+	hashes := make([]string, len(passwords))
+	errs := make([]error, len(passwords))
+	antrean := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(passwords)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range antrean {
+				var hash []byte
+				hash, errs[i] = bcrypt.GenerateFromPassword([]byte(passwords[i]), bcrypt.DefaultCost)
+				hashes[i] = string(hash)
+			}
+		}()
+	}
+
+kirim:
+	for i := range passwords {
+		select {
+		case antrean <- i:
+		case <-ctx.Done():
+			break kirim
+		}
+	}
+	close(antrean)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+// RenderCredentialSlips membuat halaman HTML siap cetak, satu slip per keluarga.
+// Keluarga yang kedua akunnya sudah ada sebelumnya tidak dicetak.
+func (uc *userUsecase) RenderCredentialSlips(hasil *domain.HasilProvisioning) ([]byte, error) {
+	var keluarga []domain.KredensialKeluarga
+	for _, k := range hasil.Keluarga {
+		if k.AkunSiswa.Dibuat || k.AkunOrtu.Dibuat {
+			keluarga = append(keluarga, k)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := slipTemplate.ExecuteTemplate(&buf, "slip.html", map[string]interface{}{
+		"Kelas":    hasil.Kelas,
+		"LoginURL": hasil.LoginURL,
+		"Keluarga": keluarga,
+		"Tanggal":  uc.clock.Now().Format("02-01-2006"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
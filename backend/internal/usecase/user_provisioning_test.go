@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPasswordParalel(t *testing.T) {
+	var passwords []string
+	for i := 0; i < 4; i++ {
+		passwords = append(passwords, fmt.Sprintf("password-%d", i))
+	}
+
+	hashes, err := hashPasswordParalel(context.Background(), passwords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Hash harus tetap sejajar dengan urutan password walau dikerjakan beberapa worker
+	for i, p := range passwords {
+		if err := bcrypt.CompareHashAndPassword([]byte(hashes[i]), []byte(p)); err != nil {
+			t.Errorf("hash ke-%d tidak cocok dengan %q", i, p)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := hashPasswordParalel(ctx, passwords); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, ingin context.Canceled", err)
+	}
+}
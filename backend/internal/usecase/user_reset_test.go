@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+func TestResetPasswordTokenHanyaSekali(t *testing.T) {
+	ctx := context.Background()
+	jam := &jamUji{t: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)}
+	users := newUserRepoUji(domain.User{Username: "budi", Role: domain.RoleAdmin})
+	tokens := &resetTokenRepoUji{}
+	uc := NewUserUsecase(users, tokens, newSesiRepoUji(), &auditRepoUji{}, nil, nil, nil, UserUsecaseConfig{Clock: jam})
+
+	token := "token-rahasia"
+	tokens.Save(ctx, &domain.TokenReset{
+		TokenHash: hashToken(token),
+		Username:  "budi",
+		CreatedAt: jam.Now(),
+		ExpiresAt: jam.Now().Add(time.Hour),
+	})
+
+	// Beberapa permintaan bersamaan yang sama-sama lolos pemeriksaan UsedAt
+	const n = 5
+	var wg sync.WaitGroup
+	hasil := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hasil[i] = uc.ResetPassword(ctx, token, "rahasia-baru")
+		}(i)
+	}
+	wg.Wait()
+
+	berhasil := 0
+	for _, err := range hasil {
+		if err == nil {
+			berhasil++
+		}
+	}
+	if berhasil != 1 {
+		t.Fatalf("token dipakai %d kali, want tepat 1 (hasil %v)", berhasil, hasil)
+	}
+
+	if err := uc.ResetPassword(ctx, token, "lagi"); err == nil {
+		t.Fatal("token yang sudah dipakai masih diterima")
+	}
+}
+
+func TestResetPasswordKedaluwarsa(t *testing.T) {
+	ctx := context.Background()
+	jam := &jamUji{t: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)}
+	tokens := &resetTokenRepoUji{}
+	uc := NewUserUsecase(newUserRepoUji(domain.User{Username: "budi"}), tokens, newSesiRepoUji(), &auditRepoUji{}, nil, nil, nil, UserUsecaseConfig{Clock: jam})
+
+	tokens.Save(ctx, &domain.TokenReset{TokenHash: hashToken("t"), Username: "budi", CreatedAt: jam.Now(), ExpiresAt: jam.Now().Add(time.Hour)})
+	jam.maju(2 * time.Hour)
+	if err := uc.ResetPassword(ctx, "t", "baru"); err == nil {
+		t.Fatal("token kedaluwarsa masih diterima")
+	}
+	if len(tokens.klaim) != 0 {
+		t.Fatalf("token kedaluwarsa tidak boleh diklaim, ada %d klaim", len(tokens.klaim))
+	}
+}
@@ -0,0 +1,298 @@
+// file: internal/usecase/user_session.go
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// Access token dibuat singkat; frontend menukarnya lewat /refresh saat ditolak (lihat
+	// frontend/src/lib/auth.js), jadi klaim seperti role dan ganti_password cepat diperbarui
+	accessTokenTTL = 15 * time.Minute
+	// Refresh token berlaku selama ini sejak terakhir dirotasi
+	refreshTokenTTL = 30 * 24 * time.Hour
+	// Lama hasil pengecekan sesi disimpan di memori agar tidak setiap request membaca sheet.
+	// Cache ini per instance: sesi yang dicabut lewat replika lain masih diterima di sini
+	// paling lama selama TTL ini.
+	sessionCacheTTL = 30 * time.Second
+)
+
+// SesiRepository menyimpan sesi login di server agar bisa dicabut (logout, perangkat hilang, dll.)
+type SesiRepository interface {
+	Save(ctx context.Context, sesi *domain.SesiPengguna) error
+	FindByID(ctx context.Context, id string) (*domain.SesiPengguna, error)
+	FindByUsername(ctx context.Context, username string) ([]domain.SesiPengguna, error)
+	Update(ctx context.Context, sesi *domain.SesiPengguna) error
+	RevokeAllForUser(ctx context.Context, username string, at time.Time) ([]string, error)
+	DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// sessionCache menyimpan sementara status aktif/tidaknya sebuah sesi. Entri yang sudah
+// lewat TTL dibuang berkala agar sesi yang tidak pernah dipakai lagi tidak menumpuk.
+type sessionCache struct {
+	mu          sync.Mutex
+	entries     map[string]sessionCacheEntry
+	dibersihkan time.Time
+}
+
+type sessionCacheEntry struct {
+	active    bool
+	checkedAt time.Time
+}
+
+func newSessionCache() *sessionCache {
+	return &sessionCache{entries: make(map[string]sessionCacheEntry)}
+}
+
+func (c *sessionCache) get(id string, now time.Time) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[id]
+	if !ok || now.Sub(entry.checkedAt) > sessionCacheTTL {
+		delete(c.entries, id)
+		return false, false
+	}
+	return entry.active, true
+}
+
+func (c *sessionCache) set(id string, active bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[id] = sessionCacheEntry{active: active, checkedAt: now}
+	if now.Sub(c.dibersihkan) > sessionCacheTTL {
+		for k, e := range c.entries {
+			if now.Sub(e.checkedAt) > sessionCacheTTL {
+				delete(c.entries, k)
+			}
+		}
+		c.dibersihkan = now
+	}
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// idBaru membuat ID baris acak dengan awalan jenis data, misal "WHD-3f9a0c1d2e4b5a6f". ID tidak
+// diambil dari jam agar baris yang dibuat bersamaan (atau dengan jam uji yang tetap) tidak bentrok.
+func idBaru(prefix string) string {
+	b := make([]byte, 8)
+	rand.Read(b) // crypto/rand.Read tidak pernah gagal sejak Go 1.24
+	return prefix + "-" + hex.EncodeToString(b)
+}
+
+// generateJWT membuat access token singkat yang terikat ke satu sesi
+func (uc *userUsecase) generateJWT(user *domain.User, sessionID string) (string, error) {
+	now := uc.clock.Now()
+	claims := jwt.MapClaims{
+		"username": user.Username,
+		"role":     user.Role,
+		"sid":      sessionID,
+		"iat":      now.Unix(),
+		"exp":      now.Add(accessTokenTTL).Unix(),
+	}
+	// Token pengguna yang wajib ganti password hanya diterima oleh rute ganti password
+	if user.MustChangePassword {
+		claims["ganti_password"] = true
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// Gunakan secret dari struct: uc.jwtSecret
+	return token.SignedString(uc.jwtSecret)
+}
+
+// startSession membuat sesi baru untuk user dan mengembalikan pasangan access + refresh token
+func (uc *userUsecase) startSession(ctx context.Context, user *domain.User, client domain.ClientInfo) (*domain.LoginResult, error) {
+	sessionID, err := randomToken(12)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.clock.Now()
+	sesi := &domain.SesiPengguna{
+		ID:               sessionID,
+		Username:         user.Username,
+		RefreshTokenHash: hashToken(secret),
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(refreshTokenTTL),
+		LastUsedAt:       now,
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+	}
+	if err := uc.sesiRepo.Save(ctx, sesi); err != nil {
+		return nil, err
+	}
+	uc.sessions.set(sessionID, true, now)
+
+	return uc.issueTokens(user, sessionID, secret)
+}
+
+func (uc *userUsecase) issueTokens(user *domain.User, sessionID, secret string) (*domain.LoginResult, error) {
+	accessToken, err := uc.generateJWT(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LoginResult{
+		AccessToken: accessToken,
+		// Format refresh token: "<id sesi>.<rahasia>", hanya hash rahasianya yang disimpan
+		RefreshToken: sessionID + "." + secret,
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+
+		PasswordChangeRequired: user.MustChangePassword,
+	}, nil
+}
+
+// RefreshSession menukar refresh token dengan access token baru dan merotasi refresh token-nya.
+// Jika refresh token lama dipakai ulang, sesi dianggap bocor dan langsung dicabut.
+func (uc *userUsecase) RefreshSession(ctx context.Context, refreshToken string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	sessionID, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return nil, domain.ErrSesiTidakValid
+	}
+
+	sesi, err := uc.sesiRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	now := uc.clock.Now()
+	if sesi == nil || !sesi.Active(now) {
+		return nil, domain.ErrSesiTidakValid
+	}
+
+	presented := hashToken(secret)
+	if subtle.ConstantTimeCompare([]byte(presented), []byte(sesi.RefreshTokenHash)) != 1 {
+		if sesi.PreviousTokenHash != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(sesi.PreviousTokenHash)) == 1 {
+			log.Printf("WARNING: Refresh token lama dipakai ulang untuk sesi %s (%s), sesi dicabut", sesi.ID, sesi.Username)
+			sesi.RevokedAt = now
+			if err := uc.sesiRepo.Update(ctx, sesi); err != nil {
+				return nil, err
+			}
+			uc.sessions.set(sesi.ID, false, now)
+		}
+		return nil, domain.ErrSesiTidakValid
+	}
+
+	user, err := uc.userRepo.FindByUsername(ctx, sesi.Username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.Disabled {
+		return nil, domain.ErrSesiTidakValid
+	}
+
+	newSecret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	sesi.PreviousTokenHash = sesi.RefreshTokenHash
+	sesi.RefreshTokenHash = hashToken(newSecret)
+	sesi.ExpiresAt = now.Add(refreshTokenTTL)
+	sesi.LastUsedAt = now
+	if client.UserAgent != "" {
+		sesi.UserAgent = client.UserAgent
+	}
+	if client.IPAddress != "" {
+		sesi.IPAddress = client.IPAddress
+	}
+	if err := uc.sesiRepo.Update(ctx, sesi); err != nil {
+		return nil, err
+	}
+
+	return uc.issueTokens(user, sesi.ID, newSecret)
+}
+
+// Logout mencabut satu sesi (perangkat yang sedang dipakai)
+func (uc *userUsecase) Logout(ctx context.Context, sessionID string) error {
+	sesi, err := uc.sesiRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if sesi == nil {
+		return errors.New("sesi tidak ditemukan")
+	}
+
+	now := uc.clock.Now()
+	if sesi.RevokedAt.IsZero() {
+		sesi.RevokedAt = now
+		if err := uc.sesiRepo.Update(ctx, sesi); err != nil {
+			return err
+		}
+	}
+	uc.sessions.set(sessionID, false, now)
+	return nil
+}
+
+// LogoutAll mencabut semua sesi milik username, dipakai untuk "keluar dari semua perangkat"
+// maupun oleh admin untuk mengakhiri sesi pengguna lain
+func (uc *userUsecase) LogoutAll(ctx context.Context, username string) (int, error) {
+	now := uc.clock.Now()
+	revoked, err := uc.sesiRepo.RevokeAllForUser(ctx, username, now)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range revoked {
+		uc.sessions.set(id, false, now)
+	}
+	log.Printf("INFO: %d sesi milik %s dicabut", len(revoked), username)
+	return len(revoked), nil
+}
+
+func (uc *userUsecase) ListSessions(ctx context.Context, username string) ([]domain.SesiPengguna, error) {
+	sessions, err := uc.sesiRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.clock.Now()
+	active := []domain.SesiPengguna{}
+	for _, s := range sessions {
+		if s.Active(now) {
+			active = append(active, s)
+		}
+	}
+	return active, nil
+}
+
+// IsSessionActive dipanggil oleh JWTMiddleware untuk setiap request terproteksi
+func (uc *userUsecase) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	now := uc.clock.Now()
+	if active, ok := uc.sessions.get(sessionID, now); ok {
+		return active, nil
+	}
+
+	sesi, err := uc.sesiRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("gagal memeriksa sesi: %v", err)
+	}
+	active := sesi != nil && sesi.Active(now)
+	uc.sessions.set(sessionID, active, now)
+	return active, nil
+}
+
+func (uc *userUsecase) PurgeExpiredSessions(ctx context.Context) (int, error) {
+	return uc.sesiRepo.DeleteExpiredBefore(ctx, uc.clock.Now())
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSessionCache(t *testing.T) {
+	c := newSessionCache()
+	t0 := time.Date(2025, 3, 10, 7, 0, 0, 0, time.UTC)
+
+	c.set("a", true, t0)
+	if active, ok := c.get("a", t0.Add(sessionCacheTTL)); !ok || !active {
+		t.Fatalf("get dalam TTL = %v, %v", active, ok)
+	}
+	if _, ok := c.get("a", t0.Add(sessionCacheTTL+time.Second)); ok {
+		t.Fatal("entri lewat TTL masih dipakai")
+	}
+
+	// Entri yang tidak pernah dibaca lagi ikut dibuang saat set berikutnya
+	c.set("lama", false, t0)
+	c.set("baru", true, t0.Add(2*sessionCacheTTL))
+	if _, ada := c.entries["lama"]; ada {
+		t.Fatal("entri kedaluwarsa tidak dibersihkan")
+	}
+	if _, ada := c.entries["baru"]; !ada {
+		t.Fatal("entri baru ikut terhapus")
+	}
+}
+
+func TestGantiPasswordMenerbitkanTokenBaru(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("rahasia")
+	hash, _ := bcrypt.GenerateFromPassword([]byte("sementara"), bcrypt.MinCost)
+	// Akun hasil provisioning massal wajib mengganti password sementaranya
+	users := newUserRepoUji(domain.User{Username: "0012345678", PasswordHash: string(hash), Role: domain.RoleSiswa, MustChangePassword: true})
+	uc := NewUserUsecase(users, &resetTokenRepoUji{}, newSesiRepoUji(), &auditRepoUji{}, nil, nil, nil, UserUsecaseConfig{
+		JWTSecret:  secret,
+		LoginGuard: DefaultLoginGuardConfig(),
+		// Token divalidasi dengan jam sistem
+		Clock: &jamUji{t: time.Now()},
+	})
+	klaim := func(token string) jwt.MapClaims {
+		t.Helper()
+		parsed, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return secret, nil })
+		if err != nil {
+			t.Fatalf("token tidak valid: %v", err)
+		}
+		return parsed.Claims.(jwt.MapClaims)
+	}
+
+	login, err := uc.Login(ctx, "0012345678", "sementara", domain.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lama := klaim(login.AccessToken)
+	if lama["ganti_password"] != true {
+		t.Fatalf("token login tidak membawa pembatasan: %v", lama)
+	}
+
+	hasil, err := uc.UpdateUser(ctx, "0012345678", lama["sid"].(string), "", "passwordbaru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	baru := klaim(hasil.AccessToken)
+	if _, ada := baru["ganti_password"]; ada || hasil.PasswordChangeRequired {
+		t.Errorf("token setelah ganti password masih dibatasi: %v", baru)
+	}
+	if baru["sid"] != lama["sid"] || baru["username"] != "0012345678" {
+		t.Errorf("token baru = %v, ingin sesi dan username yang sama dengan %v", baru, lama)
+	}
+	if u, _ := users.FindByUsername(ctx, "0012345678"); u.MustChangePassword {
+		t.Error("kewajiban ganti password tidak dihapus")
+	}
+}
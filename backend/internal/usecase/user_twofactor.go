@@ -0,0 +1,280 @@
+// file: internal/usecase/user_twofactor.go
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// Jenis challenge token (claim "typ"). Token ini tidak bisa dipakai untuk mengakses API.
+	challengeLogin = "2fa"
+	challengeSetup = "2fa-setup"
+	// Waktu yang diberikan untuk memasukkan kode setelah password benar
+	challengeTokenTTL = 5 * time.Minute
+)
+
+// generateChallengeToken membuat token singkat yang membuktikan password sudah benar
+func (uc *userUsecase) generateChallengeToken(username, typ string) (string, error) {
+	now := uc.clock.Now()
+	claims := jwt.MapClaims{
+		"username": username,
+		"typ":      typ,
+		"iat":      now.Unix(),
+		"exp":      now.Add(challengeTokenTTL).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(uc.jwtSecret)
+}
+
+// parseChallengeToken memvalidasi challenge token dan mengembalikan pemiliknya
+func (uc *userUsecase) parseChallengeToken(ctx context.Context, tokenString, typ string) (*domain.User, error) {
+	errTidakValid := errors.New("sesi verifikasi sudah berakhir, silakan login kembali")
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errTidakValid
+		}
+		return uc.jwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errTidakValid
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != typ {
+		return nil, errTidakValid
+	}
+	username, _ := claims["username"].(string)
+
+	user, err := uc.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errTidakValid
+	}
+	if user.Disabled {
+		return nil, domain.ErrAkunNonaktif
+	}
+	return user, nil
+}
+
+// newTwoFactorSetup membuat rahasia baru (belum aktif) dan QR code untuk dipindai
+func (uc *userUsecase) newTwoFactorSetup(ctx context.Context, user *domain.User) (*domain.TwoFactorSetup, error) {
+	if user.TOTPEnabled {
+		return nil, errors.New("2FA sudah aktif untuk akun ini")
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.userRepo.UpdateTwoFactor(ctx, user.Username, secret, false, nil); err != nil {
+		return nil, err
+	}
+
+	otpURL := totpURL(uc.totpIssuer, user.Username, secret)
+	png, err := qrcode.Encode(otpURL, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TwoFactorSetup{Secret: secret, OTPAuthURL: otpURL, QRCodePNG: png}, nil
+}
+
+// activateTwoFactor memverifikasi kode pertama dari authenticator lalu mengaktifkan 2FA
+func (uc *userUsecase) activateTwoFactor(ctx context.Context, user *domain.User, code string) ([]string, error) {
+	if user.TOTPEnabled {
+		return nil, errors.New("2FA sudah aktif untuk akun ini")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("lakukan pendaftaran 2FA terlebih dahulu")
+	}
+	counter, ok := verifyTOTP(user.TOTPSecret, code, uc.clock.Now(), user.TOTPCounterTerakhir)
+	if !ok {
+		return nil, domain.ErrKodeTidakValid
+	}
+	if err := uc.userRepo.UpdateTOTPCounter(ctx, user.Username, counter); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.userRepo.UpdateTwoFactor(ctx, user.Username, user.TOTPSecret, true, hashes); err != nil {
+		return nil, err
+	}
+	uc.audit(ctx, domain.Audit2FAAktif, user.Username, "", "")
+	return codes, nil
+}
+
+// checkSecondFactor menerima kode authenticator atau salah satu kode pemulihan (sekali pakai)
+func (uc *userUsecase) checkSecondFactor(ctx context.Context, user *domain.User, code string) (bool, error) {
+	if counter, ok := verifyTOTP(user.TOTPSecret, code, uc.clock.Now(), user.TOTPCounterTerakhir); ok {
+		if err := uc.userRepo.UpdateTOTPCounter(ctx, user.Username, counter); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	hash := hashRecoveryCode(code)
+	idx := slices.Index(user.RecoveryCodeHashes, hash)
+	if idx < 0 {
+		return false, nil
+	}
+	sisa := slices.Delete(slices.Clone(user.RecoveryCodeHashes), idx, idx+1)
+	if err := uc.userRepo.UpdateTwoFactor(ctx, user.Username, user.TOTPSecret, true, sisa); err != nil {
+		return false, err
+	}
+	uc.audit(ctx, domain.AuditKodePemulih, user.Username, "", fmt.Sprintf("sisa %d kode", len(sisa)))
+	return true, nil
+}
+
+// VerifyTwoFactorLogin adalah langkah kedua login: JWT baru diterbitkan setelah kode valid
+func (uc *userUsecase) VerifyTwoFactorLogin(ctx context.Context, challengeToken, code string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	user, err := uc.parseChallengeToken(ctx, challengeToken, challengeLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tebakan kode 6 digit juga dibatasi seperti tebakan password
+	now := uc.clock.Now()
+	if ditunda, _ := uc.guard.check(user.Username, client.IPAddress, now); ditunda != nil {
+		return nil, ditunda
+	}
+	// Penguncian yang tersimpan di DataPengguna berlaku juga untuk instance lain dan setelah
+	// restart, saat catatan kegagalan di memori sudah hilang
+	if now.Before(user.LockedUntil) {
+		return nil, &domain.LoginDitundaError{RetryAfter: user.LockedUntil.Sub(now), Terkunci: true}
+	}
+
+	ok, err := uc.checkSecondFactor(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		uc.audit(ctx, domain.Audit2FAGagal, user.Username, client.IPAddress, "kode salah")
+		if uc.guard.recordFailure(user.Username, client.IPAddress, now) {
+			until := now.Add(uc.guard.cfg.LockoutDuration)
+			if err := uc.userRepo.UpdateLockout(ctx, user.Username, until); err != nil {
+				return nil, err
+			}
+			uc.audit(ctx, domain.AuditAkunTerkunci, user.Username, client.IPAddress, "terkunci sampai "+until.Format("2006-01-02 15:04:05"))
+		}
+		return nil, domain.ErrKodeTidakValid
+	}
+
+	uc.hapusKegagalan(ctx, user)
+	return uc.startSession(ctx, user, client)
+}
+
+// BeginRequiredTwoFactorSetup dipakai saat login untuk peran yang wajib 2FA tetapi belum mendaftar
+func (uc *userUsecase) BeginRequiredTwoFactorSetup(ctx context.Context, challengeToken string) (*domain.TwoFactorSetup, error) {
+	user, err := uc.parseChallengeToken(ctx, challengeToken, challengeSetup)
+	if err != nil {
+		return nil, err
+	}
+	return uc.newTwoFactorSetup(ctx, user)
+}
+
+// CompleteRequiredTwoFactorSetup mengaktifkan 2FA lalu langsung menerbitkan sesi login
+func (uc *userUsecase) CompleteRequiredTwoFactorSetup(ctx context.Context, challengeToken, code string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	user, err := uc.parseChallengeToken(ctx, challengeToken, challengeSetup)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := uc.activateTwoFactor(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := uc.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	result.RecoveryCodes = codes
+	return result, nil
+}
+
+// SetupTwoFactor memulai pendaftaran 2FA dari halaman profil
+func (uc *userUsecase) SetupTwoFactor(ctx context.Context, username string) (*domain.TwoFactorSetup, error) {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("pengguna tidak ditemukan")
+	}
+	return uc.newTwoFactorSetup(ctx, user)
+}
+
+// EnableTwoFactor menyelesaikan pendaftaran dan mengembalikan kode pemulihan (hanya ditampilkan sekali)
+func (uc *userUsecase) EnableTwoFactor(ctx context.Context, username, code string) ([]string, error) {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("pengguna tidak ditemukan")
+	}
+	return uc.activateTwoFactor(ctx, user, code)
+}
+
+// DisableTwoFactor mematikan 2FA setelah password dan kode dikonfirmasi ulang
+func (uc *userUsecase) DisableTwoFactor(ctx context.Context, username, password, code string) error {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("pengguna tidak ditemukan")
+	}
+	if !user.TOTPEnabled {
+		return errors.New("2FA belum aktif untuk akun ini")
+	}
+	if uc.wajib2FA[user.Role] {
+		return errors.New("2FA wajib untuk peran ini dan tidak bisa dinonaktifkan")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return domain.ErrKredensialSalah
+	}
+	ok, err := uc.checkSecondFactor(ctx, user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrKodeTidakValid
+	}
+
+	if err := uc.userRepo.UpdateTwoFactor(ctx, user.Username, "", false, nil); err != nil {
+		return err
+	}
+	uc.audit(ctx, domain.Audit2FANonaktif, user.Username, "", "dinonaktifkan oleh pengguna")
+	return nil
+}
+
+// ResetTwoFactor dipakai admin jika pengguna kehilangan HP dan kode pemulihannya.
+// Semua sesi pengguna ikut diakhiri; untuk peran wajib 2FA, pendaftaran diulang saat login berikutnya.
+func (uc *userUsecase) ResetTwoFactor(ctx context.Context, username, resetBy string) error {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("pengguna tidak ditemukan")
+	}
+	if err := uc.userRepo.UpdateTwoFactor(ctx, user.Username, "", false, nil); err != nil {
+		return err
+	}
+	if _, err := uc.LogoutAll(ctx, user.Username); err != nil {
+		return err
+	}
+	uc.audit(ctx, domain.Audit2FANonaktif, user.Username, "", "direset oleh "+resetBy)
+	return nil
+}
@@ -4,85 +4,242 @@ package usecase
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"log"
+	"net/url"
+	"strings"
 	"time"
 
+	"daarulilmi-presence/internal/clock"
 	"daarulilmi-presence/internal/domain" // Ganti dengan nama modul Anda
 
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Map untuk menyimpan token reset [token] -> username
-var resetTokens = make(map[string]string)
-
-// Map untuk menyimpan waktu kedaluwarsa token [token] -> waktu
-var resetTokenExpiry = make(map[string]time.Time)
+const (
+	// Masa berlaku token reset password
+	resetTokenTTL = 15 * time.Minute
+	// Batas permintaan reset per username dalam satu jendela waktu
+	resetRateLimit  = 3
+	resetRateWindow = time.Hour
+)
 
 // Definisikan "kontrak" atau job description untuk repository
 type UserRepository interface {
 	FindByUsername(ctx context.Context, username string) (*domain.User, error)
+	FindAll(ctx context.Context) ([]domain.User, error)
 	Save(ctx context.Context, user *domain.User) error
+	SaveAll(ctx context.Context, users []domain.User) error
 	Update(ctx context.Context, currentUsername string, user *domain.User) error
+	UpdateLockout(ctx context.Context, username string, until time.Time) error
+	UpdateTwoFactor(ctx context.Context, username, secret string, enabled bool, recoveryCodeHashes []string) error
+	UpdateTOTPCounter(ctx context.Context, username string, counter int64) error
+	UpdateDisabled(ctx context.Context, username string, disabled bool) error
+	UpdateMustChangePassword(ctx context.Context, username string, must bool) error
+}
+
+// ResetTokenRepository menyimpan token reset password (dalam bentuk hash) agar tahan restart
+// dan bisa dipakai bersama oleh beberapa instance server
+type ResetTokenRepository interface {
+	Save(ctx context.Context, token *domain.TokenReset) error
+	FindByHash(ctx context.Context, tokenHash string) (*domain.TokenReset, error)
+	MarkUsed(ctx context.Context, rowNumber int, usedAt time.Time) error
+	// Claim mencatat klaim pemakaian token dan mengembalikan true hanya untuk klaim pertama.
+	// Harus aman dipanggil bersamaan dari beberapa instance server.
+	Claim(ctx context.Context, tokenHash, claimID string, at time.Time) (bool, error)
+	CountCreatedSince(ctx context.Context, username string, since time.Time) (int, error)
+	DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// UserUsecaseConfig berisi pengaturan usecase pengguna yang berasal dari konfigurasi server
+type UserUsecaseConfig struct {
+	JWTSecret     []byte
+	PublicBaseURL string // Alamat publik aplikasi, dipakai untuk tautan di email
+	LoginGuard    LoginGuardConfig
+	// Nama aplikasi yang tampil di authenticator (Google Authenticator, dll.)
+	TOTPIssuer string
+	// Peran yang wajib memakai 2FA, misal admin dan wali kelas
+	TwoFactorRequiredRoles []string
+	// Jam di zona waktu sekolah; nil berarti jam sistem di clock.DefaultTimezone
+	Clock clock.Clock
 }
 
 type userUsecase struct {
-	userRepo  UserRepository
-	jwtSecret []byte
+	userRepo       UserRepository
+	resetTokenRepo ResetTokenRepository
+	sesiRepo       SesiRepository
+	sessions       *sessionCache
+	auditRepo      AuditRepository
+	guard          *loginGuard
+	siswaRepo      SiswaRepository
+	relasiRepo     RelasiWaliRepository
+	mailer         Mailer
+	jwtSecret      []byte
+	publicBaseURL  string
+	totpIssuer     string
+	wajib2FA       map[string]bool
+	clock          clock.Clock
 }
 
 // NewUserUsecase adalah "pabrik" untuk usecase
-func NewUserUsecase(userRepo UserRepository, jwtSecret []byte) domain.UserUsecase {
+func NewUserUsecase(userRepo UserRepository, resetTokenRepo ResetTokenRepository, sesiRepo SesiRepository, auditRepo AuditRepository, siswaRepo SiswaRepository, relasiRepo RelasiWaliRepository, mailer Mailer, cfg UserUsecaseConfig) domain.UserUsecase {
+	wajib2FA := make(map[string]bool)
+	for _, role := range cfg.TwoFactorRequiredRoles {
+		wajib2FA[strings.TrimSpace(role)] = true
+	}
+	issuer := cfg.TOTPIssuer
+	if issuer == "" {
+		issuer = "Presensi Daarul Ilmi"
+	}
+
 	return &userUsecase{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:       userRepo,
+		resetTokenRepo: resetTokenRepo,
+		sesiRepo:       sesiRepo,
+		sessions:       newSessionCache(),
+		auditRepo:      auditRepo,
+		guard:          newLoginGuard(cfg.LoginGuard),
+		siswaRepo:      siswaRepo,
+		relasiRepo:     relasiRepo,
+		mailer:         mailer,
+		jwtSecret:      cfg.JWTSecret,
+		publicBaseURL:  strings.TrimRight(cfg.PublicBaseURL, "/"),
+		totpIssuer:     issuer,
+		wajib2FA:       wajib2FA,
+		clock:          clockAtauSistem(cfg.Clock),
 	}
 }
 
-// --- FUNGSI BARU UNTUK MEMBUAT TOKEN ---
-func (uc *userUsecase) generateJWT(user *domain.User) (string, error) {
-	claims := jwt.MapClaims{
-		"username": user.Username,
-		"role":     user.Role,
-		"exp":      time.Now().Add(time.Hour * 72).Unix(),
+// hashToken menghasilkan hash SHA-256 dari token acak, sehingga isi sheet tidak bisa dipakai langsung
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// --- MODIFIKASI FUNGSI LOGIN ---
+// Login membuat sesi baru dan mengembalikan access token + refresh token.
+// Percobaan yang gagal dihitung per username dan per IP; terlalu banyak kegagalan
+// membuat login diperlambat lalu akun dikunci sementara.
+func (uc *userUsecase) Login(ctx context.Context, username, password string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	now := uc.clock.Now()
+	if ditunda, audit := uc.guard.check(username, client.IPAddress, now); ditunda != nil {
+		if audit {
+			uc.audit(ctx, domain.AuditLoginDitunda, username, client.IPAddress, ditunda.Error())
+		}
+		return nil, ditunda
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	// Gunakan secret dari struct: uc.jwtSecret
-	tokenString, err := token.SignedString(uc.jwtSecret)
+	user, err := uc.userRepo.FindByUsername(ctx, username)
 	if err != nil {
-		return "", err
+		return nil, err // Error saat koneksi ke DB
 	}
-	return tokenString, nil
+
+	// Akun dikunci (bisa oleh instance lain) dan belum lewat waktunya
+	if user != nil && now.Before(user.LockedUntil) {
+		if uc.guard.tandaiTerkunci(username, now) {
+			uc.audit(ctx, domain.AuditLoginDitunda, username, client.IPAddress, "akun masih terkunci")
+		}
+		return nil, &domain.LoginDitundaError{RetryAfter: user.LockedUntil.Sub(now), Terkunci: true}
+	}
+
+	if user == nil || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		detail := "password salah"
+		if user == nil {
+			detail = "username tidak ditemukan"
+		}
+		uc.audit(ctx, domain.AuditLoginGagal, username, client.IPAddress, detail)
+
+		if uc.guard.recordFailure(username, client.IPAddress, now) && user != nil {
+			until := now.Add(uc.guard.cfg.LockoutDuration)
+			if err := uc.userRepo.UpdateLockout(ctx, user.Username, until); err != nil {
+				log.Printf("ERROR menyimpan penguncian akun %s: %v", user.Username, err)
+			}
+			uc.audit(ctx, domain.AuditAkunTerkunci, username, client.IPAddress, "terkunci sampai "+until.Format("2006-01-02 15:04:05"))
+		}
+		return nil, domain.ErrKredensialSalah
+	}
+
+	// Password benar tetapi akun sudah dinonaktifkan admin
+	if user.Disabled {
+		uc.audit(ctx, domain.AuditLoginGagal, username, client.IPAddress, "akun nonaktif")
+		return nil, domain.ErrAkunNonaktif
+	}
+
+	// Langkah kedua: akun dengan 2FA harus memasukkan kode authenticator dulu. Catatan
+	// kegagalan baru dihapus setelah kodenya benar, agar login ulang dengan password tidak
+	// mereset batas tebakan kode.
+	if user.TOTPEnabled {
+		challenge, err := uc.generateChallengeToken(user.Username, challengeLogin)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.LoginResult{TwoFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+
+	// Login berhasil: hapus catatan kegagalan dan sisa penguncian yang sudah lewat
+	uc.hapusKegagalan(ctx, user)
+	if uc.wajib2FA[user.Role] {
+		challenge, err := uc.generateChallengeToken(user.Username, challengeSetup)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.LoginResult{TwoFactorSetupRequired: true, ChallengeToken: challenge}, nil
+	}
+
+	// Buat sesi dan token untuk perangkat ini
+	return uc.startSession(ctx, user, client)
 }
 
-// --- MODIFIKASI FUNGSI LOGIN ---
-// Sekarang fungsi Login akan mengembalikan token (string) bukan user object
-func (uc *userUsecase) Login(ctx context.Context, username, password string) (string, error) {
+// hapusKegagalan menghapus catatan kegagalan login dan sisa penguncian yang sudah lewat
+func (uc *userUsecase) hapusKegagalan(ctx context.Context, user *domain.User) {
+	uc.guard.reset(user.Username)
+	if !user.LockedUntil.IsZero() {
+		if err := uc.userRepo.UpdateLockout(ctx, user.Username, time.Time{}); err != nil {
+			log.Printf("WARNING: Gagal menghapus penguncian akun %s: %v", user.Username, err)
+		}
+	}
+}
+
+// UnlockAccount dipakai admin untuk membuka akun yang terkunci sebelum waktunya
+func (uc *userUsecase) UnlockAccount(ctx context.Context, username, unlockedBy string) error {
 	user, err := uc.userRepo.FindByUsername(ctx, username)
 	if err != nil {
-		return "", err // Error saat koneksi ke DB
+		return err
 	}
 	if user == nil {
-		return "", nil // User tidak ditemukan
+		return errors.New("pengguna tidak ditemukan")
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
-	if err != nil {
-		return "", nil // Password salah
+	if err := uc.userRepo.UpdateLockout(ctx, user.Username, time.Time{}); err != nil {
+		return err
 	}
+	uc.guard.reset(user.Username)
+	uc.audit(ctx, domain.AuditAkunDibuka, user.Username, "", "dibuka oleh "+unlockedBy)
+	return nil
+}
 
-	// Jika login berhasil, panggil fungsi untuk membuat token
-	token, err := uc.generateJWT(user)
-	if err != nil {
-		return "", err
+// audit mencatat kejadian keamanan; kegagalan mencatat tidak boleh menggagalkan proses utama
+func (uc *userUsecase) audit(ctx context.Context, event, username, ip, detail string) {
+	entry := &domain.AuditLog{
+		Timestamp: uc.clock.Now(),
+		Event:     event,
+		Username:  username,
+		IPAddress: ip,
+		Detail:    detail,
+	}
+	if err := uc.auditRepo.Save(ctx, entry); err != nil {
+		log.Printf("WARNING: Gagal mencatat audit %s untuk %s: %v", event, username, err)
 	}
-
-	return token, nil // Kembalikan token sebagai string
 }
 
+// Register adalah pendaftaran mandiri dari halaman publik. Akun baru selalu berperan wali murid;
+// peran lain dan tautan ke siswa hanya bisa diatur oleh admin.
 func (uc *userUsecase) Register(ctx context.Context, user *domain.User) error {
+	user.Role = domain.RoleOrtu
+	user.SiswaNISN = ""
+
 	// 1. Cek apakah username sudah ada
 	existingUser, err := uc.userRepo.FindByUsername(ctx, user.Username)
 	if err != nil {
@@ -103,29 +260,25 @@ func (uc *userUsecase) Register(ctx context.Context, user *domain.User) error {
 	return uc.userRepo.Save(ctx, user)
 }
 
-func (uc *userUsecase) UpdateUser(ctx context.Context, currentUsername string, newUsername, newPassword string) error {
+func (uc *userUsecase) UpdateUser(ctx context.Context, currentUsername, sessionID string, newUsername, newPassword string) (*domain.LoginResult, error) {
 	// Ambil data user saat ini untuk mendapatkan data yang tidak berubah
 	currentUser, err := uc.userRepo.FindByUsername(ctx, currentUsername)
 	if err != nil || currentUser == nil {
-		return errors.New("pengguna saat ini tidak ditemukan")
+		return nil, errors.New("pengguna saat ini tidak ditemukan")
 	}
 
-	// Siapkan data untuk diupdate
-	updateData := domain.User{
-		Username:     currentUser.Username,
-		PasswordHash: currentUser.PasswordHash, // Defaultnya pakai yg lama
-		Role:         currentUser.Role,
-	}
+	// Siapkan data untuk diupdate, defaultnya pakai data yang lama
+	updateData := *currentUser
 
 	// Jika username baru diisi, gunakan itu
 	if newUsername != "" {
 		// Cek apakah username baru sudah dipakai orang lain
 		existingUser, err := uc.userRepo.FindByUsername(ctx, newUsername)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if existingUser != nil {
-			return errors.New("username baru sudah digunakan")
+			return nil, errors.New("username baru sudah digunakan")
 		}
 		updateData.Username = newUsername
 	}
@@ -134,77 +287,197 @@ func (uc *userUsecase) UpdateUser(ctx context.Context, currentUsername string, n
 	if newPassword != "" {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		updateData.PasswordHash = string(hashedPassword)
 	}
 
 	// Panggil repository untuk menyimpan perubahan
-	return uc.userRepo.Update(ctx, currentUsername, &updateData)
+	if err := uc.userRepo.Update(ctx, currentUsername, &updateData); err != nil {
+		return nil, err
+	}
+
+	// Password sudah diganti: hapus kewajiban ganti password
+	if newPassword != "" && currentUser.MustChangePassword {
+		if err := uc.userRepo.UpdateMustChangePassword(ctx, updateData.Username, false); err != nil {
+			return nil, err
+		}
+		updateData.MustChangePassword = false
+	}
+
+	// Token lama masih membawa username dan klaim ganti_password yang lama, jadi klien langsung
+	// diberi access token baru untuk sesi yang sama tanpa menunggu /refresh
+	accessToken, err := uc.generateJWT(&updateData, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LoginResult{
+		AccessToken:            accessToken,
+		ExpiresIn:              int(accessTokenTTL.Seconds()),
+		PasswordChangeRequired: updateData.MustChangePassword,
+	}, nil
 }
 
 // --- FUNGSI BARU UNTUK MINTA RESET ---
-func (uc *userUsecase) RequestPasswordReset(ctx context.Context, username string) (string, error) {
+// RequestPasswordReset membuat token reset dan mengirimkan tautannya ke email pemilik akun
+func (uc *userUsecase) RequestPasswordReset(ctx context.Context, username string) error {
 	// Cek apakah user ada
 	user, err := uc.userRepo.FindByUsername(ctx, username)
 	if err != nil || user == nil {
-		return "", errors.New("username tidak ditemukan")
+		return errors.New("username tidak ditemukan")
+	}
+
+	recipient, err := uc.resolveEmailRecipient(ctx, user)
+	if err != nil {
+		return err
+	}
+	if recipient == "" {
+		return errors.New("akun ini tidak memiliki alamat email")
+	}
+
+	// Batasi jumlah permintaan per username agar tidak bisa dipakai untuk spam
+	now := uc.clock.Now()
+	count, err := uc.resetTokenRepo.CountCreatedSince(ctx, username, now.Add(-resetRateWindow))
+	if err != nil {
+		return err
+	}
+	if count >= resetRateLimit {
+		return domain.ErrTerlaluBanyakPermintaanReset
 	}
 
 	// Buat token acak yang aman
-	bytes := make([]byte, 16)
+	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+		return err
 	}
 	token := hex.EncodeToString(bytes)
 
-	// Simpan token dan waktu kedaluwarsa (15 menit)
-	resetTokens[token] = username
-	resetTokenExpiry[token] = time.Now().Add(15 * time.Minute)
+	// Simpan hash token dan waktu kedaluwarsa, token aslinya hanya dikirim ke pengguna
+	err = uc.resetTokenRepo.Save(ctx, &domain.TokenReset{
+		TokenHash: hashToken(token),
+		Username:  username,
+		CreatedAt: now,
+		ExpiresAt: now.Add(resetTokenTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	namaLengkap := user.NamaLengkap
+	if namaLengkap == "" {
+		namaLengkap = user.Username
+	}
+	msg, err := renderEmail("reset_password", recipient, "Reset Password Akun Presensi Daarul Ilmi", map[string]interface{}{
+		"NamaLengkap":  namaLengkap,
+		"Username":     user.Username,
+		"ResetURL":     uc.publicBaseURL + "/reset-password?token=" + url.QueryEscape(token),
+		"BerlakuMenit": int(resetTokenTTL.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+	return uc.mailer.Send(ctx, msg)
+}
 
-	return token, nil
+// resolveEmailRecipient menentukan alamat email tujuan: email akun, atau email orang tua
+// di DataSiswa untuk akun wali murid yang belum mengisi email sendiri
+func (uc *userUsecase) resolveEmailRecipient(ctx context.Context, user *domain.User) (string, error) {
+	if user.Email != "" {
+		return user.Email, nil
+	}
+	if user.Role == domain.RoleOrtu && user.SiswaNISN != "" {
+		siswa, err := uc.siswaRepo.FindByNISN(ctx, user.SiswaNISN)
+		if err != nil {
+			return "", err
+		}
+		if siswa != nil {
+			return siswa.EmailOrtu, nil
+		}
+	}
+	return "", nil
 }
 
 // --- FUNGSI BARU UNTUK PROSES RESET ---
 func (uc *userUsecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if token == "" || newPassword == "" {
+		return errors.New("token dan password baru wajib diisi")
+	}
+
 	// Cek token di penyimpanan
-	username, exists := resetTokens[token]
-	if !exists {
+	stored, err := uc.resetTokenRepo.FindByHash(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+	if stored == nil {
 		return errors.New("token tidak valid")
 	}
+	if !stored.UsedAt.IsZero() {
+		return errors.New("token sudah pernah digunakan")
+	}
 
 	// Cek waktu kedaluwarsa
-	if time.Now().After(resetTokenExpiry[token]) {
-		delete(resetTokens, token)
-		delete(resetTokenExpiry, token)
+	now := uc.clock.Now()
+	if now.After(stored.ExpiresAt) {
 		return errors.New("token sudah kedaluwarsa")
 	}
 
-	// Hash password baru
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	// Klaim token lebih dulu agar tidak bisa dipakai dua kali. Permintaan bersamaan (atau dari
+	// replika lain) yang sama-sama lolos pemeriksaan UsedAt di atas hanya satu yang menang.
+	claimID, err := randomToken(16)
+	if err != nil {
+		return err
+	}
+	menang, err := uc.resetTokenRepo.Claim(ctx, stored.TokenHash, claimID, now)
 	if err != nil {
 		return err
 	}
+	if !menang {
+		return errors.New("token sudah pernah digunakan")
+	}
+	if err := uc.resetTokenRepo.MarkUsed(ctx, stored.RowNumber, now); err != nil {
+		return err
+	}
 
-	// Siapkan data update
-	updateData := &domain.User{
-		Username:     username, // Username tidak berubah
-		PasswordHash: string(hashedPassword),
+	user, err := uc.userRepo.FindByUsername(ctx, stored.Username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("pengguna tidak ditemukan")
 	}
 
-	// Panggil repo untuk update (kita perlu modifikasi repo.Update sedikit)
-	err = uc.userRepo.Update(ctx, username, updateData)
+	// Hash password baru
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
-	// Hapus token setelah berhasil digunakan
-	delete(resetTokens, token)
-	delete(resetTokenExpiry, token)
+	// Siapkan data update, data lain (peran, profil) tetap dipertahankan
+	updateData := *user
+	updateData.PasswordHash = string(hashedPassword)
 
+	if err := uc.userRepo.Update(ctx, user.Username, &updateData); err != nil {
+		return err
+	}
+	if user.MustChangePassword {
+		if err := uc.userRepo.UpdateMustChangePassword(ctx, user.Username, false); err != nil {
+			return err
+		}
+	}
+
+	// Password berubah: akhiri semua sesi lama di perangkat lain
+	if _, err := uc.LogoutAll(ctx, user.Username); err != nil {
+		log.Printf("WARNING: Gagal mencabut sesi %s setelah reset password: %v", user.Username, err)
+	}
 	return nil
 }
 
+// PurgeExpiredResetTokens menghapus token yang sudah lewat masa berlakunya.
+// Token disimpan sampai jendela rate limit lewat agar hitungan per username tetap akurat.
+func (uc *userUsecase) PurgeExpiredResetTokens(ctx context.Context) (int, error) {
+	return uc.resetTokenRepo.DeleteExpiredBefore(ctx, uc.clock.Now().Add(-resetRateWindow))
+}
+
 func (uc *userUsecase) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	// Tugasnya hanya meneruskan permintaan ke repository
 	return uc.userRepo.FindByUsername(ctx, username)
@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"daarulilmi-presence/internal/domain"
+)
+
+// webhookRepoUji menyimpan langganan dan log pengiriman di memori
+type webhookRepoUji struct {
+	mu         sync.Mutex
+	daftar     []domain.Webhook
+	pengiriman []domain.PengirimanWebhook
+}
+
+func (r *webhookRepoUji) FindAll(ctx context.Context) ([]domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.Webhook(nil), r.daftar...), nil
+}
+
+func (r *webhookRepoUji) Save(ctx context.Context, w *domain.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.daftar = append(r.daftar, *w)
+	return nil
+}
+
+func (r *webhookRepoUji) Update(ctx context.Context, w *domain.Webhook) error { return nil }
+func (r *webhookRepoUji) Delete(ctx context.Context, rowNumber int) error     { return nil }
+
+func (r *webhookRepoUji) FindPengiriman(ctx context.Context) ([]domain.PengirimanWebhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.PengirimanWebhook(nil), r.pengiriman...), nil
+}
+
+func (r *webhookRepoUji) SavePengiriman(ctx context.Context, list []domain.PengirimanWebhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pengiriman = append(r.pengiriman, list...)
+	return nil
+}
+
+func TestTandaTanganWebhook(t *testing.T) {
+	// Dihitung terpisah: HMAC-SHA256("rahasia", `1700000000.{"id":"EVT-1"}`)
+	const ingin = "87574d486b6e0d66d1ba8a8a58a65fd824d944ee0aa8d19a3d9a4b144f2737e7"
+	if got := TandaTanganWebhook("rahasia", "1700000000", []byte(`{"id":"EVT-1"}`)); got != ingin {
+		t.Errorf("tanda tangan = %s, ingin %s", got, ingin)
+	}
+	if TandaTanganWebhook("rahasia", "1700000001", []byte(`{"id":"EVT-1"}`)) == ingin {
+		t.Error("timestamp berbeda menghasilkan tanda tangan yang sama")
+	}
+	if TandaTanganWebhook("rahasia-lain", "1700000000", []byte(`{"id":"EVT-1"}`)) == ingin {
+		t.Error("secret berbeda menghasilkan tanda tangan yang sama")
+	}
+}
+
+func TestAlamatInternal(t *testing.T) {
+	tests := []struct {
+		ip       string
+		internal bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.10", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"8.8.8.8", false},
+		{"100.128.0.1", false},
+		{"2001:4860:4860::8888", false},
+	}
+	for _, tt := range tests {
+		if got := alamatInternal(netip.MustParseAddr(tt.ip)); got != tt.internal {
+			t.Errorf("alamatInternal(%s) = %v, ingin %v", tt.ip, got, tt.internal)
+		}
+	}
+}
+
+func TestValidasiWebhookJaringanLokal(t *testing.T) {
+	uc := NewWebhookUsecase(&webhookRepoUji{}, WebhookUsecaseConfig{}).(*webhookUsecase)
+	lokal := NewWebhookUsecase(&webhookRepoUji{}, WebhookUsecaseConfig{IzinkanJaringanLokal: true}).(*webhookUsecase)
+
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://hooks.example.com/presensi", true},
+		{"http://203.0.113.5:8080/hook", true},
+		{"ftp://hooks.example.com", false},
+		{"http://localhost:8080/hook", false},
+		{"http://api.localhost/hook", false},
+		{"http://127.0.0.1/hook", false},
+		{"http://169.254.169.254/latest/meta-data/", false},
+		{"http://[::1]:9000/", false},
+		{"http://192.168.1.20/hook", false},
+	}
+	for _, tt := range tests {
+		err := uc.validasi(domain.WebhookRequest{URL: tt.url}, &domain.Webhook{})
+		if (err == nil) != tt.valid {
+			t.Errorf("validasi(%s) err = %v, ingin valid = %v", tt.url, err, tt.valid)
+		}
+		if err != nil && !errors.Is(err, domain.ErrWebhookTidakValid) {
+			t.Errorf("validasi(%s) err = %v, ingin ErrWebhookTidakValid", tt.url, err)
+		}
+	}
+	if err := lokal.validasi(domain.WebhookRequest{URL: "http://192.168.1.20/hook"}, &domain.Webhook{}); err != nil {
+		t.Errorf("jaringan lokal diizinkan tetapi ditolak: %v", err)
+	}
+}
+
+func TestKlienWebhookMenolakJaringanLokal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	if _, err := klienWebhook(false).Get(srv.URL); err == nil {
+		t.Error("koneksi ke 127.0.0.1 seharusnya ditolak dialer")
+	}
+	resp, err := klienWebhook(true).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("jaringan lokal diizinkan tetapi ditolak: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestWebhookPenerimaLambatTidakMenahanLainnya(t *testing.T) {
+	lepas := make(chan struct{})
+	lambat := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-lepas:
+		case <-r.Context().Done():
+		}
+	}))
+	defer lambat.Close()
+	defer close(lepas)
+
+	diterima := make(chan string, 10)
+	cepat := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		diterima <- r.Header.Get(HeaderWebhookEvent)
+	}))
+	defer cepat.Close()
+
+	repo := &webhookRepoUji{daftar: []domain.Webhook{
+		{ID: "WH-lambat", URL: lambat.URL, Aktif: true, Secret: "a"},
+		{ID: "WH-cepat", URL: cepat.URL, Aktif: true, Secret: "b"},
+	}}
+	uc := NewWebhookUsecase(repo, WebhookUsecaseConfig{HTTPClient: &http.Client{Timeout: time.Minute}}).(*webhookUsecase)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	berhenti := make(chan struct{})
+	go func() {
+		uc.Jalankan(ctx)
+		close(berhenti)
+	}()
+
+	// Event pertama membuat satu pekerja tertahan di penerima lambat
+	uc.Terbitkan(domain.EventAbsensiMasuk, map[string]string{"nisn": "0012345678"})
+	uc.Terbitkan(domain.EventAbsensiPulang, map[string]string{"nisn": "0012345678"})
+	// Pekerja berjalan bersamaan, jadi urutan kedatangan tidak dijamin
+	tiba := map[string]bool{}
+	for len(tiba) < 2 {
+		select {
+		case jenis := <-diterima:
+			tiba[jenis] = true
+		case <-time.After(3 * time.Second):
+			t.Fatalf("penerima cepat hanya menerima %v selama penerima lambat belum membalas", tiba)
+		}
+	}
+	if !tiba[domain.EventAbsensiMasuk] || !tiba[domain.EventAbsensiPulang] {
+		t.Errorf("event diterima = %v", tiba)
+	}
+
+	// Tunggu balasan penerima cepat tercatat; pengiriman yang terputus saat berhenti memang
+	// dikirim ulang (at-least-once)
+	for batas := time.Now().Add(3 * time.Second); ; {
+		uc.logMu.Lock()
+		n := len(uc.logBaru)
+		uc.logMu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(batas) {
+			t.Fatalf("log pengiriman = %d, ingin 2", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Saat berhenti, pengiriman yang terputus dicatat tertunda dan log tersimpan
+	cancel()
+	select {
+	case <-berhenti:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Jalankan tidak berhenti setelah ctx dibatalkan")
+	}
+	list, _ := repo.FindPengiriman(context.Background())
+	var berhasil, tertunda int
+	for _, p := range list {
+		switch {
+		case p.WebhookID == "WH-cepat" && p.Status == domain.PengirimanBerhasil:
+			berhasil++
+		case p.WebhookID == "WH-lambat" && p.Status == domain.PengirimanGagal && !p.BerikutnyaPada.IsZero():
+			tertunda++
+			if p.Percobaan != 0 {
+				t.Errorf("percobaan yang terputus ikut dihitung: %d", p.Percobaan)
+			}
+		}
+	}
+	if berhasil != 2 || tertunda != 2 {
+		t.Errorf("log: %d berhasil, %d tertunda; ingin 2 dan 2 (%+v)", berhasil, tertunda, list)
+	}
+
+	// Instance baru melanjutkan pengiriman yang tertunda
+	baru := NewWebhookUsecase(repo, WebhookUsecaseConfig{HTTPClient: &http.Client{Timeout: time.Minute}}).(*webhookUsecase)
+	baru.muatMenunggu(context.Background())
+	if len(baru.menunggu) != 2 {
+		t.Errorf("pengiriman dilanjutkan = %d, ingin 2", len(baru.menunggu))
+	}
+}
@@ -0,0 +1,686 @@
+// file: internal/usecase/webhook_usecase.go
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"daarulilmi-presence/internal/clock"
+	"daarulilmi-presence/internal/domain"
+)
+
+// WebhookRepository menyimpan langganan webhook dan log pengiriman (satu baris per percobaan)
+type WebhookRepository interface {
+	FindAll(ctx context.Context) ([]domain.Webhook, error)
+	Save(ctx context.Context, w *domain.Webhook) error
+	Update(ctx context.Context, w *domain.Webhook) error
+	Delete(ctx context.Context, rowNumber int) error
+	FindPengiriman(ctx context.Context) ([]domain.PengirimanWebhook, error)
+	SavePengiriman(ctx context.Context, list []domain.PengirimanWebhook) error
+}
+
+// Header yang dikirim bersama setiap event. Tanda tangan adalah HMAC-SHA256 dari
+// "<timestamp>.<body>" dengan secret webhook, ditulis "sha256=<hex>".
+const (
+	HeaderWebhookEvent      = "X-Presensi-Event"
+	HeaderWebhookPengiriman = "X-Presensi-Delivery"
+	HeaderWebhookTimestamp  = "X-Presensi-Timestamp"
+	HeaderWebhookSignature  = "X-Presensi-Signature"
+)
+
+const (
+	// Event yang belum diproses; jika penuh, event baru dibuang dengan peringatan di log
+	ukuranAntrianWebhook = 5000
+	// Daftar webhook dibaca ulang dari sheet paling lama setiap interval ini
+	cacheWebhook = time.Minute
+	// Interval pemeriksaan percobaan ulang dan penyimpanan log pengiriman
+	intervalWebhook = 10 * time.Second
+	batasLogWebhook = 100
+	maksLogWebhook  = 1000
+	// Jumlah pengiriman yang berjalan bersamaan, agar satu penerima yang lambat tidak menahan
+	// penerima lain maupun percobaan ulang
+	pekerjaWebhook = 4
+)
+
+// DefaultJedaUlangWebhook adalah jeda sebelum percobaan ke-2, ke-3, dan seterusnya.
+// Setelah jeda terakhir habis, pengiriman ditandai menyerah.
+func DefaultJedaUlangWebhook() []time.Duration {
+	return []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour, 6 * time.Hour}
+}
+
+// WebhookUsecaseConfig berisi pengaturan pengiriman webhook
+type WebhookUsecaseConfig struct {
+	// Jam di zona waktu sekolah; nil berarti jam sistem di clock.DefaultTimezone
+	Clock clock.Clock
+	// Klien HTTP; nil berarti klien dengan batas waktu 10 detik yang menolak alamat jaringan
+	// lokal (lihat IzinkanJaringanLokal)
+	HTTPClient *http.Client
+	// Jeda percobaan ulang; kosong berarti DefaultJedaUlangWebhook
+	JedaUlang []time.Duration
+	// Izinkan URL webhook yang mengarah ke loopback, IP privat, atau link-local
+	IzinkanJaringanLokal bool
+}
+
+type webhookUsecase struct {
+	repo         WebhookRepository
+	clock        clock.Clock
+	client       *http.Client
+	jedaUlang    []time.Duration
+	antrian      chan domain.EventWebhook
+	izinkanLokal bool
+
+	// Salinan daftar webhook agar setiap scan tidak membaca sheet
+	mu         sync.Mutex
+	daftar     []domain.Webhook
+	dimuatPada time.Time
+
+	// Percobaan yang belum tersimpan ke sheet (ditulis sekaligus setiap intervalWebhook) dan
+	// pengiriman yang menunggu percobaan ulang
+	logMu    sync.Mutex
+	logBaru  []domain.PengirimanWebhook
+	menunggu []domain.PengirimanWebhook
+}
+
+// tugasWebhook adalah satu percobaan pengiriman yang dikerjakan pekerja
+type tugasWebhook struct {
+	w domain.Webhook
+	p domain.PengirimanWebhook
+}
+
+// NewWebhookUsecase adalah "pabrik" untuk usecase webhook. Event hanya dikirim selama
+// Jalankan berjalan di goroutine tersendiri.
+func NewWebhookUsecase(repo WebhookRepository, cfg WebhookUsecaseConfig) domain.WebhookUsecase {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = klienWebhook(cfg.IzinkanJaringanLokal)
+	}
+	jeda := cfg.JedaUlang
+	if len(jeda) == 0 {
+		jeda = DefaultJedaUlangWebhook()
+	}
+	return &webhookUsecase{
+		repo:         repo,
+		clock:        clockAtauSistem(cfg.Clock),
+		client:       client,
+		jedaUlang:    jeda,
+		antrian:      make(chan domain.EventWebhook, ukuranAntrianWebhook),
+		izinkanLokal: cfg.IzinkanJaringanLokal,
+	}
+}
+
+// klienWebhook membuat klien HTTP untuk pengiriman webhook. Tanpa izinkanLokal, setiap koneksi
+// ke alamat jaringan lokal ditolak oleh dialer, yaitu setelah nama host di-resolve, sehingga
+// nama domain yang mengarah (atau kemudian dialihkan) ke alamat internal ikut tertolak.
+func klienWebhook(izinkanLokal bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}
+	if !izinkanLokal {
+		dialer.Control = tolakJaringanLokal
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		// Penerima webhook tidak perlu mengalihkan; balasan 3xx dicatat apa adanya
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// Carrier-grade NAT (RFC 6598) tidak termasuk netip.Addr.IsPrivate
+var rentangCGNAT = netip.MustParsePrefix("100.64.0.0/10")
+
+// alamatInternal memeriksa IP yang tidak boleh dijangkau webhook: loopback, jaringan privat,
+// link-local (termasuk 169.254.169.254, alamat metadata cloud), multicast, dan alamat kosong
+func alamatInternal(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || rentangCGNAT.Contains(ip)
+}
+
+// tolakJaringanLokal dipasang sebagai net.Dialer.Control; address sudah berupa IP:port
+func tolakJaringanLokal(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("alamat %q tidak dikenal", address)
+	}
+	if alamatInternal(ip) {
+		return fmt.Errorf("alamat %s ada di jaringan lokal dan tidak boleh dipakai webhook", ip)
+	}
+	return nil
+}
+
+// --- PENGELOLAAN LANGGANAN ---
+
+func (uc *webhookUsecase) List(ctx context.Context) ([]domain.Webhook, error) {
+	list, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].DibuatPada.Before(list[j].DibuatPada) })
+	return list, nil
+}
+
+// validasi memeriksa data dari admin dan mengisinya ke webhook
+func (uc *webhookUsecase) validasi(req domain.WebhookRequest, w *domain.Webhook) error {
+	alamat := strings.TrimSpace(req.URL)
+	u, err := url.Parse(alamat)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: URL harus diawali http:// atau https://", domain.ErrWebhookTidakValid)
+	}
+	// Nama domain baru diperiksa saat dihubungi (lihat klienWebhook); di sini hanya penolakan
+	// awal agar admin langsung tahu
+	if !uc.izinkanLokal {
+		host := strings.ToLower(u.Hostname())
+		ip, errIP := netip.ParseAddr(host)
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") || (errIP == nil && alamatInternal(ip)) {
+			return fmt.Errorf("%w: URL tidak boleh mengarah ke jaringan lokal server", domain.ErrWebhookTidakValid)
+		}
+	}
+	events := []string{}
+	sudah := make(map[string]bool)
+	for _, e := range req.Events {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" || sudah[e] {
+			continue
+		}
+		if !domain.IsValidEventWebhook(e) {
+			return fmt.Errorf("%w: event %q tidak dikenal (pilihan: %s)", domain.ErrWebhookTidakValid, e,
+				strings.Join(domain.DaftarEventWebhook, ", "))
+		}
+		sudah[e] = true
+		events = append(events, e)
+	}
+	w.URL = alamat
+	w.Events = events
+	w.Keterangan = strings.TrimSpace(req.Keterangan)
+	if req.Aktif != nil {
+		w.Aktif = *req.Aktif
+	}
+	return nil
+}
+
+func secretWebhook() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// lupakanDaftar memaksa daftar webhook dibaca ulang pada event berikutnya
+func (uc *webhookUsecase) lupakanDaftar() {
+	uc.mu.Lock()
+	uc.dimuatPada = time.Time{}
+	uc.mu.Unlock()
+}
+
+func (uc *webhookUsecase) Create(ctx context.Context, req domain.WebhookRequest, by string) (*domain.WebhookDenganSecret, error) {
+	now := uc.clock.Now()
+	w := &domain.Webhook{
+		ID:         idBaru("WH"),
+		Aktif:      true,
+		DibuatOleh: by,
+		DibuatPada: now,
+		DiubahPada: now,
+	}
+	if err := uc.validasi(req, w); err != nil {
+		return nil, err
+	}
+	secret, err := secretWebhook()
+	if err != nil {
+		return nil, err
+	}
+	w.Secret = secret
+	if err := uc.repo.Save(ctx, w); err != nil {
+		return nil, err
+	}
+	uc.lupakanDaftar()
+	log.Printf("INFO: Webhook %s (%s) didaftarkan oleh %s", w.ID, w.URL, by)
+	return &domain.WebhookDenganSecret{Webhook: *w, Secret: secret}, nil
+}
+
+func (uc *webhookUsecase) cari(ctx context.Context, id string) (*domain.Webhook, error) {
+	list, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].ID == id {
+			return &list[i], nil
+		}
+	}
+	return nil, domain.ErrWebhookTidakDitemukan
+}
+
+func (uc *webhookUsecase) Update(ctx context.Context, id string, req domain.WebhookRequest, by string) (*domain.Webhook, error) {
+	w, err := uc.cari(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.validasi(req, w); err != nil {
+		return nil, err
+	}
+	w.DiubahPada = uc.clock.Now()
+	if err := uc.repo.Update(ctx, w); err != nil {
+		return nil, err
+	}
+	uc.lupakanDaftar()
+	log.Printf("INFO: Webhook %s diubah oleh %s (aktif: %t)", w.ID, by, w.Aktif)
+	return w, nil
+}
+
+func (uc *webhookUsecase) Delete(ctx context.Context, id, by string) error {
+	w, err := uc.cari(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.repo.Delete(ctx, w.RowNumber); err != nil {
+		return err
+	}
+	uc.lupakanDaftar()
+	log.Printf("INFO: Webhook %s (%s) dihapus oleh %s", w.ID, w.URL, by)
+	return nil
+}
+
+func (uc *webhookUsecase) RotateSecret(ctx context.Context, id, by string) (*domain.WebhookDenganSecret, error) {
+	w, err := uc.cari(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := secretWebhook()
+	if err != nil {
+		return nil, err
+	}
+	w.Secret = secret
+	w.DiubahPada = uc.clock.Now()
+	if err := uc.repo.Update(ctx, w); err != nil {
+		return nil, err
+	}
+	uc.lupakanDaftar()
+	log.Printf("INFO: Secret webhook %s diganti oleh %s", w.ID, by)
+	return &domain.WebhookDenganSecret{Webhook: *w, Secret: secret}, nil
+}
+
+func (uc *webhookUsecase) GetPengiriman(ctx context.Context, filter domain.FilterPengirimanWebhook) ([]domain.PengirimanWebhook, error) {
+	list, err := uc.repo.FindPengiriman(ctx)
+	if err != nil {
+		return nil, err
+	}
+	uc.logMu.Lock()
+	list = append(list, uc.logBaru...)
+	uc.logMu.Unlock()
+
+	batas := filter.Batas
+	if batas <= 0 {
+		batas = batasLogWebhook
+	}
+	if batas > maksLogWebhook {
+		batas = maksLogWebhook
+	}
+	hasil := []domain.PengirimanWebhook{}
+	// Log ditulis berurutan, jadi dibaca dari belakang agar yang terbaru di atas
+	for i := len(list) - 1; i >= 0 && len(hasil) < batas; i-- {
+		p := list[i]
+		if filter.WebhookID != "" && p.WebhookID != filter.WebhookID {
+			continue
+		}
+		if filter.Status != "" && p.Status != filter.Status {
+			continue
+		}
+		hasil = append(hasil, p)
+	}
+	return hasil, nil
+}
+
+// KirimUji mengirim event ping langsung (tanpa antrian dan tanpa percobaan ulang), juga ke
+// webhook yang sedang nonaktif, agar admin bisa memeriksa URL dan secret
+func (uc *webhookUsecase) KirimUji(ctx context.Context, id string) (*domain.PengirimanWebhook, error) {
+	w, err := uc.cari(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ev := uc.eventBaru(domain.EventPing, map[string]string{"pesan": "Uji webhook dari Presensi Daarul Ilmi"})
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+	p := domain.PengirimanWebhook{
+		ID:        idBaru("WHD"),
+		WebhookID: w.ID,
+		EventID:   ev.ID,
+		Jenis:     ev.Jenis,
+		Payload:   string(payload),
+	}
+	p = uc.coba(ctx, *w, p, false)
+	if err := uc.repo.SavePengiriman(ctx, []domain.PengirimanWebhook{p}); err != nil {
+		log.Printf("WARNING: Gagal mencatat uji webhook %s: %v", w.ID, err)
+	}
+	return &p, nil
+}
+
+// --- PENGIRIMAN EVENT ---
+
+func (uc *webhookUsecase) eventBaru(jenis string, data interface{}) domain.EventWebhook {
+	return domain.EventWebhook{
+		ID:    idBaru("EVT"),
+		Jenis: jenis,
+		Waktu: uc.clock.Now(),
+		Data:  data,
+	}
+}
+
+func (uc *webhookUsecase) Terbitkan(jenis string, data interface{}) {
+	select {
+	case uc.antrian <- uc.eventBaru(jenis, data):
+	default:
+		log.Printf("WARNING: Antrian webhook penuh, event %s dibuang", jenis)
+	}
+}
+
+func (uc *webhookUsecase) Jalankan(ctx context.Context) {
+	uc.muatMenunggu(ctx)
+
+	kerja := make(chan tugasWebhook, ukuranAntrianWebhook)
+	var wg sync.WaitGroup
+	for i := 0; i < pekerjaWebhook; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range kerja {
+				uc.catat(uc.kerjakan(ctx, t))
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(intervalWebhook)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// Event yang belum sempat diproses ikut dicatat sebagai pengiriman tertunda, lalu
+			// log ditulis setelah semua pekerja selesai
+			for selesai := false; !selesai; {
+				select {
+				case ev := <-uc.antrian:
+					for _, t := range uc.tugasEvent(ctx, ev) {
+						kerja <- t
+					}
+				default:
+					selesai = true
+				}
+			}
+			close(kerja)
+			wg.Wait()
+			uc.simpanLog(context.Background())
+			return
+		case ev := <-uc.antrian:
+			for _, t := range uc.tugasEvent(ctx, ev) {
+				kerja <- t
+			}
+		case <-ticker.C:
+			for _, t := range uc.tugasUlang(ctx) {
+				kerja <- t
+			}
+			uc.simpanLog(ctx)
+		}
+	}
+}
+
+// kerjakan melakukan satu percobaan. Jika server berhenti sebelum atau selama pengiriman,
+// percobaan itu tidak dihitung dan pengiriman dicatat tertunda agar dilanjutkan muatMenunggu
+// setelah server hidup lagi.
+func (uc *webhookUsecase) kerjakan(ctx context.Context, t tugasWebhook) domain.PengirimanWebhook {
+	if ctx.Err() == nil {
+		if hasil := uc.coba(ctx, t.w, t.p, true); hasil.Status == domain.PengirimanBerhasil || ctx.Err() == nil {
+			return hasil
+		}
+	}
+	p := t.p
+	p.Status = domain.PengirimanGagal
+	p.Waktu = uc.clock.Now()
+	p.BerikutnyaPada = p.Waktu
+	p.KodeHTTP, p.DurasiMs = 0, 0
+	p.Error = "server berhenti sebelum pengiriman selesai"
+	return p
+}
+
+// muatMenunggu melanjutkan percobaan ulang yang tertunda sebelum server restart. Percobaan
+// terakhir setiap pengiriman ada di baris paling bawah.
+func (uc *webhookUsecase) muatMenunggu(ctx context.Context) {
+	list, err := uc.repo.FindPengiriman(ctx)
+	if err != nil {
+		log.Printf("WARNING: Gagal membaca log pengiriman webhook, percobaan ulang lama dilewati: %v", err)
+		return
+	}
+	terakhir := make(map[string]domain.PengirimanWebhook)
+	var urutan []string
+	for _, p := range list {
+		if _, ada := terakhir[p.ID]; !ada {
+			urutan = append(urutan, p.ID)
+		}
+		terakhir[p.ID] = p
+	}
+	uc.logMu.Lock()
+	defer uc.logMu.Unlock()
+	for _, id := range urutan {
+		if p := terakhir[id]; p.Status == domain.PengirimanGagal && !p.BerikutnyaPada.IsZero() && p.Payload != "" {
+			uc.menunggu = append(uc.menunggu, p)
+		}
+	}
+	if len(uc.menunggu) > 0 {
+		log.Printf("INFO: %d pengiriman webhook tertunda dilanjutkan", len(uc.menunggu))
+	}
+}
+
+// webhookSemua mengembalikan daftar webhook dari salinan, dibaca ulang jika sudah lama.
+// Jika sheet gagal dibaca, salinan lama tetap dipakai.
+func (uc *webhookUsecase) webhookSemua(ctx context.Context) []domain.Webhook {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if time.Since(uc.dimuatPada) < cacheWebhook {
+		return uc.daftar
+	}
+	list, err := uc.repo.FindAll(ctx)
+	if err != nil {
+		log.Printf("WARNING: Gagal membaca daftar webhook: %v", err)
+		return uc.daftar
+	}
+	uc.daftar = list
+	uc.dimuatPada = time.Now()
+	return list
+}
+
+// tugasEvent menyusun satu pengiriman untuk setiap webhook yang berlangganan event
+func (uc *webhookUsecase) tugasEvent(ctx context.Context, ev domain.EventWebhook) []tugasWebhook {
+	var penerima []domain.Webhook
+	for _, w := range uc.webhookSemua(ctx) {
+		if w.Menerima(ev.Jenis) {
+			penerima = append(penerima, w)
+		}
+	}
+	if len(penerima) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("ERROR membuat JSON event %s: %v", ev.Jenis, err)
+		return nil
+	}
+	tugas := make([]tugasWebhook, 0, len(penerima))
+	for _, w := range penerima {
+		tugas = append(tugas, tugasWebhook{w: w, p: domain.PengirimanWebhook{
+			ID:        idBaru("WHD"),
+			WebhookID: w.ID,
+			EventID:   ev.ID,
+			Jenis:     ev.Jenis,
+			Payload:   string(payload),
+		}})
+	}
+	return tugas
+}
+
+// tugasUlang mengambil pengiriman yang sudah waktunya dicoba lagi. Webhook yang dihapus atau
+// dinonaktifkan sejak percobaan sebelumnya tidak dicoba lagi.
+func (uc *webhookUsecase) tugasUlang(ctx context.Context) []tugasWebhook {
+	now := uc.clock.Now()
+	uc.logMu.Lock()
+	sisa := uc.menunggu[:0]
+	var jatuhTempo []domain.PengirimanWebhook
+	for _, p := range uc.menunggu {
+		if p.BerikutnyaPada.After(now) {
+			sisa = append(sisa, p)
+		} else {
+			jatuhTempo = append(jatuhTempo, p)
+		}
+	}
+	uc.menunggu = sisa
+	uc.logMu.Unlock()
+	if len(jatuhTempo) == 0 {
+		return nil
+	}
+
+	daftar := uc.webhookSemua(ctx)
+	var tugas []tugasWebhook
+	for _, p := range jatuhTempo {
+		var w *domain.Webhook
+		for i := range daftar {
+			if daftar[i].ID == p.WebhookID {
+				w = &daftar[i]
+				break
+			}
+		}
+		if w == nil || !w.Menerima(p.Jenis) {
+			p.Status = domain.PengirimanMenyerah
+			p.Error = "webhook dihapus, dinonaktifkan, atau tidak lagi berlangganan event ini"
+			p.KodeHTTP, p.DurasiMs = 0, 0
+			p.Waktu, p.BerikutnyaPada = now, time.Time{}
+			uc.catat(p)
+			continue
+		}
+		tugas = append(tugas, tugasWebhook{w: *w, p: p})
+	}
+	return tugas
+}
+
+// catat menyimpan hasil percobaan ke antrian log dan, jika masih gagal, ke daftar tunggu
+func (uc *webhookUsecase) catat(p domain.PengirimanWebhook) {
+	uc.logMu.Lock()
+	defer uc.logMu.Unlock()
+	if p.Status == domain.PengirimanGagal {
+		uc.menunggu = append(uc.menunggu, p)
+	}
+	uc.logBaru = append(uc.logBaru, p)
+}
+
+// simpanLog menulis log pengiriman yang belum tersimpan dalam satu append. Jika gagal, log
+// dicoba disimpan lagi pada putaran berikutnya.
+func (uc *webhookUsecase) simpanLog(ctx context.Context) {
+	uc.logMu.Lock()
+	list := uc.logBaru
+	uc.logBaru = nil
+	uc.logMu.Unlock()
+	if len(list) == 0 {
+		return
+	}
+	if err := uc.repo.SavePengiriman(ctx, list); err != nil {
+		uc.logMu.Lock()
+		uc.logBaru = append(list, uc.logBaru...)
+		uc.logMu.Unlock()
+	}
+}
+
+// coba melakukan satu percobaan pengiriman dan mengisi hasilnya. Percobaan ulang hanya
+// dijadwalkan untuk kegagalan sementara: tidak tersambung, 5xx, 408, atau 429.
+func (uc *webhookUsecase) coba(ctx context.Context, w domain.Webhook, p domain.PengirimanWebhook, bolehUlang bool) domain.PengirimanWebhook {
+	p.Percobaan++
+	p.Waktu = uc.clock.Now()
+	p.BerikutnyaPada = time.Time{}
+	mulai := time.Now()
+	kode, err := uc.kirim(ctx, w, p)
+	p.DurasiMs = time.Since(mulai).Milliseconds()
+	p.KodeHTTP = kode
+	p.Error = ""
+
+	if err == nil && kode >= 200 && kode < 300 {
+		p.Status = domain.PengirimanBerhasil
+		return p
+	}
+	if err != nil {
+		p.Error = err.Error()
+	} else {
+		p.Error = fmt.Sprintf("penerima membalas HTTP %d", kode)
+	}
+	if len(p.Error) > 200 {
+		p.Error = p.Error[:200]
+	}
+
+	sementara := err != nil || kode >= 500 || kode == http.StatusRequestTimeout || kode == http.StatusTooManyRequests
+	if bolehUlang && sementara && p.Percobaan <= len(uc.jedaUlang) {
+		p.Status = domain.PengirimanGagal
+		p.BerikutnyaPada = p.Waktu.Add(uc.jedaUlang[p.Percobaan-1])
+		return p
+	}
+	p.Status = domain.PengirimanMenyerah
+	log.Printf("WARNING: Pengiriman webhook %s (%s) ke %s gagal setelah %d percobaan: %s", p.ID, p.Jenis, w.URL, p.Percobaan, p.Error)
+	return p
+}
+
+// kirim mengirim payload yang sudah ditandatangani dan mengembalikan kode status HTTP
+func (uc *webhookUsecase) kirim(ctx context.Context, w domain.Webhook, p domain.PengirimanWebhook) (int, error) {
+	timestamp := strconv.FormatInt(uc.clock.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewBufferString(p.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Presensi-Daarul-Ilmi-Webhook/1.0")
+	req.Header.Set(HeaderWebhookEvent, p.Jenis)
+	req.Header.Set(HeaderWebhookPengiriman, p.ID)
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, "sha256="+TandaTanganWebhook(w.Secret, timestamp, []byte(p.Payload)))
+
+	resp, err := uc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Isi balasan tidak dipakai, tapi dibaca sedikit agar koneksi bisa dipakai ulang
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// TandaTanganWebhook menghitung HMAC-SHA256 (hex) dari "<timestamp>.<body>". Penerima
+// menghitung ulang nilai ini dengan secret-nya lalu membandingkannya dengan header
+// X-Presensi-Signature, dan sebaiknya menolak timestamp yang terlalu lama.
+func TandaTanganWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
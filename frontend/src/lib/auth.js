@@ -0,0 +1,107 @@
+// file: src/lib/auth.js
+// Access token hanya berlaku beberapa menit. Semua request API lewat authFetch agar token
+// yang kedaluwarsa ditukar otomatis lewat /refresh tanpa memaksa pengguna login ulang.
+
+const apiUrl = import.meta.env.VITE_API_BASE_URL;
+const KUNCI_TOKEN = 'jwt_token';
+const KUNCI_REFRESH = 'refresh_token';
+
+/** @type {Promise<boolean> | null} */
+let refreshBerjalan = null;
+
+/**
+ * Simpan token dari respons login, verifikasi 2FA, atau refresh
+ * @param {{ token?: string, refreshToken?: string }} data
+ */
+export function simpanToken(data) {
+  if (data.token) localStorage.setItem(KUNCI_TOKEN, data.token);
+  if (data.refreshToken) localStorage.setItem(KUNCI_REFRESH, data.refreshToken);
+}
+
+export function hapusToken() {
+  localStorage.removeItem(KUNCI_TOKEN);
+  localStorage.removeItem(KUNCI_REFRESH);
+}
+
+/**
+ * Tukar refresh token dengan pasangan token baru. Refresh token lama yang dipakai ulang
+ * membuat server mencabut sesi, jadi tab lain yang sudah merotasi token tidak boleh
+ * di-refresh lagi: token dibaca ulang di dalam kunci lintas tab sebelum dikirim.
+ * @param {string | null} tokenLama refresh token yang tersimpan saat request gagal
+ * @returns {Promise<boolean>}
+ */
+async function tukarToken(tokenLama) {
+  const jalankan = async () => {
+    const refreshToken = localStorage.getItem(KUNCI_REFRESH);
+    if (!refreshToken) return false;
+    if (refreshToken !== tokenLama) return true; // Sudah dirotasi tab lain
+
+    const response = await fetch(`${apiUrl}/refresh`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
+      body: new URLSearchParams({ refresh_token: refreshToken })
+    });
+    if (!response.ok) {
+      hapusToken();
+      return false;
+    }
+    simpanToken(await response.json());
+    return true;
+  };
+
+  if (navigator.locks) {
+    return navigator.locks.request('refresh-token', jalankan);
+  }
+  return jalankan();
+}
+
+/**
+ * fetch ke API dengan access token. Jika ditolak 401, token di-refresh sekali lalu
+ * request diulang; jika refresh juga gagal, token dihapus dan pengguna diarahkan ke login.
+ * @param {string} path path API, misal '/api/siswa'
+ * @param {RequestInit} [init]
+ * @returns {Promise<Response>}
+ */
+export async function authFetch(path, init = {}) {
+  const kirim = () => {
+    const headers = new Headers(init.headers);
+    headers.set('Authorization', 'Bearer ' + (localStorage.getItem(KUNCI_TOKEN) || ''));
+    return fetch(`${apiUrl}${path}`, { ...init, headers });
+  };
+
+  const refreshToken = localStorage.getItem(KUNCI_REFRESH);
+  const response = await kirim();
+  if (response.status !== 401 || !refreshToken) {
+    if (response.status === 401) keLogin();
+    return response;
+  }
+
+  // Request yang gagal bersamaan cukup menunggu satu refresh
+  if (!refreshBerjalan) {
+    refreshBerjalan = tukarToken(refreshToken).finally(() => {
+      refreshBerjalan = null;
+    });
+  }
+  if (!(await refreshBerjalan)) {
+    keLogin();
+    return response;
+  }
+  return kirim();
+}
+
+/**
+ * Cabut sesi di server (best effort) lalu hapus token lokal
+ */
+export async function logout() {
+  try {
+    await authFetch('/api/logout', { method: 'POST' });
+  } catch (e) {
+    console.error('Gagal mencabut sesi:', e);
+  }
+  hapusToken();
+}
+
+function keLogin() {
+  hapusToken();
+  window.location.href = '/';
+}